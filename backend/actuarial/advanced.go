@@ -3,6 +3,7 @@ package actuarial
 import (
 	"fmt"
 	"math"
+	"math/rand/v2"
 
 	"github.com/lubasinkal/v-star/pkg/rates"
 	"github.com/lubasinkal/v-star/pkg/risk"
@@ -18,6 +19,7 @@ type RiskReport struct {
 	VaR99  float64 `json:"var_99"`
 	CTE95  float64 `json:"cte_95"`
 	CTE99  float64 `json:"cte_99"`
+	Seed   uint64  `json:"seed,omitempty"` // The seed a simulation ran from; the same seed and inputs give the same report
 }
 
 type VStarRateConverter struct {
@@ -48,11 +50,18 @@ func (v *VStarRateConverter) VStar(j float64) float64 {
 	return v.inner.VStar(j)
 }
 
+// v-star returns 0 for the annuities certain at i=0; undiscounted they are n payments
 func (v *VStarRateConverter) AnnuityImmediate(n int) float64 {
+	if v.Effective == 0 {
+		return float64(max(n, 0))
+	}
 	return rates.AnnuityCertainImmediate(v.Effective, n)
 }
 
 func (v *VStarRateConverter) AnnuityDue(n int) float64 {
+	if v.Effective == 0 {
+		return float64(max(n, 0))
+	}
 	return rates.AnnuityCertainDue(v.Effective, n)
 }
 
@@ -69,12 +78,35 @@ func ForceOfInterest(i float64) float64 {
 }
 
 func ComputeDuration(cfs []float64, rate float64) (mac, mod, conv float64) {
+	if rate == 0 {
+		return zeroRateDuration(cfs)
+	}
 	mac = rates.MacaulayDuration(rate, cfs)
 	mod = rates.ModifiedDuration(rate, cfs)
 	conv = rates.Convexity(rate, cfs)
 	return
 }
 
+// zeroRateDuration is ComputeDuration at i=0, which v-star reports as all zeros:
+// with no discounting the durations are cash-flow-weighted averages of the times.
+func zeroRateDuration(cfs []float64) (mac, mod, conv float64) {
+	total := 0.0
+	for t, cf := range cfs {
+		if cf <= 0 {
+			continue
+		}
+		total += cf
+		mac += float64(t+1) * cf
+		conv += float64(t+1) * float64(t+2) * cf
+	}
+	if total <= 0 {
+		return 0, 0, 0
+	}
+	mac /= total
+	conv /= total
+	return mac, mac, conv
+}
+
 type MonteCarloEngine struct {
 	gen   *stochastic.RateGenerator
 	drift float64
@@ -156,17 +188,27 @@ func ComputeCTE(losses []float64, conf float64) float64 {
 	return risk.CTE(losses, conf)
 }
 
+// drawSeed picks a seed for a simulation that wasn't given one. Zero is
+// reserved for "not given".
+func drawSeed() uint64 {
+	for {
+		if seed := rand.Uint64(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// RunMCWithRisk always simulates from a seed, drawing one when seed is 0, and
+// reports it so the run can be repeated exactly.
 func RunMCWithRisk(numPaths int, notional, drift, vol float64, seed uint64) RiskReport {
 	dt := 1.0
 	steps := 10
 
-	mc := NewMonteCarlo(drift, vol)
-	var paths [][]float64
-	if seed > 0 {
-		paths = mc.RunWithSeed(numPaths, steps, dt, seed)
-	} else {
-		paths = mc.RunSimulation(numPaths, steps, dt)
+	if seed == 0 {
+		seed = drawSeed()
 	}
+	mc := NewMonteCarlo(drift, vol)
+	paths := mc.RunWithSeed(numPaths, steps, dt, seed)
 
 	losses := make([]float64, numPaths)
 	for i, path := range paths {
@@ -174,7 +216,9 @@ func RunMCWithRisk(numPaths int, notional, drift, vol float64, seed uint64) Risk
 		losses[i] = math.Max(0, mc.drift-finalRate) * notional
 	}
 
-	return ComputeRiskReport(losses)
+	report := ComputeRiskReport(losses)
+	report.Seed = seed
+	return report
 }
 
 type BondValuation struct {
@@ -191,9 +235,7 @@ func ValueBond(face, couponRate float64, years int, ytm float64) BondValuation {
 	}
 	cfs[years-1] = face*couponRate + face
 
-	macDur := rates.MacaulayDuration(ytm, cfs)
-	modDur := rates.ModifiedDuration(ytm, cfs)
-	conv := rates.Convexity(ytm, cfs)
+	macDur, modDur, conv := ComputeDuration(cfs, ytm)
 
 	price := 0.0
 	rc := rates.NewRateConverter(ytm)
@@ -293,16 +335,17 @@ func CalcRetrospectiveReserve(p *Policy, mt MortalityTable, np float64) RetroRes
 	}
 }
 
-func RunMCRates(numPaths int, initRate, drift, vol float64, seed uint64) []float64 {
+// RunMCRates simulates the rate at the end of each path. Like RunMCWithRisk
+// it always runs from a seed, drawing one when seed is 0, and returns the seed
+// with the rates so the run can be repeated exactly.
+func RunMCRates(numPaths int, initRate, drift, vol float64, seed uint64) ([]float64, uint64) {
 	dt := 1.0
 	steps := 10
 
-	var gen *stochastic.RateGenerator
-	if seed > 0 {
-		gen = stochastic.NewRateGeneratorWithSeed(initRate, drift, vol, seed)
-	} else {
-		gen = stochastic.NewRateGenerator(initRate, drift, vol)
+	if seed == 0 {
+		seed = drawSeed()
 	}
+	gen := stochastic.NewRateGeneratorWithSeed(initRate, drift, vol, seed)
 
 	finalRates := make([]float64, numPaths)
 	for i := 0; i < numPaths; i++ {
@@ -310,7 +353,7 @@ func RunMCRates(numPaths int, initRate, drift, vol float64, seed uint64) []float
 		finalRates[i] = path[steps-1]
 	}
 
-	return finalRates
+	return finalRates, seed
 }
 
 func BenchmarkValuation(file string) (int, float64, error) {
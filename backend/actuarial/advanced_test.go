@@ -0,0 +1,45 @@
+package actuarial
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMonteCarloSeed(t *testing.T) {
+	first := RunMCWithRisk(500, 1000000, 0.02, 0.15, 42)
+	second := RunMCWithRisk(500, 1000000, 0.02, 0.15, 42)
+	if first != second {
+		t.Fatalf("Expected the same seed to give the same report, got %+v and %+v", first, second)
+	}
+	if first.Seed != 42 {
+		t.Errorf("Expected the seed to be reported, got %d", first.Seed)
+	}
+	if other := RunMCWithRisk(500, 1000000, 0.02, 0.15, 43); other == first {
+		t.Error("Expected a different seed to give a different report")
+	}
+
+	// An unseeded run reports the seed it drew, which repeats it
+	drawn := RunMCWithRisk(500, 1000000, 0.02, 0.15, 0)
+	if drawn.Seed == 0 {
+		t.Fatal("Expected an unseeded run to report the seed it drew")
+	}
+	if repeated := RunMCWithRisk(500, 1000000, 0.02, 0.15, drawn.Seed); repeated != drawn {
+		t.Errorf("Expected the drawn seed to repeat the run, got %+v and %+v", drawn, repeated)
+	}
+}
+
+func TestMonteCarloRatesSeed(t *testing.T) {
+	first, seed := RunMCRates(100, 0.05, 0.02, 0.15, 42)
+	if second, _ := RunMCRates(100, 0.05, 0.02, 0.15, 42); seed != 42 || !slices.Equal(first, second) {
+		t.Fatalf("Expected seed 42 to repeat its rates, got seed %d", seed)
+	}
+
+	// An unseeded run draws a seed and returns it, which repeats it
+	drawn, seed := RunMCRates(100, 0.05, 0.02, 0.15, 0)
+	if seed == 0 {
+		t.Fatal("Expected an unseeded run to return the seed it drew")
+	}
+	if repeated, _ := RunMCRates(100, 0.05, 0.02, 0.15, seed); !slices.Equal(drawn, repeated) {
+		t.Error("Expected the drawn seed to repeat the run")
+	}
+}
@@ -0,0 +1,40 @@
+package actuarial
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rules for rounding the age at issue worked out from a date of birth
+const (
+	AgeLastBirthday    = "last_birthday"    // Whole years lived, the default
+	AgeNearestBirthday = "nearest_birthday" // One more from six months past a birthday
+	AgeNextBirthday    = "next_birthday"    // The age at the coming birthday
+)
+
+// AgeAtIssue is the whole age at issue for someone born on dateOfBirth,
+// rounded by rule. A birthday on 29 February falls on 1 March in other years.
+func AgeAtIssue(dateOfBirth, issueDate time.Time, rule string) (int, error) {
+	if issueDate.Before(dateOfBirth) {
+		return 0, fmt.Errorf("date of birth %s is after the issue date %s", dateOfBirth.Format(time.DateOnly), issueDate.Format(time.DateOnly))
+	}
+	age := issueDate.Year() - dateOfBirth.Year()
+	if issueDate.Before(dateOfBirth.AddDate(age, 0, 0)) {
+		age--
+	}
+	lastBirthday := dateOfBirth.AddDate(age, 0, 0)
+
+	switch rule {
+	case "", AgeLastBirthday:
+		return age, nil
+	case AgeNearestBirthday:
+		if !issueDate.Before(lastBirthday.AddDate(0, 6, 0)) {
+			age++
+		}
+		return age, nil
+	case AgeNextBirthday:
+		return age + 1, nil
+	default:
+		return 0, fmt.Errorf("unknown age rounding '%s'", rule)
+	}
+}
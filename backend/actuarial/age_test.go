@@ -0,0 +1,44 @@
+package actuarial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeAtIssue(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		dateOfBirth, issueDate string
+		rule                   string
+		age                    int
+	}{
+		{"1985-03-10", "2025-03-09", "", 39},
+		{"1985-03-10", "2025-03-10", AgeLastBirthday, 40},
+		{"1985-03-10", "2025-09-09", AgeNearestBirthday, 40},
+		{"1985-03-10", "2025-09-10", AgeNearestBirthday, 41},
+		{"1985-03-10", "2025-03-10", AgeNextBirthday, 41},
+		{"1985-03-10", "2025-03-09", AgeNextBirthday, 40},
+		{"1984-02-29", "2025-02-28", AgeLastBirthday, 40},
+		{"1984-02-29", "2025-03-01", AgeLastBirthday, 41},
+		{"2025-01-01", "2025-01-01", AgeNearestBirthday, 0},
+	}
+	for _, tt := range tests {
+		age, err := AgeAtIssue(date(tt.dateOfBirth), date(tt.issueDate), tt.rule)
+		if err != nil || age != tt.age {
+			t.Errorf("born %s, issued %s, %q: expected %d, got %d (%v)", tt.dateOfBirth, tt.issueDate, tt.rule, tt.age, age, err)
+		}
+	}
+
+	if _, err := AgeAtIssue(date("2025-01-02"), date("2025-01-01"), ""); err == nil {
+		t.Error("Expected a birth after issue to be rejected")
+	}
+	if _, err := AgeAtIssue(date("1985-03-10"), date("2025-01-01"), "closest"); err == nil {
+		t.Error("Expected an unknown rule to be rejected")
+	}
+}
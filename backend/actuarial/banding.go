@@ -0,0 +1,45 @@
+package actuarial
+
+import "fmt"
+
+// PremiumBand is a volume discount: policies with a sum assured of at least
+// MinCoverage get Discount off their gross premium (0.05 = 5%).
+type PremiumBand struct {
+	MinCoverage float64 `json:"min_coverage"`
+	Discount    float64 `json:"discount"`
+}
+
+// PremiumBandFor finds the band a sum assured falls in: the one with the
+// highest threshold at or below it. Bands must be in order (see
+// ValidatePremiumBands); false means the policy is below every band.
+func PremiumBandFor(bands []PremiumBand, coverageAmount float64) (PremiumBand, bool) {
+	for i := len(bands) - 1; i >= 0; i-- {
+		if coverageAmount >= bands[i].MinCoverage {
+			return bands[i], true
+		}
+	}
+	return PremiumBand{}, false
+}
+
+// ValidatePremiumBands checks that bands start at increasing sums assured, so
+// no two overlap, and that a bigger policy never gets a smaller discount.
+func ValidatePremiumBands(bands []PremiumBand) error {
+	for i, band := range bands {
+		if band.MinCoverage < 0 {
+			return fmt.Errorf("premium band %d starts below zero", i+1)
+		}
+		if band.Discount < 0 || band.Discount >= 1 {
+			return fmt.Errorf("premium band %d discount must be at least 0 and below 1", i+1)
+		}
+		if i == 0 {
+			continue
+		}
+		if band.MinCoverage <= bands[i-1].MinCoverage {
+			return fmt.Errorf("premium band %d must start above band %d", i+1, i)
+		}
+		if band.Discount < bands[i-1].Discount {
+			return fmt.Errorf("premium band %d gives a smaller discount than band %d", i+1, i)
+		}
+	}
+	return nil
+}
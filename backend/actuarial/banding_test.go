@@ -0,0 +1,65 @@
+package actuarial
+
+import "testing"
+
+func TestPremiumBanding(t *testing.T) {
+	bands := []PremiumBand{{MinCoverage: 500000, Discount: 0.05}, {MinCoverage: 1000000, Discount: 0.08}}
+	if err := ValidatePremiumBands(bands); err != nil {
+		t.Fatalf("Expected valid bands, got %v", err)
+	}
+
+	tests := []struct {
+		coverage float64
+		want     float64
+		inBand   bool
+	}{
+		{100000, 0, false},
+		{500000, 0.05, true}, // Thresholds are inclusive
+		{750000, 0.05, true},
+		{2000000, 0.08, true},
+	}
+	for _, tt := range tests {
+		band, ok := PremiumBandFor(bands, tt.coverage)
+		if ok != tt.inBand || band.Discount != tt.want {
+			t.Errorf("Coverage %.0f: expected discount %f (in band %t), got %f (%t)", tt.coverage, tt.want, tt.inBand, band.Discount, ok)
+		}
+	}
+
+	invalid := map[string][]PremiumBand{
+		"overlapping":       {{MinCoverage: 500000, Discount: 0.05}, {MinCoverage: 500000, Discount: 0.08}},
+		"out of order":      {{MinCoverage: 1000000, Discount: 0.05}, {MinCoverage: 500000, Discount: 0.08}},
+		"falling discount":  {{MinCoverage: 500000, Discount: 0.08}, {MinCoverage: 1000000, Discount: 0.05}},
+		"whole premium off": {{MinCoverage: 500000, Discount: 1}},
+	}
+	for name, bands := range invalid {
+		if err := ValidatePremiumBands(bands); err == nil {
+			t.Errorf("Expected %s bands to be rejected", name)
+		}
+	}
+
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 600000, InterestRate: 0.05, ProductType: "term_life"}
+	basis := DefaultBasis(policy, mortalityTable)
+	undiscounted := CalculateFullPremiumWithBasis(policy, basis)
+
+	basis.PremiumBands = bands
+	discounted := CalculateFullPremiumWithBasis(policy, basis)
+	if discounted.PremiumBand == nil || discounted.PremiumBand.MinCoverage != 500000 {
+		t.Fatalf("Expected the 500k band to apply, got %+v", discounted.PremiumBand)
+	}
+	if !floatEquals(discounted.GrossPremium, undiscounted.GrossPremium*0.95, 1e-9) ||
+		!floatEquals(discounted.VolumeDiscount, undiscounted.GrossPremium*0.05, 1e-9) {
+		t.Errorf("Expected 5%% off %f, got %f (discount %f)", undiscounted.GrossPremium, discounted.GrossPremium, discounted.VolumeDiscount)
+	}
+
+	// The minimum premium is applied to the discounted premium
+	basis.MinimumPremium = undiscounted.GrossPremium
+	floored := CalculateFullPremiumWithBasis(policy, basis)
+	if !floored.MinimumPremiumApplied || floored.GrossPremium != undiscounted.GrossPremium ||
+		!floatEquals(floored.ActuarialPremium, discounted.GrossPremium, 1e-9) {
+		t.Errorf("Expected the floor to lift the discounted %f back to %f, got %f", discounted.GrossPremium, undiscounted.GrossPremium, floored.GrossPremium)
+	}
+}
@@ -0,0 +1,55 @@
+package actuarial
+
+// Reserve methods and timing assumptions a Basis can use. Only these are
+// supported today; they are named so results can say which were used.
+const (
+	NetPremiumReserve = "net_premium" // Prospective reserve on the net premium
+
+	PremiumsAnnuallyInAdvance = "annual_in_advance" // Premium paid at the start of each policy year
+	PremiumsAnnuallyInArrears = "annual_in_arrears" // Premium paid at the end of each policy year, by those who survive it
+	ClaimsEndOfYear           = "end_of_year"       // Death benefit paid at the end of the year of death
+)
+
+// Basis is "how we value" a policy, kept apart from the Policy itself ("what
+// the policy is"). The same basis can be applied to many policies, and the same
+// policy can be valued on several bases - pricing, statutory, GAAP - by passing
+// a different Basis each time.
+type Basis struct {
+	Name                  string             // e.g. "pricing" or "statutory"; echoed in results when set
+	InterestRate          float64            // Valuation interest rate, used instead of the policy's
+	TableName             string             // Name of the mortality table, for reporting
+	Mortality             MortalityTable     // The base mortality table (before underwriting)
+	AdjustedMortality     MortalityTable     // Mortality with the policy's underwriting applied, if already worked out; never modified
+	Expenses              ExpenseStructure   // Expense and profit loadings for life products
+	Underwriting          UnderwritingConfig // Smoker and health multipliers
+	AnnuityLoading        float64            // Loading on the annuity single premium (0.10 = 10%)
+	ReserveMethod         string             // NetPremiumReserve, FullPreliminaryTermReserve or CRVMReserve
+	ExpenseAllowanceCap   *float64           // Modified reserves: largest expense allowance; nil for the method's default
+	PremiumTiming         string             // PremiumsAnnuallyInAdvance or PremiumsAnnuallyInArrears
+	ClaimTiming           string             // ClaimsEndOfYear
+	RiskDiscountRate      float64            // Hurdle rate for profit testing; 0 keeps the policy's
+	CompoundingConvention string             // AnnualCompounding or ContinuousCompounding; empty keeps the policy's
+	MinimumPremium        float64            // Smallest gross premium charged for life products; 0 for no floor
+	PremiumBands          []PremiumBand      // Volume discounts by sum assured, in increasing order
+	PremiumTaxRate        float64            // Premium tax levied on the gross premium (0.05 = 5%); 0 for none
+	Solver                SolverConfig       // Tolerance and iteration limit for iterative solves
+	ZeroPremium           string             // ZeroPremiumReject (default) or ZeroPremiumWarn
+}
+
+// DefaultBasis is the basis CalculateFullPremium uses: the policy's own
+// interest rate and table, the product's default expenses and the default
+// annuity loading.
+func DefaultBasis(policy *Policy, mortalityTable MortalityTable) Basis {
+	return Basis{
+		InterestRate:   policy.InterestRate,
+		TableName:      policy.Gender,
+		Mortality:      mortalityTable,
+		Expenses:       ExpensesForProduct(policy.ProductType),
+		Underwriting:   DefaultUnderwritingConfig(),
+		AnnuityLoading: DefaultAnnuityLoading,
+		ReserveMethod:  NetPremiumReserve,
+		PremiumTiming:  PremiumsAnnuallyInAdvance,
+		ClaimTiming:    ClaimsEndOfYear,
+		Solver:         DefaultSolverConfig(),
+	}
+}
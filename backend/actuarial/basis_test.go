@@ -0,0 +1,38 @@
+package actuarial
+
+import "testing"
+
+func TestCalculateFullPremiumWithBasis(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+
+	// The default basis reproduces the original calculation
+	original := CalculateFullPremium(policy, mortalityTable)
+	onDefault := CalculateFullPremiumWithBasis(policy, DefaultBasis(policy, mortalityTable))
+	if original.GrossPremium != onDefault.GrossPremium || original.Basis != "" {
+		t.Errorf("Expected the default basis to match CalculateFullPremium, got %f vs %f", onDefault.GrossPremium, original.GrossPremium)
+	}
+
+	// A prudent statutory basis: lower interest and no profit loading
+	statutory := DefaultBasis(policy, mortalityTable)
+	statutory.Name = "statutory"
+	statutory.InterestRate = 0.03
+	statutory.Expenses.ProfitMargin = 0
+	result := CalculateFullPremiumWithBasis(policy, statutory)
+
+	if result.Basis != "statutory" {
+		t.Errorf("Expected basis name to be echoed, got %q", result.Basis)
+	}
+	if result.NetPremium <= original.NetPremium {
+		t.Errorf("Expected lower interest to raise the net premium, got %f vs %f", result.NetPremium, original.NetPremium)
+	}
+	if policy.InterestRate != 0.05 {
+		t.Errorf("Expected the policy to be left unchanged, interest rate is now %f", policy.InterestRate)
+	}
+	if result.ExpenseDetails["profit_margin"] != 0 {
+		t.Errorf("Expected basis expenses to be used, got profit margin %f", result.ExpenseDetails["profit_margin"])
+	}
+}
@@ -3,13 +3,19 @@
 package actuarial
 
 import (
+	"bufio"
+	"cmp"
+	"compress/gzip"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // MortalityTable is just a list of death probabilities by age
@@ -26,8 +32,45 @@ type Policy struct {
 	ProductType    string  `json:"product_type"`   // Type of insurance: "term_life" or "whole_life"
 	SmokerStatus   string  `json:"smoker_status,omitempty"`   // Does person smoke? Affects risk
 	HealthRating   string  `json:"health_rating,omitempty"`   // Health status: "standard", "substandard", "preferred"
+	Impairment     string  `json:"impairment,omitempty"`      // Named condition, e.g. "diabetic", see ImpairmentBasis
 	RatingFactor   float64 `json:"rating_factor,omitempty"`   // Risk multiplier (1.0 = normal risk)
 	DeferralPeriod int     `json:"deferral_period,omitempty"` // For annuities: years to wait before payments
+	LapseRate      float64 `json:"lapse_rate,omitempty"`      // Chance each year that a surviving policyholder stops paying (e.g., 0.05)
+
+	UnderwritingClass       string          `json:"underwriting_class,omitempty"`         // "full" (default) or "simplified_issue" (no medical)
+	SimplifiedIssueLoadings []float64       `json:"simplified_issue_loadings,omitempty"`  // Overrides DefaultSimplifiedIssueLoadings
+	Renewable               bool            `json:"renewable,omitempty"`                  // Term life: renewed yearly without re-underwriting
+	AntiSelectionLoadings   []float64       `json:"anti_selection_loadings,omitempty"`    // Overrides DefaultAntiSelectionLoadings
+	DisabilityBenefit       float64         `json:"disability_benefit,omitempty"`         // Multi-decrement tables only: paid on disability
+	InForceDuration         int             `json:"in_force_duration,omitempty"`          // Years since issue, for valuing existing business
+	MortalityExtrapolation  string          `json:"mortality_extrapolation,omitempty"`    // Whole life only: "immediate" or "linear" beyond the table's last age
+	UltimateAge             int             `json:"ultimate_age,omitempty"`               // For "linear": the age at which qx reaches 1
+	SurvivalThreshold       float64         `json:"survival_threshold,omitempty"`         // Whole life only: see WholeLifeHorizon
+	PaymentFrequency        string          `json:"payment_frequency,omitempty"`          // "annual" (default), "semi_annual", "quarterly" or "monthly"
+	IncludeNetAmountAtRisk  bool            `json:"include_net_amount_at_risk,omitempty"` // Also return sum assured minus reserve by year
+	IncludeSinglePremium    bool            `json:"include_single_premium,omitempty"`     // Also return the equivalent single premium
+	IncludeDuration         bool            `json:"include_duration,omitempty"`           // Also return the durations of the expected cash flows
+	IncludeProfitByYear     bool            `json:"include_profit_by_year,omitempty"`     // Life, new business: also return the expected profit in each year
+	IncludePaidUp           bool            `json:"include_paid_up,omitempty"`            // Whole life: also return when the reserve alone pays for the cover
+	MaturityBenefit         float64         `json:"maturity_benefit,omitempty"`           // Term life: paid if alive at the end of the term
+	CompoundingConvention   string          `json:"compounding_convention,omitempty"`     // "annual" (default) or "continuous"
+	RiskDiscountRate        float64         `json:"risk_discount_rate,omitempty"`         // Hurdle rate for profit testing; set to get a ProfitTest
+	PremiumCeaseAge         int             `json:"premium_cease_age,omitempty"`          // Whole life: no premiums from this attained age on
+	PremiumPayingTerm       int             `json:"premium_paying_term,omitempty"`        // Term life: years premiums are paid for, if fewer than Term
+	FirstYearFeeWaiver      string          `json:"first_year_fee_waiver,omitempty"`      // Life: FeeWaiverAbsorbed or FeeWaiverRespread to waive the policy fee in year one
+	MassLapse               float64         `json:"mass_lapse,omitempty"`                 // Share of policies lapsing at the end of year one on top of LapseRate, for lapse stresses
+	PremiumTiming           string          `json:"premium_timing,omitempty"`             // Life: PremiumsAnnuallyInAdvance (default) or PremiumsAnnuallyInArrears
+	Layers                  []CoverageLayer `json:"layers,omitempty"`                     // Term life: the death benefit in tiers, in place of CoverageAmount; see SumAssuredInYear
+	SettlementDelay         float64         `json:"settlement_delay,omitempty"`           // Life: months from the end of the year of death until the claim is paid
+	ReserveApproach         string          `json:"reserve_approach,omitempty"`           // ReserveProspective (default) or ReserveRetrospective
+	ReturnOfPurchasePrice   string          `json:"return_of_purchase_price,omitempty"`   // Deferred annuity: RefundPremium or RefundAccumulated on death before payments start
+	CashRefund              bool            `json:"cash_refund,omitempty"`                // Immediate annuity: refund on death whatever of the price payments haven't returned
+	AnnuityTiming           string          `json:"annuity_timing,omitempty"`             // Annuities: AnnuityDue (default) or AnnuityImmediate
+	DeferralFraction        float64         `json:"deferral_fraction,omitempty"`          // Deferred annuity: part of a year deferred on top of DeferralPeriod
+	DynamicLapse            *DynamicLapse   `json:"dynamic_lapse,omitempty"`              // Life: lapses that rise when market rates beat the credited rate
+	RequiredCapital         *CapitalBasis   `json:"required_capital,omitempty"`           // Life: capital held on top of the reserve; adds a ProfitRelease to the profit test
+
+	YieldCurve YieldCurve `json:"-"` // Spot rates by term; used instead of InterestRate when set
 }
 
 type PremiumCalculation struct {
@@ -40,6 +83,67 @@ type PremiumCalculation struct {
 	TotalPremiumCost  float64            `json:"total_premium_cost,omitempty"` // For annuities
 	UnderwritingInfo  map[string]interface{} `json:"underwriting,omitempty"`
 	RiskAssessment    map[string]float64 `json:"risk_assessment,omitempty"`
+	LapseRate         float64            `json:"lapse_rate,omitempty"`
+	InForceDuration   int                `json:"in_force_duration,omitempty"` // Reserve schedule starts at this duration
+	UltimateAge       int                `json:"ultimate_age,omitempty"`      // Age at which extrapolated mortality reaches 1
+	PaymentFrequency  string             `json:"payment_frequency,omitempty"`
+	ModalFactor       float64            `json:"modal_factor,omitempty"`  // Loading for paying more often than yearly
+	ModalPremium      float64            `json:"modal_premium,omitempty"` // Each installment at PaymentFrequency
+	ModalImpliedRate  float64            `json:"modal_implied_rate,omitempty"` // Annual interest rate the modal loading charges
+	NetAmountAtRisk   []float64          `json:"net_amount_at_risk,omitempty"`
+	SinglePremium     float64            `json:"single_premium,omitempty"` // Net single premium funding the same benefits, if asked for
+	Basis             string             `json:"basis,omitempty"` // Name of the valuation basis, when one was given
+
+	NewBusinessStrain     float64           `json:"new_business_strain,omitempty"` // First-year capital cost; positive = costs capital
+	PremiumBreakdown      *PremiumBreakdown `json:"premium_breakdown,omitempty"`   // Life products: GrossPremium split into net, expense and profit
+	CompoundingConvention string            `json:"compounding_convention,omitempty"`
+	ProfitTest            *ProfitTestResult `json:"profit_test,omitempty"`
+	PremiumPayingYears    int               `json:"premium_paying_years,omitempty"` // Years premiums are actually paid for
+	DeathBenefitValue     float64           `json:"death_benefit_value,omitempty"`  // Annuities: value of any refund of the price on death
+
+	ActuarialPremium      float64 `json:"actuarial_premium,omitempty"`       // Gross premium (after any volume discount) before any minimum premium
+	MinimumPremiumApplied bool    `json:"minimum_premium_applied,omitempty"` // GrossPremium was raised to the minimum
+	MinimumPremiumLoading float64 `json:"minimum_premium_loading,omitempty"` // Extra loading the minimum implies (0.25 = 25%)
+
+	PremiumBand    *PremiumBand `json:"premium_band,omitempty"`    // Volume discount band the sum assured fell in
+	VolumeDiscount float64      `json:"volume_discount,omitempty"` // Amount taken off the gross premium for the band
+
+	PremiumTaxRate      float64 `json:"premium_tax_rate,omitempty"`      // Tax levied on the gross premium (0.05 = 5%)
+	PremiumTax          float64 `json:"premium_tax,omitempty"`           // Tax on GrossPremium
+	TaxInclusivePremium float64 `json:"tax_inclusive_premium,omitempty"` // GrossPremium plus the tax: what the policyholder pays
+	AnnuityTiming       string  `json:"annuity_timing,omitempty"`        // Annuities: when in each year payments are made
+
+	EffectiveDeferral float64 `json:"effective_deferral,omitempty"` // Deferred annuity: years until payments start, fraction included
+	ReserveApproach   string  `json:"reserve_approach,omitempty"`   // Life products: how the reserve schedule was worked out
+	PremiumTiming     string  `json:"premium_timing,omitempty"`     // Life products: when in each year premiums are paid
+
+	LayerPremiums []LayerPremium `json:"layer_premiums,omitempty"` // Layered policies: each layer's part of the net premium
+	LapsePath     []float64      `json:"lapse_path,omitempty"`     // Dynamic lapses: the lapse rate in each policy year
+
+	Warnings []string `json:"warnings,omitempty"` // Caveats: assumptions made and soft edge cases hit, see CalculationWarnings
+
+	HorizonYears     int  `json:"horizon_years,omitempty"`     // Whole life with a survival threshold: years valued
+	HorizonTruncated bool `json:"horizon_truncated,omitempty"` // HorizonYears stopped short of the end of the table
+
+	CashRefundIterations int  `json:"cash_refund_iterations,omitempty"` // Steps taken to solve a cash-refund annuity's price
+	CashRefundConverged  bool `json:"cash_refund_converged,omitempty"`  // The price settled within tolerance
+
+	SolverError  error `json:"-"` // A solve that ran out of iterations; the numbers depending on it can't be trusted
+	PremiumError error `json:"-"` // The premium can't be quoted, see CheckPremium; the rest of the result is not filled in
+
+	Duration *CashFlowDuration `json:"duration,omitempty"` // Macaulay durations of the cash flows, if asked for
+
+	PaidUp *PaidUp `json:"paid_up,omitempty"` // When the policy becomes paid-up, if asked for
+
+	FeeWaiver        string  `json:"fee_waiver,omitempty"`         // Rule applied to a first-year fee waiver
+	FirstYearPremium float64 `json:"first_year_premium,omitempty"` // With a fee waiver: the first premium, without the fee
+	RenewalPremium   float64 `json:"renewal_premium,omitempty"`    // With a fee waiver: each premium after the first
+
+	ModifiedReserve *ModifiedReserve `json:"modified_reserve,omitempty"` // Premiums the reserve was held on, for a modified reserve method
+
+	ProfitByYear *ProfitByYear `json:"profit_by_year,omitempty"` // Expected profit in each year, if asked for
+
+	SettlementDelay float64 `json:"settlement_delay,omitempty"` // Months death claims were assumed to take to pay
 }
 
 type ExpenseStructure struct {
@@ -49,56 +153,319 @@ type ExpenseStructure struct {
 	ProfitMargin       float64
 }
 
+// TableInfo describes the range of ages a loaded table actually covers.
+// A pensioner table might only start at age 50.
+type TableInfo struct {
+	MinAge   int  `json:"min_age"`
+	MaxAge   int  `json:"max_age"`
+	Abridged bool `json:"abridged,omitempty"` // Source skipped ages that were filled by interpolation
+
+	RateScale string `json:"rate_scale,omitempty"` // How the file wrote qx: RatesAsProbabilities or RatesPerThousand
+}
+
+// How a table file writes its death rates. Some published tables give deaths
+// per 1000 lives (2.0) rather than the probability (0.002).
+const (
+	RatesAsProbabilities = "probability"
+	RatesPerThousand     = "per_1000"
+)
+
+// TableLoadOptions controls how a mortality table file is read
+type TableLoadOptions struct {
+	// InterpolateAbridged fills in the missing ages of an abridged table (one
+	// that only lists every 5th age, say). Without it such a table is rejected.
+	InterpolateAbridged bool
+
+	// RateScale says how the file writes qx. Empty detects it: a table
+	// where at least a quarter of the rates are above 1 is read as per 1000.
+	RateScale string
+}
+
 // LoadMortalityTable reads death probability data from a CSV file.
 // The CSV should have death rates (qx values) showing probability of death at each age.
 // Example: Age 30 might have 0.001 (0.1% chance of death that year)
+// Gzipped files (male.csv.gz) are unpacked transparently.
 func LoadMortalityTable(filePath string) (MortalityTable, error) {
-	file, err := os.Open(filePath)
+	table, _, err := LoadMortalityTableWithInfo(filePath)
+	return table, err
+}
+
+// LoadMortalityTableWithInfo loads a table like LoadMortalityTable and also reports
+// which ages it covers. Rates are stored at their age from the first column, so a
+// table starting at age 50 has nothing usable below index 50.
+func LoadMortalityTableWithInfo(filePath string) (MortalityTable, TableInfo, error) {
+	return LoadMortalityTableWithOptions(filePath, TableLoadOptions{})
+}
+
+// LoadMortalityTableWithOptions loads a table like LoadMortalityTableWithInfo.
+// An abridged table is spotted by gaps in the age column.
+func LoadMortalityTableWithOptions(filePath string, options TableLoadOptions) (MortalityTable, TableInfo, error) {
+	tableData, closeFile, err := openTableFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("could not open mortality table file: %w", err)
+		return nil, TableInfo{}, err
 	}
-	defer file.Close()
+	defer closeFile()
 
 	// Setup CSV reader for tab-delimited files
-	csvReader := csv.NewReader(file)
+	csvReader := csv.NewReader(tableData)
 	csvReader.FieldsPerRecord = -1  // Allow variable number of fields
 	csvReader.Comma = '\t'           // Tab-delimited
 
-	// Skip the header row
-	_, err = csvReader.Read()
+	// The header names the columns; use it to find qx when it's there
+	header, err := csvReader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("could not read CSV header: %w", err)
+		return nil, TableInfo{}, fmt.Errorf("could not read CSV header: %w", err)
 	}
+	deathRateColumn := slices.IndexFunc(splitTableRow(header), func(name string) bool {
+		return strings.EqualFold(name, "qx")
+	})
 
 	// Read all death probabilities
 	deathProbabilities := MortalityTable{}
+	knownAges := []int{}
+	firstAge := -1
 	for {
 		row, err := csvReader.Read()
 		if err == io.EOF {
 			break // End of file reached
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error reading CSV row: %w", err)
+			return nil, TableInfo{}, fmt.Errorf("error reading CSV row: %w", err)
 		}
 
-		// Death rate is usually in column 3 (index 2)
-		if len(row) > 2 {
-			deathRateText := strings.TrimSpace(row[2])
-			deathRate, err := strconv.ParseFloat(deathRateText, 64)
-			
-			// If column 3 fails, try column 2 (some formats differ)
-			if err != nil {
-				deathRateText = strings.TrimSpace(row[1])
-				deathRate, err = strconv.ParseFloat(deathRateText, 64)
-				if err != nil {
-					continue // Skip bad rows
-				}
-			}
+		row = splitTableRow(row)
+		deathRate, ok := rowDeathRate(row, deathRateColumn)
+		if !ok {
+			continue // Skip bad rows
+		}
+
+		// Use the age column when there is one, otherwise carry on from the last age
+		age := len(deathProbabilities)
+		if rowAge, err := strconv.Atoi(row[0]); err == nil && rowAge >= 0 {
+			age = rowAge
+		}
+		if firstAge < 0 {
+			firstAge = age
+		}
+		knownAges = append(knownAges, age)
+
+		// Ages before the table starts are left at zero
+		for len(deathProbabilities) < age {
+			deathProbabilities = append(deathProbabilities, 0)
+		}
+		if age < len(deathProbabilities) {
+			deathProbabilities[age] = deathRate
+		} else {
 			deathProbabilities = append(deathProbabilities, deathRate)
 		}
 	}
 
-	return deathProbabilities, nil
+	info := TableInfo{MinAge: firstAge, MaxAge: len(deathProbabilities) - 1}
+	if firstAge < 0 {
+		info.MinAge = 0
+	}
+
+	// Rescale before interpolating, which needs probabilities
+	listedRates := make([]float64, len(knownAges))
+	for i, age := range knownAges {
+		listedRates[i] = deathProbabilities[age]
+	}
+	info.RateScale, err = resolveRateScale(listedRates, options.RateScale)
+	if err != nil {
+		return nil, TableInfo{}, fmt.Errorf("mortality table %s: %w", filePath, err)
+	}
+	if info.RateScale == RatesPerThousand {
+		for _, age := range knownAges {
+			deathProbabilities[age] /= 1000
+		}
+	}
+
+	if gapStart, gapEnd, found := findAgeGap(knownAges); found {
+		if !options.InterpolateAbridged {
+			return nil, TableInfo{}, fmt.Errorf("mortality table %s jumps from age %d to %d; load it with abridged interpolation", filePath, gapStart, gapEnd)
+		}
+		deathProbabilities = InterpolateAbridged(deathProbabilities, knownAges)
+		info.Abridged = true
+	}
+	return deathProbabilities, info, nil
+}
+
+// perThousandShare is the share of a table's rates above 1 from which it is
+// taken to be written per 1000. A per-1000 table's young ages are often below
+// 1 too, so it is well under all of them; a few stray values above 1 in a
+// table of probabilities are an error in the file, not a different scale.
+const perThousandShare = 0.25
+
+// resolveRateScale checks a table's rates against the scale it was said to
+// use, or works out the scale when none was given. Rates above 1 can't be
+// probabilities, so rather than being capped they either mark the table as
+// per 1000 or, when too few to be sure, reject it.
+func resolveRateScale(rates []float64, scale string) (string, error) {
+	aboveOne, largest := 0, 0.0
+	for _, rate := range rates {
+		if rate > 1 {
+			aboveOne++
+		}
+		largest = math.Max(largest, rate)
+	}
+
+	if scale == "" {
+		switch {
+		case aboveOne == 0:
+			scale = RatesAsProbabilities
+		case float64(aboveOne) >= perThousandShare*float64(len(rates)):
+			scale = RatesPerThousand
+		default:
+			return "", fmt.Errorf("%d of %d rates are above 1, too few to be a per-1000 table; set the rate scale", aboveOne, len(rates))
+		}
+	}
+	switch scale {
+	case RatesAsProbabilities:
+		if largest > 1 {
+			return "", fmt.Errorf("rates go up to %g, which can't be probabilities; is the table per 1000?", largest)
+		}
+	case RatesPerThousand:
+		if largest > 1000 {
+			return "", fmt.Errorf("rates go up to %g, more than 1000 deaths per 1000 lives", largest)
+		}
+	default:
+		return "", fmt.Errorf("unknown rate scale '%s'", scale)
+	}
+	return scale, nil
+}
+
+// splitTableRow trims a row's fields. Some exports line their columns up with
+// runs of spaces, or mix spaces and tabs, so a row that the tab delimiter
+// didn't split cleanly is split on whitespace instead.
+func splitTableRow(row []string) []string {
+	for _, field := range row {
+		if strings.ContainsFunc(strings.TrimSpace(field), unicode.IsSpace) {
+			return strings.Fields(strings.Join(row, " "))
+		}
+	}
+	fields := make([]string, len(row))
+	for i, field := range row {
+		fields[i] = strings.TrimSpace(field)
+	}
+	return fields
+}
+
+// rowDeathRate reads qx from the column the header named. Without one, the
+// death rate is usually in column 3 (index 2), or column 2 in some formats.
+func rowDeathRate(row []string, deathRateColumn int) (float64, bool) {
+	if deathRateColumn > 0 {
+		if deathRateColumn >= len(row) {
+			return 0, false
+		}
+		deathRate, err := strconv.ParseFloat(row[deathRateColumn], 64)
+		return deathRate, err == nil
+	}
+	if len(row) <= 2 {
+		return 0, false
+	}
+	if deathRate, err := strconv.ParseFloat(row[2], 64); err == nil {
+		return deathRate, true
+	}
+	deathRate, err := strconv.ParseFloat(row[1], 64)
+	return deathRate, err == nil
+}
+
+// findAgeGap reports the first place where the listed ages skip one or more years
+func findAgeGap(ages []int) (from int, to int, found bool) {
+	sortedAges := slices.Clone(ages)
+	slices.Sort(sortedAges)
+	for i := 1; i < len(sortedAges); i++ {
+		if sortedAges[i]-sortedAges[i-1] > 1 {
+			return sortedAges[i-1], sortedAges[i], true
+		}
+	}
+	return 0, 0, false
+}
+
+// CombineTables builds a new table as a weighted sum of others, e.g. 1.1 x male,
+// or 0.5 x male + 0.5 x female for a unisex table. Each factor multiplies the
+// matching table's rates. The result is only as long as the shortest table,
+// and every rate is clamped to between 0 and 1.
+func CombineTables(tables []MortalityTable, factors []float64) MortalityTable {
+	if len(tables) == 0 {
+		return MortalityTable{}
+	}
+	length := len(tables[0])
+	for _, table := range tables[1:] {
+		length = min(length, len(table))
+	}
+
+	combined := make(MortalityTable, length)
+	for age := range combined {
+		rate := 0.0
+		for i, table := range tables {
+			rate += factors[i] * table[age]
+		}
+		combined[age] = math.Min(math.Max(rate, 0), 1)
+	}
+	return combined
+}
+
+// InterpolateAbridged fills in the ages missing between the knownAges of an
+// abridged table, giving a complete single-year table.
+//
+// The chance of surviving a year, px = 1 - qx, is interpolated log-linearly
+// (geometrically) between the known ages. For an age t of the way from age a
+// to age b:
+//
+//	px = pa^(1-t) * pb^t
+//
+// Ages outside the known range are left as they are.
+func InterpolateAbridged(mortalityTable MortalityTable, knownAges []int) MortalityTable {
+	completeTable := make(MortalityTable, len(mortalityTable))
+	copy(completeTable, mortalityTable)
+
+	sortedAges := slices.Clone(knownAges)
+	slices.Sort(sortedAges)
+	for i := 1; i < len(sortedAges); i++ {
+		lowerAge, upperAge := sortedAges[i-1], sortedAges[i]
+		lowerSurvival := 1.0 - mortalityTable[lowerAge]
+		upperSurvival := 1.0 - mortalityTable[upperAge]
+
+		for age := lowerAge + 1; age < upperAge; age++ {
+			fraction := float64(age-lowerAge) / float64(upperAge-lowerAge)
+			survival := math.Pow(lowerSurvival, 1-fraction) * math.Pow(upperSurvival, fraction)
+			completeTable[age] = 1.0 - survival
+		}
+	}
+	return completeTable
+}
+
+// openTableFile opens a table file for reading. The returned function closes it.
+func openTableFile(filePath string) (io.Reader, func(), error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open mortality table file: %w", err)
+	}
+
+	// Archived tables are stored gzipped. Spot them by extension or by the
+	// gzip magic number at the start of the file.
+	bufferedFile := bufio.NewReader(file)
+	claimsGzip := strings.HasSuffix(strings.ToLower(filePath), ".gz")
+	if !claimsGzip && !looksGzipped(bufferedFile) {
+		return bufferedFile, func() { file.Close() }, nil
+	}
+
+	gzipReader, err := gzip.NewReader(bufferedFile)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("mortality table file %s is not valid gzip: %w", filePath, err)
+	}
+	return gzipReader, func() {
+		gzipReader.Close()
+		file.Close()
+	}, nil
+}
+
+// looksGzipped peeks at the first two bytes for the gzip magic number (1f 8b)
+func looksGzipped(reader *bufio.Reader) bool {
+	magic, err := reader.Peek(2)
+	return err == nil && magic[0] == 0x1f && magic[1] == 0x8b
 }
 
 // CalculatePresentValue tells us what money in the future is worth today.
@@ -113,6 +480,77 @@ func CalculatePresentValue(futureAmount float64, interestRate float64, numberOfY
 	return todaysValue
 }
 
+// Compounding conventions for discounting
+const (
+	AnnualCompounding     = "annual"     // v^t = 1 / (1+i)^t
+	ContinuousCompounding = "continuous" // v^t = e^(-δt), with the interest rate read as the force of interest δ
+)
+
+// CalculatePresentValueWithConvention discounts like CalculatePresentValue, but
+// under the given compounding convention. With "continuous" the interest rate
+// is the force of interest δ, so $1000 in 5 years at δ = 5% is worth
+// 1000 * e^(-0.25), about $779. A force δ = ln(1+i) gives exactly the same
+// values as annual compounding at rate i.
+func CalculatePresentValueWithConvention(futureAmount float64, interestRate float64, numberOfYears int, convention string) float64 {
+	if convention == ContinuousCompounding {
+		return futureAmount * math.Exp(-interestRate*float64(numberOfYears))
+	}
+	return CalculatePresentValue(futureAmount, interestRate, numberOfYears)
+}
+
+// discount is the present value of an amount due in some years, at the policy's
+// interest rate (or the spot rate for that term from its yield curve) and
+// compounding convention
+func discount(policy *Policy, futureAmount float64, numberOfYears int) float64 {
+	interestRate := policy.InterestRate
+	if len(policy.YieldCurve) > 0 {
+		interestRate = policy.YieldCurve.SpotRate(numberOfYears)
+	}
+	return CalculatePresentValueWithConvention(futureAmount, interestRate, numberOfYears, policy.CompoundingConvention)
+}
+
+// discountFractional is discount for a time that need not be a whole number
+// of years. A yield curve's rate is the spot rate for the year the time falls in.
+func discountFractional(policy *Policy, futureAmount float64, years float64) float64 {
+	interestRate := policy.InterestRate
+	if len(policy.YieldCurve) > 0 {
+		interestRate = policy.YieldCurve.SpotRate(int(math.Ceil(years)))
+	}
+	if policy.CompoundingConvention == ContinuousCompounding {
+		return futureAmount * math.Exp(-interestRate*years)
+	}
+	return futureAmount * math.Pow(1+interestRate, -years)
+}
+
+// settlementFactor is what a death claim paid after the policy's settlement
+// delay is worth at the end of the year of death: the interest earned while
+// the claim is settled makes it cheaper than paying straight away. On a yield
+// curve the delay is discounted at the one-year rate.
+func settlementFactor(policy *Policy) float64 {
+	if policy.SettlementDelay <= 0 {
+		return 1
+	}
+	return discountFractional(policy, 1.0, policy.SettlementDelay/12)
+}
+
+// deathBenefitValue is the present value of a death claim for a death in the
+// numberOfYears-th year from now, paid after the settlement delay
+func deathBenefitValue(policy *Policy, amount float64, numberOfYears int) float64 {
+	return discount(policy, amount, numberOfYears) * settlementFactor(policy)
+}
+
+// premiumValue is the present value of a year's premium for a policy in force
+// at the start of the policy year that begins yearsFromNow years from now, with
+// chance chanceOfDeath of a death in it. In advance the premium is due at the
+// start of the year. In arrears it is due at the end, from those who survived
+// the year; a lapse takes effect from the premium after.
+func premiumValue(policy *Policy, premium, chanceOfDeath float64, yearsFromNow int) float64 {
+	if policy.PremiumTiming == PremiumsAnnuallyInArrears {
+		return (1.0 - chanceOfDeath) * discount(policy, premium, yearsFromNow+1)
+	}
+	return discount(policy, premium, yearsFromNow)
+}
+
 func CalculateNetPremium(policy *Policy, mortalityTable MortalityTable) float64 {
 	if policy.ProductType == "whole_life" {
 		return CalculateWholeLifeNetPremium(policy, mortalityTable)
@@ -120,14 +558,67 @@ func CalculateNetPremium(policy *Policy, mortalityTable MortalityTable) float64
 	return CalculateTermLifeNetPremium(policy, mortalityTable)
 }
 
+// NetSinglePremium is the one-off payment at issue that funds the same
+// benefits as the annual net premium: the expected present value of the
+// benefits, before dividing by the value of the premiums
+func NetSinglePremium(policy *Policy, mortalityTable MortalityTable) float64 {
+	if policy.ProductType == "whole_life" {
+		expectedPayouts, _ := wholeLifeExpectedValues(policy, mortalityTable)
+		return expectedPayouts
+	}
+	expectedPayouts, _ := termLifeExpectedValues(policy, mortalityTable)
+	return expectedPayouts
+}
+
+// ErrZeroNetPremium means a life policy's net premium came out at zero: no
+// benefit is expected to be paid, or no premium to be collected
+var ErrZeroNetPremium = errors.New("the net premium is zero: the policy is expected to pay no benefit or collect no premium")
+
+// ErrNonFinitePremium means a premium came out infinite or not a number
+var ErrNonFinitePremium = errors.New("the premium is not a finite number")
+
+// How a zero net premium is handled
+const (
+	ZeroPremiumReject = "reject" // The default: a zero net premium is an error
+	ZeroPremiumWarn   = "warn"   // Quoted, with a warning that the gross premium is expenses alone
+)
+
+// CheckPremium makes sure a life policy's premiums can be quoted. A zero (or
+// negative) net premium would otherwise come back as a valid quote, and the
+// gross premium built on it would be the expenses alone; it is an error
+// unless zeroPremium is ZeroPremiumWarn. A premium that isn't finite is always
+// an error.
+func CheckPremium(netPremium, grossPremium float64, zeroPremium string) error {
+	for _, premium := range []float64{netPremium, grossPremium} {
+		if math.IsNaN(premium) || math.IsInf(premium, 0) {
+			return ErrNonFinitePremium
+		}
+	}
+	if netPremium <= 0 && zeroPremium != ZeroPremiumWarn {
+		return ErrZeroNetPremium
+	}
+	return nil
+}
+
+// netPremiumFrom divides the value of the benefits by the value of 1 a year of premium
+func netPremiumFrom(expectedPayouts, expectedPremiumsCollected float64) float64 {
+	if expectedPremiumsCollected > 0 {
+		return expectedPayouts / expectedPremiumsCollected
+	}
+	return 0
+}
+
 // CalculateTermLifeNetPremium calculates the fair premium for term life insurance.
 // It balances what the insurance company expects to pay out vs what they collect.
 func CalculateTermLifeNetPremium(policy *Policy, mortalityTable MortalityTable) float64 {
-	// Track total expected payouts and premium collections
-	expectedPayouts := 0.0
-	expectedPremiumsCollected := 0.0
+	return netPremiumFrom(termLifeExpectedValues(policy, mortalityTable))
+}
 
+// termLifeExpectedValues is the expected present value of a term policy's
+// benefits, and of 1 a year of premium
+func termLifeExpectedValues(policy *Policy, mortalityTable MortalityTable) (expectedPayouts, expectedPremiumsCollected float64) {
 	// Calculate for each year of the policy term
+	yearsPayingPremiums := PremiumPayingYears(policy) // Might be covered for 20 years but pay for 15
 	for yearOfPolicy := 0; yearOfPolicy < policy.Term; yearOfPolicy++ {
 		personAge := policy.Age + yearOfPolicy
 		
@@ -136,29 +627,47 @@ func CalculateTermLifeNetPremium(policy *Policy, mortalityTable MortalityTable)
 			break
 		}
 
-		// Calculate chance person is still alive at start of this year
+		// Calculate chance person is still alive (and hasn't lapsed) at start of this year
 		chanceStillAlive := calculateSurvivalProbability(policy.Age, yearOfPolicy, mortalityTable)
+		chanceStillAlive *= calculatePersistency(policy, 0, yearOfPolicy)
 		
 		// Get chance of dying this specific year
 		chanceOfDyingThisYear := mortalityTable[personAge]
 		
 		// Calculate present values (what future money is worth today)
-		deathPayoutToday := CalculatePresentValue(policy.CoverageAmount, policy.InterestRate, yearOfPolicy+1)
-		premiumToday := CalculatePresentValue(1.0, policy.InterestRate, yearOfPolicy)
+		deathPayoutToday := deathBenefitValue(policy, SumAssuredInYear(policy, yearOfPolicy), yearOfPolicy+1)
+		premiumToday := premiumValue(policy, 1.0, chanceOfDyingThisYear, yearOfPolicy)
 
 		// Add to our running totals
 		// Expected payout = chance alive * chance of dying * payout amount
 		expectedPayouts += chanceStillAlive * chanceOfDyingThisYear * deathPayoutToday
 		
-		// Expected premium = chance alive * premium unit
-		expectedPremiumsCollected += chanceStillAlive * premiumToday
+		// Expected premium = chance alive * premium unit, while premiums are payable
+		if yearOfPolicy < yearsPayingPremiums {
+			expectedPremiumsCollected += chanceStillAlive * premiumToday
+		}
 	}
 
-	// Premium = total expected payouts / total expected premium units
-	if expectedPremiumsCollected > 0 {
-		return expectedPayouts / expectedPremiumsCollected
+	// Survivors at the end of the term get the maturity benefit, if there is one
+	expectedPayouts += maturityBenefitValue(policy, mortalityTable, 0)
+
+	return expectedPayouts, expectedPremiumsCollected
+}
+
+// maturityBenefitValue is the value at policy year fromYear of the maturity
+// benefit paid at the end of the term (a pure endowment): it is only paid if the
+// person is still alive and the policy hasn't lapsed.
+func maturityBenefitValue(policy *Policy, mortalityTable MortalityTable, fromYear int) float64 {
+	if policy.MaturityBenefit <= 0 {
+		return 0
 	}
-	return 0
+	yearsToMaturity := policy.Term - fromYear
+	if policy.Age+policy.Term > len(mortalityTable) {
+		return 0 // Nobody survives past the end of the table
+	}
+	chanceAliveAtMaturity := calculateSurvivalProbability(policy.Age+fromYear, yearsToMaturity, mortalityTable)
+	chanceAliveAtMaturity *= calculatePersistency(policy, fromYear, yearsToMaturity)
+	return chanceAliveAtMaturity * discount(policy, policy.MaturityBenefit, yearsToMaturity)
 }
 
 // calculateSurvivalProbability calculates the chance someone survives to a certain year
@@ -176,17 +685,84 @@ func calculateSurvivalProbability(startAge int, yearsLater int, mortalityTable M
 	return survivalChance
 }
 
+// calculatePersistency calculates the chance a policy is still in force (not lapsed)
+// after yearsLater more years, starting from policy year startYear.
+// With no lapse assumption this is always 1.
+func calculatePersistency(policy *Policy, startYear int, yearsLater int) float64 {
+	persistency := 1.0
+	for year := 0; year < yearsLater; year++ {
+		persistency *= 1.0 - lapseRateForYear(policy, startYear+year)
+	}
+	return persistency
+}
+
+// lapseRateForYear returns the assumed lapse rate in a given policy year.
+// With dynamic lapses the rate moves with interest rates, and a mass lapse
+// comes on top of the usual rate in the first year.
+func lapseRateForYear(policy *Policy, policyYear int) float64 {
+	rate := policy.LapseRate
+	if policy.DynamicLapse != nil {
+		rate = policy.DynamicLapse.Rate(policy, rate, policyYear)
+	}
+	if policyYear == 0 && policy.MassLapse > 0 {
+		return 1 - (1-rate)*(1-policy.MassLapse)
+	}
+	return rate
+}
+
+// ErrAgeBeyondTable means the person is already at (or past) the last age in the
+// mortality table, so there is no year of coverage left to price.
+var ErrAgeBeyondTable = errors.New("age is at or beyond the mortality table's maximum age")
+
+// CheckWholeLifeAge makes sure a whole life policy has at least one year of
+// mortality data to work with. Without this guard a very old life would loop
+// zero times and come back with a premium of 0 - a free policy.
+func CheckWholeLifeAge(policy *Policy, mortalityTable MortalityTable) error {
+	oldestAgeInTable := len(mortalityTable) - 1
+	if policy.Age >= oldestAgeInTable {
+		return fmt.Errorf("%w: age %d, table ends at age %d", ErrAgeBeyondTable, policy.Age, oldestAgeInTable)
+	}
+	return nil
+}
+
+// WholeLifeHorizon is how many years of a whole life policy are valued: to
+// the last age in the table, or, when SurvivalThreshold is set, until the
+// chance of the policy still being in force drops below it. Young lives on a
+// table running to 120 spend most of the loops on years nobody reaches, and
+// the reserve schedule's cost grows with the square of the horizon.
+//
+// Everything after a truncated horizon is ignored. Each of those years is
+// weighted by a chance below the threshold, so the benefits left out are worth
+// less than threshold x sum assured in total (and the premiums left out less
+// than threshold x the value of an annuity of the premium).
+func WholeLifeHorizon(policy *Policy, mortalityTable MortalityTable) (years int, truncated bool) {
+	years = len(mortalityTable) - 1 - policy.Age
+	if policy.SurvivalThreshold <= 0 {
+		return years, false
+	}
+	chanceInForce := 1.0
+	for year := 0; year < years; year++ {
+		chanceInForce *= (1.0 - mortalityTable[policy.Age+year]) * (1.0 - lapseRateForYear(policy, year))
+		if chanceInForce < policy.SurvivalThreshold {
+			return year + 1, year+1 < years
+		}
+	}
+	return years, false
+}
+
 // CalculateWholeLifeNetPremium calculates premium for lifetime coverage.
 // Unlike term life, this covers until death whenever that happens.
 // Person might pay premiums for X years but coverage lasts their whole life.
 func CalculateWholeLifeNetPremium(policy *Policy, mortalityTable MortalityTable) float64 {
-	expectedPayouts := 0.0
-	expectedPremiumsCollected := 0.0
+	return netPremiumFrom(wholeLifeExpectedValues(policy, mortalityTable))
+}
 
+// wholeLifeExpectedValues is the expected present value of a whole life
+// policy's death benefit, and of 1 a year of premium while premiums are paid
+func wholeLifeExpectedValues(policy *Policy, mortalityTable MortalityTable) (expectedPayouts, expectedPremiumsCollected float64) {
 	// Coverage goes until maximum age in our table (usually 100-120 years)
-	oldestAgeInTable := len(mortalityTable) - 1
-	yearsOfCoverage := oldestAgeInTable - policy.Age
-	yearsPayingPremiums := policy.Term // Might pay for 20 years but covered for life
+	yearsOfCoverage, _ := WholeLifeHorizon(policy, mortalityTable)
+	yearsPayingPremiums := PremiumPayingYears(policy) // Might pay for 20 years but covered for life
 
 	// Calculate expected costs and premiums year by year
 	for yearOfPolicy := 0; yearOfPolicy < yearsOfCoverage; yearOfPolicy++ {
@@ -196,26 +772,133 @@ func CalculateWholeLifeNetPremium(policy *Policy, mortalityTable MortalityTable)
 			break // No more data
 		}
 
-		// What's the chance person is still alive this year?
+		// What's the chance person is still alive (and hasn't lapsed) this year?
 		chanceStillAlive := calculateSurvivalProbability(policy.Age, yearOfPolicy, mortalityTable)
+		chanceStillAlive *= calculatePersistency(policy, 0, yearOfPolicy)
 		
 		// Death benefit calculation (same as term life)
 		chanceOfDyingThisYear := mortalityTable[personAge]
-		deathPayoutToday := CalculatePresentValue(policy.CoverageAmount, policy.InterestRate, yearOfPolicy+1)
+		deathPayoutToday := deathBenefitValue(policy, policy.CoverageAmount, yearOfPolicy+1)
 		expectedPayouts += chanceStillAlive * chanceOfDyingThisYear * deathPayoutToday
 
 		// Premium collection (only during payment period)
 		if yearOfPolicy < yearsPayingPremiums {
-			premiumToday := CalculatePresentValue(1.0, policy.InterestRate, yearOfPolicy)
+			premiumToday := premiumValue(policy, 1.0, chanceOfDyingThisYear, yearOfPolicy)
 			expectedPremiumsCollected += chanceStillAlive * premiumToday
 		}
 	}
 
-	// Calculate fair premium
-	if expectedPremiumsCollected > 0 {
-		return expectedPayouts / expectedPremiumsCollected
+	return expectedPayouts, expectedPremiumsCollected
+}
+
+// ModalFactors is the commercial loading for paying premiums more often than
+// once a year. It covers the extra collection costs and the interest lost by
+// receiving the money in pieces. Monthly at 1.05 means each installment is
+// 1/12 x 1.05 of the annual premium.
+var ModalFactors = map[string]float64{
+	"annual":      1.0,
+	"semi_annual": 1.02,
+	"quarterly":   1.03,
+	"monthly":     1.05,
+}
+
+// PaymentsPerYear is how many installments each payment frequency has
+var PaymentsPerYear = map[string]int{
+	"annual":      1,
+	"semi_annual": 2,
+	"quarterly":   4,
+	"monthly":     12,
+}
+
+// ModalPremium splits an annual gross premium into installments at the given
+// frequency (annual when empty), returning the modal factor used and the
+// amount of each installment.
+func ModalPremium(annualGrossPremium float64, frequency string) (modalFactor float64, installment float64) {
+	if frequency == "" {
+		frequency = "annual"
 	}
-	return 0
+	modalFactor, ok := ModalFactors[frequency]
+	if !ok {
+		modalFactor = 1.0
+	}
+	payments := max(PaymentsPerYear[frequency], 1)
+	return modalFactor, annualGrossPremium * modalFactor / float64(payments)
+}
+
+// ModalImpliedRate is the effective annual interest rate a payment
+// frequency's modal loading charges. Paying in installments rather than the
+// annual premium up front is a loan of the annual premium, less the first
+// installment, repaid by the rest; the implied rate is the internal rate of
+// return of that loan. It doesn't depend on the size of the premium, and
+// ignores the installments death would stop. If it is above what the
+// policyholder's money earns, paying annually is worthwhile. ok is false for
+// annual payment and unknown frequencies, which have no installments.
+func ModalImpliedRate(frequency string) (rate float64, ok bool) {
+	modalFactor, known := ModalFactors[frequency]
+	payments := PaymentsPerYear[frequency]
+	if !known || payments <= 1 {
+		return 0, false
+	}
+	installment := modalFactor / float64(payments)
+	cashflows := make([]float64, payments)
+	cashflows[0] = 1 - installment
+	for period := 1; period < payments; period++ {
+		cashflows[period] = -installment
+	}
+	periodRate, err := FindIRR(cashflows)
+	if err != nil {
+		return 0, false
+	}
+	return math.Pow(1+periodRate, float64(payments)) - 1, true
+}
+
+// ExtendMortalityTable carries mortality on past the table's last age so whole
+// life policies count the people who outlive the table. Without it everyone
+// still alive at the table's last age simply drops out of the calculation.
+//
+// Two methods are supported:
+//   - "immediate": the table's rates are used as-is and qx = 1 in the year after
+//   - "linear": qx climbs in a straight line from the last rate to 1 at ultimateAge
+//
+// It returns the extended table and the ultimate age - the age at which qx is 1.
+// The whole life loops treat the last row as the end of the table, so one
+// extra row is added after the ultimate age.
+func ExtendMortalityTable(mortalityTable MortalityTable, method string, ultimateAge int) (MortalityTable, int) {
+	lastAge := len(mortalityTable) - 1
+	if method != "linear" || ultimateAge <= lastAge {
+		// A ramp that reaches 1 in the very next year is the immediate method
+		ultimateAge = lastAge + 1
+	}
+
+	extendedTable := make(MortalityTable, ultimateAge+2)
+	copy(extendedTable, mortalityTable)
+
+	lastRate := mortalityTable[lastAge]
+	yearsToUltimate := float64(ultimateAge - lastAge)
+	for age := lastAge + 1; age <= ultimateAge; age++ {
+		extendedTable[age] = lastRate + (1.0-lastRate)*float64(age-lastAge)/yearsToUltimate
+	}
+	extendedTable[ultimateAge+1] = 1.0
+
+	return extendedTable, ultimateAge
+}
+
+// PremiumPayingYears is how many years premiums are paid for. That is the Term,
+// except that a whole life policy with a PremiumCeaseAge stops at that attained
+// age - e.g. premiums cease at 100 - even if the Term runs longer, and a term
+// policy with a PremiumPayingTerm (20-year term, 15-pay) stops after it.
+func PremiumPayingYears(policy *Policy) int {
+	premiumYears := policy.Term
+	if policy.ProductType == "term_life" && policy.PremiumPayingTerm > 0 {
+		return min(policy.PremiumPayingTerm, policy.Term)
+	}
+	if policy.ProductType == "whole_life" && policy.PremiumCeaseAge > 0 {
+		yearsToCeaseAge := max(policy.PremiumCeaseAge-policy.Age, 0)
+		if premiumYears == 0 || yearsToCeaseAge < premiumYears {
+			premiumYears = yearsToCeaseAge
+		}
+	}
+	return premiumYears
 }
 
 // CreateDefaultExpenses returns standard insurance company expense assumptions.
@@ -229,13 +912,61 @@ func CreateDefaultExpenses() ExpenseStructure {
 	}
 }
 
+// ApplyMinimumPremium raises a gross premium to the minimum the company will
+// charge. It returns the premium to charge and the extra loading that implies
+// over the calculated premium (0 when the floor doesn't bite).
+func ApplyMinimumPremium(grossPremium, minimumPremium float64) (charged float64, loading float64) {
+	if minimumPremium <= 0 || grossPremium >= minimumPremium {
+		return grossPremium, 0
+	}
+	if grossPremium > 0 {
+		loading = minimumPremium/grossPremium - 1
+	}
+	return minimumPremium, loading
+}
+
+// ApplyPremiumTax is the premium tax on a gross premium and the premium the
+// policyholder pays with it. The tax is levied on the premium charged, after
+// any discount or minimum, and plays no part in pricing: reserves, profit and
+// strain all use the premium before tax.
+func ApplyPremiumTax(grossPremium, taxRate float64) (tax float64, taxInclusive float64) {
+	tax = grossPremium * taxRate
+	return tax, grossPremium + tax
+}
+
+// DefaultAnnuityLoading is the loading added to an annuity's single premium.
+// Annuities are priced with this loading rather than an ExpenseStructure.
+const DefaultAnnuityLoading = 0.10
+
+// ProductExpenses holds the default expenses for each life product. Whole life
+// is sold with higher commission and kept on the books for longer than term.
+// Products not listed here use CreateDefaultExpenses.
+var ProductExpenses = map[string]ExpenseStructure{
+	"term_life": CreateDefaultExpenses(),
+	"whole_life": {
+		InitialExpenseRate: 0.04, // Higher first-year commission
+		RenewalExpenseRate: 0.05,
+		MaintenanceExpense: 60.0, // Administered for life
+		ProfitMargin:       0.15,
+	},
+}
+
+// ExpensesForProduct returns the default expenses for a product type
+func ExpensesForProduct(productType string) ExpenseStructure {
+	if productType == "" {
+		productType = "term_life"
+	}
+	if expenses, ok := ProductExpenses[productType]; ok {
+		return expenses
+	}
+	return CreateDefaultExpenses()
+}
+
 // CalculateGrossPremium adds company expenses and profit to the net premium.
 // Net premium = pure cost of death benefit
 // Gross premium = what customer actually pays (includes expenses + profit)
 func CalculateGrossPremium(policy *Policy, mortalityTable MortalityTable, netPremium float64, expenses ExpenseStructure) float64 {
-	// One-time setup costs spread over policy term
-	setupCost := policy.CoverageAmount * expenses.InitialExpenseRate
-	setupCostPerYear := setupCost / float64(policy.Term)
+	setupCostPerYear := setupCostPerPremium(policy, expenses)
 	
 	// Profit the company wants to make
 	profitAmount := netPremium * expenses.ProfitMargin
@@ -250,23 +981,115 @@ func CalculateGrossPremium(policy *Policy, mortalityTable MortalityTable, netPre
 		grossPremium = netPremium + profitAmount + yearlyExpenses
 	}
 
-	// Round to 2 decimal places (cents)
-	return math.Round(grossPremium*100) / 100
+	// Kept at full precision - rounding to cents is done when results are displayed
+	return grossPremium
 }
 
+// setupCostPerPremium spreads the one-time setup cost over the premiums we
+// expect to collect. Lapses mean fewer premiums, so each one has to carry
+// more of the cost.
+func setupCostPerPremium(policy *Policy, expenses ExpenseStructure) float64 {
+	setupCost := SumAssuredInYear(policy, 0) * expenses.InitialExpenseRate
+	expectedPremiumYears := 0.0
+	for year := 0; year < PremiumPayingYears(policy); year++ {
+		expectedPremiumYears += calculatePersistency(policy, 0, year)
+	}
+	return setupCost / expectedPremiumYears
+}
+
+// PremiumBreakdown splits a gross premium into what it pays for: the net
+// premium for the benefits, the expenses it is loaded for and the profit left
+// over. The three add up to the gross premium.
+type PremiumBreakdown struct {
+	NetPremium     float64 `json:"net_premium"`
+	ExpenseLoading float64 `json:"expense_loading"` // Setup cost spread over the premiums, commission and maintenance
+	ProfitLoading  float64 `json:"profit_loading"`  // The rest: the profit margin, less any volume discount, plus any raise to a minimum premium
+}
+
+// BreakDownPremium splits the gross premium charged into net premium, the
+// expense loading CalculateGrossPremium builds in from the expense structure -
+// its share of the setup cost, the commission on the premium charged and the
+// maintenance expense - and the profit loading, whatever remains
+func BreakDownPremium(policy *Policy, netPremium, grossPremium float64, expenses ExpenseStructure) PremiumBreakdown {
+	expenseLoading := setupCostPerPremium(policy, expenses) + grossPremium*expenses.RenewalExpenseRate + expenses.MaintenanceExpense
+	return PremiumBreakdown{
+		NetPremium:     netPremium,
+		ExpenseLoading: expenseLoading,
+		ProfitLoading:  grossPremium - netPremium - expenseLoading,
+	}
+}
+
+// NewBusinessStrain is the capital a new policy uses up in its first year: the
+// first-year expenses plus the reserve that must be set up at the end of year
+// one, less the first gross premium. A positive strain means the policy costs
+// capital in year one; negative means the first premium more than pays for it.
+//
+// First-year expenses are the whole setup cost (sum assured x initial rate)
+// plus the commission on the premium and the yearly maintenance expense.
+// reserveSchedule must start at issue.
+func NewBusinessStrain(policy *Policy, grossPremium float64, expenses ExpenseStructure, reserveSchedule []float64) float64 {
+	firstYearExpenses := SumAssuredInYear(policy, 0)*expenses.InitialExpenseRate +
+		grossPremium*expenses.RenewalExpenseRate +
+		expenses.MaintenanceExpense
+
+	reserveAfterYearOne := 0.0
+	if len(reserveSchedule) > 1 {
+		reserveAfterYearOne = reserveSchedule[1]
+	}
+	return firstYearExpenses + reserveAfterYearOne - grossPremium
+}
+
+// CalculateReserveSchedule returns the reserve at each policy year. For a policy
+// that is already in force (InForceDuration > 0) the schedule starts at the
+// current duration, so entry 0 is today's reserve at attained age Age+InForceDuration.
 func CalculateReserveSchedule(policy *Policy, mortalityTable MortalityTable, netPremium float64) []float64 {
-	if policy.ProductType == "whole_life" {
-		return CalculateWholeLifeReserveSchedule(policy, mortalityTable, netPremium)
+	var reserveSchedule []float64
+	if policy.ReserveApproach == ReserveRetrospective {
+		reserveSchedule = CalculateRetrospectiveReserveSchedule(policy, mortalityTable, netPremium)
+	} else if policy.ProductType == "whole_life" {
+		reserveSchedule = CalculateWholeLifeReserveSchedule(policy, mortalityTable, netPremium)
+	} else {
+		reserveSchedule = CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
 	}
-	return CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+	return InForceReserves(policy, reserveSchedule)
 }
 
+// InForceReserves drops the years that have already passed from a reserve
+// schedule that starts at issue. Age is always the age at issue, so the reserve
+// still uses the premium set when the policy was sold.
+func InForceReserves(policy *Policy, reserveSchedule []float64) []float64 {
+	yearsElapsed := policy.InForceDuration
+	if yearsElapsed <= 0 {
+		return reserveSchedule
+	}
+	if yearsElapsed >= len(reserveSchedule) {
+		return []float64{}
+	}
+	return reserveSchedule[yearsElapsed:]
+}
+
+// NetAmountAtRisk is what the insurer would lose beyond the reserve it already
+// holds if the person died in each policy year: max(0, sum assured - reserve).
+// This is the amount cost-of-insurance charges and reinsurance cessions are based on.
+func NetAmountAtRisk(coverageAmount float64, reserveSchedule []float64) []float64 {
+	amountsAtRisk := make([]float64, len(reserveSchedule))
+	for year, reserve := range reserveSchedule {
+		amountsAtRisk[year] = math.Max(0, coverageAmount-reserve)
+	}
+	return amountsAtRisk
+}
+
+// CalculateTermLifeReserveSchedule is the prospective reserve at each duration
+// from issue. Rates are read at the attained age (issue age + duration), so on
+// a select table's PolicyTable the reserve uses select rates for whatever is
+// left of the select period and ultimate rates after it.
 func CalculateTermLifeReserveSchedule(policy *Policy, mortalityTable MortalityTable, netPremium float64) []float64 {
 	reserveSchedule := make([]float64, policy.Term+1)
 
 	for currentYear := 0; currentYear <= policy.Term; currentYear++ {
 		if currentYear == policy.Term {
-			reserveSchedule[currentYear] = 0
+			// At maturity the reserve is exactly what is about to be paid out
+			reserveSchedule[currentYear] = policy.MaturityBenefit
 			continue
 		}
 
@@ -285,14 +1108,19 @@ func CalculateTermLifeReserveSchedule(policy *Policy, mortalityTable MortalityTa
 			for yearIndex := 0; yearIndex < futureYear; yearIndex++ {
 				survivalProbability *= (1.0 - mortalityTable[currentAgeAtYear+yearIndex])
 			}
+			survivalProbability *= calculatePersistency(policy, currentYear, futureYear)
 
 			deathProbability := mortalityTable[ageAtFutureYear]
-			benefitPresentValue := CalculatePresentValue(policy.CoverageAmount, policy.InterestRate, futureYear+1)
-			premiumPresentValue := CalculatePresentValue(netPremium, policy.InterestRate, futureYear)
-
+			benefitPresentValue := deathBenefitValue(policy, SumAssuredInYear(policy, currentYear+futureYear), futureYear+1)
 			futureBenefitValue += survivalProbability * deathProbability * benefitPresentValue
-			futurePremiumValue += survivalProbability * premiumPresentValue
+
+			// Premium payments only during premium paying period
+			if currentYear+futureYear < PremiumPayingYears(policy) {
+				premiumPresentValue := premiumValue(policy, netPremium, deathProbability, futureYear)
+				futurePremiumValue += survivalProbability * premiumPresentValue
+			}
 		}
+		futureBenefitValue += maturityBenefitValue(policy, mortalityTable, currentYear)
 
 		reserveSchedule[currentYear] = futureBenefitValue - futurePremiumValue
 	}
@@ -301,8 +1129,7 @@ func CalculateTermLifeReserveSchedule(policy *Policy, mortalityTable MortalityTa
 }
 
 func CalculateWholeLifeReserveSchedule(policy *Policy, mortalityTable MortalityTable, netPremium float64) []float64 {
-	maxAge := len(mortalityTable) - 1
-	lifetimeYears := maxAge - policy.Age
+	lifetimeYears, _ := WholeLifeHorizon(policy, mortalityTable)
 	reserveSchedule := make([]float64, lifetimeYears+1)
 
 	for currentYear := 0; currentYear <= lifetimeYears; currentYear++ {
@@ -325,14 +1152,15 @@ func CalculateWholeLifeReserveSchedule(policy *Policy, mortalityTable MortalityT
 			for yearIndex := 0; yearIndex < futureYear; yearIndex++ {
 				survivalProbability *= (1.0 - mortalityTable[currentAgeAtYear+yearIndex])
 			}
+			survivalProbability *= calculatePersistency(policy, currentYear, futureYear)
 
 			deathProbability := mortalityTable[ageAtFutureYear]
-			benefitPresentValue := CalculatePresentValue(policy.CoverageAmount, policy.InterestRate, futureYear+1)
+			benefitPresentValue := deathBenefitValue(policy, policy.CoverageAmount, futureYear+1)
 			futureBenefitValue += survivalProbability * deathProbability * benefitPresentValue
 
 			// Premium payments only during premium paying period
-			if currentYear+futureYear < policy.Term {
-				premiumPresentValue := CalculatePresentValue(netPremium, policy.InterestRate, futureYear)
+			if currentYear+futureYear < PremiumPayingYears(policy) {
+				premiumPresentValue := premiumValue(policy, netPremium, deathProbability, futureYear)
 				futurePremiumValue += survivalProbability * premiumPresentValue
 			}
 		}
@@ -343,44 +1171,335 @@ func CalculateWholeLifeReserveSchedule(policy *Policy, mortalityTable MortalityT
 	return reserveSchedule
 }
 
+// How a reserve schedule is worked out. The two agree when the net premium is
+// the one the same basis gives, so either is a check on the other.
+const (
+	ReserveProspective   = "prospective"   // Value of future benefits less value of future premiums
+	ReserveRetrospective = "retrospective" // Premiums received less claims paid, accumulated for the survivors
+)
+
+// CalculateRetrospectiveReserveSchedule is the reserve at each duration from
+// issue worked out from the past: each year the fund takes in the net premium
+// from everyone still in force, earns a year's interest, pays the death
+// claims, and is shared among the policies still in force a year later:
+//
+//	reserve_t+1 = ((reserve_t + premium) x (1+i) - q x sum assured) / p
+//
+// Premiums in arrears come in at the end of the year from the survivors
+// instead, adding (1-q) x premium after the interest.
+//
+// Here p is the chance of surviving and not lapsing, and a claim paid after a
+// settlement delay is its value at the end of the year. Lapses take nothing,
+// as in the prospective reserve. A year's interest is at the pricing rate,
+// so on a yield curve the two methods only agree roughly. The schedule
+// covers the same durations as the prospective one for the product.
+func CalculateRetrospectiveReserveSchedule(policy *Policy, mortalityTable MortalityTable, netPremium float64) []float64 {
+	years := policy.Term
+	if policy.ProductType == "whole_life" {
+		years, _ = WholeLifeHorizon(policy, mortalityTable)
+	}
+	reserveSchedule := make([]float64, max(years, 0)+1)
+
+	interestGrowth := 1.0 / discount(policy, 1.0, 1)
+	premiumYears := PremiumPayingYears(policy)
+	for year := 0; year < years; year++ {
+		personAge := policy.Age + year
+		if personAge >= len(mortalityTable) {
+			break
+		}
+		chanceOfDeath := mortalityTable[personAge]
+		chanceStaying := (1.0 - chanceOfDeath) * (1.0 - lapseRateForYear(policy, year))
+		if chanceStaying <= 0 {
+			break // Nobody is left to hold a reserve
+		}
+
+		premiumInAdvance, premiumInArrears := 0.0, 0.0
+		if year < premiumYears && policy.PremiumTiming == PremiumsAnnuallyInArrears {
+			premiumInArrears = (1.0 - chanceOfDeath) * netPremium
+		} else if year < premiumYears {
+			premiumInAdvance = netPremium
+		}
+		claimCost := SumAssuredInYear(policy, year) * settlementFactor(policy)
+		reserveSchedule[year+1] = ((reserveSchedule[year]+premiumInAdvance)*interestGrowth +
+			premiumInArrears - chanceOfDeath*claimCost) / chanceStaying
+	}
+	return reserveSchedule
+}
+
+// DefaultSimplifiedIssueLoadings is the select loading applied to simplified-issue
+// (no medical) business by policy year. Without medical evidence, people who know
+// they are unwell are more likely to buy, so early mortality is heavier. That
+// anti-selection wears off as the policy ages:
+//
+//	Year 1: qx x 1.500
+//	Year 2: qx x 1.375
+//	Year 3: qx x 1.250
+//	Year 4: qx x 1.125
+//	Year 5+: standard rates
+//
+// Change this to adjust the schedule for every policy, or send
+// simplified_issue_loadings with a policy to override it for that quote.
+var DefaultSimplifiedIssueLoadings = []float64{1.5, 1.375, 1.25, 1.125}
+
+// SimplifiedIssueLoadings returns the select loadings that apply to a policy,
+// or nil when it isn't simplified issue
+func SimplifiedIssueLoadings(policy *Policy) []float64 {
+	if policy.UnderwritingClass != "simplified_issue" {
+		return nil
+	}
+	if len(policy.SimplifiedIssueLoadings) > 0 {
+		return policy.SimplifiedIssueLoadings
+	}
+	return DefaultSimplifiedIssueLoadings
+}
+
+// DefaultAntiSelectionLoadings is the loading applied to renewable term by
+// policy year. Healthy lives lapse and buy again at fully underwritten rates,
+// so those who keep renewing without fresh evidence are increasingly the worse
+// risks. Unlike the simplified-issue loading this grows with duration, and the
+// last loading carries on for the rest of the policy:
+//
+//	Year 1: standard rates
+//	Year 2: qx x 1.05
+//	Year 3: qx x 1.10
+//	Year 4: qx x 1.15
+//	Year 5+: qx x 1.20
+//
+// Change this to adjust the shape for every policy, or send
+// anti_selection_loadings with a policy to override it for that quote.
+var DefaultAntiSelectionLoadings = []float64{1.0, 1.05, 1.10, 1.15, 1.20}
+
+// AntiSelectionLoadings returns the renewal loadings that apply to a policy,
+// or nil when it isn't renewable term
+func AntiSelectionLoadings(policy *Policy) []float64 {
+	if !policy.Renewable || (policy.ProductType != "" && policy.ProductType != "term_life") {
+		return nil
+	}
+	if len(policy.AntiSelectionLoadings) > 0 {
+		return policy.AntiSelectionLoadings
+	}
+	return DefaultAntiSelectionLoadings
+}
+
+// UnderwritingConfig maps each smoker status and health rating to the multiplier
+// applied to the mortality rates. Calibrate it to your own claims experience.
+// A status or rating that isn't listed gets a multiplier of 1 (standard rates).
+type UnderwritingConfig struct {
+	SmokerStatus map[string]float64         `json:"smoker_status"`
+	HealthRating map[string]float64         `json:"health_rating"`
+	Impairments  map[string]ImpairmentBasis `json:"impairments,omitempty"`
+}
+
+// ImpairmentBasis is an underwriter's mortality basis for one impairment, such
+// as "diabetic" or "hypertensive". A registered impairment replaces the
+// health rating multiplier (the generic 1.5 for substandard lives) with
+// rates that follow the condition's own pattern by age.
+//
+// Table, when set, replaces the base qx at each age it covers. RatingCurve
+// multiplies the rates by attained age, interpolating linearly between its
+// points and holding the end values beyond them. Either or both may be set.
+type ImpairmentBasis struct {
+	Table       MortalityTable `json:"table,omitempty"`
+	RatingCurve []RatingPoint  `json:"rating_curve,omitempty"`
+}
+
+// RatingPoint is an impairment's mortality multiplier at one attained age
+type RatingPoint struct {
+	Age        int     `json:"age"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Source says which kind of impairment data the basis holds, for reporting
+func (impairment ImpairmentBasis) Source() string {
+	switch {
+	case len(impairment.Table) > 0 && len(impairment.RatingCurve) > 0:
+		return "table_and_rating_curve"
+	case len(impairment.Table) > 0:
+		return "table"
+	default:
+		return "rating_curve"
+	}
+}
+
+// Rate is the impaired mortality rate at age, given the base table's rate
+func (impairment ImpairmentBasis) Rate(age int, baseRate float64) float64 {
+	if age >= 0 && age < len(impairment.Table) {
+		baseRate = impairment.Table[age]
+	}
+	return baseRate * impairment.Multiplier(age)
+}
+
+// Multiplier reads the rating curve at age; 1 when there is no curve
+func (impairment ImpairmentBasis) Multiplier(age int) float64 {
+	curve := impairment.RatingCurve
+	if len(curve) == 0 {
+		return 1.0
+	}
+	if age <= curve[0].Age {
+		return curve[0].Multiplier
+	}
+	for i := 1; i < len(curve); i++ {
+		if age <= curve[i].Age {
+			below, above := curve[i-1], curve[i]
+			weight := float64(age-below.Age) / float64(above.Age-below.Age)
+			return below.Multiplier + weight*(above.Multiplier-below.Multiplier)
+		}
+	}
+	return curve[len(curve)-1].Multiplier
+}
+
+// ImpairmentFor returns the registered basis for the policy's impairment, if
+// it has one and one is registered
+func (config UnderwritingConfig) ImpairmentFor(policy *Policy) (ImpairmentBasis, bool) {
+	if policy.Impairment == "" {
+		return ImpairmentBasis{}, false
+	}
+	impairment, ok := config.Impairments[policy.Impairment]
+	return impairment, ok
+}
+
+// DefaultUnderwritingConfig returns the standard multipliers
+func DefaultUnderwritingConfig() UnderwritingConfig {
+	return UnderwritingConfig{
+		SmokerStatus: map[string]float64{
+			"smoker":     2.0, // Smokers have roughly 2x mortality
+			"non_smoker": 0.8, // Non-smokers get a discount
+		},
+		HealthRating: map[string]float64{
+			"preferred":   0.75, // 25% discount for preferred risks
+			"substandard": 1.5,  // 50% loading for substandard risks
+		},
+	}
+}
+
+// Multipliers returns the smoker and health multipliers for a policy
+func (config UnderwritingConfig) Multipliers(policy *Policy) (smokerMultiplier float64, healthMultiplier float64) {
+	smokerMultiplier, healthMultiplier = 1.0, 1.0
+	if multiplier, ok := config.SmokerStatus[policy.SmokerStatus]; ok {
+		smokerMultiplier = multiplier
+	}
+	if _, ok := config.ImpairmentFor(policy); ok {
+		return smokerMultiplier, healthMultiplier // The impairment basis replaces the health rating
+	}
+	healthRating := policy.HealthRating
+	if healthRating == "" && policy.Impairment != "" {
+		healthRating = "substandard" // An impaired life without its own basis gets the generic loading
+	}
+	if multiplier, ok := config.HealthRating[healthRating]; ok {
+		healthMultiplier = multiplier
+	}
+	return smokerMultiplier, healthMultiplier
+}
+
 // Apply underwriting factors to mortality table
 func ApplyUnderwritingFactors(policy *Policy, baseMortalityTable MortalityTable) MortalityTable {
+	return ApplyUnderwritingFactorsWithConfig(policy, baseMortalityTable, DefaultUnderwritingConfig())
+}
+
+// ApplyUnderwritingFactorsWithConfig applies underwriting using the given multipliers
+func ApplyUnderwritingFactorsWithConfig(policy *Policy, baseMortalityTable MortalityTable, config UnderwritingConfig) MortalityTable {
 	adjustedTable := make(MortalityTable, len(baseMortalityTable))
 	copy(adjustedTable, baseMortalityTable)
 
 	// Apply rating factor
 	ratingMultiplier := 1.0
+	var impairment ImpairmentBasis
+	impaired := false
 	if policy.RatingFactor > 0 {
 		ratingMultiplier = policy.RatingFactor
 	} else {
 		// Apply standard underwriting factors
-		switch policy.SmokerStatus {
-		case "smoker":
-			ratingMultiplier = 2.0 // Smokers have roughly 2x mortality
-		case "non_smoker":
-			ratingMultiplier = 0.8 // Non-smokers get a discount
-		default:
-			ratingMultiplier = 1.0
-		}
-
-		switch policy.HealthRating {
-		case "preferred":
-			ratingMultiplier *= 0.75 // 25% discount for preferred risks
-		case "substandard":
-			ratingMultiplier *= 1.5 // 50% loading for substandard risks
-		default:
-			ratingMultiplier *= 1.0
-		}
+		smokerMultiplier, healthMultiplier := config.Multipliers(policy)
+		ratingMultiplier = smokerMultiplier * healthMultiplier
+		impairment, impaired = config.ImpairmentFor(policy)
 	}
 
 	// Apply the multiplier to all mortality rates, capping at 1.0
 	for i, rate := range adjustedTable {
+		if impaired {
+			rate = impairment.Rate(i, rate)
+		}
 		adjustedTable[i] = math.Min(rate*ratingMultiplier, 1.0)
 	}
 
+	// Simplified issue: extra loading in the first few policy years only.
+	// The table is per policy, so policy year d lives at age Age+d.
+	for policyYear, loading := range SimplifiedIssueLoadings(policy) {
+		age := policy.Age + policyYear
+		if age < 0 || age >= len(adjustedTable) {
+			break
+		}
+		adjustedTable[age] = math.Min(adjustedTable[age]*loading, 1.0)
+	}
+
+	// Renewable term: a loading that grows with duration and then stays at
+	// its last value for every later year
+	if renewalLoadings := AntiSelectionLoadings(policy); renewalLoadings != nil {
+		for age := max(policy.Age, 0); age < len(adjustedTable); age++ {
+			loading := renewalLoadings[min(age-policy.Age, len(renewalLoadings)-1)]
+			adjustedTable[age] = math.Min(adjustedTable[age]*loading, 1.0)
+		}
+	}
+
 	return adjustedTable
 }
 
+// UnderwritingSignature identifies the adjustment ApplyUnderwritingFactorsWithConfig
+// makes for a policy: policies with the same signature under the same config
+// get the same table from the same base table. It holds the combined
+// multiplier, the impairment (by name, so only within one config) and, when
+// select or renewal loadings apply, the age they start from and the loadings.
+func UnderwritingSignature(policy *Policy, config UnderwritingConfig) string {
+	var signature strings.Builder
+	if policy.RatingFactor > 0 {
+		fmt.Fprintf(&signature, "rating=%v", policy.RatingFactor)
+	} else {
+		smokerMultiplier, healthMultiplier := config.Multipliers(policy)
+		fmt.Fprintf(&signature, "rating=%v", smokerMultiplier*healthMultiplier)
+		if _, impaired := config.ImpairmentFor(policy); impaired {
+			fmt.Fprintf(&signature, ";impairment=%s", policy.Impairment)
+		}
+	}
+	selectLoadings, renewalLoadings := SimplifiedIssueLoadings(policy), AntiSelectionLoadings(policy)
+	if selectLoadings != nil || renewalLoadings != nil {
+		fmt.Fprintf(&signature, ";age=%d;select=%v;renewal=%v", policy.Age, selectLoadings, renewalLoadings)
+	}
+	return signature.String()
+}
+
+// When in each year an annuity pays. The product called an immediate annuity
+// starts paying now rather than after a deferral period; either product can
+// pay in advance or in arrears.
+const (
+	AnnuityDue       = "due"       // At the start of each year, the first payment straight away
+	AnnuityImmediate = "immediate" // At the end of each year, only to those still alive then
+)
+
+// annuityPayment is the value today of the payment for year, paid to a life
+// alive at the start of that year with the given chance. Paid in arrears, the
+// life must also survive the year, and the money is a year further off.
+//
+// A fractional deferral moves every payment a further part of a year out. The
+// chance of surviving that part is interpolated from the qx of the year the
+// whole deferral ends in, assuming deaths are spread evenly through it:
+// 1 - fraction x qx. With a whole deferral period of 0 that is the first qx.
+func annuityPayment(policy *Policy, mortalityTable MortalityTable, year int, survivalProbability float64) float64 {
+	if policy.AnnuityTiming == AnnuityImmediate {
+		survivalProbability *= 1.0 - mortalityTable[policy.Age+year]
+		year++
+	}
+	if policy.DeferralFraction <= 0 {
+		return survivalProbability * discount(policy, policy.CoverageAmount, year)
+	}
+	survivalProbability *= 1.0 - policy.DeferralFraction*mortalityTable[policy.Age+policy.DeferralPeriod]
+	return survivalProbability * discountFractional(policy, policy.CoverageAmount, float64(year)+policy.DeferralFraction)
+}
+
+// EffectiveDeferral is a deferred annuity's deferral in years, whole and fractional
+func EffectiveDeferral(policy *Policy) float64 {
+	return float64(policy.DeferralPeriod) + policy.DeferralFraction
+}
+
 // Calculate immediate annuity premium
 func CalculateImmediateAnnuityPremium(policy *Policy, mortalityTable MortalityTable) float64 {
 	totalPresentValue := 0.0
@@ -397,13 +1516,93 @@ func CalculateImmediateAnnuityPremium(policy *Policy, mortalityTable MortalityTa
 			survivalProbability *= (1.0 - mortalityTable[policy.Age+previousYear])
 		}
 
-		annuityPaymentPV := CalculatePresentValue(policy.CoverageAmount, policy.InterestRate, year)
-		totalPresentValue += survivalProbability * annuityPaymentPV
+		totalPresentValue += annuityPayment(policy, mortalityTable, year, survivalProbability)
 	}
 
 	return totalPresentValue
 }
 
+// CashRefundValue is the value today of a cash refund on an immediate annuity
+// bought for purchasePrice: on death, the part of the price the payments so far
+// haven't returned, paid at the end of the year of death. Paid in advance, a
+// life dying in year t has had t+1 payments; paid in arrears, t. Once the
+// payments pass the price there is nothing left to refund.
+func CashRefundValue(policy *Policy, mortalityTable MortalityTable, purchasePrice float64) float64 {
+	value := 0.0
+	chanceStillAlive := 1.0
+	for year := 0; year < len(mortalityTable)-1-policy.Age; year++ {
+		paymentsReceived := float64(year+1) * policy.CoverageAmount
+		if policy.AnnuityTiming == AnnuityImmediate {
+			paymentsReceived = float64(year) * policy.CoverageAmount
+		}
+		refund := purchasePrice - paymentsReceived
+		if refund <= 0 {
+			break
+		}
+		currentAge := policy.Age + year
+		value += chanceStillAlive * mortalityTable[currentAge] * discount(policy, refund, year+1)
+		chanceStillAlive *= 1.0 - mortalityTable[currentAge]
+	}
+	return value
+}
+
+// SolveCashRefundPrice finds the price of a cash-refund immediate annuity. The
+// refund depends on the price and the price on the refund:
+//
+//	price = (annuity value + CashRefundValue(price)) x (1 + loading)
+//
+// so it is solved by fixed-point iteration, starting from the price without
+// a refund. A unit more price adds at most the discounted chance of dying while
+// a refund is due to the refund, so each step moves less than the last and
+// the iteration settles unless the loading is extreme. A step counts as
+// settled when it moves the price by less than the solver's tolerance,
+// relative to the price. If the price never settles, the last price is
+// returned with a *ConvergenceError.
+func SolveCashRefundPrice(policy *Policy, mortalityTable MortalityTable, annuityValue, loading float64, solver SolverConfig) (price float64, iterations int, err error) {
+	solver = solver.orDefault()
+	price = annuityValue * (1 + loading)
+	residual := 0.0
+	for iterations < solver.MaxIterations {
+		iterations++
+		next := (annuityValue + CashRefundValue(policy, mortalityTable, price)) * (1 + loading)
+		residual = math.Abs(next - price)
+		if residual <= solver.Tolerance*math.Max(next, 1) {
+			return next, iterations, nil
+		}
+		price = next
+	}
+	return price, iterations, &ConvergenceError{Solve: "cash refund price", Iterations: iterations, Residual: residual}
+}
+
+// What a deferred annuity with ReturnOfPurchasePrice pays on death before the
+// annuity starts: the single premium back, or the premium rolled up at the
+// pricing interest rate to the end of the year of death.
+const (
+	RefundPremium     = "premium"
+	RefundAccumulated = "accumulated"
+)
+
+// ReturnOfPurchasePriceFactor is the value today of refunding 1 of purchase
+// price at the end of the year of death, if death happens during the deferral
+// period. Multiply by the single premium for the value of the death benefit.
+func ReturnOfPurchasePriceFactor(policy *Policy, mortalityTable MortalityTable) float64 {
+	factor := 0.0
+	chanceStillAlive := 1.0
+	for year := 0; year < policy.DeferralPeriod; year++ {
+		currentAge := policy.Age + year
+		if currentAge >= len(mortalityTable) {
+			break
+		}
+		refund := 1.0
+		if policy.ReturnOfPurchasePrice == RefundAccumulated {
+			refund = 1.0 / discount(policy, 1.0, year+1)
+		}
+		factor += chanceStillAlive * mortalityTable[currentAge] * discount(policy, refund, year+1)
+		chanceStillAlive *= 1.0 - mortalityTable[currentAge]
+	}
+	return factor
+}
+
 // Calculate deferred annuity premium
 func CalculateDeferredAnnuityPremium(policy *Policy, mortalityTable MortalityTable) float64 {
 	totalPresentValue := 0.0
@@ -432,8 +1631,7 @@ func CalculateDeferredAnnuityPremium(policy *Policy, mortalityTable MortalityTab
 			survivalProbability *= (1.0 - mortalityTable[policy.Age+previousYear])
 		}
 
-		annuityPaymentPV := CalculatePresentValue(policy.CoverageAmount, policy.InterestRate, year)
-		totalPresentValue += survivalProbability * annuityPaymentPV
+		totalPresentValue += annuityPayment(policy, mortalityTable, year, survivalProbability)
 	}
 
 	return totalPresentValue
@@ -441,32 +1639,84 @@ func CalculateDeferredAnnuityPremium(policy *Policy, mortalityTable MortalityTab
 
 // Risk assessment for underwriting
 func AssessRisk(policy *Policy, mortalityTable MortalityTable) map[string]float64 {
+	return assessRisk(policy, mortalityTable, ApplyUnderwritingFactors(policy, mortalityTable))
+}
+
+// assessRisk compares the policy's first-year rate on the base and underwritten tables
+func assessRisk(policy *Policy, mortalityTable, adjustedTable MortalityTable) map[string]float64 {
 	baseRate := mortalityTable[policy.Age]
-	adjustedTable := ApplyUnderwritingFactors(policy, mortalityTable)
 	adjustedRate := adjustedTable[policy.Age]
 
-	return map[string]float64{
-		"base_mortality_rate":     baseRate,
-		"adjusted_mortality_rate": adjustedRate,
-		"risk_multiplier":         adjustedRate / baseRate,
+	riskAssessment := map[string]float64{
+		"base_mortality_rate":      baseRate,
+		"adjusted_mortality_rate":  adjustedRate,
 		"annual_death_probability": adjustedRate,
-		"expected_lifetime_years":  1.0 / adjustedRate,
 	}
+	// A qx of 0 leaves these undefined, and JSON can't carry infinities
+	if baseRate > 0 {
+		riskAssessment["risk_multiplier"] = adjustedRate / baseRate
+	}
+	if adjustedRate > 0 {
+		riskAssessment["expected_lifetime_years"] = 1.0 / adjustedRate
+	}
+	return riskAssessment
 }
 
+// CalculateFullPremium prices a policy on the default basis - its own interest
+// rate and the standard expense assumptions
 func CalculateFullPremium(policy *Policy, mortalityTable MortalityTable) PremiumCalculation {
+	return CalculateFullPremiumWithBasis(policy, DefaultBasis(policy, mortalityTable))
+}
+
+// CalculateFullPremiumWithBasis prices a policy on the given valuation basis.
+// The basis interest rate is used in place of the policy's. The result's
+// Warnings carry any caveats on the numbers.
+func CalculateFullPremiumWithBasis(policy *Policy, basis Basis) PremiumCalculation {
+	result := calculateFullPremiumWithBasis(policy, basis)
+	if basis.PremiumTaxRate > 0 {
+		result.PremiumTaxRate = basis.PremiumTaxRate
+		result.PremiumTax, result.TaxInclusivePremium = ApplyPremiumTax(result.GrossPremium, basis.PremiumTaxRate)
+	}
+	result.Warnings = CalculationWarnings(policy, basis, result)
+	return result
+}
+
+func calculateFullPremiumWithBasis(policy *Policy, basis Basis) PremiumCalculation {
 	// Set default product type if not specified
 	if policy.ProductType == "" {
 		policy.ProductType = "term_life"
 	}
 
-	// Apply underwriting factors
-	adjustedMortalityTable := ApplyUnderwritingFactors(policy, mortalityTable)
-	riskAssessment := AssessRisk(policy, mortalityTable)
+	// Value a copy of the policy at the basis interest rate
+	valuedPolicy := *policy
+	valuedPolicy.InterestRate = basis.InterestRate
+	if basis.CompoundingConvention != "" {
+		valuedPolicy.CompoundingConvention = basis.CompoundingConvention
+	}
+	if basis.RiskDiscountRate > 0 {
+		valuedPolicy.RiskDiscountRate = basis.RiskDiscountRate
+	}
+	if basis.PremiumTiming != "" {
+		valuedPolicy.PremiumTiming = basis.PremiumTiming
+	}
+	policy = &valuedPolicy
+	mortalityTable := basis.Mortality
+
+	// Apply underwriting factors, unless the caller already has
+	adjustedMortalityTable := basis.AdjustedMortality
+	if adjustedMortalityTable == nil {
+		adjustedMortalityTable = ApplyUnderwritingFactorsWithConfig(policy, mortalityTable, basis.Underwriting)
+	}
+	riskAssessment := assessRisk(policy, mortalityTable, adjustedMortalityTable)
 
 	var result PremiumCalculation
 	result.ProductType = policy.ProductType
 	result.RiskAssessment = riskAssessment
+	result.Basis = basis.Name
+	result.CompoundingConvention = policy.CompoundingConvention
+	if result.CompoundingConvention == "" {
+		result.CompoundingConvention = AnnualCompounding
+	}
 
 	// Build underwriting info
 	underwritingInfo := make(map[string]interface{})
@@ -476,8 +1726,31 @@ func CalculateFullPremium(policy *Policy, mortalityTable MortalityTable) Premium
 	if policy.HealthRating != "" {
 		underwritingInfo["health_rating"] = policy.HealthRating
 	}
+	if policy.Impairment != "" {
+		underwritingInfo["impairment"] = policy.Impairment
+		switch impairment, ok := basis.Underwriting.ImpairmentFor(policy); {
+		case policy.RatingFactor > 0:
+			underwritingInfo["impairment_basis"] = "custom_rating_factor"
+		case ok:
+			underwritingInfo["impairment_basis"] = impairment.Source()
+		default:
+			underwritingInfo["impairment_basis"] = "generic"
+		}
+	}
 	if policy.RatingFactor > 0 {
 		underwritingInfo["custom_rating_factor"] = policy.RatingFactor
+	} else if policy.SmokerStatus != "" || policy.HealthRating != "" || policy.Impairment != "" {
+		smokerMultiplier, healthMultiplier := basis.Underwriting.Multipliers(policy)
+		underwritingInfo["smoker_multiplier"] = smokerMultiplier
+		underwritingInfo["health_multiplier"] = healthMultiplier
+	}
+	if selectLoadings := SimplifiedIssueLoadings(policy); selectLoadings != nil {
+		underwritingInfo["underwriting_class"] = policy.UnderwritingClass
+		underwritingInfo["select_loadings"] = selectLoadings
+	}
+	if renewalLoadings := AntiSelectionLoadings(policy); renewalLoadings != nil {
+		underwritingInfo["renewable"] = true
+		underwritingInfo["anti_selection_loadings"] = renewalLoadings
 	}
 	if len(underwritingInfo) > 0 {
 		result.UnderwritingInfo = underwritingInfo
@@ -487,26 +1760,107 @@ func CalculateFullPremium(policy *Policy, mortalityTable MortalityTable) Premium
 	switch policy.ProductType {
 	case "immediate_annuity":
 		premiumCost := CalculateImmediateAnnuityPremium(policy, adjustedMortalityTable)
+		grossPremium := premiumCost * (1 + basis.AnnuityLoading)
+		if policy.CashRefund {
+			var err error
+			grossPremium, result.CashRefundIterations, err = SolveCashRefundPrice(
+				policy, adjustedMortalityTable, premiumCost, basis.AnnuityLoading, basis.Solver)
+			result.CashRefundConverged, result.SolverError = err == nil, err
+			result.DeathBenefitValue = CashRefundValue(policy, adjustedMortalityTable, grossPremium)
+			premiumCost += result.DeathBenefitValue
+		}
 		result.TotalPremiumCost = premiumCost
 		result.AnnualPayout = policy.CoverageAmount
+		result.AnnuityTiming = cmp.Or(policy.AnnuityTiming, AnnuityDue)
 		result.NetPremium = premiumCost
-		result.GrossPremium = premiumCost * 1.1 // Simple 10% loading for annuities
+		result.GrossPremium = grossPremium
+		if policy.IncludeDuration {
+			duration := CashFlowDurations(policy, adjustedMortalityTable, grossPremium)
+			result.Duration = &duration
+		}
 		return result
 
 	case "deferred_annuity":
 		premiumCost := CalculateDeferredAnnuityPremium(policy, adjustedMortalityTable)
+		grossPremium := premiumCost * (1 + basis.AnnuityLoading)
+		if policy.ReturnOfPurchasePrice != "" {
+			// The refund is of the premium itself, so solve
+			// gross = (annuity + gross x refund factor) x (1 + loading)
+			refundFactor := ReturnOfPurchasePriceFactor(policy, adjustedMortalityTable)
+			denominator := 1 - refundFactor*(1+basis.AnnuityLoading)
+			if denominator <= 0 {
+				grossPremium = math.Inf(1) // The refund alone costs more than any premium
+			} else {
+				grossPremium /= denominator
+			}
+			result.DeathBenefitValue = grossPremium * refundFactor
+			premiumCost += result.DeathBenefitValue
+		}
 		result.TotalPremiumCost = premiumCost
 		result.AnnualPayout = policy.CoverageAmount
+		result.AnnuityTiming = cmp.Or(policy.AnnuityTiming, AnnuityDue)
+		result.EffectiveDeferral = EffectiveDeferral(policy)
 		result.NetPremium = premiumCost
-		result.GrossPremium = premiumCost * 1.1 // Simple 10% loading for annuities
+		result.GrossPremium = grossPremium
+		if policy.IncludeDuration {
+			duration := CashFlowDurations(policy, adjustedMortalityTable, grossPremium)
+			result.Duration = &duration
+		}
 		return result
 
 	default:
 		// Life insurance calculations
+		if policy.ProductType == "whole_life" && policy.MortalityExtrapolation != "" {
+			adjustedMortalityTable, result.UltimateAge = ExtendMortalityTable(
+				adjustedMortalityTable, policy.MortalityExtrapolation, policy.UltimateAge)
+		}
 		netPremium := CalculateNetPremium(policy, adjustedMortalityTable)
-		expenseAssumptions := CreateDefaultExpenses()
+		result.LayerPremiums = LayerNetPremiums(policy, adjustedMortalityTable)
+		expenseAssumptions := basis.Expenses
 		grossPremium := CalculateGrossPremium(policy, adjustedMortalityTable, netPremium, expenseAssumptions)
+		if err := CheckPremium(netPremium, grossPremium, basis.ZeroPremium); err != nil {
+			result.PremiumError = err
+			return result
+		}
+		if band, ok := PremiumBandFor(basis.PremiumBands, SumAssuredInYear(policy, 0)); ok && band.Discount > 0 {
+			// The discount comes first, so a small discounted premium is still floored
+			result.PremiumBand = &band
+			result.VolumeDiscount = grossPremium * band.Discount
+			grossPremium -= result.VolumeDiscount
+		}
+		if basis.MinimumPremium > 0 {
+			// Everything from here on - profit, strain, installments - uses the premium actually charged
+			result.ActuarialPremium = grossPremium
+			grossPremium, result.MinimumPremiumLoading = ApplyMinimumPremium(grossPremium, basis.MinimumPremium)
+			result.MinimumPremiumApplied = grossPremium != result.ActuarialPremium
+		}
+		firstYearPremium := grossPremium
+		if policy.FirstYearFeeWaiver != "" {
+			// The gross premium reported from here on is the renewal premium
+			firstYearPremium, grossPremium, result.FeeWaiver = WaiveFirstYearFee(
+				policy, grossPremium, expenseAssumptions, premiumAnnuityValue(policy, adjustedMortalityTable))
+			result.FirstYearPremium, result.RenewalPremium = firstYearPremium, grossPremium
+		}
 		reserveSchedule := CalculateReserveSchedule(policy, adjustedMortalityTable, netPremium)
+		result.ReserveApproach = cmp.Or(policy.ReserveApproach, ReserveProspective)
+		if IsModifiedReserve(basis.ReserveMethod) {
+			var modified ModifiedReserve
+			reserveSchedule, modified = ModifiedReserveSchedule(
+				policy, adjustedMortalityTable, netPremium, grossPremium, basis.ReserveMethod, basis.ExpenseAllowanceCap)
+			reserveSchedule = InForceReserves(policy, reserveSchedule)
+			result.ModifiedReserve = &modified
+		}
+		if policy.ProductType == "whole_life" && policy.SurvivalThreshold > 0 {
+			result.HorizonYears, result.HorizonTruncated = WholeLifeHorizon(policy, adjustedMortalityTable)
+		}
+		if policy.DynamicLapse != nil {
+			years := policy.Term
+			if policy.ProductType == "whole_life" {
+				years, _ = WholeLifeHorizon(policy, adjustedMortalityTable)
+			}
+			result.LapsePath = LapsePath(policy, years)
+		}
+		result.PremiumTiming = cmp.Or(policy.PremiumTiming, PremiumsAnnuallyInAdvance)
 
 		expenseBreakdown := map[string]float64{
 			"initial_expense_rate": expenseAssumptions.InitialExpenseRate,
@@ -517,9 +1871,90 @@ func CalculateFullPremium(policy *Policy, mortalityTable MortalityTable) Premium
 
 		result.NetPremium = netPremium
 		result.GrossPremium = grossPremium
+		breakdown := BreakDownPremium(policy, netPremium, grossPremium, expenseAssumptions)
+		result.PremiumBreakdown = &breakdown
 		result.ReserveSchedule = reserveSchedule
 		result.ExpenseDetails = expenseBreakdown
+		// An in-force policy is profit tested from today, on the reserves from today
+		if policy.RiskDiscountRate > 0 {
+			profitTest := ProfitTest(policy, adjustedMortalityTable, grossPremium, expenseAssumptions, reserveSchedule, policy.RiskDiscountRate)
+			var err error
+			profitTest.BreakEvenMortality, profitTest.BreakEvenMortalityStatus, err = BreakEvenMortality(
+				policy, adjustedMortalityTable, grossPremium, expenseAssumptions, reserveSchedule, policy.RiskDiscountRate, basis.Solver)
+			if err != nil {
+				result.SolverError = err
+			}
+			if policy.RequiredCapital != nil {
+				release := ProfitReleasePattern(policy, adjustedMortalityTable, reserveSchedule, profitTest, *policy.RequiredCapital)
+				profitTest.ProfitRelease = &release
+			}
+			result.ProfitTest = &profitTest
+		}
+		if policy.InForceDuration == 0 {
+			// Strain only means something for new business, where the schedule starts at issue
+			result.NewBusinessStrain = NewBusinessStrain(policy, firstYearPremium, expenseAssumptions, reserveSchedule)
+			if policy.IncludeProfitByYear {
+				profitByYear := ExpectedProfitByYear(policy, adjustedMortalityTable, grossPremium, expenseAssumptions, reserveSchedule)
+				result.ProfitByYear = &profitByYear
+			}
+		}
+		result.PremiumPayingYears = PremiumPayingYears(policy)
+		result.LapseRate = policy.LapseRate
+		result.SettlementDelay = policy.SettlementDelay
+		result.InForceDuration = policy.InForceDuration
+		result.PaymentFrequency = policy.PaymentFrequency
+		if result.PaymentFrequency == "" {
+			result.PaymentFrequency = "annual"
+		}
+		result.ModalFactor, result.ModalPremium = ModalPremium(grossPremium, result.PaymentFrequency)
+		result.ModalImpliedRate, _ = ModalImpliedRate(result.PaymentFrequency)
+		if policy.IncludeNetAmountAtRisk {
+			result.NetAmountAtRisk = policyNetAmountAtRisk(policy, reserveSchedule)
+		}
+		if policy.IncludeDuration {
+			duration := CashFlowDurations(policy, adjustedMortalityTable, grossPremium)
+			result.Duration = &duration
+		}
+		if policy.IncludeSinglePremium {
+			result.SinglePremium = NetSinglePremium(policy, adjustedMortalityTable)
+		}
+		if policy.IncludePaidUp {
+			paidUp := PaidUpDuration(policy, adjustedMortalityTable, reserveSchedule)
+			result.PaidUp = &paidUp
+		}
 		return result
 	}
 }
 
+// BreakEvenDurations finds two milestones in a policy's life:
+//   - the first year in which the total gross premiums paid exceed that year's sum assured
+//   - the first year in which the reserve exceeds the total premiums paid so far
+//
+// Premiums are paid at the start of each of the policy's premium paying years:
+// the first at firstYearPremium, which is lower when a fee is waived, and the
+// rest at renewalPremium. Years count from issue, and the cover in each is
+// SumAssuredInYear's, so layers that have run off no longer count. The reserve
+// schedule starts at the policy's in-force duration, as
+// CalculateReserveSchedule's does for a policy already in force, so the
+// reserve milestone is only looked for from then on. A nil result means the
+// milestone is never reached within the reserve schedule.
+func BreakEvenDurations(policy *Policy, firstYearPremium, renewalPremium float64, reserveSchedule []float64) (premiumExceedsCover *int, reserveExceedsPremiums *int) {
+	premiumYears := PremiumPayingYears(policy)
+	inForceDuration := policy.InForceDuration
+	for year := 1; year < inForceDuration+len(reserveSchedule); year++ {
+		totalPremiums := 0.0
+		if premiumsPaid := min(year, premiumYears); premiumsPaid > 0 {
+			totalPremiums = firstYearPremium + float64(premiumsPaid-1)*renewalPremium
+		}
+
+		if premiumExceedsCover == nil && totalPremiums > SumAssuredInYear(policy, year-1) {
+			found := year
+			premiumExceedsCover = &found
+		}
+		if reserveExceedsPremiums == nil && year >= inForceDuration && reserveSchedule[year-inForceDuration] > totalPremiums {
+			found := year
+			reserveExceedsPremiums = &found
+		}
+	}
+	return premiumExceedsCover, reserveExceedsPremiums
+}
@@ -1,7 +1,15 @@
 package actuarial
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -75,3 +83,1582 @@ func TestReserveSchedule(t *testing.T) {
 		t.Errorf("Expected Reserve at t=2 to be %f, but got %f", expectedReserves[2], actualReserves[2])
 	}
 }
+
+func TestWholeLifeBoundaryAge(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.01
+	}
+	mortalityTable[99] = 1.0
+	oldestAge := len(mortalityTable) - 1
+
+	// One year below the end of the table still has a year of coverage to price
+	policy := &Policy{Age: oldestAge - 1, Term: 5, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "whole_life"}
+	if err := CheckWholeLifeAge(policy, mortalityTable); err != nil {
+		t.Fatalf("Expected age %d to be accepted, got %v", policy.Age, err)
+	}
+	if premium := CalculateWholeLifeNetPremium(policy, mortalityTable); premium <= 0 {
+		t.Errorf("Expected a positive premium at age %d, got %f", policy.Age, premium)
+	}
+
+	// At the last age of the table the loop would run zero times and price the policy as free
+	for _, age := range []int{oldestAge, oldestAge + 1} {
+		policy.Age = age
+		err := CheckWholeLifeAge(policy, mortalityTable)
+		if !errors.Is(err, ErrAgeBeyondTable) {
+			t.Errorf("Expected ErrAgeBeyondTable at age %d, got %v", age, err)
+		}
+	}
+}
+
+func TestLoadGzippedMortalityTable(t *testing.T) {
+	plainTable, err := LoadMortalityTable("../data/male.csv")
+	if err != nil {
+		t.Fatalf("Could not load plain table: %v", err)
+	}
+
+	// Gzip the same file into a temp dir
+	rawData, err := os.ReadFile("../data/male.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressed)
+	gzipWriter.Write(rawData)
+	gzipWriter.Close()
+
+	dir := t.TempDir()
+	gzipPath := filepath.Join(dir, "male.csv.gz")
+	if err := os.WriteFile(gzipPath, compressed.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the .gz name and a misleading plain name should load identically
+	sniffPath := filepath.Join(dir, "male_archive.csv")
+	if err := os.WriteFile(sniffPath, compressed.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{gzipPath, sniffPath} {
+		gzippedTable, err := LoadMortalityTable(path)
+		if err != nil {
+			t.Fatalf("Could not load gzipped table %s: %v", path, err)
+		}
+		if len(gzippedTable) != len(plainTable) {
+			t.Fatalf("Expected %d ages from %s, got %d", len(plainTable), path, len(gzippedTable))
+		}
+		for age := range plainTable {
+			if gzippedTable[age] != plainTable[age] {
+				t.Errorf("Age %d: expected qx %f, got %f", age, plainTable[age], gzippedTable[age])
+			}
+		}
+	}
+
+	// A file that claims to be gzip but isn't should fail clearly
+	fakePath := filepath.Join(dir, "fake.csv.gz")
+	if err := os.WriteFile(fakePath, rawData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadMortalityTable(fakePath); err == nil || !strings.Contains(err.Error(), "not valid gzip") {
+		t.Errorf("Expected a 'not valid gzip' error, got %v", err)
+	}
+}
+
+func TestLapseAssumption(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+
+	noLapsePremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	noLapseGross := CalculateGrossPremium(policy, mortalityTable, noLapsePremium, CreateDefaultExpenses())
+
+	policy.LapseRate = 0.10
+	lapsePremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	lapseGross := CalculateGrossPremium(policy, mortalityTable, lapsePremium, CreateDefaultExpenses())
+
+	// Lapses remove lives before the expensive later years, so the net premium falls
+	if lapsePremium >= noLapsePremium {
+		t.Errorf("Expected lapses to lower the net premium: %f vs %f", lapsePremium, noLapsePremium)
+	}
+	// ...but the setup cost is spread over fewer expected premiums, so the gross premium rises
+	if lapseGross <= noLapseGross {
+		t.Errorf("Expected lapses to raise the gross premium: %f vs %f", lapseGross, noLapseGross)
+	}
+
+	// The reserve still starts at zero on the lapse-adjusted basis
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, lapsePremium)
+	if !floatEquals(reserves[0], 0, 0.01) {
+		t.Errorf("Expected reserve at t=0 to be 0, got %f", reserves[0])
+	}
+
+	// A mass lapse only adds to the first year's lapses
+	policy.MassLapse = 0.40
+	if rate := lapseRateForYear(policy, 0); !floatEquals(rate, 1-0.9*0.6, 1e-12) {
+		t.Errorf("Expected a first-year lapse rate of %f, got %f", 1-0.9*0.6, rate)
+	}
+	if rate := lapseRateForYear(policy, 1); rate != policy.LapseRate {
+		t.Errorf("Expected the usual lapse rate after year one, got %f", rate)
+	}
+	if massLapseGross := CalculateGrossPremium(policy, mortalityTable, CalculateTermLifeNetPremium(policy, mortalityTable), CreateDefaultExpenses()); massLapseGross <= lapseGross {
+		t.Errorf("Expected a mass lapse to raise the gross premium: %f vs %f", massLapseGross, lapseGross)
+	}
+}
+
+func TestDynamicLapse(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.04, LapseRate: 0.05, ProductType: "term_life"}
+	flat := CalculateFullPremium(policy, mortalityTable)
+
+	// A 3% spread over the credited rate, less a 1% threshold, adds 2 x 2% to lapses
+	policy.DynamicLapse = &DynamicLapse{ReferenceRate: 0.07, CreditedRate: 0.04, Sensitivity: 2, Threshold: 0.01}
+	if rate := lapseRateForYear(policy, 3); !floatEquals(rate, 0.09, 1e-12) {
+		t.Errorf("Expected a lapse rate of 9%%, got %f", rate)
+	}
+	dynamic := CalculateFullPremium(policy, mortalityTable)
+	if len(dynamic.LapsePath) == 0 || !floatEquals(dynamic.LapsePath[0], 0.09, 1e-12) || flat.LapsePath != nil {
+		t.Errorf("Expected a lapse path of 9%% a year, got %v", dynamic.LapsePath)
+	}
+	if dynamic.GrossPremium <= flat.GrossPremium {
+		t.Errorf("Expected more lapses to raise the gross premium: %f vs %f", dynamic.GrossPremium, flat.GrossPremium)
+	}
+
+	// Market rates below the credited rate leave the base lapse rate alone, and
+	// the ceiling stops a wide spread
+	policy.DynamicLapse.ReferenceRate = 0.02
+	if rate := lapseRateForYear(policy, 0); rate != policy.LapseRate {
+		t.Errorf("Expected the base lapse rate, got %f", rate)
+	}
+	policy.DynamicLapse.ReferenceRate = 0.50
+	if rate := lapseRateForYear(policy, 0); rate != DefaultMaxDynamicLapse {
+		t.Errorf("Expected the lapse rate capped at %f, got %f", DefaultMaxDynamicLapse, rate)
+	}
+
+	// On a yield curve the reference rate is each year's forward rate
+	policy.YieldCurve = YieldCurve{0.04, math.Sqrt(1.04*1.08) - 1}
+	path := LapsePath(policy, 2)
+	if !floatEquals(path[0], 0.05, 1e-12) || !floatEquals(path[1], 0.05+2*(0.08-0.04-0.01), 1e-9) {
+		t.Errorf("Expected lapses to follow the forward rates, got %v", path)
+	}
+}
+
+func TestLoadTableStartingAboveZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pensioner.csv")
+	contents := "age\tmx\tqx\n50\t0.0031\t0.003\n51\t0.0041\t0.004\n52\t0.0051\t0.005\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, info, err := LoadMortalityTableWithInfo(path)
+	if err != nil {
+		t.Fatalf("Could not load table: %v", err)
+	}
+	if info.MinAge != 50 || info.MaxAge != 52 {
+		t.Errorf("Expected ages 50-52, got %d-%d", info.MinAge, info.MaxAge)
+	}
+	if table[51] != 0.004 {
+		t.Errorf("Expected qx at age 51 to be 0.004, got %f", table[51])
+	}
+}
+
+func TestLoadSpaceDelimitedTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"spaces", "age    mx        qx\n50    0.0031    0.003\n51    0.0041    0.004\n52    0.0051    0.005\n"},
+		{"mixed", "age\tmx  qx\n50\t0.0031  0.003\n51  0.0041\t0.004\n52\t 0.0051 \t0.005\n"},
+		{"qx second", "age  qx  lx\n50  0.003  100000\n51  0.004  99700\n52  0.005  99301\n"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "export.csv")
+		if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		table, info, err := LoadMortalityTableWithInfo(path)
+		if err != nil {
+			t.Fatalf("%s: could not load table: %v", tt.name, err)
+		}
+		if info.MinAge != 50 || info.MaxAge != 52 {
+			t.Errorf("%s: expected every row to load for ages 50-52, got %d-%d", tt.name, info.MinAge, info.MaxAge)
+			continue
+		}
+		for age, want := range map[int]float64{50: 0.003, 51: 0.004, 52: 0.005} {
+			if table[age] != want {
+				t.Errorf("%s: expected qx at age %d to be %f, got %f", tt.name, age, want, table[age])
+			}
+		}
+	}
+}
+
+func TestLoadPerThousandTable(t *testing.T) {
+	// Deaths per 1000 lives: the young ages are below 1 even so
+	perThousand := "age\tqx\n20\t0.6\n21\t0.7\n22\t0.9\n23\t1.1\n24\t1.4\n25\t1.8\n26\t2.5\n27\t3.3\n"
+	path := filepath.Join(t.TempDir(), "per_mille.csv")
+	if err := os.WriteFile(path, []byte(perThousand), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, info, err := LoadMortalityTableWithInfo(path)
+	if err != nil {
+		t.Fatalf("Could not load table: %v", err)
+	}
+	if info.RateScale != RatesPerThousand {
+		t.Errorf("Expected the table to be read per 1000, got %q", info.RateScale)
+	}
+	for age, want := range map[int]float64{20: 0.0006, 23: 0.0011, 27: 0.0033} {
+		if !floatEquals(table[age], want, 1e-15) {
+			t.Errorf("Expected qx at age %d to be %f, got %f", age, want, table[age])
+		}
+	}
+
+	// Saying so gives the same table; saying otherwise is an error, not a cap at 1
+	if explicit, _, err := LoadMortalityTableWithOptions(path, TableLoadOptions{RateScale: RatesPerThousand}); err != nil || !slices.Equal(explicit, table) {
+		t.Errorf("Expected the explicit scale to load the same table, got %v (%v)", explicit, err)
+	}
+	if _, _, err := LoadMortalityTableWithOptions(path, TableLoadOptions{RateScale: RatesAsProbabilities}); err == nil {
+		t.Error("Expected rates above 1 to be rejected as probabilities")
+	}
+
+	// Probabilities are left alone
+	if _, info, err := LoadMortalityTableWithInfo(filepath.Join("..", "data", "male.csv")); err != nil || info.RateScale != RatesAsProbabilities {
+		t.Errorf("Expected the male table to be probabilities, got %q (%v)", info.RateScale, err)
+	}
+
+	// One stray value is too few to say the table is per 1000
+	stray := "age\tqx\n50\t0.003\n51\t0.004\n52\t0.005\n53\t0.006\n54\t7.0\n"
+	if err := os.WriteFile(path, []byte(stray), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadMortalityTableWithInfo(path); err == nil {
+		t.Error("Expected a table with one rate above 1 to be rejected")
+	}
+}
+
+func TestSimplifiedIssueLoading(t *testing.T) {
+	baseTable := make(MortalityTable, 100)
+	for age := range baseTable {
+		baseTable[age] = 0.01
+	}
+	policy := &Policy{Age: 40, Term: 10, CoverageAmount: 1000, InterestRate: 0.05, UnderwritingClass: "simplified_issue"}
+
+	adjustedTable := ApplyUnderwritingFactors(policy, baseTable)
+
+	// Heaviest in year 1, grading down to standard by year 5
+	expected := map[int]float64{40: 0.015, 41: 0.01375, 42: 0.0125, 43: 0.01125, 44: 0.01, 60: 0.01}
+	for age, qx := range expected {
+		if !floatEquals(adjustedTable[age], qx, 1e-9) {
+			t.Errorf("Age %d: expected qx %f, got %f", age, qx, adjustedTable[age])
+		}
+	}
+
+	// A per-policy schedule overrides the default
+	policy.SimplifiedIssueLoadings = []float64{2.0}
+	adjustedTable = ApplyUnderwritingFactors(policy, baseTable)
+	if !floatEquals(adjustedTable[40], 0.02, 1e-9) || !floatEquals(adjustedTable[41], 0.01, 1e-9) {
+		t.Errorf("Expected custom loading in year 1 only, got %f and %f", adjustedTable[40], adjustedTable[41])
+	}
+}
+
+func TestAntiSelectionLoading(t *testing.T) {
+	baseTable := make(MortalityTable, 100)
+	for age := range baseTable {
+		baseTable[age] = 0.01
+	}
+	policy := &Policy{Age: 40, Term: 10, CoverageAmount: 1000, InterestRate: 0.05, Renewable: true}
+
+	// Standard in year 1, worsening to 1.2 by year 5 and staying there
+	adjustedTable := ApplyUnderwritingFactors(policy, baseTable)
+	expected := map[int]float64{39: 0.01, 40: 0.01, 41: 0.0105, 42: 0.011, 43: 0.0115, 44: 0.012, 60: 0.012}
+	for age, qx := range expected {
+		if !floatEquals(adjustedTable[age], qx, 1e-9) {
+			t.Errorf("Age %d: expected qx %f, got %f", age, qx, adjustedTable[age])
+		}
+	}
+	plain := *policy
+	plain.Renewable = false
+	if renewable, standard := CalculateFullPremium(policy, baseTable), CalculateFullPremium(&plain, baseTable); renewable.GrossPremium <= standard.GrossPremium {
+		t.Errorf("Expected renewable term to cost more, got %f vs %f", renewable.GrossPremium, standard.GrossPremium)
+	}
+	if info := CalculateFullPremium(policy, baseTable).UnderwritingInfo; info["renewable"] != true || info["anti_selection_loadings"] == nil {
+		t.Errorf("Expected the loadings in the underwriting info, got %v", info)
+	}
+
+	// A per-policy shape overrides the default; only term renews
+	policy.AntiSelectionLoadings = []float64{1.1, 1.3}
+	adjustedTable = ApplyUnderwritingFactors(policy, baseTable)
+	if !floatEquals(adjustedTable[40], 0.011, 1e-9) || !floatEquals(adjustedTable[70], 0.013, 1e-9) {
+		t.Errorf("Expected custom loadings 1.1 then 1.3, got %f and %f", adjustedTable[40], adjustedTable[70])
+	}
+	policy.ProductType = "whole_life"
+	if AntiSelectionLoadings(policy) != nil {
+		t.Error("Expected no anti-selection loading on whole life")
+	}
+}
+
+func TestInForceReserves(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	newPolicy := &Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+	netPremium := CalculateTermLifeNetPremium(newPolicy, mortalityTable)
+	freshSchedule := CalculateReserveSchedule(newPolicy, mortalityTable, netPremium)
+
+	// Duration 0 is just a new policy
+	atIssue := *newPolicy
+	atIssue.InForceDuration = 0
+	if got := CalculateReserveSchedule(&atIssue, mortalityTable, netPremium); got[0] != freshSchedule[0] {
+		t.Errorf("Expected duration 0 reserve %f, got %f", freshSchedule[0], got[0])
+	}
+
+	for _, duration := range []int{1, 4, 9} {
+		inForce := *newPolicy
+		inForce.InForceDuration = duration
+		schedule := CalculateReserveSchedule(&inForce, mortalityTable, netPremium)
+
+		if len(schedule) != len(freshSchedule)-duration {
+			t.Fatalf("Duration %d: expected %d reserves, got %d", duration, len(freshSchedule)-duration, len(schedule))
+		}
+		if !floatEquals(schedule[0], freshSchedule[duration], 1e-9) {
+			t.Errorf("Duration %d: expected reserve %f, got %f", duration, freshSchedule[duration], schedule[0])
+		}
+
+		// Same as valuing the remaining term from the attained age on the original premium
+		attained := &Policy{Age: 40 + duration, Term: 10 - duration, CoverageAmount: 100000, InterestRate: 0.05}
+		direct := CalculateTermLifeReserveSchedule(attained, mortalityTable, netPremium)
+		if !floatEquals(schedule[0], direct[0], 1e-9) {
+			t.Errorf("Duration %d: expected attained-age reserve %f, got %f", duration, direct[0], schedule[0])
+		}
+	}
+}
+
+func TestMortalityExtrapolation(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.002
+	}
+	lastRate := mortalityTable[100]
+
+	immediate, ultimate := ExtendMortalityTable(mortalityTable, "immediate", 0)
+	if ultimate != 101 || immediate[100] != lastRate || immediate[101] != 1.0 {
+		t.Errorf("Expected qx=1 at 101 after the table's own rates, got ultimate %d, q100 %f, q101 %f", ultimate, immediate[100], immediate[101])
+	}
+
+	linear, ultimate := ExtendMortalityTable(mortalityTable, "linear", 110)
+	if ultimate != 110 || len(linear) != 112 {
+		t.Fatalf("Expected ultimate age 110 and 112 rows, got %d and %d", ultimate, len(linear))
+	}
+	if expected := lastRate + (1-lastRate)*0.5; !floatEquals(linear[105], expected, 1e-12) {
+		t.Errorf("Expected halfway qx %f at 105, got %f", expected, linear[105])
+	}
+	if linear[110] != 1.0 {
+		t.Errorf("Expected qx=1 at the ultimate age, got %f", linear[110])
+	}
+
+	// Counting the people who outlive the table raises the whole life liability
+	policy := &Policy{Age: 60, Term: 20, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "whole_life"}
+	truncated := CalculateWholeLifeNetPremium(policy, mortalityTable)
+	extended := CalculateWholeLifeNetPremium(policy, linear)
+	if extended <= truncated {
+		t.Errorf("Expected extrapolated premium above %f, got %f", truncated, extended)
+	}
+
+	policy.MortalityExtrapolation = "linear"
+	policy.UltimateAge = 110
+	result := CalculateFullPremium(policy, mortalityTable)
+	if result.UltimateAge != 110 {
+		t.Errorf("Expected ultimate age 110 reported, got %d", result.UltimateAge)
+	}
+	if len(result.ReserveSchedule) != 111-60+1 {
+		t.Errorf("Expected reserves to run to the end of the extended table, got %d entries", len(result.ReserveSchedule))
+	}
+}
+
+func TestModalPremium(t *testing.T) {
+	tests := []struct {
+		frequency   string
+		factor      float64
+		installment float64
+	}{
+		{"", 1.0, 1200},
+		{"annual", 1.0, 1200},
+		{"semi_annual", 1.02, 612},
+		{"quarterly", 1.03, 309},
+		{"monthly", 1.05, 105},
+	}
+	for _, tt := range tests {
+		factor, installment := ModalPremium(1200, tt.frequency)
+		if factor != tt.factor || !floatEquals(installment, tt.installment, 1e-9) {
+			t.Errorf("%q: expected factor %f and installment %f, got %f and %f", tt.frequency, tt.factor, tt.installment, factor, installment)
+		}
+	}
+}
+
+func TestModalImpliedRate(t *testing.T) {
+	// Semi-annually at 1.02: 0.49 of the annual premium kept for half a year costs 0.51
+	rate, ok := ModalImpliedRate("semi_annual")
+	if want := math.Pow(0.51/0.49, 2) - 1; !ok || !floatEquals(rate, want, 1e-9) {
+		t.Errorf("Expected a semi-annual implied rate of %f, got %f (%v)", want, rate, ok)
+	}
+
+	// At the implied rate the installments are worth exactly the annual premium
+	for _, frequency := range []string{"quarterly", "monthly"} {
+		rate, ok := ModalImpliedRate(frequency)
+		if !ok || rate <= 0 {
+			t.Fatalf("%s: expected a positive implied rate, got %f (%v)", frequency, rate, ok)
+		}
+		payments := PaymentsPerYear[frequency]
+		value := 0.0
+		for period := range payments {
+			value += 1200 * ModalFactors[frequency] / float64(payments) * math.Pow(1+rate, -float64(period)/float64(payments))
+		}
+		if !floatEquals(value, 1200, 1e-6) {
+			t.Errorf("%s: expected installments worth the annual premium at %f, got %f", frequency, rate, value)
+		}
+	}
+
+	for _, frequency := range []string{"", "annual", "fortnightly"} {
+		if _, ok := ModalImpliedRate(frequency); ok {
+			t.Errorf("%q: expected no implied rate", frequency)
+		}
+	}
+}
+
+func TestInterpolateAbridgedTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "abridged.csv")
+	contents := "age\tmx\tqx\n40\t0\t0.002\n45\t0\t0.004\n50\t0\t0.008\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := LoadMortalityTableWithInfo(path); err == nil {
+		t.Fatal("Expected an abridged table to be rejected without interpolation")
+	}
+
+	table, info, err := LoadMortalityTableWithOptions(path, TableLoadOptions{InterpolateAbridged: true})
+	if err != nil {
+		t.Fatalf("Could not load abridged table: %v", err)
+	}
+	if !info.Abridged || info.MinAge != 40 || info.MaxAge != 50 {
+		t.Errorf("Expected an abridged table covering 40-50, got %+v", info)
+	}
+
+	// Known ages are kept exactly
+	if table[45] != 0.004 || table[50] != 0.008 {
+		t.Errorf("Expected known rates to be unchanged, got %f and %f", table[45], table[50])
+	}
+
+	// Age 42 is 2/5 of the way from 40 to 45: p42 = 0.998^0.6 * 0.996^0.4
+	expected := 1 - math.Pow(0.998, 0.6)*math.Pow(0.996, 0.4)
+	if !floatEquals(table[42], expected, 1e-12) {
+		t.Errorf("Expected qx at 42 to be %.8f, got %.8f", expected, table[42])
+	}
+	for age := 41; age < 50; age++ {
+		if table[age] <= table[age-1] {
+			t.Errorf("Expected interpolated rates to rise with age, got q%d=%f after %f", age, table[age], table[age-1])
+		}
+	}
+
+	// The filled table works with the normal calculations
+	policy := &Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+	if premium := CalculateTermLifeNetPremium(policy, table); premium <= 0 {
+		t.Errorf("Expected a positive premium from the interpolated table, got %f", premium)
+	}
+}
+
+func TestCombineTables(t *testing.T) {
+	male := MortalityTable{0.01, 0.02, 0.6, 0.9}
+	female := MortalityTable{0.03, 0.04, 0.5}
+
+	unisex := CombineTables([]MortalityTable{male, female}, []float64{0.5, 0.5})
+	if len(unisex) != 3 {
+		t.Fatalf("Expected the shortest table's length 3, got %d", len(unisex))
+	}
+	if !floatEquals(unisex[0], 0.02, 1e-12) || !floatEquals(unisex[1], 0.03, 1e-12) {
+		t.Errorf("Expected averaged rates 0.02 and 0.03, got %f and %f", unisex[0], unisex[1])
+	}
+
+	loaded := CombineTables([]MortalityTable{male}, []float64{2})
+	if !floatEquals(loaded[1], 0.04, 1e-12) || loaded[2] != 1 || loaded[3] != 1 {
+		t.Errorf("Expected doubled rates clamped at 1, got %v", loaded)
+	}
+}
+
+func TestNetAmountAtRisk(t *testing.T) {
+	amounts := NetAmountAtRisk(100000, []float64{0, 2500, 120000, -10})
+	expected := []float64{100000, 97500, 0, 100010}
+	for year := range expected {
+		if amounts[year] != expected[year] {
+			t.Errorf("Year %d: expected %f at risk, got %f", year, expected[year], amounts[year])
+		}
+	}
+
+	policy := &Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	if result := CalculateFullPremium(policy, mortalityTable); result.NetAmountAtRisk != nil {
+		t.Errorf("Expected no net amount at risk unless requested")
+	}
+	policy.IncludeNetAmountAtRisk = true
+	if result := CalculateFullPremium(policy, mortalityTable); len(result.NetAmountAtRisk) != len(result.ReserveSchedule) {
+		t.Errorf("Expected one amount at risk per reserve, got %d for %d", len(result.NetAmountAtRisk), len(result.ReserveSchedule))
+	}
+}
+
+func TestMaturityBenefit(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+	withoutMaturity := CalculateTermLifeNetPremium(policy, mortalityTable)
+
+	policy.MaturityBenefit = 5000
+	netPremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+
+	// The extra cost is the pure endowment 5000 * 10p40 * v^10 spread over the premiums
+	survival := calculateSurvivalProbability(40, 10, mortalityTable)
+	premiumUnits := 0.0
+	for year := 0; year < 10; year++ {
+		premiumUnits += calculateSurvivalProbability(40, year, mortalityTable) * math.Pow(1.05, -float64(year))
+	}
+	expectedExtra := 5000 * survival * math.Pow(1.05, -10) / premiumUnits
+	if !floatEquals(netPremium-withoutMaturity, expectedExtra, 1e-9) {
+		t.Errorf("Expected maturity benefit to add %f to the premium, added %f", expectedExtra, netPremium-withoutMaturity)
+	}
+
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+	if reserves[10] != 5000 {
+		t.Errorf("Expected reserve at maturity to equal the maturity benefit, got %f", reserves[10])
+	}
+	if !floatEquals(reserves[0], 0, 1e-6) {
+		t.Errorf("Expected a zero reserve at issue, got %f", reserves[0])
+	}
+}
+
+func TestUnderwritingConfig(t *testing.T) {
+	baseTable := MortalityTable{0.01, 0.01, 0.01}
+	policy := &Policy{Age: 0, SmokerStatus: "smoker", HealthRating: "preferred"}
+
+	// The defaults reproduce the standard multipliers: 2.0 x 0.75
+	if adjusted := ApplyUnderwritingFactors(policy, baseTable); !floatEquals(adjusted[0], 0.015, 1e-12) {
+		t.Errorf("Expected default smoker preferred rate 0.015, got %f", adjusted[0])
+	}
+
+	config := DefaultUnderwritingConfig()
+	config.SmokerStatus["smoker"] = 1.6
+	if adjusted := ApplyUnderwritingFactorsWithConfig(policy, baseTable, config); !floatEquals(adjusted[0], 0.012, 1e-12) {
+		t.Errorf("Expected calibrated rate 0.012, got %f", adjusted[0])
+	}
+
+	// Anything not in the config is standard
+	policy.SmokerStatus = "vaper"
+	if smoker, health := config.Multipliers(policy); smoker != 1.0 || health != 0.75 {
+		t.Errorf("Expected multipliers 1.0 and 0.75, got %f and %f", smoker, health)
+	}
+}
+
+func TestUnderwritingSignature(t *testing.T) {
+	config := DefaultUnderwritingConfig()
+	policy := &Policy{Age: 40, SmokerStatus: "smoker", HealthRating: "preferred"}
+	older := &Policy{Age: 55, SmokerStatus: "smoker", HealthRating: "preferred"}
+	if UnderwritingSignature(policy, config) != UnderwritingSignature(older, config) {
+		t.Error("Expected age not to matter without select or renewal loadings")
+	}
+
+	nonSmoker := &Policy{Age: 40, SmokerStatus: "non_smoker", HealthRating: "preferred"}
+	if UnderwritingSignature(policy, config) == UnderwritingSignature(nonSmoker, config) {
+		t.Error("Expected smokers and non-smokers to be told apart")
+	}
+
+	// An explicit rating factor replaces the smoker and health multipliers
+	policy.RatingFactor, nonSmoker.RatingFactor = 1.5, 1.5
+	if UnderwritingSignature(policy, config) != UnderwritingSignature(nonSmoker, config) {
+		t.Error("Expected the same rating factor to give the same signature")
+	}
+}
+
+func TestImpairmentBasis(t *testing.T) {
+	baseTable := make(MortalityTable, 100)
+	for age := range baseTable {
+		baseTable[age] = 0.01
+	}
+	config := DefaultUnderwritingConfig()
+	config.Impairments = map[string]ImpairmentBasis{
+		// Heavy when young, easing to 1.5 by 70
+		"diabetic": {RatingCurve: []RatingPoint{{Age: 30, Multiplier: 3}, {Age: 70, Multiplier: 1.5}}},
+		// The condition's own rates up to age 49, the base table after
+		"hypertensive": {Table: MortalityTable(slices.Repeat([]float64{0.02}, 50))},
+	}
+
+	// The curve replaces the substandard 1.5; smoker status still applies
+	policy := &Policy{Age: 40, Term: 10, CoverageAmount: 1000, InterestRate: 0.05, HealthRating: "substandard", Impairment: "diabetic", SmokerStatus: "smoker"}
+	adjusted := ApplyUnderwritingFactorsWithConfig(policy, baseTable, config)
+	expected := map[int]float64{20: 0.06, 30: 0.06, 50: 0.045, 70: 0.03, 90: 0.03}
+	for age, qx := range expected {
+		if !floatEquals(adjusted[age], qx, 1e-12) {
+			t.Errorf("diabetic age %d: expected qx %f, got %f", age, qx, adjusted[age])
+		}
+	}
+
+	policy = &Policy{Age: 40, Term: 10, CoverageAmount: 1000, InterestRate: 0.05, Impairment: "hypertensive"}
+	adjusted = ApplyUnderwritingFactorsWithConfig(policy, baseTable, config)
+	if !floatEquals(adjusted[49], 0.02, 1e-12) || !floatEquals(adjusted[50], 0.01, 1e-12) {
+		t.Errorf("Expected the impairment table to age 49 then the base table, got %f and %f", adjusted[49], adjusted[50])
+	}
+
+	// Nothing registered: the generic substandard loading
+	policy.Impairment = "asthmatic"
+	adjusted = ApplyUnderwritingFactorsWithConfig(policy, baseTable, config)
+	if !floatEquals(adjusted[40], 0.015, 1e-12) {
+		t.Errorf("Expected the substandard 1.5 for an unregistered impairment, got %f", adjusted[40])
+	}
+
+	// A custom rating factor is the underwriter's last word
+	policy.Impairment, policy.RatingFactor = "hypertensive", 1.25
+	adjusted = ApplyUnderwritingFactorsWithConfig(policy, baseTable, config)
+	if !floatEquals(adjusted[40], 0.0125, 1e-12) {
+		t.Errorf("Expected the rating factor to override the impairment, got %f", adjusted[40])
+	}
+
+	basis := DefaultBasis(policy, baseTable)
+	basis.Underwriting = config
+	for impairment, want := range map[string]string{"hypertensive": "table", "diabetic": "rating_curve", "asthmatic": "generic"} {
+		impaired := *policy
+		impaired.Impairment, impaired.RatingFactor = impairment, 0
+		info := CalculateFullPremiumWithBasis(&impaired, basis).UnderwritingInfo
+		if info["impairment"] != impairment || info["impairment_basis"] != want {
+			t.Errorf("%s: expected impairment basis %q in the underwriting info, got %v", impairment, want, info)
+		}
+	}
+}
+
+func TestExpensesForProduct(t *testing.T) {
+	if ExpensesForProduct("") != ExpensesForProduct("term_life") {
+		t.Error("Expected an unset product type to get the term life expenses")
+	}
+	if ExpensesForProduct("unknown") != CreateDefaultExpenses() {
+		t.Error("Expected unlisted products to fall back to the default expenses")
+	}
+
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 40, Term: 30, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "whole_life"}
+	result := CalculateFullPremium(policy, mortalityTable)
+	wholeLife := ProductExpenses["whole_life"]
+	if result.ExpenseDetails["initial_expense_rate"] != wholeLife.InitialExpenseRate || result.ExpenseDetails["maintenance_expense"] != wholeLife.MaintenanceExpense {
+		t.Errorf("Expected whole life to be priced with its own expenses, got %v", result.ExpenseDetails)
+	}
+
+	// A basis with its own expenses still takes precedence
+	basis := DefaultBasis(policy, mortalityTable)
+	basis.Expenses = CreateDefaultExpenses()
+	if overridden := CalculateFullPremiumWithBasis(policy, basis); overridden.GrossPremium >= result.GrossPremium {
+		t.Errorf("Expected the cheaper basis expenses to lower the premium, got %f vs %f", overridden.GrossPremium, result.GrossPremium)
+	}
+}
+
+func TestMinimumPremium(t *testing.T) {
+	if charged, loading := ApplyMinimumPremium(80, 100); charged != 100 || !floatEquals(loading, 0.25, 1e-12) {
+		t.Errorf("Expected 80 raised to 100 with a 25%% loading, got %f and %f", charged, loading)
+	}
+	if charged, loading := ApplyMinimumPremium(120, 100); charged != 120 || loading != 0 {
+		t.Errorf("Expected a premium above the minimum to be unchanged, got %f and %f", charged, loading)
+	}
+
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 30, Term: 5, CoverageAmount: 1000, InterestRate: 0.05, PaymentFrequency: "monthly"}
+	basis := DefaultBasis(policy, mortalityTable)
+	basis.MinimumPremium = 500
+	result := CalculateFullPremiumWithBasis(policy, basis)
+	if !result.MinimumPremiumApplied || result.GrossPremium != 500 || result.ActuarialPremium >= 500 {
+		t.Fatalf("Expected the small policy to be charged the minimum, got %+v", result)
+	}
+	if !floatEquals(result.MinimumPremiumLoading, 500/result.ActuarialPremium-1, 1e-12) {
+		t.Errorf("Expected the implied loading over %f, got %f", result.ActuarialPremium, result.MinimumPremiumLoading)
+	}
+	if !floatEquals(result.ModalPremium, 500*ModalFactors["monthly"]/12, 1e-9) {
+		t.Errorf("Expected installments of the minimum premium, got %f", result.ModalPremium)
+	}
+}
+
+func TestPremiumTax(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05}
+	untaxed := CalculateFullPremium(policy, mortalityTable)
+	basis := DefaultBasis(policy, mortalityTable)
+	basis.PremiumTaxRate = 0.05
+	taxed := CalculateFullPremiumWithBasis(policy, basis)
+
+	// The tax sits on top of the gross premium and changes nothing underneath it
+	if taxed.GrossPremium != untaxed.GrossPremium || taxed.NewBusinessStrain != untaxed.NewBusinessStrain {
+		t.Errorf("Expected the tax to leave pricing alone, got %f vs %f", taxed.GrossPremium, untaxed.GrossPremium)
+	}
+	if !floatEquals(taxed.PremiumTax, 0.05*taxed.GrossPremium, 1e-9) || !floatEquals(taxed.TaxInclusivePremium, 1.05*taxed.GrossPremium, 1e-9) {
+		t.Errorf("Expected 5%% tax on %f, got %f (%f inclusive)", taxed.GrossPremium, taxed.PremiumTax, taxed.TaxInclusivePremium)
+	}
+	if untaxed.PremiumTax != 0 || untaxed.TaxInclusivePremium != 0 {
+		t.Errorf("Expected no tax without a rate, got %f", untaxed.PremiumTax)
+	}
+}
+
+func TestNewBusinessStrain(t *testing.T) {
+	policy := &Policy{CoverageAmount: 100000}
+	expenses := ExpenseStructure{InitialExpenseRate: 0.03, RenewalExpenseRate: 0.05, MaintenanceExpense: 50}
+
+	// 3000 setup + 50 commission + 50 maintenance + 400 reserve - 1000 premium
+	strain := NewBusinessStrain(policy, 1000, expenses, []float64{0, 400, 700})
+	if !floatEquals(strain, 2500, 1e-9) {
+		t.Errorf("Expected strain of 2500, got %f", strain)
+	}
+
+	// A large premium with little to reserve releases capital instead
+	if strain := NewBusinessStrain(policy, 5000, expenses, []float64{0, 100}); strain >= 0 {
+		t.Errorf("Expected a negative strain, got %f", strain)
+	}
+}
+
+func TestCompoundingConvention(t *testing.T) {
+	// At the same effective rate, δ = ln(1+i), the two conventions agree exactly
+	effectiveRate := 0.05
+	force := math.Log(1 + effectiveRate)
+	for _, years := range []int{0, 1, 5, 30} {
+		annual := CalculatePresentValueWithConvention(1000, effectiveRate, years, AnnualCompounding)
+		continuous := CalculatePresentValueWithConvention(1000, force, years, ContinuousCompounding)
+		if !floatEquals(annual, continuous, 1e-9) {
+			t.Errorf("%d years: expected equal values, got %f annual and %f continuous", years, annual, continuous)
+		}
+	}
+
+	// Reading the same number as a force of interest discounts harder: e^(-it) < (1+i)^-t
+	if continuous, annual := CalculatePresentValueWithConvention(1000, 0.05, 5, ContinuousCompounding), CalculatePresentValue(1000, 0.05, 5); continuous >= annual {
+		t.Errorf("Expected e^(-0.25) x 1000 below %f, got %f", annual, continuous)
+	}
+
+	// The convention runs through premiums, reserves and annuities alike
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.002
+	}
+	for _, productType := range []string{"term_life", "whole_life", "immediate_annuity"} {
+		annualPolicy := &Policy{Age: 50, Term: 20, CoverageAmount: 100000, InterestRate: effectiveRate, ProductType: productType}
+		continuousPolicy := *annualPolicy
+		continuousPolicy.InterestRate = force
+		continuousPolicy.CompoundingConvention = ContinuousCompounding
+
+		annual := CalculateFullPremium(annualPolicy, mortalityTable)
+		continuous := CalculateFullPremium(&continuousPolicy, mortalityTable)
+		if !floatEquals(annual.GrossPremium, continuous.GrossPremium, 1e-6) {
+			t.Errorf("%s: expected equal premiums, got %f and %f", productType, annual.GrossPremium, continuous.GrossPremium)
+		}
+		for year := range annual.ReserveSchedule {
+			if !floatEquals(annual.ReserveSchedule[year], continuous.ReserveSchedule[year], 1e-6) {
+				t.Errorf("%s: reserves differ in year %d", productType, year)
+				break
+			}
+		}
+		if continuous.CompoundingConvention != ContinuousCompounding || annual.CompoundingConvention != AnnualCompounding {
+			t.Errorf("%s: expected conventions to be reported, got %q and %q", productType, annual.CompoundingConvention, continuous.CompoundingConvention)
+		}
+	}
+}
+
+func TestPremiumCeaseAge(t *testing.T) {
+	mortalityTable := make(MortalityTable, 111)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001*math.Exp(0.07*float64(age)), 1)
+	}
+	policy := &Policy{Age: 50, Term: 60, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "whole_life"}
+	fullTerm := CalculateWholeLifeNetPremium(policy, mortalityTable)
+
+	// Premiums stop at 85, which is the same as a 35-year premium term
+	policy.PremiumCeaseAge = 85
+	if years := PremiumPayingYears(policy); years != 35 {
+		t.Fatalf("Expected 35 premium paying years, got %d", years)
+	}
+	ceased := CalculateWholeLifeNetPremium(policy, mortalityTable)
+	equivalent := &Policy{Age: 50, Term: 35, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "whole_life"}
+	if !floatEquals(ceased, CalculateWholeLifeNetPremium(equivalent, mortalityTable), 1e-9) {
+		t.Errorf("Expected the same premium as a 35-year premium term")
+	}
+	if ceased <= fullTerm {
+		t.Errorf("Expected fewer premium years to raise the premium, got %f vs %f", ceased, fullTerm)
+	}
+
+	// The reserve schedule stops collecting premiums at the same age
+	reserves := CalculateWholeLifeReserveSchedule(policy, mortalityTable, ceased)
+	equivalentReserves := CalculateWholeLifeReserveSchedule(equivalent, mortalityTable, ceased)
+	for year := range reserves {
+		if !floatEquals(reserves[year], equivalentReserves[year], 1e-6) {
+			t.Fatalf("Year %d: expected reserve %f, got %f", year, equivalentReserves[year], reserves[year])
+		}
+	}
+
+	// A cease age beyond the term leaves the term in charge
+	policy.PremiumCeaseAge = 100
+	policy.Term = 20
+	if years := PremiumPayingYears(policy); years != 20 {
+		t.Errorf("Expected the 20 year term to apply, got %d", years)
+	}
+}
+
+func TestPremiumPayingTerm(t *testing.T) {
+	mortalityTable := make(MortalityTable, 111)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001*math.Exp(0.07*float64(age)), 1)
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "term_life"}
+	regular := CalculateTermLifeNetPremium(policy, mortalityTable)
+
+	policy.PremiumPayingTerm = 20
+	if got := CalculateTermLifeNetPremium(policy, mortalityTable); !floatEquals(got, regular, 1e-12) {
+		t.Errorf("Expected paying for the whole term to change nothing: %f, got %f", regular, got)
+	}
+
+	// 20-year term, 15-pay: the same benefits bought with fewer premiums
+	policy.PremiumPayingTerm = 15
+	if years := PremiumPayingYears(policy); years != 15 {
+		t.Fatalf("Expected 15 premium paying years, got %d", years)
+	}
+	limited := CalculateTermLifeNetPremium(policy, mortalityTable)
+	premiumAnnuity := func(years int) float64 {
+		value := 0.0
+		for year := range years {
+			value += calculateSurvivalProbability(40, year, mortalityTable) * math.Pow(1.04, -float64(year))
+		}
+		return value
+	}
+	if !floatEquals(limited*premiumAnnuity(15), regular*premiumAnnuity(20), 1e-6) {
+		t.Errorf("Expected both premiums to buy the same benefits: %f vs %f", limited*premiumAnnuity(15), regular*premiumAnnuity(20))
+	}
+
+	// Once premiums stop the reserve is just the value of the cover left
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, limited)
+	if !floatEquals(reserves[0], 0, 1e-6) {
+		t.Errorf("Expected a zero reserve at issue, got %f", reserves[0])
+	}
+	paidUp := *policy
+	paidUp.Age, paidUp.Term, paidUp.PremiumPayingTerm = 55, 5, 0
+	if want := CalculateTermLifeReserveSchedule(&paidUp, mortalityTable, 0)[0]; !floatEquals(reserves[15], want, 1e-6) || reserves[15] <= 0 {
+		t.Errorf("Expected the reserve at 15 to be the value of 5 years' cover %f, got %f", want, reserves[15])
+	}
+
+	if result := CalculateFullPremium(policy, mortalityTable); result.PremiumPayingYears != 15 {
+		t.Errorf("Expected the paying term to be reported, got %d", result.PremiumPayingYears)
+	}
+}
+
+func TestWholeLifeHorizon(t *testing.T) {
+	mortalityTable := make(MortalityTable, 121)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.0002*math.Exp(0.09*float64(age)), 0.9)
+	}
+	policy := &Policy{Age: 20, Term: 30, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "whole_life"}
+	full := CalculateFullPremium(policy, mortalityTable)
+	if full.HorizonYears != 0 || full.HorizonTruncated {
+		t.Errorf("Expected no horizon reported without a threshold, got %d", full.HorizonYears)
+	}
+
+	capped := *policy
+	capped.SurvivalThreshold = 0.0001
+	truncated := CalculateFullPremium(&capped, mortalityTable)
+	if !truncated.HorizonTruncated || truncated.HorizonYears >= 100 {
+		t.Fatalf("Expected the horizon cut short of 100 years, got %d (truncated %v)", truncated.HorizonYears, truncated.HorizonTruncated)
+	}
+	if len(truncated.ReserveSchedule) != truncated.HorizonYears+1 {
+		t.Errorf("Expected %d reserves, got %d", truncated.HorizonYears+1, len(truncated.ReserveSchedule))
+	}
+
+	// The benefits left out are worth less than threshold x sum assured
+	if lost := NetSinglePremium(policy, mortalityTable) - NetSinglePremium(&capped, mortalityTable); lost < 0 || lost > capped.SurvivalThreshold*policy.CoverageAmount {
+		t.Errorf("Expected to lose under %f of benefit value, lost %f", capped.SurvivalThreshold*policy.CoverageAmount, lost)
+	}
+	if !floatEquals(truncated.NetPremium, full.NetPremium, 1e-4*full.NetPremium) {
+		t.Errorf("Expected the net premium nearly unchanged, got %f vs %f", truncated.NetPremium, full.NetPremium)
+	}
+	for year := range truncated.HorizonYears / 2 {
+		if !floatEquals(truncated.ReserveSchedule[year], full.ReserveSchedule[year], 1e-3*policy.CoverageAmount) {
+			t.Errorf("Year %d: expected reserve near %f, got %f", year, full.ReserveSchedule[year], truncated.ReserveSchedule[year])
+		}
+	}
+
+	// A threshold nobody falls below by the end of the table changes nothing
+	capped.SurvivalThreshold = 1e-300
+	if years, cut := WholeLifeHorizon(&capped, mortalityTable); cut || years != 100 {
+		t.Errorf("Expected the full 100 years, got %d (truncated %v)", years, cut)
+	}
+}
+
+func TestNetSinglePremium(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+
+	for _, productType := range []string{"term_life", "whole_life"} {
+		policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: productType}
+		if result := CalculateFullPremium(policy, mortalityTable); result.SinglePremium != 0 {
+			t.Errorf("%s: expected no single premium unless asked for, got %f", productType, result.SinglePremium)
+		}
+
+		// The single premium and the annual premium buy the same benefits
+		policy.IncludeSinglePremium = true
+		result := CalculateFullPremium(policy, mortalityTable)
+		var expectedPayouts, premiumAnnuity float64
+		if productType == "whole_life" {
+			expectedPayouts, premiumAnnuity = wholeLifeExpectedValues(policy, mortalityTable)
+		} else {
+			expectedPayouts, premiumAnnuity = termLifeExpectedValues(policy, mortalityTable)
+		}
+		if !floatEquals(result.SinglePremium, expectedPayouts, 1e-9) || !floatEquals(result.SinglePremium, result.NetPremium*premiumAnnuity, 1e-6) {
+			t.Errorf("%s: expected single premium %f = %f x %f, got %f", productType, expectedPayouts, result.NetPremium, premiumAnnuity, result.SinglePremium)
+		}
+		if result.SinglePremium <= result.NetPremium {
+			t.Errorf("%s: expected the single premium above one year's premium, got %f vs %f", productType, result.SinglePremium, result.NetPremium)
+		}
+	}
+}
+
+func TestRetrospectiveReserves(t *testing.T) {
+	mortalityTable := make(MortalityTable, 111)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.0003 * math.Exp(0.07*float64(age))
+	}
+
+	// On the basis the net premium was priced on, the money received less the
+	// claims paid is exactly what is needed for the claims still to come
+	policies := map[string]*Policy{
+		"term":              {Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"},
+		"term with lapses":  {Age: 35, Term: 25, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "term_life", LapseRate: 0.05},
+		"endowment":         {Age: 40, Term: 15, CoverageAmount: 50000, InterestRate: 0.05, ProductType: "term_life", MaturityBenefit: 50000},
+		"limited pay term":  {Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life", PremiumPayingTerm: 10},
+		"whole life":        {Age: 50, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "whole_life", PremiumCeaseAge: 85},
+		"limited pay whole": {Age: 50, Term: 20, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "whole_life"},
+		"continuous":        {Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life", CompoundingConvention: ContinuousCompounding},
+		"layered":           {Age: 40, Term: 30, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life", Layers: []CoverageLayer{{100000, 20}, {50000, 30}}},
+	}
+	for name, policy := range policies {
+		netPremium := CalculateNetPremium(policy, mortalityTable)
+		prospective := CalculateReserveSchedule(policy, mortalityTable, netPremium)
+		retrospectivePolicy := *policy
+		retrospectivePolicy.ReserveApproach = ReserveRetrospective
+		retrospective := CalculateReserveSchedule(&retrospectivePolicy, mortalityTable, netPremium)
+
+		if len(retrospective) != len(prospective) {
+			t.Fatalf("%s: expected %d reserves, got %d", name, len(prospective), len(retrospective))
+		}
+		for year := range prospective {
+			if !floatEquals(retrospective[year], prospective[year], 1e-6*policy.CoverageAmount) {
+				t.Errorf("%s year %d: prospective reserve %f, retrospective %f", name, year, prospective[year], retrospective[year])
+			}
+		}
+	}
+
+	policy := *policies["term"]
+	if result := CalculateFullPremium(&policy, mortalityTable); result.ReserveApproach != ReserveProspective {
+		t.Errorf("Expected prospective reserves by default, got %q", result.ReserveApproach)
+	}
+	policy.ReserveApproach = ReserveRetrospective
+	if result := CalculateFullPremium(&policy, mortalityTable); result.ReserveApproach != ReserveRetrospective {
+		t.Errorf("Expected the approach used to be reported, got %q", result.ReserveApproach)
+	}
+}
+
+func TestAnnuityTiming(t *testing.T) {
+	// Nobody survives the table's last full year, so in advance and in arrears
+	// cover exactly the same lives: a(x) = ä(x) - 1, and a deferred annuity
+	// paid in arrears is one deferred a year longer paid in advance
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.005 + float64(age)*0.001
+	}
+	mortalityTable[99] = 1
+
+	due := &Policy{Age: 60, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "immediate_annuity"}
+	arrears := *due
+	arrears.AnnuityTiming = AnnuityImmediate
+	annuityDue := CalculateImmediateAnnuityPremium(due, mortalityTable)
+	annuityImmediate := CalculateImmediateAnnuityPremium(&arrears, mortalityTable)
+	if !floatEquals(annuityImmediate, annuityDue-1000, 1e-6) {
+		t.Errorf("Expected paying in arrears to lose the first payment: %f, got %f", annuityDue-1000, annuityImmediate)
+	}
+
+	deferredArrears := &Policy{Age: 50, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "deferred_annuity", DeferralPeriod: 10, AnnuityTiming: AnnuityImmediate}
+	deferredDue := &Policy{Age: 50, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "deferred_annuity", DeferralPeriod: 11}
+	if got, want := CalculateDeferredAnnuityPremium(deferredArrears, mortalityTable), CalculateDeferredAnnuityPremium(deferredDue, mortalityTable); !floatEquals(got, want, 1e-6) {
+		t.Errorf("Expected a 10 year deferred annuity in arrears to equal an 11 year one in advance: %f, got %f", want, got)
+	}
+
+	if result := CalculateFullPremium(&arrears, mortalityTable); result.AnnuityTiming != AnnuityImmediate {
+		t.Errorf("Expected the timing used to be reported, got %q", result.AnnuityTiming)
+	}
+	if result := CalculateFullPremium(due, mortalityTable); result.AnnuityTiming != AnnuityDue {
+		t.Errorf("Expected annuities to be due by default, got %q", result.AnnuityTiming)
+	}
+}
+
+func TestFractionalDeferral(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 50, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "deferred_annuity", DeferralPeriod: 10}
+	whole := CalculateDeferredAnnuityPremium(policy, mortalityTable)
+
+	policy.DeferralFraction = 0.5
+	half := CalculateDeferredAnnuityPremium(policy, mortalityTable)
+	longer := *policy
+	longer.DeferralPeriod, longer.DeferralFraction = 11, 0
+	if next := CalculateDeferredAnnuityPremium(&longer, mortalityTable); half >= whole || half <= next {
+		t.Errorf("Expected a 10.5 year deferral to cost between 10 and 11 years': %f < %f < %f", next, half, whole)
+	}
+	if result := CalculateFullPremium(policy, mortalityTable); !floatEquals(result.EffectiveDeferral, 10.5, 1e-12) {
+		t.Errorf("Expected effective deferral 10.5, got %f", result.EffectiveDeferral)
+	}
+
+	// Deferred less than a year, the survival to the first payment comes from
+	// the first year's qx
+	short := &Policy{Age: 50, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "deferred_annuity", DeferralFraction: 0.25}
+	expected := (1 - 0.25*mortalityTable[50]) * math.Pow(1.05, -0.25) * 1000
+	if got := annuityPayment(short, mortalityTable, 0, 1); !floatEquals(got, expected, 1e-9) {
+		t.Errorf("Expected first payment worth %f, got %f", expected, got)
+	}
+}
+
+func TestReturnOfPurchasePrice(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 50, CoverageAmount: 10000, InterestRate: 0.05, ProductType: "deferred_annuity", DeferralPeriod: 15}
+	plain := CalculateFullPremium(policy, mortalityTable)
+
+	policy.ReturnOfPurchasePrice = RefundPremium
+	refunded := CalculateFullPremium(policy, mortalityTable)
+	if refunded.GrossPremium <= plain.GrossPremium || refunded.DeathBenefitValue <= 0 {
+		t.Fatalf("Expected the refund to add to the premium, got %f vs %f", refunded.GrossPremium, plain.GrossPremium)
+	}
+	// The loaded cost of the annuity plus the refund is the premium itself
+	if expected := (plain.NetPremium + refunded.DeathBenefitValue) * 1.10; !floatEquals(refunded.GrossPremium, expected, 1e-6) {
+		t.Errorf("Expected gross premium %f, got %f", expected, refunded.GrossPremium)
+	}
+
+	// Rolled up at the pricing rate, the refund's discounting cancels out and
+	// the factor is just the chance of dying during the deferral
+	policy.ReturnOfPurchasePrice = RefundAccumulated
+	survival := 1.0
+	for age := 50; age < 65; age++ {
+		survival *= 1 - mortalityTable[age]
+	}
+	if factor := ReturnOfPurchasePriceFactor(policy, mortalityTable); !floatEquals(factor, 1-survival, 1e-12) {
+		t.Errorf("Expected accumulated refund factor %f, got %f", 1-survival, factor)
+	}
+	if accumulated := CalculateFullPremium(policy, mortalityTable); accumulated.GrossPremium <= refunded.GrossPremium {
+		t.Errorf("Expected refunding with interest to cost more, got %f vs %f", accumulated.GrossPremium, refunded.GrossPremium)
+	}
+}
+
+// At a zero interest rate nothing is discounted, so every value is a plain
+// survival-weighted sum that can be checked by hand
+func TestCashRefundAnnuity(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 65, CoverageAmount: 10000, InterestRate: 0.05, ProductType: "immediate_annuity"}
+	plain := CalculateFullPremium(policy, mortalityTable)
+
+	policy.CashRefund = true
+	refunded := CalculateFullPremium(policy, mortalityTable)
+	if !refunded.CashRefundConverged || refunded.CashRefundIterations == 0 {
+		t.Fatalf("Expected the price to settle, got %d iterations", refunded.CashRefundIterations)
+	}
+	if refunded.GrossPremium <= plain.GrossPremium || refunded.DeathBenefitValue <= 0 {
+		t.Fatalf("Expected the refund to add to the price, got %f vs %f", refunded.GrossPremium, plain.GrossPremium)
+	}
+	// The price is the fixed point: the loaded annuity plus the refund it implies
+	if expected := (plain.NetPremium + CashRefundValue(policy, mortalityTable, refunded.GrossPremium)) * 1.10; !floatEquals(refunded.GrossPremium, expected, 1e-8*expected) {
+		t.Errorf("Expected price %f, got %f", expected, refunded.GrossPremium)
+	}
+
+	// Stopped after one step the price hasn't settled, and the result says so
+	basis := DefaultBasis(policy, mortalityTable)
+	basis.Solver = SolverConfig{Tolerance: 1e-10, MaxIterations: 1}
+	unsettled := CalculateFullPremiumWithBasis(policy, basis)
+	var convergence *ConvergenceError
+	if unsettled.CashRefundConverged || !errors.As(unsettled.SolverError, &convergence) {
+		t.Errorf("Expected a convergence error after one step, got %v", unsettled.SolverError)
+	}
+
+	// By hand for a price of 2.5 payments: a death in the first year has had
+	// one payment and gets 1.5 back, a death in the second 0.5, then nothing
+	price := 2.5 * policy.CoverageAmount
+	q65, q66 := mortalityTable[65], mortalityTable[66]
+	expected := q65*1.5*policy.CoverageAmount/1.05 + (1-q65)*q66*0.5*policy.CoverageAmount/(1.05*1.05)
+	if value := CashRefundValue(policy, mortalityTable, price); !floatEquals(value, expected, 1e-9) {
+		t.Errorf("Expected refund value %f, got %f", expected, value)
+	}
+	// Paid in arrears, each death has had one payment fewer
+	policy.AnnuityTiming = AnnuityImmediate
+	if value := CashRefundValue(policy, mortalityTable, price); value <= expected {
+		t.Errorf("Expected a larger refund in arrears, got %f vs %f", value, expected)
+	}
+	if value := CashRefundValue(policy, mortalityTable, 0); value != 0 {
+		t.Errorf("Expected nothing to refund on a zero price, got %f", value)
+	}
+}
+
+func TestZeroInterestRate(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.002+float64(age)*0.0004, 1)
+	}
+
+	// Term life: premium = S x sum(tpx x qx+t) / sum(tpx)
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0, ProductType: "term_life"}
+	claims, premiums, survival := 0.0, 0.0, 1.0
+	for year := 0; year < 20; year++ {
+		claims += survival * mortalityTable[40+year]
+		premiums += survival
+		survival *= 1 - mortalityTable[40+year]
+	}
+	expected := 100000 * claims / premiums
+	if got := CalculateNetPremium(policy, mortalityTable); !floatEquals(got, expected, 1e-9) {
+		t.Errorf("Term life at i=0: expected net premium %f, got %f", expected, got)
+	}
+	for _, convention := range []string{AnnualCompounding, ContinuousCompounding} {
+		policy.CompoundingConvention = convention
+		if got := CalculateNetPremium(policy, mortalityTable); !floatEquals(got, expected, 1e-9) {
+			t.Errorf("Term life at i=0 (%s): expected net premium %f, got %f", convention, expected, got)
+		}
+	}
+	policy.CompoundingConvention = ""
+
+	// The reserve runs off to nothing at the end of the term
+	result := CalculateFullPremium(policy, mortalityTable)
+	if last := result.ReserveSchedule[len(result.ReserveSchedule)-1]; !floatEquals(last, 0, 1e-9) {
+		t.Errorf("Term life at i=0: expected a zero reserve at expiry, got %f", last)
+	}
+	if math.IsNaN(result.GrossPremium) || result.GrossPremium <= result.NetPremium {
+		t.Errorf("Term life at i=0: expected a gross premium above the net, got %f", result.GrossPremium)
+	}
+
+	// Annuities: the undiscounted sum of payments to each year the annuitant is alive for
+	annuity := &Policy{Age: 65, CoverageAmount: 1000, InterestRate: 0, ProductType: "immediate_annuity"}
+	payments, survival := 0.0, 1.0
+	for age := 65; age < len(mortalityTable)-1; age++ {
+		payments += survival * 1000
+		survival *= 1 - mortalityTable[age]
+	}
+	if got := CalculateImmediateAnnuityPremium(annuity, mortalityTable); !floatEquals(got, payments, 1e-9) {
+		t.Errorf("Immediate annuity at i=0: expected %f, got %f", payments, got)
+	}
+
+	annuity.Age, annuity.DeferralPeriod = 55, 10
+	deferral := 1.0
+	for age := 55; age < 65; age++ {
+		deferral *= 1 - mortalityTable[age]
+	}
+	if got := CalculateDeferredAnnuityPremium(annuity, mortalityTable); !floatEquals(got, deferral*payments, 1e-9) {
+		t.Errorf("Deferred annuity at i=0: expected %f, got %f", deferral*payments, got)
+	}
+
+	// The annuity due also pays at the table's last age, so it is slightly more per unit
+	if got := SingleLifeAnnuityDue(65, mortalityTable, 0); got < payments/1000 || math.IsInf(got, 0) {
+		t.Errorf("Annuity due at i=0: expected a finite value of at least %f, got %f", payments/1000, got)
+	}
+
+	// Annuities certain and durations have closed forms that divide by i
+	converter := NewVStarConverter(0)
+	if converter.AnnuityImmediate(10) != 10 || converter.AnnuityDue(10) != 10 {
+		t.Errorf("Annuities certain at i=0: expected 10 payments, got %f and %f", converter.AnnuityImmediate(10), converter.AnnuityDue(10))
+	}
+	mac, mod, conv := ComputeDuration([]float64{100, 100}, 0)
+	if !floatEquals(mac, 1.5, 1e-12) || !floatEquals(mod, 1.5, 1e-12) || !floatEquals(conv, 4, 1e-12) {
+		t.Errorf("Duration at i=0: expected 1.5, 1.5 and 4, got %f, %f and %f", mac, mod, conv)
+	}
+	if mac, _, _ := ComputeDuration([]float64{100, 100}, 1e-9); !floatEquals(mac, 1.5, 1e-6) {
+		t.Errorf("Duration at i=0 should match the limit as i->0, got %f", mac)
+	}
+
+	// Profit testing at a zero risk discount rate just adds up the signature
+	profitTest := ProfitTest(policy, mortalityTable, result.GrossPremium, CreateDefaultExpenses(), result.ReserveSchedule, 0)
+	total := 0.0
+	for _, profit := range profitTest.ProfitSignature {
+		total += profit
+	}
+	if !floatEquals(profitTest.ProfitValue, total, 1e-9) {
+		t.Errorf("Profit test at a zero discount rate: expected profit value %f, got %f", total, profitTest.ProfitValue)
+	}
+}
+
+func TestCashFlowDurations(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+	if result := CalculateFullPremium(policy, mortalityTable); result.Duration != nil {
+		t.Fatalf("Expected no duration unless asked for, got %+v", result.Duration)
+	}
+	policy.IncludeDuration = true
+	result := CalculateFullPremium(policy, mortalityTable)
+	if result.Duration == nil {
+		t.Fatal("Expected a duration")
+	}
+	// The benefits are worth the net single premium; premiums come first
+	expectedPayouts, _ := termLifeExpectedValues(policy, mortalityTable)
+	benefits, _ := expectedCashFlows(policy, mortalityTable, result.GrossPremium)
+	if _, value := macaulayDuration(policy, benefits); !floatEquals(value, expectedPayouts, 1e-6) {
+		t.Errorf("Expected benefits worth %f, got %f", expectedPayouts, value)
+	}
+	duration := result.Duration
+	if duration.Premiums <= 0 || duration.Benefits <= duration.Premiums || duration.Benefits > float64(policy.Term) {
+		t.Errorf("Expected premiums before benefits within the term, got %+v", duration)
+	}
+	// The gross premium more than pays for the benefits
+	if duration.NetPresentValue >= 0 {
+		t.Errorf("Expected a negative net value on the gross premium, got %f", duration.NetPresentValue)
+	}
+
+	longer := *policy
+	longer.Term = 30
+	if longerResult := CalculateFullPremium(&longer, mortalityTable); longerResult.Duration.Benefits <= duration.Benefits {
+		t.Errorf("Expected a longer term to lengthen the duration, got %f vs %f", longerResult.Duration.Benefits, duration.Benefits)
+	}
+
+	annuity := &Policy{Age: 65, CoverageAmount: 10000, InterestRate: 0.05, ProductType: "immediate_annuity", IncludeDuration: true}
+	annuityResult := CalculateFullPremium(annuity, mortalityTable)
+	payments, _ := expectedCashFlows(annuity, mortalityTable, annuityResult.GrossPremium)
+	if _, value := macaulayDuration(annuity, payments); !floatEquals(value, CalculateImmediateAnnuityPremium(annuity, mortalityTable), 1e-6) {
+		t.Errorf("Expected payments worth the annuity, got %f", value)
+	}
+	// A single premium at issue has no duration
+	if annuityResult.Duration.Premiums != 0 || annuityResult.Duration.Benefits <= 0 {
+		t.Errorf("Expected payments after a premium at issue, got %+v", annuityResult.Duration)
+	}
+}
+
+func TestSurvivalCurve(t *testing.T) {
+	mortalityTable := MortalityTable{0.1, 0.2, 0.5, 1}
+	points := SurvivalCurve(mortalityTable, 1)
+	expected := []SurvivalPoint{{1, 1}, {2, 0.8}, {3, 0.4}, {4, 0}}
+	if len(points) != len(expected) {
+		t.Fatalf("Expected %d points, got %+v", len(expected), points)
+	}
+	for i, point := range points {
+		if point.Age != expected[i].Age || !floatEquals(point.SurvivalProbability, expected[i].SurvivalProbability, 1e-12) {
+			t.Errorf("Point %d: expected %+v, got %+v", i, expected[i], point)
+		}
+	}
+	if points := SurvivalCurve(mortalityTable, 4); points != nil {
+		t.Errorf("Expected no curve past the table, got %+v", points)
+	}
+}
+
+func TestFirstYearFeeWaiver(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life", RiskDiscountRate: 0.1}
+	level := CalculateFullPremium(policy, mortalityTable)
+	fee := ExpensesForProduct("term_life").MaintenanceExpense
+
+	policy.FirstYearFeeWaiver = FeeWaiverAbsorbed
+	absorbed := CalculateFullPremium(policy, mortalityTable)
+	if absorbed.FeeWaiver != FeeWaiverAbsorbed || !floatEquals(absorbed.FirstYearPremium, level.GrossPremium-fee, 1e-9) ||
+		absorbed.RenewalPremium != level.GrossPremium || absorbed.GrossPremium != level.GrossPremium {
+		t.Errorf("Expected the fee off the first premium only, got %f then %f", absorbed.FirstYearPremium, absorbed.RenewalPremium)
+	}
+	// The insurer pays for it: less profit and more strain
+	if absorbed.ProfitTest.ProfitValue >= level.ProfitTest.ProfitValue || absorbed.NewBusinessStrain <= level.NewBusinessStrain {
+		t.Errorf("Expected the absorbed fee to cost the insurer, got profit %f vs %f", absorbed.ProfitTest.ProfitValue, level.ProfitTest.ProfitValue)
+	}
+
+	// Respread, the premiums are worth what the level premiums were
+	policy.FirstYearFeeWaiver = FeeWaiverRespread
+	respread := CalculateFullPremium(policy, mortalityTable)
+	premiumAnnuity := premiumAnnuityValue(policy, mortalityTable)
+	waivedValue := respread.FirstYearPremium + respread.RenewalPremium*(premiumAnnuity-1)
+	if respread.FeeWaiver != FeeWaiverRespread || respread.RenewalPremium <= level.GrossPremium ||
+		!floatEquals(waivedValue, level.GrossPremium*premiumAnnuity, 1e-6) {
+		t.Errorf("Expected respread premiums worth %f, got %f", level.GrossPremium*premiumAnnuity, waivedValue)
+	}
+
+	// One premium leaves nothing to respread over
+	policy.Term = 1
+	if single := CalculateFullPremium(policy, mortalityTable); single.FeeWaiver != FeeWaiverAbsorbed || len(single.Warnings) == 0 {
+		t.Errorf("Expected a one-year policy to absorb the fee with a warning, got %q and %v", single.FeeWaiver, single.Warnings)
+	}
+}
+
+func TestSettlementDelay(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	immediate := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05}
+	delayed := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, SettlementDelay: 6}
+
+	// Six months' more interest on every claim
+	netPremium := CalculateTermLifeNetPremium(immediate, mortalityTable)
+	delayedPremium := CalculateTermLifeNetPremium(delayed, mortalityTable)
+	if want := netPremium / math.Sqrt(1.05); !floatEquals(delayedPremium, want, 1e-9) {
+		t.Errorf("Expected a net premium of %f with a six-month delay, got %f", want, delayedPremium)
+	}
+
+	// The reserves allow for it too, so the two methods still agree and the
+	// net premium still breaks even
+	prospective := CalculateTermLifeReserveSchedule(delayed, mortalityTable, delayedPremium)
+	retrospective := CalculateRetrospectiveReserveSchedule(delayed, mortalityTable, delayedPremium)
+	for year := range prospective {
+		if !floatEquals(prospective[year], retrospective[year], 1e-6) {
+			t.Errorf("Year %d: prospective reserve %f but retrospective %f", year, prospective[year], retrospective[year])
+		}
+	}
+	if value := ProfitTest(delayed, mortalityTable, delayedPremium, ExpenseStructure{}, prospective, 0.05).ProfitValue; !floatEquals(value, 0, 1e-6) {
+		t.Errorf("Expected the delayed net premium to break even, got a profit value of %f", value)
+	}
+
+	whole := &Policy{Age: 40, Term: 60, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "whole_life", SettlementDelay: 3}
+	result := CalculateFullPremium(whole, mortalityTable)
+	if result.SettlementDelay != 3 {
+		t.Errorf("Expected the delay to be reported, got %f", result.SettlementDelay)
+	}
+	whole.SettlementDelay = 0
+	if undelayed := CalculateFullPremium(whole, mortalityTable); result.NetPremium >= undelayed.NetPremium {
+		t.Errorf("Expected the delay to lower the whole life premium, got %f vs %f", result.NetPremium, undelayed.NetPremium)
+	}
+}
+
+func TestPremiumTiming(t *testing.T) {
+	// One year of cover at q = 0.01 and 5%: the benefit is worth 0.01 x 1000 / 1.05.
+	// In advance the premium is certain, so P = 9.5238. In arrears only the
+	// 99% who survive pay it, a year later, so P = 10 / 0.99.
+	oneYear := MortalityTable{0.01, 0.01}
+	due := &Policy{Age: 0, Term: 1, CoverageAmount: 1000, InterestRate: 0.05}
+	arrears := &Policy{Age: 0, Term: 1, CoverageAmount: 1000, InterestRate: 0.05, PremiumTiming: PremiumsAnnuallyInArrears}
+	if premium := CalculateTermLifeNetPremium(due, oneYear); !floatEquals(premium, 10/1.05, 1e-9) {
+		t.Errorf("Expected a premium in advance of %f, got %f", 10/1.05, premium)
+	}
+	if premium := CalculateTermLifeNetPremium(arrears, oneYear); !floatEquals(premium, 10/0.99, 1e-9) {
+		t.Errorf("Expected a premium in arrears of %f, got %f", 10/0.99, premium)
+	}
+
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	for _, policy := range []*Policy{
+		{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, LapseRate: 0.03, PremiumTiming: PremiumsAnnuallyInArrears},
+		{Age: 40, Term: 60, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "whole_life", PremiumTiming: PremiumsAnnuallyInArrears},
+	} {
+		netPremium := CalculateNetPremium(policy, mortalityTable)
+		inAdvance := *policy
+		inAdvance.PremiumTiming = ""
+		if due := CalculateNetPremium(&inAdvance, mortalityTable); netPremium <= due {
+			t.Errorf("%s: expected paying later to cost more, got %f in arrears vs %f in advance", policy.ProductType, netPremium, due)
+		}
+
+		// Both reserve methods and the profit test allow for the timing
+		prospective := CalculateReserveSchedule(policy, mortalityTable, netPremium)
+		retrospective := CalculateRetrospectiveReserveSchedule(policy, mortalityTable, netPremium)
+		for year := range prospective {
+			if !floatEquals(prospective[year], retrospective[year], 1e-6) {
+				t.Fatalf("%s year %d: prospective reserve %f but retrospective %f", policy.ProductType, year, prospective[year], retrospective[year])
+			}
+		}
+		if value := ProfitTest(policy, mortalityTable, netPremium, ExpenseStructure{}, prospective, 0.05).ProfitValue; !floatEquals(value, 0, 1e-6) {
+			t.Errorf("%s: expected the net premium in arrears to break even, got a profit value of %f", policy.ProductType, value)
+		}
+		if duration := CashFlowDurations(policy, mortalityTable, netPremium); !floatEquals(duration.NetPresentValue, 0, 1e-6) {
+			t.Errorf("%s: expected the cash flows to balance, got %f", policy.ProductType, duration.NetPresentValue)
+		}
+	}
+
+	basis := DefaultBasis(due, mortalityTable)
+	basis.PremiumTiming = PremiumsAnnuallyInArrears
+	if result := CalculateFullPremiumWithBasis(&Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}, basis); result.PremiumTiming != PremiumsAnnuallyInArrears {
+		t.Errorf("Expected the timing used to be reported, got %q", result.PremiumTiming)
+	}
+}
+
+func TestLayeredCoverage(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+
+	// One layer is the same as no layers
+	single := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+	layered := *single
+	layered.Layers = []CoverageLayer{{CoverageAmount: 100000, Term: 20}}
+	if got, want := CalculateNetPremium(&layered, mortalityTable), CalculateNetPremium(single, mortalityTable); !floatEquals(got, want, 1e-9) {
+		t.Errorf("Expected a single layer to cost %f, got %f", want, got)
+	}
+
+	// 500k for 20 years and another 200k for 30 years, premiums paid for 30
+	policy := &Policy{Age: 35, Term: 30, CoverageAmount: 500000, InterestRate: 0.05, ProductType: "term_life",
+		Layers: []CoverageLayer{{CoverageAmount: 500000, Term: 20}, {CoverageAmount: 200000, Term: 30}}}
+	if got := SumAssuredInYear(policy, 19); got != 700000 {
+		t.Errorf("Expected both layers in year 20, got %f", got)
+	}
+	if got := SumAssuredInYear(policy, 20); got != 200000 {
+		t.Errorf("Expected only the longer layer in year 21, got %f", got)
+	}
+
+	result := CalculateFullPremium(policy, mortalityTable)
+	if len(result.LayerPremiums) != 2 {
+		t.Fatalf("Expected a premium for each layer, got %v", result.LayerPremiums)
+	}
+	total := 0.0
+	for _, layer := range result.LayerPremiums {
+		total += layer.NetPremium
+	}
+	if !floatEquals(total, result.NetPremium, 1e-6) {
+		t.Errorf("Expected the layer premiums to add up to the net premium %f, got %f", result.NetPremium, total)
+	}
+	if len(result.ReserveSchedule) == 0 || !floatEquals(result.ReserveSchedule[len(result.ReserveSchedule)-1], 0, 1e-6) {
+		t.Errorf("Expected the reserve to run off by the end of the longest layer, got %v", result.ReserveSchedule)
+	}
+	if result := CalculateFullPremium(single, mortalityTable); result.LayerPremiums != nil {
+		t.Errorf("Expected no layer breakdown without layers, got %v", result.LayerPremiums)
+	}
+}
+
+func TestZeroNetPremium(t *testing.T) {
+	// No one dies, so no benefit is ever paid
+	mortalityTable := make(MortalityTable, 101)
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05}
+	basis := DefaultBasis(policy, mortalityTable)
+	result := CalculateFullPremiumWithBasis(policy, basis)
+	if !errors.Is(result.PremiumError, ErrZeroNetPremium) {
+		t.Fatalf("Expected a zero net premium to be rejected, got %v", result.PremiumError)
+	}
+
+	// Warned, the expense-only premium is quoted with a warning
+	basis.ZeroPremium = ZeroPremiumWarn
+	result = CalculateFullPremiumWithBasis(policy, basis)
+	if result.PremiumError != nil || result.NetPremium != 0 || result.GrossPremium <= 0 {
+		t.Fatalf("Expected an expense-only premium, got %+v", result)
+	}
+	warned := false
+	for _, warning := range result.Warnings {
+		warned = warned || strings.Contains(warning, "net premium is zero")
+	}
+	if !warned {
+		t.Errorf("Expected a zero net premium warning, got %v", result.Warnings)
+	}
+
+	// The smallest chance of a claim is priced as usual
+	mortalityTable[50] = 1e-9
+	basis.ZeroPremium = ZeroPremiumReject
+	if result := CalculateFullPremiumWithBasis(policy, basis); result.PremiumError != nil || result.NetPremium <= 0 {
+		t.Errorf("Expected a tiny positive net premium, got %f (%v)", result.NetPremium, result.PremiumError)
+	}
+
+	if err := CheckPremium(math.NaN(), 100, ZeroPremiumWarn); !errors.Is(err, ErrNonFinitePremium) {
+		t.Errorf("Expected a NaN net premium to be rejected, got %v", err)
+	}
+	if err := CheckPremium(10, math.Inf(1), ZeroPremiumReject); !errors.Is(err, ErrNonFinitePremium) {
+		t.Errorf("Expected an infinite gross premium to be rejected, got %v", err)
+	}
+}
+
+func TestPremiumBreakdown(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = math.Min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+
+	// With no commission the gross premium is exactly net + profit + expenses
+	expenses := ExpenseStructure{InitialExpenseRate: 0.02, MaintenanceExpense: 50, ProfitMargin: 0.2}
+	netPremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	grossPremium := CalculateGrossPremium(policy, mortalityTable, netPremium, expenses)
+	breakdown := BreakDownPremium(policy, netPremium, grossPremium, expenses)
+	// 2% of the sum assured over 10 expected premiums, plus maintenance
+	if !floatEquals(breakdown.ExpenseLoading, 2000.0/10+50, 1e-9) {
+		t.Errorf("Expected an expense loading of 250, got %f", breakdown.ExpenseLoading)
+	}
+	if !floatEquals(breakdown.ProfitLoading, 0.2*netPremium, 1e-9) {
+		t.Errorf("Expected a profit loading of %f, got %f", 0.2*netPremium, breakdown.ProfitLoading)
+	}
+
+	// Commission is on the premium charged, and whatever the premium holds
+	// over expenses is profit
+	expenses.RenewalExpenseRate = 0.1
+	if got := BreakDownPremium(policy, netPremium, 1000, expenses); !floatEquals(got.ExpenseLoading, 350, 1e-9) || !floatEquals(got.ProfitLoading, 650-netPremium, 1e-9) {
+		t.Errorf("Expected 350 of expenses and %f of profit in 1000, got %+v", 650-netPremium, got)
+	}
+
+	result := CalculateFullPremium(policy, mortalityTable)
+	if result.PremiumBreakdown == nil {
+		t.Fatal("Expected a life premium to be broken down")
+	}
+	total := result.PremiumBreakdown.NetPremium + result.PremiumBreakdown.ExpenseLoading + result.PremiumBreakdown.ProfitLoading
+	if !floatEquals(total, result.GrossPremium, 1e-9) {
+		t.Errorf("Expected the breakdown to add up to %f, got %f", result.GrossPremium, total)
+	}
+}
+
+func TestBreakEvenDurations(t *testing.T) {
+	year := func(y int) *int { return &y }
+	tests := []struct {
+		name            string
+		policy          Policy
+		firstYear       float64 // Lower than the renewal premium when a fee is waived
+		renewal         float64
+		reserveSchedule []float64
+		wantCover       *int
+		wantReserve     *int
+	}{
+		{"term", Policy{Term: 5, CoverageAmount: 4500, ProductType: "term_life"}, 1000, 1000, []float64{0, 500, 2500, 2800, 3000, 0}, year(5), year(2)},
+		// Premiums stop after three years, so they never reach the cover and
+		// the reserve overtakes them in year 4
+		{"limited pay", Policy{Term: 5, PremiumPayingTerm: 3, CoverageAmount: 3500, ProductType: "term_life"}, 1000, 1000, []float64{0, 500, 1500, 2500, 3200, 3600}, nil, year(4)},
+		// The schedule starts at duration 3, but years still count from issue
+		{"in force", Policy{Term: 6, InForceDuration: 3, CoverageAmount: 1500, ProductType: "term_life"}, 1000, 1000, []float64{2000, 3500, 5500, 0}, year(2), year(5)},
+		{"never reached", Policy{Term: 5, CoverageAmount: 100000, ProductType: "term_life"}, 100, 100, []float64{0, 0, 0, 0, 0, 0}, nil, nil},
+		// The waived fee makes the first premium 600, so 2700 of cover is
+		// passed in year 4 rather than year 3
+		{"fee waiver", Policy{Term: 5, CoverageAmount: 2700, ProductType: "term_life"}, 600, 1000, []float64{0, 0, 0, 0, 0, 0}, year(4), nil},
+		// Once the 5000 layer runs off after two years only 1000 of cover is left
+		{"layers", Policy{Term: 5, CoverageAmount: 5000, ProductType: "term_life", Layers: []CoverageLayer{{CoverageAmount: 5000, Term: 2}, {CoverageAmount: 1000, Term: 5}}},
+			1000, 1000, []float64{0, 0, 0, 0, 0, 0}, year(3), nil},
+	}
+	for _, tt := range tests {
+		cover, reserve := BreakEvenDurations(&tt.policy, tt.firstYear, tt.renewal, tt.reserveSchedule)
+		if (cover == nil) != (tt.wantCover == nil) || cover != nil && *cover != *tt.wantCover {
+			t.Errorf("%s: expected premiums to exceed cover in year %v, got %v", tt.name, describeYear(tt.wantCover), describeYear(cover))
+		}
+		if (reserve == nil) != (tt.wantReserve == nil) || reserve != nil && *reserve != *tt.wantReserve {
+			t.Errorf("%s: expected the reserve to exceed premiums in year %v, got %v", tt.name, describeYear(tt.wantReserve), describeYear(reserve))
+		}
+	}
+}
+
+// describeYear shows an optional year in a test message
+func describeYear(year *int) string {
+	if year == nil {
+		return "never"
+	}
+	return strconv.Itoa(*year)
+}
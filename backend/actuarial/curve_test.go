@@ -0,0 +1,127 @@
+package actuarial
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadYieldCurve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing_2024.csv")
+	contents := "term\trate\n2\t0.040\n5\t0.046\n10\t0.050\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	curve, err := LoadYieldCurve(path)
+	if err != nil {
+		t.Fatalf("Could not load curve: %v", err)
+	}
+	if len(curve) != 10 {
+		t.Fatalf("Expected rates for terms 1 to 10, got %d", len(curve))
+	}
+
+	tests := []struct {
+		years int
+		want  float64
+	}{
+		{1, 0.040},  // Before the first term: flat
+		{2, 0.040},  // Given
+		{3, 0.042},  // A third of the way from 2 to 5
+		{5, 0.046},  // Given
+		{10, 0.050}, // Given
+		{30, 0.050}, // Past the end: flat
+	}
+	for _, tt := range tests {
+		if got := curve.SpotRate(tt.years); !floatEquals(got, tt.want, 1e-12) {
+			t.Errorf("Spot rate for %d years: expected %f, got %f", tt.years, tt.want, got)
+		}
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.csv")
+	if err := os.WriteFile(bad, []byte("term\trate\n0\t0.04\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadYieldCurve(bad); err == nil {
+		t.Error("Expected a zero term to be rejected")
+	}
+}
+
+func TestYieldCurvePricing(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+	flatRate := CalculateFullPremium(policy, mortalityTable)
+
+	// A flat curve is the same as a single interest rate
+	curved := *policy
+	curved.InterestRate = 0
+	curved.YieldCurve = YieldCurve{0.05, 0.05, 0.05}
+	if got := CalculateFullPremium(&curved, mortalityTable); !floatEquals(got.GrossPremium, flatRate.GrossPremium, 1e-9) {
+		t.Errorf("Expected a flat 5%% curve to match a 5%% rate, got %f vs %f", got.GrossPremium, flatRate.GrossPremium)
+	}
+
+	// Shifting a flat curve is the same as shifting the rate
+	shiftedRate := *policy
+	shiftedRate.InterestRate = 0.04
+	curved.YieldCurve = YieldCurve{0.05, 0.05, 0.05}.Shift(-0.01)
+	if got, want := CalculateFullPremium(&curved, mortalityTable), CalculateFullPremium(&shiftedRate, mortalityTable); !floatEquals(got.GrossPremium, want.GrossPremium, 1e-9) {
+		t.Errorf("Expected a curve shifted to 4%% to match a 4%% rate, got %f vs %f", got.GrossPremium, want.GrossPremium)
+	}
+
+	// An upward sloping curve discounts the later claims more heavily
+	curved.YieldCurve = YieldCurve{0.05, 0.055, 0.06, 0.065, 0.07}
+	if got := CalculateFullPremium(&curved, mortalityTable); got.NetPremium >= flatRate.NetPremium {
+		t.Errorf("Expected higher long rates to lower the premium, got %f vs %f", got.NetPremium, flatRate.NetPremium)
+	}
+}
+
+func TestLoadScenarioSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regulator.csv")
+	contents := "scenario\t1\t2\t3\nbase\t0.04\t0.05\t0.06\nshort\t0.03\t0.03\t\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scenarios, err := LoadScenarioSet(path)
+	if err != nil {
+		t.Fatalf("Could not load scenarios: %v", err)
+	}
+	if len(scenarios) != 2 || scenarios[0].Name != "base" || len(scenarios[0].Path) != 3 || len(scenarios[1].Path) != 2 {
+		t.Fatalf("Expected a 3 and a 2 year scenario, got %+v", scenarios)
+	}
+
+	// Money grows along the path, so the 2 year spot rate compounds the first two years
+	curve := scenarios[0].Curve()
+	if !floatEquals(curve[0], 0.04, 1e-12) || !floatEquals(curve[1], math.Sqrt(1.04*1.05)-1, 1e-12) {
+		t.Errorf("Expected spot rates compounded from the path, got %v", curve)
+	}
+
+	for name, contents := range map[string]string{
+		"skipped year":   "scenario\t1\t2\t3\nbase\t0.04\t\t0.06\n",
+		"bad header":     "scenario\t1\t3\nbase\t0.04\t0.05\n",
+		"no scenarios":   "scenario\t1\n",
+		"too many rates": "scenario\t1\nbase\t0.04\t0.05\n",
+	} {
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadScenarioSet(path); err == nil {
+			t.Errorf("%s: expected the scenario set to be rejected", name)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+	for share, want := range map[float64]float64{0: 1, 0.5: 3, 0.25: 2, 0.9: 4.6, 1: 5} {
+		if got := Percentile(values, share); !floatEquals(got, want, 1e-12) {
+			t.Errorf("Percentile %g: expected %f, got %f", share, want, got)
+		}
+	}
+	if values[0] != 5 {
+		t.Error("Expected the values to be left in their order")
+	}
+}
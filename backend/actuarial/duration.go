@@ -0,0 +1,116 @@
+package actuarial
+
+import "math"
+
+// CashFlowDuration is the Macaulay duration in years of a policy's expected
+// cash flows: the time to each flow weighted by its present value. The longer
+// it is, the more the flows' value moves when interest rates do.
+type CashFlowDuration struct {
+	Benefits float64 `json:"benefits"` // Death, maturity or annuity payments
+	Premiums float64 `json:"premiums"` // Premiums as charged; 0 for a single premium
+
+	// Net is the duration of benefits less premiums, the insurer's liability.
+	// Premiums offset benefits, so it is usually longer than either and can
+	// be negative; it is left at 0 when the net value is too close to nothing
+	// to weight by.
+	Net             float64 `json:"net"`
+	NetPresentValue float64 `json:"net_present_value"` // Value of the benefits less the premiums
+}
+
+// cashFlow is an expected amount at a time in years from issue
+type cashFlow struct {
+	time   float64
+	amount float64
+}
+
+// CashFlowDurations works out the durations of a policy's expected benefits and
+// of premium at the given rate, on the same probabilities and discounting as
+// its pricing: premiums at the start of each year (or the end, in arrears),
+// death benefits at the end.
+func CashFlowDurations(policy *Policy, mortalityTable MortalityTable, premium float64) CashFlowDuration {
+	benefits, premiums := expectedCashFlows(policy, mortalityTable, premium)
+	benefitDuration, benefitValue := macaulayDuration(policy, benefits)
+	premiumDuration, premiumValue := macaulayDuration(policy, premiums)
+
+	duration := CashFlowDuration{
+		Benefits:        benefitDuration,
+		Premiums:        premiumDuration,
+		NetPresentValue: benefitValue - premiumValue,
+	}
+	if math.Abs(duration.NetPresentValue) > 1e-9*math.Max(benefitValue, premiumValue) {
+		duration.Net = (benefitDuration*benefitValue - premiumDuration*premiumValue) / duration.NetPresentValue
+	}
+	return duration
+}
+
+// macaulayDuration is the present-value-weighted mean time of the flows, and
+// their present value
+func macaulayDuration(policy *Policy, flows []cashFlow) (duration, presentValue float64) {
+	weightedTime := 0.0
+	for _, flow := range flows {
+		value := discountFractional(policy, flow.amount, flow.time)
+		presentValue += value
+		weightedTime += flow.time * value
+	}
+	if presentValue == 0 {
+		return 0, 0
+	}
+	return weightedTime / presentValue, presentValue
+}
+
+// expectedCashFlows lists a policy's benefit and premium flows, each weighted
+// by the chance it is paid
+func expectedCashFlows(policy *Policy, mortalityTable MortalityTable, premium float64) (benefits, premiums []cashFlow) {
+	switch policy.ProductType {
+	case "immediate_annuity", "deferred_annuity":
+		return annuityCashFlows(policy, mortalityTable), []cashFlow{{0, premium}}
+	}
+
+	years := policy.Term
+	if policy.ProductType == "whole_life" {
+		years, _ = WholeLifeHorizon(policy, mortalityTable)
+	}
+	premiumYears := PremiumPayingYears(policy)
+	chanceInForce := 1.0
+	for year := 0; year < years; year++ {
+		age := policy.Age + year
+		if age >= len(mortalityTable) {
+			break
+		}
+		if year < premiumYears && policy.PremiumTiming == PremiumsAnnuallyInArrears {
+			premiums = append(premiums, cashFlow{float64(year + 1), chanceInForce * (1.0 - mortalityTable[age]) * premium})
+		} else if year < premiumYears {
+			premiums = append(premiums, cashFlow{float64(year), chanceInForce * premium})
+		}
+		benefits = append(benefits, cashFlow{float64(year+1) + policy.SettlementDelay/12, chanceInForce * mortalityTable[age] * SumAssuredInYear(policy, year)})
+		chanceInForce *= (1.0 - mortalityTable[age]) * (1.0 - lapseRateForYear(policy, year))
+	}
+	if policy.MaturityBenefit > 0 && policy.Age+years <= len(mortalityTable) {
+		benefits = append(benefits, cashFlow{float64(years), chanceInForce * policy.MaturityBenefit})
+	}
+	return benefits, premiums
+}
+
+// annuityCashFlows are the expected annuity payments, timed as annuityPayment
+// times them
+func annuityCashFlows(policy *Policy, mortalityTable MortalityTable) []cashFlow {
+	var payments []cashFlow
+	chanceAlive := 1.0
+	for year := 0; year < len(mortalityTable)-1-policy.Age; year++ {
+		age := policy.Age + year
+		if year >= policy.DeferralPeriod || policy.ProductType == "immediate_annuity" {
+			chancePaid, paidAt := chanceAlive, float64(year)
+			if policy.AnnuityTiming == AnnuityImmediate {
+				chancePaid *= 1.0 - mortalityTable[age]
+				paidAt++
+			}
+			if policy.DeferralFraction > 0 {
+				chancePaid *= 1.0 - policy.DeferralFraction*mortalityTable[policy.Age+policy.DeferralPeriod]
+				paidAt += policy.DeferralFraction
+			}
+			payments = append(payments, cashFlow{paidAt, chancePaid * policy.CoverageAmount})
+		}
+		chanceAlive *= 1.0 - mortalityTable[age]
+	}
+	return payments
+}
@@ -0,0 +1,54 @@
+package actuarial
+
+import "fmt"
+
+// CapitalBasis sets the capital a policy requires on top of its reserve, in
+// the simple statutory form of a share of the reserve plus a share of the sum
+// at risk
+type CapitalBasis struct {
+	ReserveFactor   float64 `json:"reserve_factor"`     // Share of the reserve, e.g. 0.04
+	SumAtRiskFactor float64 `json:"sum_at_risk_factor"` // Share of the sum assured less the reserve, e.g. 0.003
+}
+
+// DefaultCapitalBasis is 4% of the reserve plus 0.3% of the sum at risk
+var DefaultCapitalBasis = CapitalBasis{ReserveFactor: 0.04, SumAtRiskFactor: 0.003}
+
+// Validate checks both factors are shares between 0 and 1
+func (basis CapitalBasis) Validate() error {
+	if !(basis.ReserveFactor >= 0 && basis.ReserveFactor <= 1) {
+		return fmt.Errorf("reserve factor must be between 0 and 1")
+	}
+	if !(basis.SumAtRiskFactor >= 0 && basis.SumAtRiskFactor <= 1) {
+		return fmt.Errorf("sum at risk factor must be between 0 and 1")
+	}
+	return nil
+}
+
+// RequiredCapital is the capital held against a policy with this reserve and
+// sum assured. A reserve above the sum assured leaves nothing at risk.
+func (basis CapitalBasis) RequiredCapital(reserve, sumAssured float64) float64 {
+	return basis.ReserveFactor*reserve + basis.SumAtRiskFactor*max(0, sumAssured-reserve)
+}
+
+// EmbeddedValue is the shareholders' value of a book of business: the net
+// assets they hold today plus the value of the profits still to come from
+// the policies in force. The net assets split into the capital required
+// against the book and the free surplus over it.
+type EmbeddedValue struct {
+	NetAssetValue   float64
+	RequiredCapital float64
+	FreeSurplus     float64 // Net assets less required capital; negative when short of capital
+	ValueOfInForce  float64 // Profit signatures discounted at the risk discount rate
+	EmbeddedValue   float64 // Net assets plus value of in-force
+}
+
+// CalculateEmbeddedValue puts together an embedded value from its components
+func CalculateEmbeddedValue(netAssetValue, requiredCapital, valueOfInForce float64) EmbeddedValue {
+	return EmbeddedValue{
+		NetAssetValue:   netAssetValue,
+		RequiredCapital: requiredCapital,
+		FreeSurplus:     netAssetValue - requiredCapital,
+		ValueOfInForce:  valueOfInForce,
+		EmbeddedValue:   netAssetValue + valueOfInForce,
+	}
+}
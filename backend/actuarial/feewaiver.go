@@ -0,0 +1,45 @@
+package actuarial
+
+// First-year fee waiver rules: who pays for the policy fee waived in year one
+const (
+	FeeWaiverAbsorbed = "absorb"   // The insurer bears the cost; renewal premiums are unchanged
+	FeeWaiverRespread = "respread" // Renewal premiums rise by enough to recover its value
+)
+
+// FirstYearFeeWaived is the policy fee taken off a policy's first premium: the
+// fixed yearly maintenance expense every premium carries, but never more
+// than the premium itself. It is 0 unless the policy has a fee waiver.
+func FirstYearFeeWaived(policy *Policy, grossPremium float64, expenses ExpenseStructure) float64 {
+	if policy.FirstYearFeeWaiver == "" {
+		return 0
+	}
+	return max(min(expenses.MaintenanceExpense, grossPremium), 0)
+}
+
+// WaiveFirstYearFee splits a level gross premium into a first-year premium
+// without the policy fee and the renewal premium charged after it.
+// premiumAnnuity is the value of 1 a year of premium, as in the net premium.
+//
+// Absorbed, the renewal premium is the level premium. Respread, the renewals
+// carry the waived fee's value between them: renewal = gross + fee / (a - 1),
+// where a - 1 is the value of the premiums after the first. With no renewal
+// premiums to spread it over, the fee is absorbed, and the rule returned says so.
+func WaiveFirstYearFee(policy *Policy, grossPremium float64, expenses ExpenseStructure, premiumAnnuity float64) (firstYear, renewal float64, rule string) {
+	waived := FirstYearFeeWaived(policy, grossPremium, expenses)
+	firstYear, renewal, rule = grossPremium-waived, grossPremium, FeeWaiverAbsorbed
+	if policy.FirstYearFeeWaiver == FeeWaiverRespread && premiumAnnuity > 1 {
+		renewal += waived / (premiumAnnuity - 1)
+		rule = FeeWaiverRespread
+	}
+	return firstYear, renewal, rule
+}
+
+// premiumAnnuityValue is the expected present value of 1 a year of premium
+func premiumAnnuityValue(policy *Policy, mortalityTable MortalityTable) float64 {
+	if policy.ProductType == "whole_life" {
+		_, premiumAnnuity := wholeLifeExpectedValues(policy, mortalityTable)
+		return premiumAnnuity
+	}
+	_, premiumAnnuity := termLifeExpectedValues(policy, mortalityTable)
+	return premiumAnnuity
+}
@@ -0,0 +1,10 @@
+package actuarial
+
+import "math"
+
+// ExperienceRefund is what a group scheme gets back when its claims come in
+// below expected: the shortfall times the scheme's profit share, never less
+// than zero. A bad year is not clawed back.
+func ExperienceRefund(expectedClaims, actualClaims, profitShare float64) float64 {
+	return math.Max(0, (expectedClaims-actualClaims)*profitShare)
+}
@@ -0,0 +1,21 @@
+package actuarial
+
+import "testing"
+
+func TestExperienceRefund(t *testing.T) {
+	tests := []struct {
+		name                    string
+		expected, actual, share float64
+		want                    float64
+	}{
+		{"claims below expected", 10000, 6000, 0.5, 2000},
+		{"claims equal expected", 10000, 10000, 0.5, 0},
+		{"claims above expected", 10000, 15000, 0.5, 0},
+		{"no profit share", 10000, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := ExperienceRefund(tt.expected, tt.actual, tt.share); !floatEquals(got, tt.want, 1e-9) {
+			t.Errorf("%s: expected refund %f, got %f", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package actuarial
+
+import (
+	"fmt"
+	"math"
+)
+
+// FulfilmentCashFlows is the IFRS 17 best-estimate value of a policy's future
+// cash flows, on the same basis as GrossPremiumValuation: the premium
+// actually charged, the expenses expected and a best-estimate table. Premiums
+// and expenses fall at the start of each year and claims at the end.
+type FulfilmentCashFlows struct {
+	PremiumValue          float64 `json:"premium_value"`           // Present value of future gross premiums
+	ClaimsValue           float64 `json:"claims_value"`            // Present value of death and maturity benefits
+	ExpenseValue          float64 `json:"expense_value"`           // Present value of initial, renewal and maintenance expenses
+	BestEstimateLiability float64 `json:"best_estimate_liability"` // Claims and expenses less premiums
+
+	// Standard deviation of the present value of the claims actually paid, for
+	// a confidence level risk adjustment
+	ClaimsStandardDeviation float64 `json:"claims_standard_deviation"`
+}
+
+// CalculateFulfilmentCashFlows values a policy's best-estimate cash flows from
+// its in-force duration, with the initial expense only for new business. The
+// best-estimate liability equals the gross premium reserve at that duration.
+//
+// The present value of the claims is a random amount: the discounted benefit
+// for the year the policy ends in by death or maturity, or nothing if it
+// lapses. Its variance comes from its second moment, the sum over those
+// outcomes of their chance times the discounted benefit squared.
+func CalculateFulfilmentCashFlows(policy *Policy, mortalityTable MortalityTable, grossPremium float64, expenses ExpenseStructure) FulfilmentCashFlows {
+	years := policy.Term
+	if policy.ProductType == "whole_life" {
+		years, _ = WholeLifeHorizon(policy, mortalityTable)
+	}
+	premiumYears := PremiumPayingYears(policy)
+	fromYear := policy.InForceDuration
+
+	var result FulfilmentCashFlows
+	if fromYear == 0 {
+		result.ExpenseValue = SumAssuredInYear(policy, 0) * expenses.InitialExpenseRate
+	}
+	claimsSecondMoment := 0.0
+	chanceInForce := 1.0
+	for futureYear := 0; fromYear+futureYear < years; futureYear++ {
+		policyYear := fromYear + futureYear
+		age := policy.Age + policyYear
+		if age >= len(mortalityTable) {
+			break
+		}
+
+		outgo := expenses.MaintenanceExpense
+		if policyYear < premiumYears {
+			result.PremiumValue += chanceInForce * discount(policy, grossPremium, futureYear)
+			outgo += grossPremium * expenses.RenewalExpenseRate
+		}
+		result.ExpenseValue += chanceInForce * discount(policy, outgo, futureYear)
+
+		claim := deathBenefitValue(policy, SumAssuredInYear(policy, policyYear), futureYear+1)
+		result.ClaimsValue += chanceInForce * mortalityTable[age] * claim
+		claimsSecondMoment += chanceInForce * mortalityTable[age] * claim * claim
+
+		chanceInForce *= (1 - mortalityTable[age]) * (1 - lapseRateForYear(policy, policyYear))
+	}
+	if policy.MaturityBenefit > 0 && policy.ProductType != "whole_life" && policy.Age+policy.Term <= len(mortalityTable) {
+		maturity := discount(policy, policy.MaturityBenefit, years-fromYear)
+		result.ClaimsValue += chanceInForce * maturity
+		claimsSecondMoment += chanceInForce * maturity * maturity
+	}
+
+	result.BestEstimateLiability = result.ClaimsValue + result.ExpenseValue - result.PremiumValue
+	result.ClaimsStandardDeviation = math.Sqrt(max(0, claimsSecondMoment-result.ClaimsValue*result.ClaimsValue))
+	return result
+}
+
+// Ways of setting the IFRS 17 risk adjustment for non-financial risk
+const (
+	RiskAdjustmentPercentage      = "percentage"       // A loading on the present value of claims
+	RiskAdjustmentConfidenceLevel = "confidence_level" // The margin taking claims to a percentile
+)
+
+// RiskAdjustmentConfig is how the risk adjustment is worked out
+type RiskAdjustmentConfig struct {
+	Method          string  // RiskAdjustmentPercentage (default) or RiskAdjustmentConfidenceLevel
+	Percentage      float64 // Percentage method: loading on the claims value (0.05 = 5%)
+	ConfidenceLevel float64 // Confidence level method: e.g. 0.75 for the 75th percentile
+}
+
+// Validate checks the config can be applied
+func (config RiskAdjustmentConfig) Validate() error {
+	switch config.Method {
+	case "", RiskAdjustmentPercentage:
+		if config.Percentage < 0 {
+			return fmt.Errorf("risk adjustment percentage must not be negative")
+		}
+	case RiskAdjustmentConfidenceLevel:
+		if config.ConfidenceLevel <= 0.5 || config.ConfidenceLevel >= 1 {
+			return fmt.Errorf("risk adjustment confidence level must be above 0.5 and below 1")
+		}
+	default:
+		return fmt.Errorf("unknown risk adjustment method '%s'", config.Method)
+	}
+	return nil
+}
+
+// RiskAdjustment is the compensation for bearing the uncertainty in the
+// claims. The percentage method loads the claims value. The confidence level
+// method is the margin that takes the claims value to the given percentile,
+// treating the present value of claims as normally distributed: z x its
+// standard deviation, where z is the standard normal percentile.
+func RiskAdjustment(cashFlows FulfilmentCashFlows, config RiskAdjustmentConfig) float64 {
+	if config.Method == RiskAdjustmentConfidenceLevel {
+		z := math.Sqrt2 * math.Erfinv(2*config.ConfidenceLevel-1)
+		return z * cashFlows.ClaimsStandardDeviation
+	}
+	return config.Percentage * cashFlows.ClaimsValue
+}
@@ -0,0 +1,146 @@
+package actuarial
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrNoIRR means no rate makes the cash flows' present value zero: they
+	// never change sign, so they are all income or all outgo
+	ErrNoIRR = errors.New("cash flows have no internal rate of return")
+	// ErrMultipleIRR means the cash flows change sign more than once, so
+	// several rates may make their present value zero and none is "the" IRR
+	ErrMultipleIRR = errors.New("cash flows change sign more than once, so the internal rate of return is not unique")
+)
+
+const (
+	irrInitialGuess  = 0.1
+	irrBracketWidens = 100 // Times the bisection bracket may widen looking for a change of sign
+)
+
+// FindIRR is the internal rate of return of cash flows at the end of years
+// 0, 1, 2, ...: the rate r above -100% at which their present value
+// sum(cashflows[t] / (1+r)^t) is zero.
+//
+// With exactly one change of sign - outgo followed by income, like a new
+// policy's strain and later profits - there is exactly one such rate. It is
+// found by Newton-Raphson, falling back to bisection when Newton's steps leave
+// the valid range or fail to converge. Zero flows are ignored when counting
+// sign changes.
+func FindIRR(cashflows []float64) (float64, error) {
+	return FindIRRWithConfig(cashflows, DefaultSolverConfig())
+}
+
+// FindIRRWithConfig is FindIRR solving to the given tolerance. If neither
+// method settles within the iterations allowed, the error is a
+// *ConvergenceError.
+func FindIRRWithConfig(cashflows []float64, solver SolverConfig) (float64, error) {
+	switch signChanges(cashflows) {
+	case 0:
+		return 0, ErrNoIRR
+	case 1:
+	default:
+		return 0, ErrMultipleIRR
+	}
+
+	solver = solver.orDefault()
+	if rate, ok := newtonIRR(cashflows, solver); ok {
+		return rate, nil
+	}
+	return bisectIRR(cashflows, solver)
+}
+
+// signChanges counts the changes of sign from one non-zero cash flow to the next
+func signChanges(cashflows []float64) int {
+	changes := 0
+	previous := 0.0
+	for _, cashflow := range cashflows {
+		if cashflow == 0 || math.IsNaN(cashflow) {
+			continue
+		}
+		if previous != 0 && (cashflow > 0) != (previous > 0) {
+			changes++
+		}
+		previous = cashflow
+	}
+	return changes
+}
+
+// netPresentValue is the cash flows' value at rate, and its derivative by rate
+func netPresentValue(cashflows []float64, rate float64) (value, derivative float64) {
+	for year, cashflow := range cashflows {
+		discountFactor := math.Pow(1+rate, -float64(year))
+		value += cashflow * discountFactor
+		derivative -= float64(year) * cashflow * discountFactor / (1 + rate)
+	}
+	return value, derivative
+}
+
+func newtonIRR(cashflows []float64, solver SolverConfig) (float64, bool) {
+	rate := irrInitialGuess
+	for range solver.MaxIterations {
+		value, derivative := netPresentValue(cashflows, rate)
+		if derivative == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, false
+		}
+		next := rate - value/derivative
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			return 0, false
+		}
+		if math.Abs(next-rate) < solver.Tolerance {
+			return next, true
+		}
+		rate = next
+	}
+	return 0, false
+}
+
+// bisectIRR widens a bracket towards -100% and upwards until the present value
+// changes sign across it, then halves it down to the root
+func bisectIRR(cashflows []float64, solver SolverConfig) (float64, error) {
+	low, high := -0.5, 1.0
+	lowValue, _ := netPresentValue(cashflows, low)
+	highValue, _ := netPresentValue(cashflows, high)
+	for range irrBracketWidens {
+		if (lowValue > 0) != (highValue > 0) {
+			break
+		}
+		low = -1 + (low+1)/2
+		high = 2*high + 1
+		lowValue, _ = netPresentValue(cashflows, low)
+		highValue, _ = netPresentValue(cashflows, high)
+	}
+	if (lowValue > 0) == (highValue > 0) {
+		return 0, ErrNoIRR
+	}
+
+	presentValue := func(rate float64) float64 {
+		value, _ := netPresentValue(cashflows, rate)
+		return value
+	}
+	return bisect("internal rate of return", presentValue, low, high, lowValue, solver)
+}
+
+// bisect halves a bracket [low, high] across which f changes sign until it is
+// narrower than the solver's tolerance. lowValue is f(low). Running out of
+// iterations first is a *ConvergenceError naming the solve.
+func bisect(solve string, f func(float64) float64, low, high, lowValue float64, solver SolverConfig) (float64, error) {
+	middleValue := lowValue
+	for range solver.MaxIterations {
+		middle := (low + high) / 2
+		if high-low < solver.Tolerance {
+			return middle, nil
+		}
+		middleValue = f(middle)
+		if (middleValue > 0) == (lowValue > 0) {
+			low, lowValue = middle, middleValue
+		} else {
+			high = middle
+		}
+	}
+	if high-low < solver.Tolerance {
+		return (low + high) / 2, nil
+	}
+	return (low + high) / 2, &ConvergenceError{Solve: solve, Iterations: solver.MaxIterations, Residual: math.Abs(middleValue)}
+}
@@ -0,0 +1,101 @@
+package actuarial
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFindIRR(t *testing.T) {
+	// 100(1+r)^2 = 50(1+r) + 60 has the root 1+r = (50 + sqrt(26500)) / 200
+	twoYear := (50+math.Sqrt(26500))/200 - 1
+
+	tests := []struct {
+		name      string
+		cashflows []float64
+		want      float64
+	}{
+		{"one year", []float64{-100, 110}, 0.10},
+		{"two years", []float64{-100, 50, 60}, twoYear},
+		{"loss", []float64{-100, 90}, -0.10},
+		{"zeros ignored", []float64{0, -100, 0, 121}, 0.10},
+		{"income first", []float64{100, -110}, 0.10},
+		{"very high", []float64{-1, 1000}, 999},
+		{"close to -100%", []float64{-1000, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1e-7}, -0.9},
+	}
+	for _, tt := range tests {
+		got, err := FindIRR(tt.cashflows)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !floatEquals(got, tt.want, 1e-8*max(1, math.Abs(tt.want))) {
+			t.Errorf("%s: expected IRR %f, got %f", tt.name, tt.want, got)
+		}
+	}
+
+	// A level annuity: whatever rate comes back must value the flows at zero
+	annuity := []float64{-1000}
+	for range 10 {
+		annuity = append(annuity, 150)
+	}
+	rate, err := FindIRR(annuity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := netPresentValue(annuity, rate); !floatEquals(value, 0, 1e-6) {
+		t.Errorf("Expected zero present value at the IRR %f, got %f", rate, value)
+	}
+}
+
+func TestFindIRRFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		cashflows []float64
+		want      error
+	}{
+		{"empty", nil, ErrNoIRR},
+		{"all zero", []float64{0, 0, 0}, ErrNoIRR},
+		{"all income", []float64{100, 50}, ErrNoIRR},
+		{"all outgo", []float64{-100, -50}, ErrNoIRR},
+		// Both 10% and 20% give a present value of zero
+		{"two roots", []float64{-100, 230, -132}, ErrMultipleIRR},
+	}
+	for _, tt := range tests {
+		if _, err := FindIRR(tt.cashflows); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestBisectIRR(t *testing.T) {
+	// The fallback on its own finds the same rates Newton does
+	for _, cashflows := range [][]float64{{-100, 110}, {-100, 90}, {-1, 1000}} {
+		newton, _ := newtonIRR(cashflows, DefaultSolverConfig())
+		bisected, err := bisectIRR(cashflows, DefaultSolverConfig())
+		if err != nil || !floatEquals(bisected, newton, 1e-8*max(1, math.Abs(newton))) {
+			t.Errorf("%v: expected %f, got %f (%v)", cashflows, newton, bisected, err)
+		}
+	}
+}
+
+func TestSolverConvergence(t *testing.T) {
+	// Two steps are too few for either method, and the error says how far off it was
+	_, err := FindIRRWithConfig([]float64{-100, 150}, SolverConfig{Tolerance: 1e-10, MaxIterations: 2})
+	var convergence *ConvergenceError
+	if !errors.As(err, &convergence) || convergence.Iterations != 2 || convergence.Residual <= 0 {
+		t.Fatalf("Expected a convergence error after 2 iterations, got %v", err)
+	}
+
+	// A looser tolerance is met sooner and still lands near the answer
+	rate, err := FindIRRWithConfig([]float64{-100, 110}, SolverConfig{Tolerance: 1e-3, MaxIterations: 200})
+	if err != nil || !floatEquals(rate, 0.10, 1e-3) {
+		t.Errorf("Expected about 10%%, got %f (%v)", rate, err)
+	}
+
+	for _, config := range []SolverConfig{{Tolerance: 0, MaxIterations: 10}, {Tolerance: 1e-6, MaxIterations: 0}} {
+		if config.Validate() == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package actuarial
+
+import (
+	"fmt"
+	"math"
+)
+
+// ValidateJointDependence checks a dependence is a Kendall's tau from 0,
+// independent lives, up to but not including 1, at which the two would always
+// die together
+func ValidateJointDependence(dependence float64) error {
+	if !(dependence >= 0 && dependence < 1) {
+		return fmt.Errorf("dependence must be at least 0 and below 1")
+	}
+	return nil
+}
+
+// JointSurvival is the chance two lives are both alive when each survives
+// alone with chance survivalX and survivalY. The lives are joined by a
+// Clayton survival copula,
+//
+//	C(u, v) = (u^-θ + v^-θ - 1)^(-1/θ)  with  θ = 2τ / (1 - τ)
+//
+// where τ, the dependence, is Kendall's tau between their lifetimes. At 0 the
+// lives are independent and C is u x v; above it a death makes the other
+// more likely soon after, as with the broken-heart effect, so both are alive
+// more often than independence says. Each life's own mortality is unchanged.
+func JointSurvival(survivalX, survivalY, dependence float64) float64 {
+	if dependence == 0 {
+		return survivalX * survivalY
+	}
+	if survivalX <= 0 || survivalY <= 0 {
+		return 0
+	}
+	theta := 2 * dependence / (1 - dependence)
+	return math.Pow(math.Pow(survivalX, -theta)+math.Pow(survivalY, -theta)-1, -1/theta)
+}
+
+// SingleLifeAnnuityDue is the value today of 1 paid at the start of every year
+// the person is alive, from the given age to the end of the table (ä_x)
+func SingleLifeAnnuityDue(age int, mortalityTable MortalityTable, interestRate float64) float64 {
+	annuityValue := 0.0
+	chanceStillAlive := 1.0
+	for personAge := age; personAge < len(mortalityTable); personAge++ {
+		annuityValue += chanceStillAlive * CalculatePresentValue(1.0, interestRate, personAge-age)
+		chanceStillAlive *= 1.0 - mortalityTable[personAge]
+	}
+	return annuityValue
+}
+
+// JointLifeAnnuityDue is the value today of 1 paid at the start of every year
+// while BOTH people are alive (ä_xy). Payments stop at the first death.
+// The chance both are alive comes from JointSurvival, so a dependence of 0
+// treats the lives as independent.
+func JointLifeAnnuityDue(ageX, ageY int, tableX, tableY MortalityTable, interestRate, dependence float64) float64 {
+	annuityValue := 0.0
+	chanceXAlive, chanceYAlive := 1.0, 1.0
+	for year := 0; ageX+year < len(tableX) && ageY+year < len(tableY); year++ {
+		annuityValue += JointSurvival(chanceXAlive, chanceYAlive, dependence) * CalculatePresentValue(1.0, interestRate, year)
+		chanceXAlive *= 1.0 - tableX[ageX+year]
+		chanceYAlive *= 1.0 - tableY[ageY+year]
+	}
+	return annuityValue
+}
+
+// ReversionaryAnnuityDue is the value today of 1 paid at the start of every
+// year while both people are alive and reversion (a share, 0.5 for half) of it
+// to whichever survives the other, until they die too. Exactly one of the two
+// is alive with probability tpx + tpy - 2 tpx tpy, so the survivor's payments
+// are worth ä_x + ä_y - 2 ä_xy. A reversion of 0 is the joint-life annuity and
+// 1 the last-survivor annuity. Dependence, as for JointLifeAnnuityDue, leaves
+// each life's own annuity alone, so the survivor's payments are worth less as
+// the joint annuity is worth more.
+func ReversionaryAnnuityDue(ageX, ageY int, tableX, tableY MortalityTable, interestRate, reversion, dependence float64) float64 {
+	jointValue := JointLifeAnnuityDue(ageX, ageY, tableX, tableY, interestRate, dependence)
+	survivorValue := SingleLifeAnnuityDue(ageX, tableX, interestRate) + SingleLifeAnnuityDue(ageY, tableY, interestRate) - 2*jointValue
+	return jointValue + reversion*survivorValue
+}
+
+// EquivalentJointAge is the "equal age" shortcut for couples: it finds the single
+// age whose single-life annuity (from singleTable) is closest to the couple's
+// joint-life annuity. Pricing a single life at that age approximates the joint
+// policy without needing both tables for every calculation.
+func EquivalentJointAge(ageX, ageY int, tableX, tableY, singleTable MortalityTable, interestRate, dependence float64) int {
+	jointValue := JointLifeAnnuityDue(ageX, ageY, tableX, tableY, interestRate, dependence)
+
+	// A joint annuity is worth less than either single annuity, so the
+	// equivalent age is at least as old as the older life
+	bestAge := max(ageX, ageY)
+	bestGap := math.Inf(1)
+	for age := bestAge; age < len(singleTable); age++ {
+		gap := math.Abs(SingleLifeAnnuityDue(age, singleTable, interestRate) - jointValue)
+		if gap < bestGap {
+			bestAge, bestGap = age, gap
+		}
+	}
+	return bestAge
+}
@@ -0,0 +1,120 @@
+package actuarial
+
+import (
+	"math"
+	"testing"
+)
+
+func gompertzTable(base float64) MortalityTable {
+	table := make(MortalityTable, 111)
+	for age := range table {
+		table[age] = min(base*float64(age*age)/1000, 1.0)
+	}
+	table[110] = 1.0
+	return table
+}
+
+func TestJointLifeAnnuity(t *testing.T) {
+	maleTable := gompertzTable(0.012)
+	femaleTable := gompertzTable(0.010)
+
+	jointValue := JointLifeAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 0)
+	maleValue := SingleLifeAnnuityDue(65, maleTable, 0.04)
+	femaleValue := SingleLifeAnnuityDue(60, femaleTable, 0.04)
+
+	// Payments stop at the first death, so the couple's annuity is worth less than either life's own
+	if jointValue >= maleValue || jointValue >= femaleValue {
+		t.Errorf("Expected joint annuity %f below single annuities %f and %f", jointValue, maleValue, femaleValue)
+	}
+
+	// With no deaths at all the annuity is just 1 + v + v^2 ... to the end of the table
+	immortal := make(MortalityTable, 3)
+	if got := JointLifeAnnuityDue(0, 0, immortal, immortal, 0, 0); got != 3 {
+		t.Errorf("Expected 3 undiscounted payments, got %f", got)
+	}
+}
+
+func TestReversionaryAnnuity(t *testing.T) {
+	maleTable := gompertzTable(0.012)
+	femaleTable := gompertzTable(0.010)
+
+	jointValue := JointLifeAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 0)
+	maleValue := SingleLifeAnnuityDue(65, maleTable, 0.04)
+	femaleValue := SingleLifeAnnuityDue(60, femaleTable, 0.04)
+
+	if got := ReversionaryAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 0, 0); !floatEquals(got, jointValue, 1e-12) {
+		t.Errorf("Expected no reversion to be the joint-life annuity %f, got %f", jointValue, got)
+	}
+	// Paid in full to the last survivor: ä_xy-bar = ä_x + ä_y - ä_xy
+	if got, want := ReversionaryAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 1, 0), maleValue+femaleValue-jointValue; !floatEquals(got, want, 1e-9) {
+		t.Errorf("Expected full reversion to be the last-survivor annuity %f, got %f", want, got)
+	}
+	half := ReversionaryAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 0.5, 0)
+	if half <= jointValue || half >= maleValue+femaleValue-jointValue {
+		t.Errorf("Expected a half reversion between joint and last-survivor, got %f", half)
+	}
+
+	// Immortal lives are never widowed, so the reversion is never paid
+	immortal := make(MortalityTable, 3)
+	if got := ReversionaryAnnuityDue(0, 0, immortal, immortal, 0, 0.5, 0); got != 3 {
+		t.Errorf("Expected 3 undiscounted payments, got %f", got)
+	}
+}
+
+func TestEquivalentJointAge(t *testing.T) {
+	maleTable := gompertzTable(0.012)
+	femaleTable := gompertzTable(0.010)
+
+	equivalentAge := EquivalentJointAge(65, 60, maleTable, femaleTable, maleTable, 0.04, 0)
+	if equivalentAge <= 65 {
+		t.Fatalf("Expected an equivalent age above the older life's 65, got %d", equivalentAge)
+	}
+
+	// The single-life annuity at the equivalent age should be close to the joint value
+	jointValue := JointLifeAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 0)
+	approxValue := SingleLifeAnnuityDue(equivalentAge, maleTable, 0.04)
+	if !floatEquals(jointValue, approxValue, 0.05*jointValue) {
+		t.Errorf("Expected equal-age annuity %f within 5%% of joint annuity %f", approxValue, jointValue)
+	}
+}
+
+func TestJointDependence(t *testing.T) {
+	if got := JointSurvival(0.8, 0.6, 0); !floatEquals(got, 0.48, 1e-12) {
+		t.Errorf("Expected independent lives to survive together with 0.48, got %f", got)
+	}
+	// Positive dependence keeps both alive more often, never more than the weaker life
+	dependent := JointSurvival(0.8, 0.6, 0.3)
+	if dependent <= 0.48 || dependent > 0.6 {
+		t.Errorf("Expected a joint survival between 0.48 and 0.6, got %f", dependent)
+	}
+	if got := JointSurvival(1, 0.6, 0.3); !floatEquals(got, 0.6, 1e-12) {
+		t.Errorf("Expected a certain survivor to leave the other's chance, got %f", got)
+	}
+	if got := JointSurvival(0, 0.6, 0.3); got != 0 {
+		t.Errorf("Expected no joint survival once one life is dead, got %f", got)
+	}
+
+	maleTable := gompertzTable(0.012)
+	femaleTable := gompertzTable(0.010)
+	maleValue := SingleLifeAnnuityDue(65, maleTable, 0.04)
+	femaleValue := SingleLifeAnnuityDue(60, femaleTable, 0.04)
+	independent := JointLifeAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 0)
+	correlated := JointLifeAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 0.3)
+	if correlated <= independent || correlated >= min(maleValue, femaleValue) {
+		t.Errorf("Expected dependence to raise the joint annuity from %f, short of the single lives, got %f", independent, correlated)
+	}
+	// The last survivor's annuity is worth less, since the second death comes sooner
+	lastSurvivor := ReversionaryAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 1, 0.3)
+	if want := maleValue + femaleValue - correlated; !floatEquals(lastSurvivor, want, 1e-9) {
+		t.Errorf("Expected the last-survivor annuity %f, got %f", want, lastSurvivor)
+	}
+	if lastSurvivor >= ReversionaryAnnuityDue(65, 60, maleTable, femaleTable, 0.04, 1, 0) {
+		t.Errorf("Expected dependence to lower the last-survivor annuity, got %f", lastSurvivor)
+	}
+
+	for _, dependence := range []float64{-0.1, 1, math.NaN()} {
+		if err := ValidateJointDependence(dependence); err == nil {
+			t.Errorf("Expected a dependence of %g to be rejected", dependence)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package actuarial
+
+// DefaultMaxDynamicLapse caps a dynamic lapse rate when the formula sets no
+// ceiling of its own, so a wide spread can't empty the book in a year
+const DefaultMaxDynamicLapse = 0.5
+
+// DynamicLapse makes lapses respond to interest rates. When market rates rise
+// above the rate an interest-sensitive policy credits, policyholders surrender
+// to reinvest elsewhere. Each policy year's lapse rate is
+//
+//	min(MaxLapse, LapseRate + Sensitivity x max(0, reference - CreditedRate - Threshold))
+//
+// The reference rate in each year is the one-year forward rate from the
+// policy's yield curve, so lapses move with the rates when a policy is
+// repriced on interest rate scenarios. Without a curve it is the flat
+// ReferenceRate.
+type DynamicLapse struct {
+	ReferenceRate float64 `json:"reference_rate,omitempty"` // Market rate when the policy has no yield curve
+	CreditedRate  float64 `json:"credited_rate"`            // Rate the policy credits, compared with the market rate
+	Sensitivity   float64 `json:"sensitivity"`              // Extra lapse per unit of spread: 2 adds 2% a year for each 1% of spread
+	Threshold     float64 `json:"threshold,omitempty"`      // Spread policyholders ignore before lapsing more
+	MaxLapse      float64 `json:"max_lapse,omitempty"`      // Ceiling on the lapse rate; 0 for DefaultMaxDynamicLapse
+}
+
+// ReferenceRateForYear is the market rate dynamic lapses compare against in
+// the given policy year
+func (d DynamicLapse) ReferenceRateForYear(policy *Policy, policyYear int) float64 {
+	if len(policy.YieldCurve) > 0 {
+		return policy.YieldCurve.ForwardRate(policyYear)
+	}
+	return d.ReferenceRate
+}
+
+// Rate is the lapse rate in the given policy year on top of the base rate
+func (d DynamicLapse) Rate(policy *Policy, baseRate float64, policyYear int) float64 {
+	spread := d.ReferenceRateForYear(policy, policyYear) - d.CreditedRate - d.Threshold
+	ceiling := d.MaxLapse
+	if ceiling <= 0 {
+		ceiling = DefaultMaxDynamicLapse
+	}
+	return min(ceiling, baseRate+d.Sensitivity*max(0, spread))
+}
+
+// LapsePath is the lapse rate assumed in each of a policy's first years
+// policy years, dynamic lapses and any mass lapse included
+func LapsePath(policy *Policy, years int) []float64 {
+	if years <= 0 {
+		return nil
+	}
+	path := make([]float64, years)
+	for year := range path {
+		path[year] = lapseRateForYear(policy, year)
+	}
+	return path
+}
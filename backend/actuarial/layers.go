@@ -0,0 +1,68 @@
+package actuarial
+
+// CoverageLayer is one tier of a layered term policy's death benefit: an
+// amount paid on death in the first Term years from issue. A family policy
+// might pay 500k to age 65 and another 200k to 75 as two layers.
+type CoverageLayer struct {
+	CoverageAmount float64 `json:"sum_assured"`
+	Term           int     `json:"term"`
+}
+
+// LayerPremium is one layer's share of a layered policy's net premium
+type LayerPremium struct {
+	CoverageAmount float64 `json:"sum_assured"`
+	Term           int     `json:"term"`
+	NetPremium     float64 `json:"net_premium"`
+}
+
+// SumAssuredInYear is the death benefit for a death in the given policy year,
+// counted from issue with 0 the first: the sum assured, or on a layered
+// policy the total of the layers still running that year
+func SumAssuredInYear(policy *Policy, policyYear int) float64 {
+	if len(policy.Layers) == 0 {
+		return policy.CoverageAmount
+	}
+	total := 0.0
+	for _, layer := range policy.Layers {
+		if policyYear < layer.Term {
+			total += layer.CoverageAmount
+		}
+	}
+	return total
+}
+
+// LayerNetPremiums splits a layered term policy's net premium between its
+// layers. Every layer is paid for by the same premiums, which run for the
+// policy's premium paying years, so each layer's premium is the value of its
+// death benefits over the one premium annuity and the premiums add up to the
+// policy's net premium.
+func LayerNetPremiums(policy *Policy, mortalityTable MortalityTable) []LayerPremium {
+	if len(policy.Layers) == 0 {
+		return nil
+	}
+	premiums := make([]LayerPremium, len(policy.Layers))
+	for i, layer := range policy.Layers {
+		single := *policy
+		single.Layers = []CoverageLayer{layer}
+		premiums[i] = LayerPremium{
+			CoverageAmount: layer.CoverageAmount,
+			Term:           layer.Term,
+			NetPremium:     CalculateTermLifeNetPremium(&single, mortalityTable),
+		}
+	}
+	return premiums
+}
+
+// policyNetAmountAtRisk is NetAmountAtRisk for a reserve schedule starting at
+// the policy's in-force duration, with each year's own sum assured on a
+// layered policy
+func policyNetAmountAtRisk(policy *Policy, reserveSchedule []float64) []float64 {
+	if len(policy.Layers) == 0 {
+		return NetAmountAtRisk(policy.CoverageAmount, reserveSchedule)
+	}
+	amountsAtRisk := make([]float64, len(reserveSchedule))
+	for year, reserve := range reserveSchedule {
+		amountsAtRisk[year] = max(0, SumAssuredInYear(policy, policy.InForceDuration+year)-reserve)
+	}
+	return amountsAtRisk
+}
@@ -0,0 +1,109 @@
+package actuarial
+
+// Modified reserve methods. Instead of a level net premium, the reserve is
+// held on a lower first-year premium (alpha) and a higher renewal premium
+// (beta) of the same value. The difference, beta - alpha, is the expense
+// allowance: the first-year expenses the reserve lets the insurer recover
+// over the renewal years rather than hold capital against at issue.
+const (
+	// FullPreliminaryTermReserve takes the whole first-year benefit cost as
+	// alpha, so nothing is reserved at the end of year one
+	FullPreliminaryTermReserve = "full_preliminary_term"
+	// CRVMReserve is full preliminary term with the allowance capped, by
+	// default at the allowance of a 20-pay whole life policy at the same age
+	CRVMReserve = "crvm"
+)
+
+// IsModifiedReserve reports whether a reserve method modifies the net premium
+func IsModifiedReserve(method string) bool {
+	return method == FullPreliminaryTermReserve || method == CRVMReserve
+}
+
+// ModifiedReserve describes the premiums a modified reserve was held on
+type ModifiedReserve struct {
+	Method           string  `json:"method"`
+	FirstYearPremium float64 `json:"first_year_premium"` // Alpha
+	RenewalPremium   float64 `json:"renewal_premium"`    // Beta
+	ExpenseAllowance float64 `json:"expense_allowance"`  // Beta less alpha, as used
+
+	// The allowance before any cap: the full preliminary term allowance
+	UncappedAllowance float64 `json:"uncapped_allowance"`
+	// The cap applied, when there was one
+	AllowanceCap *float64 `json:"allowance_cap,omitempty"`
+	// Beta would have been more than the gross premium, so the allowance
+	// was cut to keep it there
+	LimitedByGrossPremium bool `json:"limited_by_gross_premium,omitempty"`
+}
+
+// ModifiedReserveSchedule is the reserve schedule on a modified premium. The
+// premiums have the same value as the level net premium, so the reserve still
+// starts at 0; after year one it is the prospective reserve on beta. The
+// allowance is the full preliminary term allowance, cut to allowanceCap if
+// that is lower (nil for no cap; CRVM without a cap uses the 20-pay whole
+// life allowance), and cut again if beta would exceed the gross premium.
+//
+// The schedule starts at issue, as CalculateReserveSchedule's does before
+// in-force years are dropped. A policy with a single premium can't be
+// modified and gets the level premium schedule.
+func ModifiedReserveSchedule(policy *Policy, mortalityTable MortalityTable, netPremium, grossPremium float64, method string, allowanceCap *float64) ([]float64, ModifiedReserve) {
+	modified := ModifiedReserve{Method: method, FirstYearPremium: netPremium, RenewalPremium: netPremium}
+	premiumAnnuity := premiumAnnuityValue(policy, mortalityTable)
+	if premiumAnnuity <= 1 {
+		return levelReserveSchedule(policy, mortalityTable, netPremium), modified
+	}
+
+	modified.UncappedAllowance = preliminaryTermAllowance(policy, mortalityTable, premiumAnnuity)
+	if allowanceCap == nil && method == CRVMReserve {
+		twentyPay := *policy
+		twentyPay.ProductType = "whole_life"
+		twentyPay.Term = 0
+		twentyPay.PremiumCeaseAge = policy.Age + 20
+		twentyPay.PremiumPayingTerm = 0
+		cap := max(preliminaryTermAllowance(&twentyPay, mortalityTable, premiumAnnuityValue(&twentyPay, mortalityTable)), 0)
+		allowanceCap = &cap
+	}
+	allowance := max(modified.UncappedAllowance, 0)
+	if allowanceCap != nil {
+		modified.AllowanceCap = allowanceCap
+		allowance = min(allowance, *allowanceCap)
+	}
+
+	// beta = P + allowance / a has the same value as the level premium
+	renewalPremium := netPremium + allowance/premiumAnnuity
+	if renewalPremium > grossPremium {
+		modified.LimitedByGrossPremium = true
+		allowance = max((grossPremium-netPremium)*premiumAnnuity, 0)
+		renewalPremium = netPremium + allowance/premiumAnnuity
+	}
+	modified.ExpenseAllowance = allowance
+	modified.RenewalPremium = renewalPremium
+	modified.FirstYearPremium = renewalPremium - allowance
+
+	// Every premium after the first is beta, so from year one on this is the
+	// level schedule at beta; at issue it is 0, as on the level premium
+	reserveSchedule := levelReserveSchedule(policy, mortalityTable, renewalPremium)
+	if len(reserveSchedule) > 0 {
+		reserveSchedule[0] = levelReserveSchedule(policy, mortalityTable, netPremium)[0]
+	}
+	return reserveSchedule, modified
+}
+
+// preliminaryTermAllowance is the full preliminary term expense allowance:
+// alpha is the cost of the first year's cover, c, and beta spreads the rest
+// of the benefits over the renewal premiums, (A - c) / (a - 1)
+func preliminaryTermAllowance(policy *Policy, mortalityTable MortalityTable, premiumAnnuity float64) float64 {
+	if premiumAnnuity <= 1 || policy.Age >= len(mortalityTable) {
+		return 0
+	}
+	firstYearCost := deathBenefitValue(policy, mortalityTable[policy.Age]*SumAssuredInYear(policy, 0), 1)
+	renewalPremium := (NetSinglePremium(policy, mortalityTable) - firstYearCost) / (premiumAnnuity - 1)
+	return renewalPremium - firstYearCost
+}
+
+// levelReserveSchedule is the prospective schedule from issue on a level premium
+func levelReserveSchedule(policy *Policy, mortalityTable MortalityTable, netPremium float64) []float64 {
+	if policy.ProductType == "whole_life" {
+		return CalculateWholeLifeReserveSchedule(policy, mortalityTable, netPremium)
+	}
+	return CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+}
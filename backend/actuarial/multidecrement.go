@@ -0,0 +1,243 @@
+package actuarial
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MultiDecrementTable holds three ways a life can leave the policy, by age:
+// death, disability and withdrawal. Each list holds the associated
+// single-decrement rate - the chance of that exit if it were the only one.
+type MultiDecrementTable struct {
+	Death      MortalityTable `json:"qx"`
+	Disability MortalityTable `json:"qi"`
+	Withdrawal MortalityTable `json:"qw"`
+}
+
+// Len returns the number of ages covered by all three decrements
+func (t MultiDecrementTable) Len() int {
+	return min(len(t.Death), len(t.Disability), len(t.Withdrawal))
+}
+
+// DependentRates turns the single-decrement rates at an age into the chances of
+// actually leaving by each route when all three compete. A life that becomes
+// disabled can't then die as an active member, so each exit is a little less
+// likely than its single-decrement rate suggests.
+//
+// Assumes each decrement is spread evenly through the year (UDD):
+//
+//	death = q'd * (1 - (q'i + q'w)/2 + q'i*q'w/3), and similarly for the others
+func (t MultiDecrementTable) DependentRates(age int) (death, disability, withdrawal float64) {
+	qd, qi, qw := t.Death[age], t.Disability[age], t.Withdrawal[age]
+	death = qd * (1 - (qi+qw)/2 + qi*qw/3)
+	disability = qi * (1 - (qd+qw)/2 + qd*qw/3)
+	withdrawal = qw * (1 - (qd+qi)/2 + qd*qi/3)
+	return death, disability, withdrawal
+}
+
+// StayProbability is the chance an active life at this age is still on the
+// books a year later, having escaped all three decrements
+func (t MultiDecrementTable) StayProbability(age int) float64 {
+	return (1 - t.Death[age]) * (1 - t.Disability[age]) * (1 - t.Withdrawal[age])
+}
+
+// LoadMultiDecrementTable reads a tab-delimited table with qx, qi and qw columns.
+// Columns are found by their header names, so extra columns are fine.
+func LoadMultiDecrementTable(filePath string) (MultiDecrementTable, error) {
+	tableData, closeFile, err := openTableFile(filePath)
+	if err != nil {
+		return MultiDecrementTable{}, err
+	}
+	defer closeFile()
+
+	csvReader := csv.NewReader(tableData)
+	csvReader.FieldsPerRecord = -1
+	csvReader.Comma = '\t'
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return MultiDecrementTable{}, fmt.Errorf("could not read CSV header: %w", err)
+	}
+
+	// Find which column holds each decrement
+	columns := map[string]int{"qx": -1, "qi": -1, "qw": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, wanted := columns[name]; wanted {
+			columns[name] = i
+		}
+	}
+	for name, index := range columns {
+		if index < 0 {
+			return MultiDecrementTable{}, fmt.Errorf("multi-decrement table is missing the %s column", name)
+		}
+	}
+
+	table := MultiDecrementTable{}
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return MultiDecrementTable{}, fmt.Errorf("error reading CSV row: %w", err)
+		}
+
+		rates := make(map[string]float64, len(columns))
+		for name, index := range columns {
+			if index >= len(row) {
+				return MultiDecrementTable{}, fmt.Errorf("row %v has no %s value", row, name)
+			}
+			rate, err := strconv.ParseFloat(strings.TrimSpace(row[index]), 64)
+			if err != nil {
+				return MultiDecrementTable{}, fmt.Errorf("bad %s value %q: %w", name, row[index], err)
+			}
+			rates[name] = rate
+		}
+		table.Death = append(table.Death, rates["qx"])
+		table.Disability = append(table.Disability, rates["qi"])
+		table.Withdrawal = append(table.Withdrawal, rates["qw"])
+	}
+
+	return table, nil
+}
+
+// CalculateMultiDecrementNetPremium prices a term policy where the life can leave
+// by death, disability or withdrawal. Death pays the sum assured, disability pays
+// DisabilityBenefit, and withdrawal pays nothing but stops future premiums.
+func CalculateMultiDecrementNetPremium(policy *Policy, table MultiDecrementTable) float64 {
+	return netPremiumFrom(multiDecrementExpectedValues(policy, table))
+}
+
+// multiDecrementExpectedValues is the expected present value of the death and
+// disability benefits, and of 1 a year of premium while the life is active
+func multiDecrementExpectedValues(policy *Policy, table MultiDecrementTable) (expectedPayouts, expectedPremiumsCollected float64) {
+	chanceStillActive := 1.0
+
+	for yearOfPolicy := 0; yearOfPolicy < policy.Term; yearOfPolicy++ {
+		personAge := policy.Age + yearOfPolicy
+		if personAge >= table.Len() {
+			break
+		}
+
+		chanceOfDeath, chanceOfDisability, _ := table.DependentRates(personAge)
+		benefitToday := discount(policy,
+			chanceOfDeath*policy.CoverageAmount+chanceOfDisability*policy.DisabilityBenefit,
+			yearOfPolicy+1)
+		expectedPayouts += chanceStillActive * benefitToday
+		if yearOfPolicy < PremiumPayingYears(policy) {
+			expectedPremiumsCollected += chanceStillActive * discount(policy, 1.0, yearOfPolicy)
+		}
+
+		chanceStillActive *= table.StayProbability(personAge)
+	}
+	return expectedPayouts, expectedPremiumsCollected
+}
+
+// CalculateMultiDecrementReserveSchedule is the prospective reserve for an active
+// life at each policy year under the multi-decrement model
+func CalculateMultiDecrementReserveSchedule(policy *Policy, table MultiDecrementTable, netPremium float64) []float64 {
+	reserveSchedule := make([]float64, policy.Term+1)
+
+	for currentYear := 0; currentYear < policy.Term; currentYear++ {
+		futureBenefitValue := 0.0
+		futurePremiumValue := 0.0
+		chanceStillActive := 1.0
+
+		for futureYear := 0; futureYear < policy.Term-currentYear; futureYear++ {
+			ageAtFutureYear := policy.Age + currentYear + futureYear
+			if ageAtFutureYear >= table.Len() {
+				break
+			}
+
+			chanceOfDeath, chanceOfDisability, _ := table.DependentRates(ageAtFutureYear)
+			futureBenefitValue += chanceStillActive * discount(policy,
+				chanceOfDeath*policy.CoverageAmount+chanceOfDisability*policy.DisabilityBenefit,
+				futureYear+1)
+			if currentYear+futureYear < PremiumPayingYears(policy) {
+				futurePremiumValue += chanceStillActive * discount(policy, netPremium, futureYear)
+			}
+
+			chanceStillActive *= table.StayProbability(ageAtFutureYear)
+		}
+
+		reserveSchedule[currentYear] = futureBenefitValue - futurePremiumValue
+	}
+
+	return reserveSchedule
+}
+
+// CalculateFullMultiDecrementPremium is the multi-decrement version of
+// CalculateFullPremium for term products. Underwriting factors load the death
+// decrement only; the withdrawal column replaces any flat lapse assumption.
+func CalculateFullMultiDecrementPremium(policy *Policy, table MultiDecrementTable) PremiumCalculation {
+	return CalculateFullMultiDecrementPremiumWithConfig(policy, table, DefaultUnderwritingConfig())
+}
+
+// CalculateFullMultiDecrementPremiumWithConfig uses the given underwriting multipliers
+func CalculateFullMultiDecrementPremiumWithConfig(policy *Policy, table MultiDecrementTable, underwriting UnderwritingConfig) PremiumCalculation {
+	if policy.ProductType == "" {
+		policy.ProductType = "term_life"
+	}
+
+	underwrittenTable := table
+	underwrittenTable.Death = ApplyUnderwritingFactorsWithConfig(policy, table.Death, underwriting)
+
+	// Withdrawals are already in the table, so don't double count a lapse rate
+	policyWithoutLapse := *policy
+	policyWithoutLapse.LapseRate = 0
+
+	netPremium := CalculateMultiDecrementNetPremium(&policyWithoutLapse, underwrittenTable)
+	expenseAssumptions := ExpensesForProduct(policy.ProductType)
+	grossPremium := CalculateGrossPremium(&policyWithoutLapse, underwrittenTable.Death, netPremium, expenseAssumptions)
+
+	paymentFrequency := policy.PaymentFrequency
+	if paymentFrequency == "" {
+		paymentFrequency = "annual"
+	}
+	modalFactor, modalPremium := ModalPremium(grossPremium, paymentFrequency)
+	modalImpliedRate, _ := ModalImpliedRate(paymentFrequency)
+
+	reserveSchedule := CalculateMultiDecrementReserveSchedule(&policyWithoutLapse, underwrittenTable, netPremium)
+	var netAmountAtRisk []float64
+	if policy.IncludeNetAmountAtRisk {
+		netAmountAtRisk = NetAmountAtRisk(policy.CoverageAmount, reserveSchedule)
+	}
+	breakdown := BreakDownPremium(&policyWithoutLapse, netPremium, grossPremium, expenseAssumptions)
+	var singlePremium float64
+	if policy.IncludeSinglePremium {
+		singlePremium, _ = multiDecrementExpectedValues(&policyWithoutLapse, underwrittenTable)
+	}
+
+	return PremiumCalculation{
+		NetPremium:      netPremium,
+		GrossPremium:    grossPremium,
+		ReserveSchedule: reserveSchedule,
+		ProductType:     policy.ProductType,
+		ExpenseDetails: map[string]float64{
+			"initial_expense_rate": expenseAssumptions.InitialExpenseRate,
+			"renewal_expense_rate": expenseAssumptions.RenewalExpenseRate,
+			"maintenance_expense":  expenseAssumptions.MaintenanceExpense,
+			"profit_margin":        expenseAssumptions.ProfitMargin,
+		},
+		RiskAssessment: map[string]float64{
+			"annual_death_probability":      underwrittenTable.Death[policy.Age],
+			"annual_disability_probability": underwrittenTable.Disability[policy.Age],
+			"annual_withdrawal_probability": underwrittenTable.Withdrawal[policy.Age],
+			"annual_exit_probability":       1 - underwrittenTable.StayProbability(policy.Age),
+		},
+		PaymentFrequency: paymentFrequency,
+		ModalFactor:      modalFactor,
+		ModalPremium:     modalPremium,
+		ModalImpliedRate: modalImpliedRate,
+		NetAmountAtRisk:  netAmountAtRisk,
+		SinglePremium:    singlePremium,
+		PremiumBreakdown: &breakdown,
+
+		NewBusinessStrain: NewBusinessStrain(policy, grossPremium, expenseAssumptions, reserveSchedule),
+		Warnings:          append(termBeyondTableWarnings(policy, table.Len()), reserveWarnings(reserveSchedule)...),
+	}
+}
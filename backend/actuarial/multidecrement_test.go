@@ -0,0 +1,56 @@
+package actuarial
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMultiDecrementMatchesSingleDecrementWhenOnlyDeath(t *testing.T) {
+	deathOnly := MultiDecrementTable{
+		Death:      make(MortalityTable, 100),
+		Disability: make(MortalityTable, 100),
+		Withdrawal: make(MortalityTable, 100),
+	}
+	for age := range deathOnly.Death {
+		deathOnly.Death[age] = 0.001 + float64(age)*0.0002
+	}
+	policy := &Policy{Age: 40, Term: 15, CoverageAmount: 100000, InterestRate: 0.05}
+
+	expected := CalculateTermLifeNetPremium(policy, deathOnly.Death)
+	actual := CalculateMultiDecrementNetPremium(policy, deathOnly)
+	if !floatEquals(expected, actual, 1e-9) {
+		t.Errorf("Expected %f with no other decrements, got %f", expected, actual)
+	}
+}
+
+func TestLoadMultiDecrementTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "group.csv")
+	contents := "age\tqx\tqi\tqw\n0\t0.01\t0.02\t0.10\n1\t0.02\t0.03\t0.10\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := LoadMultiDecrementTable(path)
+	if err != nil {
+		t.Fatalf("Could not load table: %v", err)
+	}
+	if table.Len() != 2 || table.Withdrawal[1] != 0.10 || table.Disability[0] != 0.02 {
+		t.Fatalf("Unexpected table contents: %+v", table)
+	}
+
+	// Competing decrements make each dependent rate smaller than its single-decrement rate
+	death, disability, withdrawal := table.DependentRates(0)
+	expectedDeath := 0.01 * (1 - (0.02+0.10)/2 + 0.02*0.10/3)
+	if !floatEquals(death, expectedDeath, 1e-12) {
+		t.Errorf("Expected dependent death rate %f, got %f", expectedDeath, death)
+	}
+	if disability >= 0.02 || withdrawal >= 0.10 {
+		t.Errorf("Expected dependent rates below single-decrement rates, got %f and %f", disability, withdrawal)
+	}
+
+	// Total exits plus stayers must account for everyone
+	if total := death + disability + withdrawal + table.StayProbability(0); !floatEquals(total, 1, 1e-6) {
+		t.Errorf("Expected decrements and survivors to sum to 1, got %f", total)
+	}
+}
@@ -0,0 +1,68 @@
+package actuarial
+
+// ProductDefinition describes a product type and which policy fields its
+// pricing reads. Field names are the JSON names clients send.
+type ProductDefinition struct {
+	Type        string
+	Description string
+	Required    []string // Must be set for a sensible price
+	Optional    []string // Read when set
+	Ignored     []string // Accepted but have no effect on this product
+}
+
+// Fields every life product reads the same way
+var lifeOptionalFields = []string{
+	"interest_rate", "table_name", "smoker_status", "health_rating", "impairment", "rating_factor",
+	"underwriting_class", "simplified_issue_loadings", "lapse_rate", "in_force_duration",
+	"payment_frequency", "compounding_convention", "yield_curve", "risk_discount_rate",
+	"include_net_amount_at_risk", "include_single_premium", "reserve_approach", "first_year_fee_waiver", "mass_lapse",
+	"include_profit_by_year", "settlement_delay", "dynamic_lapse",
+	"required_capital",
+}
+
+// Products lists every product type CalculateFullPremiumWithBasis prices, in
+// the order of its switch. A product type not listed here is rejected rather
+// than priced as something else.
+var Products = []ProductDefinition{
+	{
+		Type:        "term_life",
+		Description: "Pays the sum assured on death within the term, and the maturity benefit if any on survival to the end of it. The default product.",
+		Required:    []string{"age", "term", "sum_assured"},
+		Optional:    append([]string{"maturity_benefit", "premium_paying_term", "renewable", "anti_selection_loadings", "layers"}, lifeOptionalFields...),
+		Ignored:     []string{"deferral_period", "premium_cease_age", "mortality_extrapolation", "ultimate_age", "return_of_purchase_price"},
+	},
+	{
+		Type:        "whole_life",
+		Description: "Pays the sum assured on death whenever it happens. Term, when set, limits the years premiums are paid.",
+		Required:    []string{"age", "sum_assured"},
+		Optional:    append([]string{"term", "premium_cease_age", "mortality_extrapolation", "ultimate_age", "survival_threshold", "include_paid_up"}, lifeOptionalFields...),
+		Ignored:     []string{"deferral_period", "maturity_benefit", "return_of_purchase_price", "premium_paying_term"},
+	},
+	{
+		Type:        "immediate_annuity",
+		Description: "A single premium buys sum_assured a year for life, starting now.",
+		Required:    []string{"age", "sum_assured"},
+		Optional:    []string{"interest_rate", "table_name", "smoker_status", "health_rating", "impairment", "rating_factor", "compounding_convention", "yield_curve", "annuity_timing", "cash_refund"},
+		Ignored:     []string{"term", "deferral_period", "lapse_rate", "mass_lapse", "payment_frequency", "maturity_benefit", "premium_cease_age", "risk_discount_rate", "return_of_purchase_price", "premium_paying_term"},
+	},
+	{
+		Type:        "deferred_annuity",
+		Description: "A single premium buys sum_assured a year for life, starting after the deferral period.",
+		Required:    []string{"age", "sum_assured", "deferral_period"},
+		Optional:    []string{"interest_rate", "table_name", "smoker_status", "health_rating", "impairment", "rating_factor", "compounding_convention", "yield_curve", "return_of_purchase_price", "annuity_timing", "deferral_fraction"},
+		Ignored:     []string{"term", "lapse_rate", "mass_lapse", "payment_frequency", "maturity_benefit", "premium_cease_age", "risk_discount_rate", "premium_paying_term", "cash_refund"},
+	},
+}
+
+// LookupProduct finds a product's definition. An empty type is term_life.
+func LookupProduct(productType string) (ProductDefinition, bool) {
+	if productType == "" {
+		productType = "term_life"
+	}
+	for _, product := range Products {
+		if product.Type == productType {
+			return product, true
+		}
+	}
+	return ProductDefinition{}, false
+}
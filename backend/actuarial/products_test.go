@@ -0,0 +1,38 @@
+package actuarial
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProducts(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = min(0.001+float64(age)*0.001, 1)
+	}
+
+	for _, product := range Products {
+		// Each field is in at most one list
+		seen := map[string]bool{}
+		for _, field := range slices.Concat(product.Required, product.Optional, product.Ignored) {
+			if seen[field] {
+				t.Errorf("%s: field %q is listed twice", product.Type, field)
+			}
+			seen[field] = true
+		}
+
+		// Each product the list offers is priced by its own branch
+		policy := &Policy{Age: 50, Term: 10, CoverageAmount: 10000, InterestRate: 0.05, ProductType: product.Type, DeferralPeriod: 10}
+		result := CalculateFullPremium(policy, mortalityTable)
+		if result.ProductType != product.Type || result.GrossPremium <= 0 {
+			t.Errorf("%s: expected a positive premium, got %+v", product.Type, result)
+		}
+	}
+
+	if product, ok := LookupProduct(""); !ok || product.Type != "term_life" {
+		t.Errorf("Expected an empty product type to be term_life, got %q", product.Type)
+	}
+	if _, ok := LookupProduct("endowment"); ok {
+		t.Error("Expected endowment not to be a supported product")
+	}
+}
@@ -0,0 +1,251 @@
+package actuarial
+
+// ProfitTestResult is the outcome of projecting a policy's cash flows year by
+// year and discounting the profits at the risk discount rate.
+type ProfitTestResult struct {
+	RiskDiscountRate float64   `json:"risk_discount_rate"`
+	ProfitVector     []float64 `json:"profit_vector"`    // Profit in each year per policy in force at the start of it
+	ProfitSignature  []float64 `json:"profit_signature"` // Profit in each year per policy sold, after deaths and lapses
+	ProfitValue      float64   `json:"profit_value"`     // Profit signature discounted at the risk discount rate
+	PremiumValue     float64   `json:"premium_value"`    // Expected gross premiums discounted at the risk discount rate
+	ProfitMargin     float64   `json:"profit_margin"`    // ProfitValue / PremiumValue
+
+	BreakEvenMortality       float64 `json:"break_even_mortality,omitempty"`        // qx multiplier at which ProfitValue is zero, see BreakEvenMortality
+	BreakEvenMortalityStatus string  `json:"break_even_mortality_status,omitempty"` // BreakEvenMortalityFound, BreakEvenNeverProfitable or BreakEvenNotReached
+
+	ProfitRelease *ProfitRelease `json:"profit_release,omitempty"` // Set when the policy has a RequiredCapital basis
+}
+
+// ProfitTest projects the profit emerging each year on a policy.
+//
+// For a policy in force at the start of year t, the profit at the end of the year is:
+//
+//	(reserve_t + premium - expenses) x (1+i) - q x sum assured - p x reserve_t+1
+//
+// where i is the pricing interest rate earned on the funds, q the chance of
+// death and p the chance the policy is still in force a year later. Lapsing
+// policies simply release their reserve. Premiums in arrears, and the
+// commission on them, come in at the end of the year from the survivors
+// instead, adding (1-q) x (premium - commission) after the interest.
+// Multiplying by the chance the policy
+// is still in force at the start of the year gives the profit signature. A
+// claim paid after a settlement delay costs its value at the end of the year.
+//
+// The signature is discounted at the risk discount rate - the shareholders'
+// hurdle rate - which is kept separate from the interest rate used for pricing
+// and reserves. grossPremium is the renewal premium; a first-year fee waiver
+// comes off the first.
+//
+// A policy already in force is projected from its in-force duration, as its
+// reserve schedule from CalculateReserveSchedule is: the profits are per
+// policy in force today and discounted to today, and their value is the
+// policy's value of in-force business.
+func ProfitTest(policy *Policy, mortalityTable MortalityTable, grossPremium float64, expenses ExpenseStructure, reserveSchedule []float64, riskDiscountRate float64) ProfitTestResult {
+	years := len(reserveSchedule) - 1
+	result := ProfitTestResult{
+		RiskDiscountRate: riskDiscountRate,
+		ProfitVector:     make([]float64, 0, max(years, 0)),
+		ProfitSignature:  make([]float64, 0, max(years, 0)),
+	}
+
+	// One year's growth at the pricing rate and convention
+	interestGrowth := 1.0 / discount(policy, 1.0, 1)
+	chanceInForce := 1.0
+
+	premiumYears := PremiumPayingYears(policy)
+	for year := 0; year < years; year++ {
+		policyYear := policy.InForceDuration + year
+		personAge := policy.Age + policyYear
+		if personAge >= len(mortalityTable) {
+			break
+		}
+		chanceOfDeath := mortalityTable[personAge]
+		chanceStaying := (1.0 - chanceOfDeath) * (1.0 - lapseRateForYear(policy, policyYear))
+
+		premium := 0.0
+		yearExpenses := expenses.MaintenanceExpense
+		if policyYear < premiumYears {
+			premium = grossPremium
+			if policyYear == 0 {
+				premium -= FirstYearFeeWaived(policy, grossPremium, expenses)
+			}
+			yearExpenses += premium * expenses.RenewalExpenseRate
+		}
+		if policyYear == 0 {
+			yearExpenses += SumAssuredInYear(policy, 0) * expenses.InitialExpenseRate
+		}
+
+		var profit float64
+		if policy.PremiumTiming == PremiumsAnnuallyInArrears {
+			commission := premium * expenses.RenewalExpenseRate
+			profit = (reserveSchedule[year]-(yearExpenses-commission))*interestGrowth +
+				(1.0-chanceOfDeath)*(premium-commission)
+			result.PremiumValue += CalculatePresentValue(chanceInForce*(1.0-chanceOfDeath)*premium, riskDiscountRate, year+1)
+		} else {
+			profit = (reserveSchedule[year] + premium - yearExpenses) * interestGrowth
+			result.PremiumValue += CalculatePresentValue(chanceInForce*premium, riskDiscountRate, year)
+		}
+		claimCost := SumAssuredInYear(policy, policyYear) * settlementFactor(policy)
+		profit -= chanceOfDeath*claimCost + chanceStaying*reserveSchedule[year+1]
+
+		result.ProfitVector = append(result.ProfitVector, profit)
+		result.ProfitSignature = append(result.ProfitSignature, chanceInForce*profit)
+
+		result.ProfitValue += CalculatePresentValue(chanceInForce*profit, riskDiscountRate, year+1)
+
+		chanceInForce *= chanceStaying
+	}
+
+	if result.PremiumValue > 0 {
+		result.ProfitMargin = result.ProfitValue / result.PremiumValue
+	}
+	return result
+}
+
+// ProfitRelease is how a policy's profits reach the shareholders once capital
+// has to be held against it on top of the reserve
+type ProfitRelease struct {
+	CapitalStrain         float64   `json:"capital_strain"`         // Capital set up at the start out of shareholders' funds
+	RequiredCapital       []float64 `json:"required_capital"`       // Per policy in force at the start of each year
+	CostOfCapital         []float64 `json:"cost_of_capital"`        // Per policy sold: what the capital held through each year earns short of the risk discount rate
+	DistributableEarnings []float64 `json:"distributable_earnings"` // Per policy sold: the year's profit plus the capital it frees, at the end of the year
+	DistributableValue    float64   `json:"distributable_value"`    // Distributable earnings less the strain, discounted at the risk discount rate
+	CostOfCapitalValue    float64   `json:"cost_of_capital_value"`  // Cost of capital discounted at the risk discount rate
+}
+
+// ProfitReleasePattern turns a profit test into the earnings the shareholders
+// can take out each year when the capital basis must be held for each policy
+// in force. The capital for the first year is the strain; at the end of year
+// t the survivors' capital for year t+1 is set up out of the profit, and the
+// capital held through year t is released with the interest it earned at the
+// pricing rate:
+//
+//	earnings_t = signature_t + c_t x capital_t x (1+i) - c_t+1 x capital_t+1
+//
+// where c_t is the chance the policy is in force at the start of year t. No
+// capital is held after the last year. Capital earns less than the risk
+// discount rate, so holding it costs c_t x capital_t x (rdr - i) each year,
+// and the distributable value is the profit value less the value of that
+// cost. For a policy already in force the strain is the capital locked in
+// today.
+func ProfitReleasePattern(policy *Policy, mortalityTable MortalityTable, reserveSchedule []float64, profitTest ProfitTestResult, capital CapitalBasis) ProfitRelease {
+	years := len(profitTest.ProfitSignature)
+	release := ProfitRelease{
+		RequiredCapital:       make([]float64, years),
+		CostOfCapital:         make([]float64, years),
+		DistributableEarnings: make([]float64, years),
+	}
+	if years == 0 {
+		return release
+	}
+
+	interestGrowth := 1.0 / discount(policy, 1.0, 1)
+	riskDiscountRate := profitTest.RiskDiscountRate
+	for year := range years {
+		policyYear := policy.InForceDuration + year
+		release.RequiredCapital[year] = capital.RequiredCapital(reserveSchedule[year], SumAssuredInYear(policy, policyYear))
+	}
+	release.CapitalStrain = release.RequiredCapital[0]
+	release.DistributableValue = -release.CapitalStrain
+
+	chanceInForce := 1.0
+	for year := range years {
+		policyYear := policy.InForceDuration + year
+		chanceOfDeath := mortalityTable[policy.Age+policyYear]
+		chanceStaying := (1.0 - chanceOfDeath) * (1.0 - lapseRateForYear(policy, policyYear))
+
+		heldCapital := chanceInForce * release.RequiredCapital[year]
+		nextCapital := 0.0
+		if year+1 < years {
+			nextCapital = chanceInForce * chanceStaying * release.RequiredCapital[year+1]
+		}
+		release.DistributableEarnings[year] = profitTest.ProfitSignature[year] + heldCapital*interestGrowth - nextCapital
+		release.CostOfCapital[year] = heldCapital * (1.0 + riskDiscountRate - interestGrowth)
+
+		release.DistributableValue += CalculatePresentValue(release.DistributableEarnings[year], riskDiscountRate, year+1)
+		release.CostOfCapitalValue += CalculatePresentValue(release.CostOfCapital[year], riskDiscountRate, year+1)
+
+		chanceInForce *= chanceStaying
+	}
+	return release
+}
+
+// ProfitByYear is the profit a single policy is expected to make in each policy year
+type ProfitByYear struct {
+	ExpectedProfit  []float64 `json:"expected_profit"`         // Per policy sold: premium less claims, expenses and the increase in reserve, weighted by the chance of being in force
+	DiscountRate    float64   `json:"discount_rate,omitempty"` // Risk discount rate used; omitted when discounted on the pricing basis
+	DiscountedTotal float64   `json:"discounted_total"`        // Present value of ExpectedProfit
+}
+
+// ExpectedProfitByYear projects the expected profit in each year of a policy.
+// It is the profit signature from ProfitTest, discounted at the policy's risk
+// discount rate when it has one and on its pricing basis - interest rate or
+// yield curve, and compounding convention - otherwise.
+func ExpectedProfitByYear(policy *Policy, mortalityTable MortalityTable, grossPremium float64, expenses ExpenseStructure, reserveSchedule []float64) ProfitByYear {
+	profitTest := ProfitTest(policy, mortalityTable, grossPremium, expenses, reserveSchedule, policy.RiskDiscountRate)
+	result := ProfitByYear{
+		ExpectedProfit:  profitTest.ProfitSignature,
+		DiscountRate:    policy.RiskDiscountRate,
+		DiscountedTotal: profitTest.ProfitValue,
+	}
+	if policy.RiskDiscountRate == 0 {
+		result.DiscountedTotal = 0
+		for year, profit := range result.ExpectedProfit {
+			result.DiscountedTotal += discount(policy, profit, year+1)
+		}
+	}
+	return result
+}
+
+// Outcomes of solving for break-even mortality
+const (
+	BreakEvenMortalityFound  = "found"
+	BreakEvenNeverProfitable = "never_profitable" // Loses money whatever the mortality
+	BreakEvenNotReached      = "not_reached"      // Makes money whatever the mortality
+)
+
+// maxMortalityMultiplier bounds the search for break-even mortality; well
+// before it almost every rate is clamped at 1
+const maxMortalityMultiplier = 1024.0
+
+// BreakEvenMortality finds the multiplier m for which, with every qx in the
+// table replaced by m x qx (at most 1), ProfitTest's profit value is zero.
+// Premiums and reserves stay as priced; only the mortality experienced
+// changes.
+//
+// For a policy that pays on death, profit falls as mortality rises, so an m
+// above 1 is the margin for mortality worse than priced for. Survival benefits
+// work the other way: an m below 1 is how far mortality can improve before the
+// policy loses money. Either way, m on the far side of 1 from the margin
+// means the policy loses money on the table it was priced on.
+//
+// A policy that loses money whatever the mortality, from none up to the
+// search limit, is BreakEvenNeverProfitable; one that makes money whatever
+// the mortality is BreakEvenNotReached. A bisection that doesn't settle
+// within the solver's iterations is a *ConvergenceError.
+func BreakEvenMortality(policy *Policy, mortalityTable MortalityTable, grossPremium float64, expenses ExpenseStructure, reserveSchedule []float64, riskDiscountRate float64, solver SolverConfig) (float64, string, error) {
+	profitValue := func(multiplier float64) float64 {
+		shocked := CombineTables([]MortalityTable{mortalityTable}, []float64{multiplier})
+		return ProfitTest(policy, shocked, grossPremium, expenses, reserveSchedule, riskDiscountRate).ProfitValue
+	}
+
+	low, lowValue := 0.0, profitValue(0)
+	high, highValue := 1.0, profitValue(1)
+	// Widen the bracket upwards until the profit changes sign across it
+	for (lowValue > 0) == (highValue > 0) {
+		if high >= maxMortalityMultiplier {
+			if highValue > 0 {
+				return 0, BreakEvenNotReached, nil
+			}
+			return 0, BreakEvenNeverProfitable, nil
+		}
+		low, lowValue = high, highValue
+		high *= 2
+		highValue = profitValue(high)
+	}
+	multiplier, err := bisect("break-even mortality", profitValue, low, high, lowValue, solver.orDefault())
+	if err != nil {
+		return 0, "", err
+	}
+	return multiplier, BreakEvenMortalityFound, nil
+}
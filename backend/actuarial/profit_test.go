@@ -0,0 +1,221 @@
+package actuarial
+
+import "testing"
+
+func TestProfitTest(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, LapseRate: 0.03}
+	netPremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+
+	// Charging exactly the net premium with no expenses, and discounting at the
+	// pricing rate, the profits are worth nothing
+	breakEven := ProfitTest(policy, mortalityTable, netPremium, ExpenseStructure{}, reserves, 0.05)
+	if len(breakEven.ProfitSignature) != 20 {
+		t.Fatalf("Expected 20 years of profit, got %d", len(breakEven.ProfitSignature))
+	}
+	if !floatEquals(breakEven.ProfitValue, 0, 1e-6) {
+		t.Errorf("Expected a zero profit value at the net premium, got %f", breakEven.ProfitValue)
+	}
+
+	// The priced gross premium makes a profit, worth less at a higher hurdle rate
+	expenses := CreateDefaultExpenses()
+	grossPremium := CalculateGrossPremium(policy, mortalityTable, netPremium, expenses)
+	atTenPercent := ProfitTest(policy, mortalityTable, grossPremium, expenses, reserves, 0.10)
+	atFifteenPercent := ProfitTest(policy, mortalityTable, grossPremium, expenses, reserves, 0.15)
+
+	if atTenPercent.ProfitMargin <= 0 {
+		t.Errorf("Expected a positive profit margin, got %f", atTenPercent.ProfitMargin)
+	}
+	if !floatEquals(atTenPercent.ProfitMargin, atTenPercent.ProfitValue/atTenPercent.PremiumValue, 1e-12) {
+		t.Errorf("Expected margin to be profit value over premium value")
+	}
+	if atTenPercent.ProfitSignature[0] >= 0 {
+		t.Errorf("Expected first-year strain to make year 1 a loss, got %f", atTenPercent.ProfitSignature[0])
+	}
+	if atFifteenPercent.ProfitValue >= atTenPercent.ProfitValue {
+		t.Errorf("Expected a higher hurdle rate to lower the profit value, got %f vs %f", atFifteenPercent.ProfitValue, atTenPercent.ProfitValue)
+	}
+
+	// The vector is per policy in force, the signature per policy sold
+	if atTenPercent.ProfitSignature[5] >= atTenPercent.ProfitVector[5] {
+		t.Errorf("Expected deaths and lapses to shrink the signature below the vector")
+	}
+}
+
+func TestExpectedProfitByYear(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, LapseRate: 0.03}
+	netPremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+
+	// With no hurdle rate the profits are discounted at the pricing rate, where
+	// the net premium with no expenses breaks even
+	breakEven := ExpectedProfitByYear(policy, mortalityTable, netPremium, ExpenseStructure{}, reserves)
+	if len(breakEven.ExpectedProfit) != 20 || breakEven.DiscountRate != 0 {
+		t.Fatalf("Expected 20 years discounted on the pricing basis, got %+v", breakEven)
+	}
+	if !floatEquals(breakEven.DiscountedTotal, 0, 1e-6) {
+		t.Errorf("Expected a zero discounted total at the net premium, got %f", breakEven.DiscountedTotal)
+	}
+
+	// With a hurdle rate it matches the profit test
+	expenses := CreateDefaultExpenses()
+	grossPremium := CalculateGrossPremium(policy, mortalityTable, netPremium, expenses)
+	policy.RiskDiscountRate = 0.10
+	profit := ExpectedProfitByYear(policy, mortalityTable, grossPremium, expenses, reserves)
+	profitTest := ProfitTest(policy, mortalityTable, grossPremium, expenses, reserves, 0.10)
+	if profit.DiscountRate != 0.10 || !floatEquals(profit.DiscountedTotal, profitTest.ProfitValue, 1e-9) {
+		t.Errorf("Expected the profit test's value %f at 10%%, got %+v", profitTest.ProfitValue, profit)
+	}
+	for year := range profit.ExpectedProfit {
+		if profit.ExpectedProfit[year] != profitTest.ProfitSignature[year] {
+			t.Fatalf("Year %d: expected the profit signature %f, got %f", year+1, profitTest.ProfitSignature[year], profit.ExpectedProfit[year])
+		}
+	}
+}
+
+func TestBreakEvenMortality(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, LapseRate: 0.03}
+	netPremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+	expenses := CreateDefaultExpenses()
+	grossPremium := CalculateGrossPremium(policy, mortalityTable, netPremium, expenses)
+
+	// The loaded premium can stand some extra mortality, and at the multiplier
+	// found the profit is gone
+	multiplier, status, err := BreakEvenMortality(policy, mortalityTable, grossPremium, expenses, reserves, 0.10, DefaultSolverConfig())
+	if err != nil || status != BreakEvenMortalityFound || multiplier <= 1 {
+		t.Fatalf("Expected a break-even multiplier above 1, got %f (%s)", multiplier, status)
+	}
+	shocked := CombineTables([]MortalityTable{mortalityTable}, []float64{multiplier})
+	if value := ProfitTest(policy, shocked, grossPremium, expenses, reserves, 0.10).ProfitValue; !floatEquals(value, 0, 1e-4) {
+		t.Errorf("Expected no profit at %f x mortality, got %f", multiplier, value)
+	}
+
+	// Charging nothing loses money whoever dies
+	if _, status, _ := BreakEvenMortality(policy, mortalityTable, 0, expenses, reserves, 0.10, DefaultSolverConfig()); status != BreakEvenNeverProfitable {
+		t.Errorf("Expected a free policy to be never profitable, got %s", status)
+	}
+
+	// A pure endowment pays on survival, so it's lighter mortality that costs money
+	pureEndowment := &Policy{Age: 40, Term: 10, MaturityBenefit: 100000, InterestRate: 0.05}
+	endowmentNet := CalculateTermLifeNetPremium(pureEndowment, mortalityTable)
+	endowmentReserves := CalculateTermLifeReserveSchedule(pureEndowment, mortalityTable, endowmentNet)
+	multiplier, status, _ = BreakEvenMortality(pureEndowment, mortalityTable, endowmentNet*1.001, ExpenseStructure{}, endowmentReserves, 0.05, DefaultSolverConfig())
+	if status != BreakEvenMortalityFound || multiplier >= 1 {
+		t.Errorf("Expected a pure endowment to break even below the priced mortality, got %f (%s)", multiplier, status)
+	}
+
+	// With no death benefit and a generous premium, no mortality loses money
+	if _, status, _ := BreakEvenMortality(pureEndowment, mortalityTable, endowmentNet*2, ExpenseStructure{}, endowmentReserves, 0.05, DefaultSolverConfig()); status != BreakEvenNotReached {
+		t.Errorf("Expected a doubled premium to always make money, got %s", status)
+	}
+}
+
+func TestProfitTestInForce(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, LapseRate: 0.03}
+	expenses := CreateDefaultExpenses()
+	netPremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	grossPremium := CalculateGrossPremium(policy, mortalityTable, netPremium, expenses)
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+	atIssue := ProfitTest(policy, mortalityTable, grossPremium, expenses, reserves, 0.10)
+
+	// Five years on, the profits still to come are the rest of the signature
+	// per policy still in force, discounted to today
+	inForce := *policy
+	inForce.InForceDuration = 5
+	valueOfInForce := ProfitTest(&inForce, mortalityTable, grossPremium, expenses, InForceReserves(&inForce, reserves), 0.10)
+	if len(valueOfInForce.ProfitVector) != 15 {
+		t.Fatalf("Expected 15 years of profit to come, got %d", len(valueOfInForce.ProfitVector))
+	}
+	for year, profit := range valueOfInForce.ProfitVector {
+		if !floatEquals(profit, atIssue.ProfitVector[year+5], 1e-9) {
+			t.Errorf("Year %d: expected a profit of %f per policy in force, got %f", year+5, atIssue.ProfitVector[year+5], profit)
+		}
+	}
+	chanceInForce, pastValue := 1.0, 0.0
+	for year := range 5 {
+		pastValue += CalculatePresentValue(atIssue.ProfitSignature[year], 0.10, year+1)
+		chanceInForce *= (1 - mortalityTable[40+year]) * (1 - 0.03)
+	}
+	if want := pastValue + chanceInForce*CalculatePresentValue(valueOfInForce.ProfitValue, 0.10, 5); !floatEquals(atIssue.ProfitValue, want, 1e-6) {
+		t.Errorf("Expected the value at issue to split into %f, got %f", want, atIssue.ProfitValue)
+	}
+}
+
+func TestEmbeddedValue(t *testing.T) {
+	capital := CapitalBasis{ReserveFactor: 0.04, SumAtRiskFactor: 0.003}
+	if required := capital.RequiredCapital(10000, 100000); !floatEquals(required, 400+270, 1e-9) {
+		t.Errorf("Expected 4%% of the reserve and 0.3%% of the sum at risk, got %f", required)
+	}
+	if required := capital.RequiredCapital(120000, 100000); !floatEquals(required, 4800, 1e-9) {
+		t.Errorf("Expected nothing at risk above the sum assured, got %f", required)
+	}
+	if err := (CapitalBasis{ReserveFactor: 1.5}).Validate(); err == nil {
+		t.Error("Expected a reserve factor above 1 to be rejected")
+	}
+
+	value := CalculateEmbeddedValue(1000, 1500, 2500)
+	if value.FreeSurplus != -500 || value.EmbeddedValue != 3500 {
+		t.Errorf("Expected free surplus -500 and embedded value 3500, got %+v", value)
+	}
+}
+
+func TestProfitReleasePattern(t *testing.T) {
+	mortalityTable := make(MortalityTable, 100)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001 + float64(age)*0.0005
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, LapseRate: 0.03}
+	expenses := CreateDefaultExpenses()
+	netPremium := CalculateTermLifeNetPremium(policy, mortalityTable)
+	grossPremium := CalculateGrossPremium(policy, mortalityTable, netPremium, expenses)
+	reserves := CalculateTermLifeReserveSchedule(policy, mortalityTable, netPremium)
+	profitTest := ProfitTest(policy, mortalityTable, grossPremium, expenses, reserves, 0.10)
+	release := ProfitReleasePattern(policy, mortalityTable, reserves, profitTest, DefaultCapitalBasis)
+
+	if want := DefaultCapitalBasis.RequiredCapital(reserves[0], 100000); !floatEquals(release.CapitalStrain, want, 1e-9) {
+		t.Errorf("Expected a capital strain of %f, got %f", want, release.CapitalStrain)
+	}
+	if len(release.DistributableEarnings) != len(profitTest.ProfitSignature) {
+		t.Fatalf("Expected earnings for each of the %d years, got %d", len(profitTest.ProfitSignature), len(release.DistributableEarnings))
+	}
+	// Over the term the earnings give back the strain with interest on top
+	released := 0.0
+	for year, earnings := range release.DistributableEarnings {
+		released += earnings - profitTest.ProfitSignature[year]
+	}
+	if released <= release.CapitalStrain {
+		t.Errorf("Expected more than the strain of %f to be released, got %f", release.CapitalStrain, released)
+	}
+	// Holding capital at 5% costs shareholders who want 10%
+	if release.CostOfCapitalValue <= 0 {
+		t.Errorf("Expected a positive cost of capital, got %f", release.CostOfCapitalValue)
+	}
+	if want := profitTest.ProfitValue - release.CostOfCapitalValue; !floatEquals(release.DistributableValue, want, 1e-6) {
+		t.Errorf("Expected the distributable value to be the profit value less the cost of capital, %f, got %f", want, release.DistributableValue)
+	}
+
+	// With no capital required the earnings are the profit signature
+	none := ProfitReleasePattern(policy, mortalityTable, reserves, profitTest, CapitalBasis{})
+	for year, earnings := range none.DistributableEarnings {
+		if !floatEquals(earnings, profitTest.ProfitSignature[year], 1e-9) {
+			t.Errorf("Year %d: expected earnings of %f with no capital, got %f", year, profitTest.ProfitSignature[year], earnings)
+		}
+	}
+}
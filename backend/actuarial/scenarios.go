@@ -0,0 +1,125 @@
+package actuarial
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Scenario is one prescribed interest rate path: the one-year rate in each
+// future year, Path[0] for the first
+type Scenario struct {
+	Name string
+	Path []float64
+}
+
+// Curve is the yield curve the path implies: the spot rate for each term is
+// the rate that grows money as much as the path's one-year rates compounded
+// up to it
+func (scenario Scenario) Curve() YieldCurve {
+	curve := make(YieldCurve, len(scenario.Path))
+	growth := 1.0
+	for year, rate := range scenario.Path {
+		growth *= 1 + rate
+		curve[year] = math.Pow(growth, 1/float64(year+1)) - 1
+	}
+	return curve
+}
+
+// LoadScenarioSet reads a set of interest rate scenarios from a tab-delimited
+// file with a header row of the years 1, 2, 3, ... and one scenario per row,
+// its name first and then its one-year rate in each year:
+//
+//	scenario	1	2	3
+//	base	0.040	0.041	0.042
+//	down	0.030	0.028	0.027
+//
+// A scenario may stop short of the last year by leaving its last cells empty,
+// but not skip a year.
+func LoadScenarioSet(filePath string) ([]Scenario, error) {
+	scenarioData, closeFile, err := openTableFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	csvReader := csv.NewReader(scenarioData)
+	csvReader.FieldsPerRecord = -1
+	csvReader.Comma = '\t'
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+	for column, cell := range header[1:] {
+		if year, err := strconv.Atoi(strings.TrimSpace(cell)); err != nil || year != column+1 {
+			return nil, fmt.Errorf("scenario header must run 1, 2, 3, ... by year, got %q in column %d", cell, column+2)
+		}
+	}
+
+	var scenarios []Scenario
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV row: %w", err)
+		}
+		name := strings.TrimSpace(row[0])
+		if name == "" {
+			continue
+		}
+		if len(row) > len(header) {
+			return nil, fmt.Errorf("scenario '%s' has more rates than the header has years", name)
+		}
+
+		cells := row[1:]
+		for len(cells) > 0 && strings.TrimSpace(cells[len(cells)-1]) == "" {
+			cells = cells[:len(cells)-1]
+		}
+		path := make([]float64, len(cells))
+		for year, cell := range cells {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+			if err != nil {
+				return nil, fmt.Errorf("scenario '%s' year %d: %q is not a rate", name, year+1, cell)
+			}
+			if rate <= -1 {
+				return nil, fmt.Errorf("scenario '%s' year %d: rate must be above -1", name, year+1)
+			}
+			path[year] = rate
+		}
+		if len(path) == 0 {
+			return nil, fmt.Errorf("scenario '%s' has no rates", name)
+		}
+		scenarios = append(scenarios, Scenario{Name: name, Path: path})
+	}
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("scenario set %s has no scenarios", filePath)
+	}
+	return scenarios, nil
+}
+
+// Percentile is the value below which the given share of the values fall,
+// interpolating linearly between the nearest two; 0.5 is the median
+func Percentile(values []float64, share float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	if share <= 0 {
+		return sorted[0]
+	}
+	position := share * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	fraction := position - float64(lower)
+	return sorted[lower] + fraction*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,106 @@
+package actuarial
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SelectTable is a select-and-ultimate mortality table. Lives who have just
+// been underwritten die less often than the general population of the same
+// age, so for the first SelectPeriod years after issue their rates depend on
+// both the age at issue and the time since: q[x]+d. After that the ultimate
+// rates by attained age apply.
+type SelectTable struct {
+	SelectPeriod int
+	Select       map[int][]float64 // Issue age -> rates for durations 0 to SelectPeriod-1
+	Ultimate     MortalityTable    // By attained age
+}
+
+// Len is the number of ages the ultimate table covers
+func (table SelectTable) Len() int {
+	return len(table.Ultimate)
+}
+
+// PolicyTable is the mortality a life issued at issueAge experiences, indexed
+// by attained age like any other table: the select rates for the first years
+// after issue and the ultimate rates after that.
+//
+// Because the table is anchored at the age at issue, everything that looks up
+// rates by attained age - premiums, and reserves at any later duration - gets
+// the select rates for exactly the select years the policy has left. The
+// reserve at duration k uses q[x]+k, q[x]+k+1, ... and not the rates of a
+// life newly selected at age x+k.
+func (table SelectTable) PolicyTable(issueAge int) MortalityTable {
+	policyTable := make(MortalityTable, len(table.Ultimate))
+	copy(policyTable, table.Ultimate)
+	for duration, rate := range table.Select[issueAge] {
+		if age := issueAge + duration; age < len(policyTable) {
+			policyTable[age] = rate
+		}
+	}
+	return policyTable
+}
+
+// LoadSelectTable reads a tab-delimited select-and-ultimate table. Each row is
+// an age x followed by the select rates for a life issued at x, one column per
+// year of the select period, and the ultimate rate at attained age x last:
+//
+//	age	q[x]	q[x]+1	qx
+//	40	0.0008	0.0010	0.0013
+//
+// The number of select columns sets the select period.
+func LoadSelectTable(filePath string) (SelectTable, error) {
+	tableData, closeFile, err := openTableFile(filePath)
+	if err != nil {
+		return SelectTable{}, err
+	}
+	defer closeFile()
+
+	csvReader := csv.NewReader(tableData)
+	csvReader.Comma = '\t'
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return SelectTable{}, fmt.Errorf("could not read CSV header: %w", err)
+	}
+	if len(header) < 3 {
+		return SelectTable{}, fmt.Errorf("select table needs an age column, at least one select column and an ultimate column")
+	}
+
+	table := SelectTable{SelectPeriod: len(header) - 2, Select: map[int][]float64{}}
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return SelectTable{}, fmt.Errorf("error reading CSV row: %w", err)
+		}
+
+		age, err := strconv.Atoi(strings.TrimSpace(row[0]))
+		if err != nil || age < 0 {
+			return SelectTable{}, fmt.Errorf("select table row %q does not start with an age", strings.Join(row, "\t"))
+		}
+		rates := make([]float64, len(row)-1)
+		for i, text := range row[1:] {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+			if err != nil || rate < 0 || rate > 1 {
+				return SelectTable{}, fmt.Errorf("select table rate %q at age %d must be between 0 and 1", text, age)
+			}
+			rates[i] = rate
+		}
+
+		for len(table.Ultimate) <= age {
+			table.Ultimate = append(table.Ultimate, 0)
+		}
+		table.Ultimate[age] = rates[table.SelectPeriod]
+		table.Select[age] = rates[:table.SelectPeriod]
+	}
+	if len(table.Select) == 0 {
+		return SelectTable{}, fmt.Errorf("select table %s has no rates", filePath)
+	}
+	return table, nil
+}
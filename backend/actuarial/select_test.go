@@ -0,0 +1,85 @@
+package actuarial
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A two-year select period: row x holds q[x], q[x]+1 and the ultimate q_x
+const testSelectTable = "age\tq[x]\tq[x]+1\tqx\n" +
+	"40\t0.001\t0.002\t0.003\n" +
+	"41\t0.0015\t0.0025\t0.004\n" +
+	"42\t0.002\t0.003\t0.005\n" +
+	"43\t0.0025\t0.0035\t0.006\n" +
+	"44\t0.003\t0.004\t0.007\n"
+
+func TestLoadSelectTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "select.csv")
+	if err := os.WriteFile(path, []byte(testSelectTable), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := LoadSelectTable(path)
+	if err != nil {
+		t.Fatalf("Could not load select table: %v", err)
+	}
+	if table.SelectPeriod != 2 {
+		t.Errorf("Expected a 2 year select period, got %d", table.SelectPeriod)
+	}
+
+	// Issued at 40: select for two years, then ultimate by attained age
+	policyTable := table.PolicyTable(40)
+	for age, want := range map[int]float64{40: 0.001, 41: 0.002, 42: 0.005, 43: 0.006} {
+		if !floatEquals(policyTable[age], want, 1e-12) {
+			t.Errorf("Age %d: expected %f, got %f", age, want, policyTable[age])
+		}
+	}
+	// The shared ultimate rates are not changed by building a policy table
+	if table.Ultimate[40] != 0.003 {
+		t.Errorf("Expected the ultimate table to be untouched, got %f", table.Ultimate[40])
+	}
+
+	badPath := filepath.Join(t.TempDir(), "bad.csv")
+	if err := os.WriteFile(badPath, []byte("age\tq[x]\tqx\n40\t1.5\t0.003\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSelectTable(badPath); err == nil {
+		t.Error("Expected a rate above 1 to be rejected")
+	}
+}
+
+func TestSelectPeriodReserves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "select.csv")
+	if err := os.WriteFile(path, []byte(testSelectTable), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := LoadSelectTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := &Policy{Age: 40, Term: 3, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "term_life"}
+	netPremium := 3.0
+	reserves := CalculateTermLifeReserveSchedule(policy, table.PolicyTable(40), netPremium)
+
+	// At duration 1 the life is still select for one more year: q[40]+1, then
+	// the ultimate q42 - not q[41], the rate of a life newly selected at 41.
+	v := 1 / 1.05
+	want := 1000*(0.002*v+0.998*0.005*v*v) - netPremium*(1+0.998*v)
+	if !floatEquals(reserves[1], want, 1e-9) {
+		t.Errorf("Expected the duration 1 reserve on select rates to be %f, got %f", want, reserves[1])
+	}
+
+	// At duration 2 the select period is over: ultimate q42 only
+	want = 1000*0.005*v - netPremium
+	if !floatEquals(reserves[2], want, 1e-9) {
+		t.Errorf("Expected the duration 2 reserve on ultimate rates to be %f, got %f", want, reserves[2])
+	}
+
+	ultimate := CalculateTermLifeReserveSchedule(policy, table.Ultimate, netPremium)
+	if math.Abs(ultimate[1]-reserves[1]) < 0.01 {
+		t.Errorf("Expected select rates to change the duration 1 reserve, got %f both ways", reserves[1])
+	}
+}
@@ -0,0 +1,52 @@
+package actuarial
+
+import "fmt"
+
+// SolverConfig is how closely the iterative calculations - internal rates of
+// return, break-even mortality and cash-refund annuity prices - solve, and
+// how long they try before giving up
+type SolverConfig struct {
+	Tolerance     float64 // Answers this close together are the same; relative to the answer for prices
+	MaxIterations int     // Steps allowed before a solve counts as not converging
+}
+
+// DefaultSolverConfig is tight enough that every answer is exact to the cent
+// and far more steps than a well-behaved solve needs
+func DefaultSolverConfig() SolverConfig {
+	return SolverConfig{Tolerance: 1e-10, MaxIterations: 200}
+}
+
+// Validate checks the config can be solved with
+func (config SolverConfig) Validate() error {
+	if config.Tolerance <= 0 || config.Tolerance >= 1 {
+		return fmt.Errorf("solver tolerance must be above 0 and below 1")
+	}
+	if config.MaxIterations < 1 {
+		return fmt.Errorf("solver max iterations must be at least 1")
+	}
+	return nil
+}
+
+// orDefault fills in whatever the config leaves unset from DefaultSolverConfig
+func (config SolverConfig) orDefault() SolverConfig {
+	defaults := DefaultSolverConfig()
+	if config.Tolerance <= 0 {
+		config.Tolerance = defaults.Tolerance
+	}
+	if config.MaxIterations <= 0 {
+		config.MaxIterations = defaults.MaxIterations
+	}
+	return config
+}
+
+// ConvergenceError is a solve that ran out of iterations before settling, so
+// its last answer can't be trusted
+type ConvergenceError struct {
+	Solve      string  // What was being solved for
+	Iterations int     // Steps taken
+	Residual   float64 // How far from solved the last step was
+}
+
+func (e *ConvergenceError) Error() string {
+	return fmt.Sprintf("%s did not converge after %d iterations (last residual %g)", e.Solve, e.Iterations, e.Residual)
+}
@@ -0,0 +1,24 @@
+package actuarial
+
+// SurvivalPoint is the chance a life aged fromAge is still alive at Age
+type SurvivalPoint struct {
+	Age                 int     `json:"age"`
+	SurvivalProbability float64 `json:"survival_probability"`
+}
+
+// SurvivalCurve is tpx from fromAge to the end of the table: a point for each
+// age, starting at 1, and a last point one past the table's last age for
+// surviving the whole table. Scaled by any radix it is the lx column.
+func SurvivalCurve(mortalityTable MortalityTable, fromAge int) []SurvivalPoint {
+	if fromAge < 0 || fromAge >= len(mortalityTable) {
+		return nil
+	}
+	points := make([]SurvivalPoint, 0, len(mortalityTable)-fromAge+1)
+	for years := 0; fromAge+years <= len(mortalityTable); years++ {
+		points = append(points, SurvivalPoint{
+			Age:                 fromAge + years,
+			SurvivalProbability: calculateSurvivalProbability(fromAge, years, mortalityTable),
+		})
+	}
+	return points
+}
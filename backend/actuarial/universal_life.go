@@ -0,0 +1,261 @@
+package actuarial
+
+import (
+	"fmt"
+	"math"
+)
+
+// UniversalLifePolicy is a flexible-premium policy with an account value.
+// Premiums go into the account less a load; each year the account pays an
+// admin charge and the cost of insurance on the net amount at risk, and is
+// credited with interest. The death benefit is level at SumAssured unless the
+// corridor pushes it higher.
+type UniversalLifePolicy struct {
+	Age          int       // Age at issue
+	Term         int       // Years projected
+	SumAssured   float64   // Stated death benefit
+	Premiums     []float64 // Paid at the start of each policy year; years past the end pay nothing
+	CreditedRate float64   // Interest credited to the account each year
+	PremiumLoad  float64   // Part of each premium kept by the insurer (0.05 = 5%)
+	AdminCharge  float64   // Taken from the account at the start of each year
+
+	// CorridorFactors is the minimum death benefit as a multiple of the
+	// account value, by attained age. Nil uses DefaultCorridorFactors; an
+	// empty, non-nil slice applies no corridor.
+	CorridorFactors []CorridorFactor
+}
+
+// CorridorFactor is the smallest death benefit allowed at an attained age, as
+// a multiple of the account value
+type CorridorFactor struct {
+	Age    int     `json:"age"`
+	Factor float64 `json:"factor"`
+}
+
+// DefaultCorridorFactors follows the shape of the US cash value corridor
+// (IRC 7702(d)): a death benefit of at least 2.5 times the account value to
+// age 40, narrowing to the account value itself by 95. Factors between the
+// listed ages are interpolated. Replace it with your own regulator's table.
+var DefaultCorridorFactors = []CorridorFactor{
+	{40, 2.50}, {45, 2.15}, {50, 1.85}, {55, 1.50}, {60, 1.30},
+	{65, 1.20}, {70, 1.15}, {75, 1.05}, {90, 1.05}, {95, 1.00},
+}
+
+// CorridorFactorAt reads a corridor table at an attained age, interpolating
+// linearly between listed ages and holding the end factors beyond them.
+// With no factors there is no corridor, which is a factor of 0.
+func CorridorFactorAt(factors []CorridorFactor, age int) float64 {
+	if len(factors) == 0 {
+		return 0
+	}
+	if age <= factors[0].Age {
+		return factors[0].Factor
+	}
+	for i := 1; i < len(factors); i++ {
+		if age <= factors[i].Age {
+			below, above := factors[i-1], factors[i]
+			weight := float64(age-below.Age) / float64(above.Age-below.Age)
+			return below.Factor + weight*(above.Factor-below.Factor)
+		}
+	}
+	return factors[len(factors)-1].Factor
+}
+
+// AccountValueYear is one policy year of an account value projection
+type AccountValueYear struct {
+	Year            int     `json:"year"`
+	Age             int     `json:"age"`
+	Premium         float64 `json:"premium"`
+	DeathBenefit    float64 `json:"death_benefit"`      // The larger of the sum assured and the corridor minimum
+	NetAmountAtRisk float64 `json:"net_amount_at_risk"` // Death benefit less the account value it replaces
+	CostOfInsurance float64 `json:"cost_of_insurance"`
+	AccountValue    float64 `json:"account_value"` // At the end of the year, after interest
+	CorridorBinding bool    `json:"corridor_binding"`
+}
+
+// AccountValueProjection is the year-by-year account of a universal life policy
+type AccountValueProjection struct {
+	Years []AccountValueYear `json:"years"`
+
+	// First year the corridor set the death benefit, -1 if it never did
+	FirstCorridorYear int `json:"first_corridor_year"`
+	// Years the corridor set the death benefit
+	CorridorYears int `json:"corridor_years"`
+	// The account ran out and the policy lapsed in LapseYear
+	Lapsed    bool `json:"lapsed"`
+	LapseYear int  `json:"lapse_year,omitempty"`
+}
+
+// ProjectAccountValue runs a universal life account forward a year at a time.
+// At the start of each year the premium is paid in less its load and the
+// admin charge is taken. The death benefit is then the greater of the sum
+// assured and the corridor factor times the account, and the cost of
+// insurance is qx on the amount at risk above the account. What is left
+// earns the credited rate to the end of the year. An account that can't pay
+// its charges lapses and the projection stops.
+//
+// Once the account is large enough the corridor, not the sum assured, sets
+// the death benefit, so the amount at risk and the cost of insurance keep
+// growing with the account instead of shrinking to nothing.
+func ProjectAccountValue(policy UniversalLifePolicy, mortalityTable MortalityTable) AccountValueProjection {
+	corridor := policy.CorridorFactors
+	if corridor == nil {
+		corridor = DefaultCorridorFactors
+	}
+
+	projection := AccountValueProjection{FirstCorridorYear: -1}
+	accountValue := 0.0
+	for year := 0; year < policy.Term; year++ {
+		age := policy.Age + year
+		if age >= len(mortalityTable) {
+			break
+		}
+
+		premium := 0.0
+		if year < len(policy.Premiums) {
+			premium = policy.Premiums[year]
+		}
+		accountValue += premium*(1-policy.PremiumLoad) - policy.AdminCharge
+
+		deathBenefit := policy.SumAssured
+		corridorMinimum := CorridorFactorAt(corridor, age) * math.Max(accountValue, 0)
+		binding := corridorMinimum > deathBenefit
+		if binding {
+			deathBenefit = corridorMinimum
+			projection.CorridorYears++
+			if projection.FirstCorridorYear < 0 {
+				projection.FirstCorridorYear = year
+			}
+		}
+		netAmountAtRisk := math.Max(deathBenefit-math.Max(accountValue, 0), 0)
+		costOfInsurance := mortalityTable[age] * netAmountAtRisk
+		accountValue -= costOfInsurance
+
+		if accountValue < 0 {
+			projection.Lapsed = true
+			projection.LapseYear = year
+			accountValue = 0
+		} else {
+			accountValue *= 1 + policy.CreditedRate
+		}
+		projection.Years = append(projection.Years, AccountValueYear{
+			Year:            year,
+			Age:             age,
+			Premium:         premium,
+			DeathBenefit:    deathBenefit,
+			NetAmountAtRisk: netAmountAtRisk,
+			CostOfInsurance: costOfInsurance,
+			AccountValue:    accountValue,
+			CorridorBinding: binding,
+		})
+		if projection.Lapsed {
+			break
+		}
+	}
+	return projection
+}
+
+// What an equivalent level premium matches with the irregular premiums
+const (
+	LevelPremiumTerminalValue = "terminal_value" // The same account value at the end of the term
+	LevelPremiumInForce       = "in_force"       // The smallest premium that keeps the policy in force to the end
+)
+
+// EquivalentLevelPremium compares an irregular premium stream with the level
+// annual premium that funds the same universal life benefits
+type EquivalentLevelPremium struct {
+	Target       string
+	LevelPremium float64
+	Irregular    AccountValueProjection // The premiums as sent
+	Level        AccountValueProjection // The level premium every year of the term
+}
+
+// TerminalAccountValue is the account value at the end of the projection, 0
+// if the policy lapsed
+func (projection AccountValueProjection) TerminalAccountValue() float64 {
+	if projection.Lapsed || len(projection.Years) == 0 {
+		return 0
+	}
+	return projection.Years[len(projection.Years)-1].AccountValue
+}
+
+// SolveEquivalentLevelPremium finds the premium P that, paid at the start of
+// every year of the term, funds the same benefits as the policy's own
+// irregular premiums. For LevelPremiumTerminalValue the account under P ends
+// the term at the same value as under the irregular premiums, which must keep
+// the policy in force. For LevelPremiumInForce P is the least that keeps the
+// account from running out before the end of the term.
+//
+// More premium never leaves a smaller account, so P is found by bisection
+// between nothing and a bracket widened until it is enough. An in-force
+// premium is rounded up by the solver's tolerance so it never falls just
+// short.
+func SolveEquivalentLevelPremium(policy UniversalLifePolicy, mortalityTable MortalityTable, target string, solver SolverConfig) (EquivalentLevelPremium, error) {
+	if target == "" {
+		target = LevelPremiumTerminalValue
+	}
+	solver = solver.orDefault()
+	result := EquivalentLevelPremium{Target: target, Irregular: ProjectAccountValue(policy, mortalityTable)}
+
+	level := func(premium float64) AccountValueProjection {
+		levelPolicy := policy
+		levelPolicy.Premiums = make([]float64, policy.Term)
+		for year := range levelPolicy.Premiums {
+			levelPolicy.Premiums[year] = premium
+		}
+		return ProjectAccountValue(levelPolicy, mortalityTable)
+	}
+	var shortfall func(premium float64) float64
+	switch target {
+	case LevelPremiumTerminalValue:
+		if result.Irregular.Lapsed {
+			return EquivalentLevelPremium{}, fmt.Errorf("the premiums lapse the policy in year %d, so there is no terminal value to match; solve for %s instead",
+				result.Irregular.LapseYear+1, LevelPremiumInForce)
+		}
+		goal := result.Irregular.TerminalAccountValue()
+		shortfall = func(premium float64) float64 {
+			projection := level(premium)
+			if projection.Lapsed {
+				return -goal - 1
+			}
+			return projection.TerminalAccountValue() - goal
+		}
+	case LevelPremiumInForce:
+		shortfall = func(premium float64) float64 {
+			if level(premium).Lapsed {
+				return -1
+			}
+			return 1
+		}
+	default:
+		return EquivalentLevelPremium{}, fmt.Errorf("unknown level premium target '%s'", target)
+	}
+
+	lowValue := shortfall(0)
+	if lowValue >= 0 {
+		// Nothing at all already does it
+		result.Level = level(0)
+		return result, nil
+	}
+	high := max(1, policy.SumAssured*0.01)
+	for range solver.MaxIterations {
+		if shortfall(high) >= 0 {
+			break
+		}
+		high *= 2
+	}
+	if shortfall(high) < 0 {
+		return EquivalentLevelPremium{}, fmt.Errorf("no level premium up to %g funds the benefits", high)
+	}
+
+	premium, err := bisect("equivalent level premium", shortfall, 0, high, lowValue, solver)
+	if err != nil {
+		return EquivalentLevelPremium{}, err
+	}
+	if target == LevelPremiumInForce {
+		premium += solver.Tolerance
+	}
+	result.LevelPremium = premium
+	result.Level = level(premium)
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package actuarial
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCorridorFactorAt(t *testing.T) {
+	tests := []struct {
+		age  int
+		want float64
+	}{
+		{25, 2.50},  // Before the first age: flat
+		{40, 2.50},  // Listed
+		{42, 2.36},  // Two fifths of the way from 2.50 to 2.15
+		{80, 1.05},  // Between two equal factors
+		{100, 1.00}, // Past the last age: flat
+	}
+	for _, tt := range tests {
+		if got := CorridorFactorAt(DefaultCorridorFactors, tt.age); !floatEquals(got, tt.want, 1e-12) {
+			t.Errorf("Age %d: expected corridor %f, got %f", tt.age, tt.want, got)
+		}
+	}
+	if got := CorridorFactorAt(nil, 50); got != 0 {
+		t.Errorf("Expected no corridor without factors, got %f", got)
+	}
+}
+
+func TestProjectAccountValue(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.001
+	}
+
+	// One year by hand: 1000 in less 5%, less 10 admin, leaves 940. The
+	// corridor needs 2.5 x 940 = 2350, under the 100000 sum assured, so the
+	// amount at risk is 99060 and costs 99.06. 840.94 earns 4%.
+	policy := UniversalLifePolicy{Age: 35, Term: 1, SumAssured: 100000, Premiums: []float64{1000}, CreditedRate: 0.04, PremiumLoad: 0.05, AdminCharge: 10}
+	year := ProjectAccountValue(policy, mortalityTable).Years[0]
+	if !floatEquals(year.CostOfInsurance, 99.06, 1e-9) || !floatEquals(year.AccountValue, 840.94*1.04, 1e-9) {
+		t.Errorf("Expected cost of insurance 99.06 and account value %f, got %f and %f", 840.94*1.04, year.CostOfInsurance, year.AccountValue)
+	}
+	if year.CorridorBinding || year.DeathBenefit != 100000 {
+		t.Errorf("Expected the sum assured to be the death benefit, got %f", year.DeathBenefit)
+	}
+
+	// A large single premium soon outgrows a small sum assured
+	policy = UniversalLifePolicy{Age: 45, Term: 20, SumAssured: 100000, Premiums: []float64{60000}, CreditedRate: 0.05}
+	projection := ProjectAccountValue(policy, mortalityTable)
+	if projection.FirstCorridorYear != 0 || projection.CorridorYears != 20 {
+		t.Fatalf("Expected the corridor to bind from year 0 every year, got first %d for %d years", projection.FirstCorridorYear, projection.CorridorYears)
+	}
+	for _, year := range projection.Years {
+		// The start-of-year account is what the corridor multiplies
+		accountBefore := year.AccountValue/(1+policy.CreditedRate) + year.CostOfInsurance
+		want := CorridorFactorAt(DefaultCorridorFactors, year.Age) * accountBefore
+		if !floatEquals(year.DeathBenefit, want, 1e-6) || !floatEquals(year.NetAmountAtRisk, want-accountBefore, 1e-6) {
+			t.Errorf("Year %d: expected corridor death benefit %f, got %f", year.Year, want, year.DeathBenefit)
+		}
+	}
+
+	// Without a corridor the amount at risk is only what the account doesn't cover
+	policy.CorridorFactors = []CorridorFactor{}
+	noCorridor := ProjectAccountValue(policy, mortalityTable)
+	if noCorridor.FirstCorridorYear != -1 || noCorridor.Years[0].NetAmountAtRisk != 40000 {
+		t.Errorf("Expected no corridor and 40000 at risk, got first %d and %f", noCorridor.FirstCorridorYear, noCorridor.Years[0].NetAmountAtRisk)
+	}
+	if last := len(projection.Years) - 1; projection.Years[last].AccountValue >= noCorridor.Years[last].AccountValue {
+		t.Errorf("Expected the corridor's extra cover to cost account value, got %f vs %f", projection.Years[last].AccountValue, noCorridor.Years[last].AccountValue)
+	}
+
+	// Charges with nothing paid in empty the account straight away
+	policy = UniversalLifePolicy{Age: 45, Term: 10, SumAssured: 100000, Premiums: []float64{100}, AdminCharge: 50}
+	lapsed := ProjectAccountValue(policy, mortalityTable)
+	if !lapsed.Lapsed || lapsed.LapseYear != 0 || len(lapsed.Years) != 1 || math.Signbit(lapsed.Years[0].AccountValue) {
+		t.Errorf("Expected a lapse in year 0 with an empty account, got %+v", lapsed)
+	}
+}
+
+func TestSolveEquivalentLevelPremium(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = 0.002
+	}
+	policy := UniversalLifePolicy{Age: 40, Term: 10, SumAssured: 100000, Premiums: []float64{5000, 0, 3000, 0, 2000}, CreditedRate: 0.04, PremiumLoad: 0.05, AdminCharge: 20}
+
+	// The level premium ends the term with the same account
+	solved, err := SolveEquivalentLevelPremium(policy, mortalityTable, "", DefaultSolverConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solved.Target != LevelPremiumTerminalValue || solved.LevelPremium <= 0 || solved.LevelPremium >= 10000.0/10*2 {
+		t.Fatalf("Expected a level premium between nothing and twice the average, got %+v", solved)
+	}
+	if got, want := solved.Level.TerminalAccountValue(), solved.Irregular.TerminalAccountValue(); !floatEquals(got, want, 1e-6) {
+		t.Errorf("Expected a terminal account of %f, got %f", want, got)
+	}
+
+	// Paying a level premium the same amount as the sent one gives it back
+	level := policy
+	level.Premiums = []float64{1200, 1200, 1200, 1200, 1200, 1200, 1200, 1200, 1200, 1200}
+	if same, err := SolveEquivalentLevelPremium(level, mortalityTable, "", DefaultSolverConfig()); err != nil || !floatEquals(same.LevelPremium, 1200, 1e-6) {
+		t.Errorf("Expected 1200 back, got %f (%v)", same.LevelPremium, err)
+	}
+
+	// Premiums that run out can only be matched by staying in force, and the
+	// least premium that does so keeps the account just above nothing
+	policy.Premiums = []float64{300}
+	if _, err := SolveEquivalentLevelPremium(policy, mortalityTable, LevelPremiumTerminalValue, DefaultSolverConfig()); err == nil {
+		t.Error("Expected a lapsing stream to have no terminal value to match")
+	}
+	inForce, err := SolveEquivalentLevelPremium(policy, mortalityTable, LevelPremiumInForce, DefaultSolverConfig())
+	if err != nil || inForce.Level.Lapsed || !inForce.Irregular.Lapsed {
+		t.Fatalf("Expected the level premium to stay in force, got %+v (%v)", inForce, err)
+	}
+	short := policy
+	short.Premiums = []float64{inForce.LevelPremium - 0.01}
+	for range policy.Term - 1 {
+		short.Premiums = append(short.Premiums, inForce.LevelPremium-0.01)
+	}
+	if !ProjectAccountValue(short, mortalityTable).Lapsed {
+		t.Errorf("Expected a cent less than %f to lapse", inForce.LevelPremium)
+	}
+}
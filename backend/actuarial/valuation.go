@@ -0,0 +1,162 @@
+package actuarial
+
+import (
+	"fmt"
+	"math"
+)
+
+// GrossPremiumValuation is the gross premium reserve at each duration from
+// issue: the value of future claims and expenses less future gross premiums.
+// Unlike the net premium reserve it counts the expenses actually expected and
+// the premium actually charged, so on a best-estimate table it shows whether
+// the net premium reserve is enough. Where the gross premium reserve is the
+// larger, the net premium reserve is deficient.
+//
+// Timing matches the net premium reserve: premiums and expenses at the start
+// of each year, claims at the end. The initial expense only counts at issue.
+func GrossPremiumValuation(policy *Policy, mortalityTable MortalityTable, grossPremium float64, expenses ExpenseStructure) []float64 {
+	years := policy.Term
+	if policy.ProductType == "whole_life" {
+		years, _ = WholeLifeHorizon(policy, mortalityTable)
+	}
+	if years < 0 {
+		return nil
+	}
+	premiumYears := PremiumPayingYears(policy)
+
+	reserveSchedule := make([]float64, years+1)
+	for currentYear := 0; currentYear <= years; currentYear++ {
+		if currentYear == years && policy.ProductType != "whole_life" {
+			// At maturity the reserve is exactly what is about to be paid out
+			reserveSchedule[currentYear] = policy.MaturityBenefit
+			continue
+		}
+
+		value := 0.0
+		if currentYear == 0 {
+			value += SumAssuredInYear(policy, 0) * expenses.InitialExpenseRate
+		}
+		chanceInForce := 1.0
+		for futureYear := 0; currentYear+futureYear < years; futureYear++ {
+			age := policy.Age + currentYear + futureYear
+			if age >= len(mortalityTable) {
+				break
+			}
+
+			outgo := expenses.MaintenanceExpense
+			if currentYear+futureYear < premiumYears {
+				outgo += grossPremium*expenses.RenewalExpenseRate - grossPremium
+			}
+			value += chanceInForce * discount(policy, outgo, futureYear)
+			value += chanceInForce * mortalityTable[age] * deathBenefitValue(policy, SumAssuredInYear(policy, currentYear+futureYear), futureYear+1)
+
+			chanceInForce *= (1 - mortalityTable[age]) * (1 - lapseRateForYear(policy, currentYear+futureYear))
+		}
+		value += maturityBenefitValue(policy, mortalityTable, currentYear)
+
+		reserveSchedule[currentYear] = value
+	}
+	return reserveSchedule
+}
+
+// ReserveDeficiency is how far the gross premium reserve exceeds the net
+// premium reserve at each duration, and 0 where the net reserve is enough.
+func ReserveDeficiency(netPremiumReserves, grossPremiumReserves []float64) []float64 {
+	deficiency := make([]float64, min(len(netPremiumReserves), len(grossPremiumReserves)))
+	for year := range deficiency {
+		deficiency[year] = max(0, grossPremiumReserves[year]-netPremiumReserves[year])
+	}
+	return deficiency
+}
+
+// Ways of interpolating a reserve between policy anniversaries
+const (
+	InterpolateLinear          = "linear"           // (1-s) x tV + s x (t+1)V
+	InterpolatePremiumAdjusted = "premium_adjusted" // (1-s) x (tV + P) + s x (t+1)V, the default
+)
+
+// FractionalReserve is the reserve part of the way through a policy year
+type FractionalReserve struct {
+	Duration        float64 `json:"duration"` // Years since issue
+	Method          string  `json:"method"`
+	Reserve         float64 `json:"reserve"`
+	ReserveAtStart  float64 `json:"reserve_at_start"`           // tV, at the anniversary before, ahead of that year's premium
+	ReserveAtEnd    float64 `json:"reserve_at_end"`             // (t+1)V, at the anniversary after
+	UnearnedPremium float64 `json:"unearned_premium,omitempty"` // (1-s) x P: the part of the year's premium not yet used up
+}
+
+// ReserveAtDuration interpolates a reserve schedule, as CalculateReserveSchedule
+// returns it, to a duration between anniversaries, e.g. for a surrender
+// part-way through a year. duration counts years from issue.
+//
+// A premium is paid at the start of each year and a death benefit at the end,
+// so straight-line interpolation understates the reserve early in the year:
+// just after an anniversary the fund holds tV plus the premium just paid,
+// most of which has not yet been used for cover. The premium adjusted method
+// adds the unearned part of the net premium, (1-s) x P, where s is the part
+// of the year gone. At an anniversary both give tV.
+func ReserveAtDuration(policy *Policy, reserveSchedule []float64, netPremium float64, duration float64, method string) (FractionalReserve, error) {
+	if method == "" {
+		method = InterpolatePremiumAdjusted
+	}
+	if method != InterpolateLinear && method != InterpolatePremiumAdjusted {
+		return FractionalReserve{}, fmt.Errorf("unknown reserve interpolation '%s'", method)
+	}
+	firstDuration := float64(policy.InForceDuration)
+	lastDuration := firstDuration + float64(len(reserveSchedule)-1)
+	if len(reserveSchedule) == 0 || duration < firstDuration || duration > lastDuration {
+		return FractionalReserve{}, fmt.Errorf("duration must be between %g and %g", firstDuration, lastDuration)
+	}
+
+	year := int(math.Floor(duration))
+	yearGone := duration - float64(year)
+	index := year - policy.InForceDuration
+	result := FractionalReserve{Duration: duration, Method: method, ReserveAtStart: reserveSchedule[index], ReserveAtEnd: reserveSchedule[index]}
+	if yearGone == 0 {
+		result.Reserve = result.ReserveAtStart
+		return result, nil
+	}
+
+	result.ReserveAtEnd = reserveSchedule[index+1]
+	if method == InterpolatePremiumAdjusted && year < PremiumPayingYears(policy) {
+		result.UnearnedPremium = (1 - yearGone) * netPremium
+	}
+	result.Reserve = (1-yearGone)*result.ReserveAtStart + result.UnearnedPremium + yearGone*result.ReserveAtEnd
+	return result, nil
+}
+
+// PaidUp is when a policy's reserve alone would pay for the rest of its cover
+type PaidUp struct {
+	Duration         *int      `json:"duration"`            // Years from issue the reserve first covers every future claim; nil if it never does
+	CostOfCover      []float64 `json:"cost_of_cover"`       // Single premium for the remaining cover at each duration
+	PaidUpSumAssured []float64 `json:"paid_up_sum_assured"` // Cover the reserve alone buys at each duration
+}
+
+// PaidUpDuration finds the earliest duration at which a policy becomes paid-up:
+// the reserve, with no further premiums, is enough to pay for every death
+// benefit still to come on the same basis. The cost of the remaining cover is
+// the prospective reserve of the same policy with no premiums; where the
+// reserve falls short, it buys that fraction of the sum assured as reduced
+// paid-up cover.
+//
+// reserveSchedule is as CalculateReserveSchedule returns it, starting at the
+// policy's in-force duration. Durations with no cover left to pay for, such as
+// the end of the table, never count as paid-up.
+func PaidUpDuration(policy *Policy, mortalityTable MortalityTable, reserveSchedule []float64) PaidUp {
+	singlePremium := *policy
+	singlePremium.ReserveApproach = ReserveProspective
+	costOfCover := CalculateReserveSchedule(&singlePremium, mortalityTable, 0)
+
+	result := PaidUp{CostOfCover: costOfCover, PaidUpSumAssured: make([]float64, min(len(costOfCover), len(reserveSchedule)))}
+	for year := range result.PaidUpSumAssured {
+		if costOfCover[year] <= 0 {
+			continue
+		}
+		result.PaidUpSumAssured[year] = min(1, max(0, reserveSchedule[year]/costOfCover[year])) * policy.CoverageAmount
+		if result.Duration == nil && reserveSchedule[year] >= costOfCover[year]*(1-1e-9) {
+			duration := policy.InForceDuration + year
+			result.Duration = &duration
+		}
+	}
+	return result
+}
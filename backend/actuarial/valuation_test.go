@@ -0,0 +1,253 @@
+package actuarial
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGrossPremiumValuation(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = min(0.001+float64(age)*0.0005, 1)
+	}
+	noExpenses := ExpenseStructure{}
+
+	// With no expenses and the net premium charged, the gross premium reserve
+	// is the net premium reserve
+	policies := []*Policy{
+		{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life", LapseRate: 0.05, MaturityBenefit: 10000},
+		{Age: 60, Term: 10, CoverageAmount: 100000, InterestRate: 0.04, ProductType: "whole_life"},
+	}
+	for _, policy := range policies {
+		netPremium := CalculateNetPremium(policy, mortalityTable)
+		netReserves := CalculateReserveSchedule(policy, mortalityTable, netPremium)
+		grossReserves := GrossPremiumValuation(policy, mortalityTable, netPremium, noExpenses)
+		if len(grossReserves) != len(netReserves) {
+			t.Fatalf("%s: expected %d reserves, got %d", policy.ProductType, len(netReserves), len(grossReserves))
+		}
+		for year := range netReserves {
+			if !floatEquals(grossReserves[year], netReserves[year], 1e-6) {
+				t.Errorf("%s year %d: expected %f, got %f", policy.ProductType, year, netReserves[year], grossReserves[year])
+			}
+		}
+	}
+
+	// Priced with expenses and a margin, the policy is adequate on its own table...
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+	expenses := CreateDefaultExpenses()
+	netPremium := CalculateNetPremium(policy, mortalityTable)
+	grossPremium := CalculateGrossPremium(policy, mortalityTable, netPremium, expenses)
+	netReserves := CalculateReserveSchedule(policy, mortalityTable, netPremium)
+	grossReserves := GrossPremiumValuation(policy, mortalityTable, grossPremium, expenses)
+	if grossReserves[0] >= 0 {
+		t.Errorf("Expected the profit margin to show as a negative reserve at issue, got %f", grossReserves[0])
+	}
+
+	// ...but not if mortality turns out twice as heavy
+	heavier := make(MortalityTable, len(mortalityTable))
+	for age, rate := range mortalityTable {
+		heavier[age] = rate * 2
+	}
+	deficiency := ReserveDeficiency(netReserves, GrossPremiumValuation(policy, heavier, grossPremium, expenses))
+	deficient := false
+	for _, shortfall := range deficiency {
+		if shortfall < 0 {
+			t.Fatalf("Deficiency should never be negative, got %f", shortfall)
+		}
+		deficient = deficient || shortfall > 0
+	}
+	if !deficient {
+		t.Error("Expected doubled mortality to leave the net premium reserve deficient")
+	}
+}
+
+func TestReserveAtDuration(t *testing.T) {
+	policy := &Policy{Age: 40, Term: 3, ProductType: "term_life"}
+	schedule := []float64{0, 100, 150, 0}
+	netPremium := 80.0
+
+	for _, tc := range []struct {
+		duration float64
+		method   string
+		expected float64
+	}{
+		{1, "", 100}, // Anniversaries are the schedule itself
+		{1.25, InterpolateLinear, 0.75*100 + 0.25*150},      // Straight line
+		{1.25, "", 0.75*(100+80) + 0.25*150},                // Plus the unearned premium
+		{2.5, InterpolatePremiumAdjusted, 0.5 * (150 + 80)}, // Down to nothing at expiry
+		{3, "", 0},
+	} {
+		reserve, err := ReserveAtDuration(policy, schedule, netPremium, tc.duration, tc.method)
+		if err != nil {
+			t.Fatalf("%g %s: %v", tc.duration, tc.method, err)
+		}
+		if !floatEquals(reserve.Reserve, tc.expected, 1e-9) {
+			t.Errorf("%g %s: expected %f, got %f", tc.duration, tc.method, tc.expected, reserve.Reserve)
+		}
+	}
+
+	// No premium is due after the premium paying term
+	limitedPay := *policy
+	limitedPay.PremiumPayingTerm = 1
+	if reserve, _ := ReserveAtDuration(&limitedPay, schedule, netPremium, 1.5, ""); reserve.UnearnedPremium != 0 {
+		t.Errorf("Expected no unearned premium after the paying term, got %f", reserve.UnearnedPremium)
+	}
+
+	// An in-force schedule starts at the current duration
+	inForce := *policy
+	inForce.InForceDuration = 1
+	if reserve, err := ReserveAtDuration(&inForce, schedule[1:], netPremium, 1.25, InterpolateLinear); err != nil || !floatEquals(reserve.Reserve, 112.5, 1e-9) {
+		t.Errorf("Expected 112.5 from the in-force schedule, got %f (%v)", reserve.Reserve, err)
+	}
+	for _, duration := range []float64{0.5, 4} {
+		if _, err := ReserveAtDuration(&inForce, schedule[1:], netPremium, duration, ""); err == nil {
+			t.Errorf("Expected duration %g outside the schedule to fail", duration)
+		}
+	}
+	if _, err := ReserveAtDuration(policy, schedule, netPremium, 1, "cubic"); err == nil {
+		t.Error("Expected an unknown method to fail")
+	}
+}
+
+func TestModifiedReserves(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = min(0.001+float64(age)*0.0005, 1)
+	}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+	level := CalculateFullPremium(policy, mortalityTable)
+
+	// Full preliminary term: the first premium only buys the first year's cover
+	basis := DefaultBasis(policy, mortalityTable)
+	basis.ReserveMethod = FullPreliminaryTermReserve
+	fpt := CalculateFullPremiumWithBasis(policy, basis)
+	modified := fpt.ModifiedReserve
+	if modified == nil || modified.AllowanceCap != nil || modified.ExpenseAllowance != modified.UncappedAllowance {
+		t.Fatalf("Expected an uncapped allowance, got %+v", modified)
+	}
+	if !floatEquals(modified.FirstYearPremium, mortalityTable[40]*policy.CoverageAmount/1.05, 1e-9) {
+		t.Errorf("Expected alpha to be the first year's cover, got %f", modified.FirstYearPremium)
+	}
+	if !floatEquals(fpt.ReserveSchedule[0], 0, 1e-6) || !floatEquals(fpt.ReserveSchedule[1], 0, 1e-6) {
+		t.Errorf("Expected no reserve at issue or after year one, got %f and %f", fpt.ReserveSchedule[0], fpt.ReserveSchedule[1])
+	}
+	for year := 1; year < policy.Term; year++ {
+		if fpt.ReserveSchedule[year] > level.ReserveSchedule[year] {
+			t.Errorf("Year %d: expected the modified reserve below the level one, got %f vs %f", year, fpt.ReserveSchedule[year], level.ReserveSchedule[year])
+		}
+	}
+
+	// A cap of 0 leaves the level net premium reserve
+	noAllowance := 0.0
+	basis.ExpenseAllowanceCap = &noAllowance
+	capped := CalculateFullPremiumWithBasis(policy, basis)
+	for year, reserve := range capped.ReserveSchedule {
+		if !floatEquals(reserve, level.ReserveSchedule[year], 1e-6) {
+			t.Errorf("Year %d: expected the level reserve with no allowance, got %f vs %f", year, reserve, level.ReserveSchedule[year])
+		}
+	}
+
+	// CRVM caps a short-pay whole life policy at the 20-pay allowance
+	tenPay := &Policy{Age: 40, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "whole_life", PremiumCeaseAge: 50}
+	crvmBasis := DefaultBasis(tenPay, mortalityTable)
+	crvmBasis.ReserveMethod = CRVMReserve
+	crvm := CalculateFullPremiumWithBasis(tenPay, crvmBasis).ModifiedReserve
+	if crvm.AllowanceCap == nil || crvm.ExpenseAllowance != *crvm.AllowanceCap || crvm.ExpenseAllowance >= crvm.UncappedAllowance {
+		t.Errorf("Expected the 20-pay cap to bind, got %+v", crvm)
+	}
+
+	// The renewal premium is never more than the gross premium
+	noExpenses := DefaultBasis(policy, mortalityTable)
+	noExpenses.ReserveMethod = FullPreliminaryTermReserve
+	noExpenses.Expenses = ExpenseStructure{}
+	limited := CalculateFullPremiumWithBasis(policy, noExpenses)
+	if !limited.ModifiedReserve.LimitedByGrossPremium || !floatEquals(limited.ModifiedReserve.RenewalPremium, limited.GrossPremium, 1e-9) {
+		t.Errorf("Expected beta held to the gross premium %f, got %+v", limited.GrossPremium, limited.ModifiedReserve)
+	}
+	if len(limited.Warnings) == 0 {
+		t.Error("Expected a warning that the allowance was cut")
+	}
+}
+
+func TestPaidUpDuration(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = min(0.001+float64(age)*0.0005, 1)
+	}
+	mortalityTable[100] = 1
+
+	// Paid for 20 years, the reserve takes over exactly when premiums stop
+	policy := &Policy{Age: 45, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "whole_life"}
+	reserves := CalculateReserveSchedule(policy, mortalityTable, CalculateNetPremium(policy, mortalityTable))
+	paidUp := PaidUpDuration(policy, mortalityTable, reserves)
+	if paidUp.Duration == nil || *paidUp.Duration != 20 {
+		t.Fatalf("Expected the policy to be paid-up after 20 years, got %v", paidUp.Duration)
+	}
+	if !floatEquals(paidUp.PaidUpSumAssured[20], 100000, 1e-6) {
+		t.Errorf("Expected full paid-up cover once paid-up, got %f", paidUp.PaidUpSumAssured[20])
+	}
+	for year := 1; year < 20; year++ {
+		if paidUp.PaidUpSumAssured[year] <= paidUp.PaidUpSumAssured[year-1] || paidUp.PaidUpSumAssured[year] >= 100000 {
+			t.Errorf("Year %d: expected reduced paid-up cover to grow towards the sum assured, got %f after %f", year, paidUp.PaidUpSumAssured[year], paidUp.PaidUpSumAssured[year-1])
+		}
+	}
+
+	// In force for 5 years, durations still count from issue
+	inForce := *policy
+	inForce.InForceDuration = 5
+	inForceReserves := CalculateReserveSchedule(&inForce, mortalityTable, CalculateNetPremium(&inForce, mortalityTable))
+	if paidUp := PaidUpDuration(&inForce, mortalityTable, inForceReserves); paidUp.Duration == nil || *paidUp.Duration != 20 {
+		t.Errorf("Expected an in-force policy to be paid-up 20 years from issue, got %v", paidUp.Duration)
+	}
+
+	// Premiums for life never leave the reserve to do it alone
+	lifetime := &Policy{Age: 45, Term: 55, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "whole_life"}
+	lifetimeReserves := CalculateReserveSchedule(lifetime, mortalityTable, CalculateNetPremium(lifetime, mortalityTable))
+	if paidUp := PaidUpDuration(lifetime, mortalityTable, lifetimeReserves); paidUp.Duration != nil {
+		t.Errorf("Expected premiums for life never to be paid-up, got %d", *paidUp.Duration)
+	}
+}
+
+func TestFulfilmentCashFlows(t *testing.T) {
+	mortalityTable := make(MortalityTable, 101)
+	for age := range mortalityTable {
+		mortalityTable[age] = min(0.001+float64(age)*0.0005, 1)
+	}
+	expenses := ExpenseStructure{InitialExpenseRate: 0.02, RenewalExpenseRate: 0.05, MaintenanceExpense: 40}
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.04, LapseRate: 0.03, MaturityBenefit: 10000, ProductType: "term_life"}
+	grossReserves := GrossPremiumValuation(policy, mortalityTable, 900, expenses)
+
+	// The best-estimate liability is the gross premium reserve, split into its parts
+	cashFlows := CalculateFulfilmentCashFlows(policy, mortalityTable, 900, expenses)
+	if !floatEquals(cashFlows.BestEstimateLiability, grossReserves[0], 1e-6) {
+		t.Errorf("Expected the gross premium reserve %f, got %f", grossReserves[0], cashFlows.BestEstimateLiability)
+	}
+	if cashFlows.PremiumValue <= 0 || cashFlows.ClaimsValue <= 0 || cashFlows.ExpenseValue <= 0 {
+		t.Errorf("Expected every part to have a value, got %+v", cashFlows)
+	}
+	policy.InForceDuration = 5
+	if inForce := CalculateFulfilmentCashFlows(policy, mortalityTable, 900, expenses); !floatEquals(inForce.BestEstimateLiability, grossReserves[5], 1e-6) {
+		t.Errorf("Expected the gross premium reserve at 5 years %f, got %f", grossReserves[5], inForce.BestEstimateLiability)
+	}
+
+	// One year of cover by hand: the claim is paid with chance q, so its
+	// present value has standard deviation S v sqrt(q(1-q))
+	oneYear := &Policy{Age: 40, Term: 1, CoverageAmount: 100000, InterestRate: 0.04}
+	q := mortalityTable[40]
+	cashFlows = CalculateFulfilmentCashFlows(oneYear, mortalityTable, 0, ExpenseStructure{})
+	if want := 100000 / 1.04 * math.Sqrt(q*(1-q)); !floatEquals(cashFlows.ClaimsStandardDeviation, want, 1e-6) {
+		t.Errorf("Expected a claims standard deviation of %f, got %f", want, cashFlows.ClaimsStandardDeviation)
+	}
+
+	if ra := RiskAdjustment(cashFlows, RiskAdjustmentConfig{Percentage: 0.1}); !floatEquals(ra, 0.1*cashFlows.ClaimsValue, 1e-9) {
+		t.Errorf("Expected 10%% of the claims value, got %f", ra)
+	}
+	// The 84.13th percentile of a normal is one standard deviation above the mean
+	ra := RiskAdjustment(cashFlows, RiskAdjustmentConfig{Method: RiskAdjustmentConfidenceLevel, ConfidenceLevel: 0.841344746})
+	if !floatEquals(ra, cashFlows.ClaimsStandardDeviation, 1e-3) {
+		t.Errorf("Expected one standard deviation, got %f vs %f", ra, cashFlows.ClaimsStandardDeviation)
+	}
+	if (RiskAdjustmentConfig{Method: RiskAdjustmentConfidenceLevel, ConfidenceLevel: 1}).Validate() == nil {
+		t.Error("Expected a 100% confidence level to be rejected")
+	}
+}
@@ -0,0 +1,127 @@
+package actuarial
+
+import (
+	"fmt"
+	"math"
+)
+
+// CalculationWarnings lists the caveats on a result: assumptions the
+// calculation made and soft edge cases it ran into that the numbers alone
+// don't show. None of them stops a calculation - anything that should is a
+// validation error - but each means a figure may not be what the caller
+// expects. policy is as sent, before the basis was applied.
+func CalculationWarnings(policy *Policy, basis Basis, result PremiumCalculation) []string {
+	var warnings []string
+	warnings = append(warnings, interestWarnings(policy, basis)...)
+	warnings = append(warnings, tableWarnings(policy, basis.Mortality)...)
+
+	if rate, ok := result.RiskAssessment["adjusted_mortality_rate"]; ok && rate == 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"qx is 0 at age %d, so the risk multiplier and expected lifetime are left out of the risk assessment", policy.Age))
+	}
+	warnings = append(warnings, reserveWarnings(result.ReserveSchedule)...)
+	if result.ModifiedReserve != nil && result.ModifiedReserve.LimitedByGrossPremium {
+		warnings = append(warnings, fmt.Sprintf(
+			"the expense allowance was cut to %.2f to keep the modified renewal premium within the gross premium",
+			result.ModifiedReserve.ExpenseAllowance))
+	}
+	if policy.FirstYearFeeWaiver == FeeWaiverRespread && result.FeeWaiver == FeeWaiverAbsorbed {
+		warnings = append(warnings, "there are no renewal premiums to respread the waived fee over, so it is absorbed")
+	}
+	if basis.ZeroPremium == ZeroPremiumWarn && result.ReserveSchedule != nil && result.NetPremium <= 0 {
+		warnings = append(warnings, "the net premium is zero, so the gross premium covers expenses alone")
+	}
+	if result.CashRefundIterations > 0 && !result.CashRefundConverged {
+		warnings = append(warnings, fmt.Sprintf(
+			"the cash refund price had not settled after %d iterations; the last price is shown", result.CashRefundIterations))
+	}
+	return warnings
+}
+
+// interestWarnings notes when the rate the policy was sent with is not the
+// one it was valued at
+func interestWarnings(policy *Policy, basis Basis) []string {
+	if len(policy.YieldCurve) > 0 {
+		if policy.InterestRate != 0 {
+			return []string{fmt.Sprintf("interest_rate %g is ignored; the yield curve is used instead", policy.InterestRate)}
+		}
+		return nil
+	}
+	var warnings []string
+	if basis.InterestRate != policy.InterestRate {
+		warnings = append(warnings, fmt.Sprintf(
+			"valued at the basis interest rate %g, not the policy's %g", basis.InterestRate, policy.InterestRate))
+	}
+	compounding := policy.CompoundingConvention
+	if basis.CompoundingConvention != "" {
+		compounding = basis.CompoundingConvention
+	}
+	if compounding == ContinuousCompounding {
+		warnings = append(warnings, fmt.Sprintf(
+			"the interest rate %g is read as a force of interest (continuous compounding), an effective rate of %.4g",
+			basis.InterestRate, math.Exp(basis.InterestRate)-1))
+	}
+	return warnings
+}
+
+// tableWarnings notes the years a policy needs that the mortality table
+// doesn't have
+func tableWarnings(policy *Policy, mortalityTable MortalityTable) []string {
+	lastAge := len(mortalityTable) - 1
+	switch policy.ProductType {
+	case "", "term_life":
+		return termBeyondTableWarnings(policy, len(mortalityTable))
+	case "deferred_annuity":
+		if policy.Age+policy.DeferralPeriod >= lastAge {
+			return []string{fmt.Sprintf(
+				"the deferral period runs past age %d, the last in the table, so no payments are valued", lastAge)}
+		}
+	case "whole_life":
+		if policy.MortalityExtrapolation != "" {
+			return nil
+		}
+	}
+	if lastAge < 0 || policy.Age >= lastAge || mortalityTable[lastAge] >= 1 {
+		return nil
+	}
+	chanceReachingEnd := calculateSurvivalProbability(policy.Age, lastAge-policy.Age, mortalityTable)
+	if chanceReachingEnd <= 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf(
+		"the table stops at age %d with qx below 1; the %.4g%% of lives still alive then are assumed to receive nothing more",
+		lastAge, 100*chanceReachingEnd)}
+}
+
+// termBeyondTableWarnings notes a term that runs past the table's last age.
+// The loops stop at the end of the table, so those years are neither
+// charged for nor paid out on.
+func termBeyondTableWarnings(policy *Policy, tableLength int) []string {
+	if policy.Age+policy.Term <= tableLength {
+		return nil
+	}
+	return []string{fmt.Sprintf(
+		"the %d year term runs past age %d, the last in the table; the %d years beyond it are not valued",
+		policy.Term, tableLength-1, policy.Age+policy.Term-tableLength)}
+}
+
+// reserveWarnings notes negative reserves. They mean the policyholder owes
+// the insurer, which can't be collected, and usually point at a front-loaded
+// premium or an unusual basis; they are shown as calculated, not floored.
+func reserveWarnings(reserveSchedule []float64) []string {
+	negativeYears, firstYear := 0, -1
+	for year, reserve := range reserveSchedule {
+		if reserve < -1e-9 {
+			negativeYears++
+			if firstYear < 0 {
+				firstYear = year
+			}
+		}
+	}
+	if negativeYears == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf(
+		"the reserve is negative in %d of %d years, first at year %d; negative reserves are shown as calculated, not set to 0",
+		negativeYears, len(reserveSchedule), firstYear)}
+}
@@ -0,0 +1,65 @@
+package actuarial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalculationWarnings(t *testing.T) {
+	closedTable := make(MortalityTable, 101)
+	for age := range closedTable {
+		closedTable[age] = 0.001 + float64(age)*0.0005
+	}
+	closedTable[100] = 1
+
+	hasWarning := func(warnings []string, fragment string) bool {
+		for _, warning := range warnings {
+			if strings.Contains(warning, fragment) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Nothing unusual, nothing to say
+	policy := &Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05}
+	if warnings := CalculateFullPremium(policy, closedTable).Warnings; len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		table    MortalityTable
+		fragment string
+	}{
+		{"term past the table", Policy{Age: 90, Term: 20, CoverageAmount: 1000, InterestRate: 0.05}, closedTable, "runs past age 100"},
+		{"zero qx", Policy{Age: 40, Term: 10, CoverageAmount: 1000, InterestRate: 0.05}, make(MortalityTable, 101), "qx is 0 at age 40"},
+		{"yield curve", Policy{Age: 40, Term: 10, CoverageAmount: 1000, InterestRate: 0.05, YieldCurve: YieldCurve{0.04}}, closedTable, "interest_rate 0.05 is ignored"},
+		{"continuous", Policy{Age: 40, Term: 10, CoverageAmount: 1000, InterestRate: 0.05, CompoundingConvention: ContinuousCompounding}, closedTable, "force of interest"},
+		{"open table", Policy{Age: 60, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "immediate_annuity"}, closedTable[:90], "stops at age 89"},
+		{"deferral past the table", Policy{Age: 60, CoverageAmount: 1000, InterestRate: 0.05, ProductType: "deferred_annuity", DeferralPeriod: 45}, closedTable, "no payments are valued"},
+	}
+	for _, tt := range tests {
+		if warnings := CalculateFullPremium(&tt.policy, tt.table).Warnings; !hasWarning(warnings, tt.fragment) {
+			t.Errorf("%s: expected a warning containing %q, got %v", tt.name, tt.fragment, warnings)
+		}
+	}
+
+	// Mortality falling with age front-loads the level premium, so later reserves go negative
+	fallingTable := make(MortalityTable, 101)
+	for age := range fallingTable {
+		fallingTable[age] = 0.05 - float64(age)*0.0004
+	}
+	policy = &Policy{Age: 30, Term: 20, CoverageAmount: 100000, InterestRate: 0.05}
+	if warnings := CalculateFullPremium(policy, fallingTable).Warnings; !hasWarning(warnings, "reserve is negative") {
+		t.Errorf("Expected a negative reserve warning, got %v", warnings)
+	}
+
+	// A different basis rate is noted
+	basis := DefaultBasis(policy, closedTable)
+	basis.InterestRate = 0.03
+	if warnings := CalculateFullPremiumWithBasis(policy, basis).Warnings; !hasWarning(warnings, "basis interest rate 0.03") {
+		t.Errorf("Expected a basis interest warning, got %v", warnings)
+	}
+}
@@ -2,10 +2,17 @@ package handlers
 
 import (
 	"actuworry/backend/actuarial"
+	"actuworry/backend/middleware"
 	"actuworry/backend/models"
 	"actuworry/backend/services"
+	"actuworry/backend/version"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
 )
 
 type ActuarialHandler struct {
@@ -22,16 +29,83 @@ func (h *ActuarialHandler) CalculatePremium(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	var policy models.Policy
-	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &policy) {
+		return
+	}
+	result, err := h.service.QuotePremium(&policy)
+	if errors.Is(err, services.ErrQuoteNotSaved) {
+		sendError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	result, err := h.service.CalculatePremium(&policy)
 	if err != nil {
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	sendJSON(w, result, http.StatusOK)
+	sendJSON(w, presentPremium(result), http.StatusOK)
+}
+
+// GetQuote returns a stored quote by the ID its calculation reported
+func (h *ActuarialHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	quote, err := h.service.GetQuote(r.PathValue("id"))
+	switch {
+	case errors.Is(err, services.ErrQuotesDisabled), errors.Is(err, services.ErrQuoteNotFound):
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	quote.Result = presentPremium(quote.Result)
+	sendJSON(w, quote, http.StatusOK)
+}
+
+// QuotePDF prices a policy and returns the quote as a branded PDF download
+func (h *ActuarialHandler) QuotePDF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var policy models.Policy
+	if !parseJSON(w, r, &policy) {
+		return
+	}
+	document, result, err := h.service.QuotePDF(&policy)
+	if errors.Is(err, services.ErrQuoteNotSaved) || errors.Is(err, services.ErrQuoteNotRendered) {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, quoteFilename(result.QuoteID, policy.PolicyID)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(document)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(document)
+}
+
+// quoteFilename names a PDF quote after its quote ID, or else the policy ID,
+// keeping only characters that are safe in a filename
+func quoteFilename(quoteID, policyID string) string {
+	id := quoteID
+	if id == "" {
+		id = policyID
+	}
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return -1
+	}, id)
+	if safe == "" {
+		return "quote.pdf"
+	}
+	return "quote-" + safe + ".pdf"
 }
 
 func (h *ActuarialHandler) CalculateBatch(w http.ResponseWriter, r *http.Request) {
@@ -39,17 +113,111 @@ func (h *ActuarialHandler) CalculateBatch(w http.ResponseWriter, r *http.Request
 		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	page, pageSize, paginate, err := parsePagination(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	var request models.BatchCalculationRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &request) {
 		return
 	}
-	result, err := h.service.CalculateBatch(request.Policies)
+	result, err := h.service.CalculateBatch(request)
 	if err != nil {
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	sendJSON(w, result, http.StatusOK)
+	// A validation report has no results to page through
+	if paginate && !request.ValidateOnly {
+		result = paginateBatch(result, page, pageSize)
+	}
+	sendJSON(w, presentBatch(result), http.StatusOK)
+}
+
+func (h *ActuarialHandler) CalculateGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.GroupQuoteRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.CalculateGroup(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentGroup(result), http.StatusOK)
+}
+
+func (h *ActuarialHandler) CalculateExperienceRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.ExperienceRefundRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.CalculateExperienceRefund(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentExperienceRefund(result), http.StatusOK)
+}
+
+// CalculateBundle prices a main policy with spouse and child riders as one premium
+func (h *ActuarialHandler) CalculateBundle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.BundleRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.CalculateBundle(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentBundle(result), http.StatusOK)
+}
+
+func (h *ActuarialHandler) CalculateJointLife(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.JointLifeRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.CalculateJointLife(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentJointLife(result), http.StatusOK)
+}
+
+func (h *ActuarialHandler) EquivalentLevelPremium(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.LevelPremiumRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.EquivalentLevelPremium(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentLevelPremium(result), http.StatusOK)
 }
 
 func (h *ActuarialHandler) SensitivityAnalysis(w http.ResponseWriter, r *http.Request) {
@@ -58,8 +226,7 @@ func (h *ActuarialHandler) SensitivityAnalysis(w http.ResponseWriter, r *http.Re
 		return
 	}
 	var request models.SensitivityAnalysisRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &request) {
 		return
 	}
 	result, err := h.service.SensitivityAnalysis(request)
@@ -67,7 +234,7 @@ func (h *ActuarialHandler) SensitivityAnalysis(w http.ResponseWriter, r *http.Re
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	sendJSON(w, result, http.StatusOK)
+	sendJSON(w, presentSensitivity(result), http.StatusOK)
 }
 
 func (h *ActuarialHandler) PortfolioAnalysis(w http.ResponseWriter, r *http.Request) {
@@ -76,16 +243,158 @@ func (h *ActuarialHandler) PortfolioAnalysis(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	var request models.PortfolioAnalysisRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &request) {
 		return
 	}
-	result, err := h.service.PortfolioAnalysis(request.Policies)
+	result, err := h.service.PortfolioAnalysis(request)
 	if err != nil {
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	sendJSON(w, result, http.StatusOK)
+	sendJSON(w, presentPortfolio(result), http.StatusOK)
+}
+
+func (h *ActuarialHandler) BreakEvenAnalysis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var policy models.Policy
+	if !parseJSON(w, r, &policy) {
+		return
+	}
+	result, err := h.service.BreakEvenAnalysis(&policy)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentBreakEven(result), http.StatusOK)
+}
+
+// IFRS17Valuation values a policy's fulfilment cash flows and risk adjustment
+func (h *ActuarialHandler) IFRS17Valuation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.IFRS17Request
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.IFRS17Valuation(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentIFRS17(result), http.StatusOK)
+}
+
+// GrossPremiumValuation tests a policy's net premium reserve for adequacy
+func (h *ActuarialHandler) GrossPremiumValuation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.GrossPremiumValuationRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.GrossPremiumValuation(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentGrossPremiumValuation(result), http.StatusOK)
+}
+
+// EmbeddedValue values a book of policies for the shareholders
+func (h *ActuarialHandler) EmbeddedValue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.EmbeddedValueRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.EmbeddedValue(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentEmbeddedValue(result), http.StatusOK)
+}
+
+// RevalueInForce revalues in-force policies on new assumptions
+func (h *ActuarialHandler) RevalueInForce(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.RevaluationRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.RevalueInForce(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentRevaluation(result), http.StatusOK)
+}
+
+// PaidUpAnalysis returns when a whole life policy becomes paid-up
+func (h *ActuarialHandler) PaidUpAnalysis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var policy models.Policy
+	if !parseJSON(w, r, &policy) {
+		return
+	}
+	result, err := h.service.PaidUpAnalysis(&policy)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentPaidUp(result), http.StatusOK)
+}
+
+// ScenarioAnalysis reprices a policy on each of a set of interest rate scenarios
+func (h *ActuarialHandler) ScenarioAnalysis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.ScenarioAnalysisRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.ScenarioAnalysis(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentScenarioAnalysis(result), http.StatusOK)
+}
+
+// FractionalReserve returns a policy's reserve part of the way through a year
+func (h *ActuarialHandler) FractionalReserve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.FractionalReserveRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	result, err := h.service.FractionalReserve(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, presentFractionalReserve(result), http.StatusOK)
 }
 
 func (h *ActuarialHandler) GetTables(w http.ResponseWriter, r *http.Request) {
@@ -97,6 +406,91 @@ func (h *ActuarialHandler) GetTables(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, map[string]interface{}{"tables": tables, "count": len(tables)}, http.StatusOK)
 }
 
+// GetSurvivalCurve returns a table's survival curve for charting. The curve
+// starts at ?from_age and can be shifted by ?smoker_status, ?health_rating or
+// ?rating_factor, read as on a policy.
+func (h *ActuarialHandler) GetSurvivalCurve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	request := models.SurvivalCurveRequest{
+		Table:        r.PathValue("name"),
+		SmokerStatus: query.Get("smoker_status"),
+		HealthRating: query.Get("health_rating"),
+	}
+	if text := query.Get("from_age"); text != "" {
+		fromAge, err := strconv.Atoi(text)
+		if err != nil {
+			sendError(w, "from_age must be a whole number", http.StatusBadRequest)
+			return
+		}
+		request.FromAge = &fromAge
+	}
+	if text := query.Get("rating_factor"); text != "" {
+		ratingFactor, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			sendError(w, "rating_factor must be a number", http.StatusBadRequest)
+			return
+		}
+		request.RatingFactor = ratingFactor
+	}
+	curve, err := h.service.SurvivalCurve(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, curve, http.StatusOK)
+}
+
+// GetYieldCurves lists the loaded yield curves and their spot rates by term
+func (h *ActuarialHandler) GetYieldCurves(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	curves := h.service.GetYieldCurves()
+	sendJSON(w, map[string]interface{}{"curves": curves, "count": len(curves)}, http.StatusOK)
+}
+
+// GetProducts lists the supported product types and the policy fields each reads
+func (h *ActuarialHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	products := h.service.GetProducts()
+	sendJSON(w, map[string]interface{}{"products": products, "count": len(products)}, http.StatusOK)
+}
+
+// GetExpenses lists the default expenses used for each product type
+func (h *ActuarialHandler) GetExpenses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSON(w, h.service.GetDefaultExpenses(), http.StatusOK)
+}
+
+// DeriveTable registers a new table built from existing ones
+func (h *ActuarialHandler) DeriveTable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request models.DeriveTableRequest
+	if !parseJSON(w, r, &request) {
+		return
+	}
+	metadata, err := h.service.DeriveTable(request)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSON(w, metadata, http.StatusCreated)
+}
+
 func (h *ActuarialHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -106,6 +500,19 @@ func (h *ActuarialHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, map[string]interface{}{"status": "healthy", "service": "actuarial", "tables_loaded": len(tables), "tables": tables}, http.StatusOK)
 }
 
+func (h *ActuarialHandler) Version(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSON(w, models.VersionInfo{
+		Commit:    version.Commit,
+		BuildTime: version.BuildTime,
+		GoVersion: runtime.Version(),
+		Tables:    h.service.GetTableMetadata(),
+	}, http.StatusOK)
+}
+
 // v-star Advanced Features
 
 func (h *ActuarialHandler) MonteCarloSimulation(w http.ResponseWriter, r *http.Request) {
@@ -117,10 +524,9 @@ func (h *ActuarialHandler) MonteCarloSimulation(w http.ResponseWriter, r *http.R
 		NumPaths int     `json:"num_paths"`
 		Drift    float64 `json:"drift"`
 		Vol      float64 `json:"volatility"`
-		Seed     uint64  `json:"seed"`
+		Seed     uint64  `json:"seed"` // 0 draws a seed, reported back with the result
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &req) {
 		return
 	}
 	if req.NumPaths <= 0 {
@@ -144,8 +550,7 @@ func (h *ActuarialHandler) RiskAnalysis(w http.ResponseWriter, r *http.Request)
 	var req struct {
 		Losees []float64 `json:"losses"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &req) {
 		return
 	}
 	result := actuarial.ComputeRiskReport(req.Losees)
@@ -161,8 +566,7 @@ func (h *ActuarialHandler) DurationCalculator(w http.ResponseWriter, r *http.Req
 		CashFlows []float64 `json:"cash_flows"`
 		Rate      float64   `json:"rate"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &req) {
 		return
 	}
 	mac, mod, conv := actuarial.ComputeDuration(req.CashFlows, req.Rate)
@@ -179,8 +583,7 @@ func (h *ActuarialHandler) RateConverterHandler(w http.ResponseWriter, r *http.R
 		Effective   float64 `json:"effective_rate"`
 		Compounding int     `json:"compounding"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &req) {
 		return
 	}
 	if req.Compounding <= 0 {
@@ -213,8 +616,7 @@ func (h *ActuarialHandler) EndowmentCalculator(w http.ResponseWriter, r *http.Re
 		SumAssured float64 `json:"sum_assured"`
 		Rate       float64 `json:"rate"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &req) {
 		return
 	}
 	mortTable, _ := h.service.GetMortalityTable("male")
@@ -230,8 +632,7 @@ func (h *ActuarialHandler) RetrospectiveReserve(w http.ResponseWriter, r *http.R
 	var req struct {
 		Policy models.Policy `json:"policy"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &req) {
 		return
 	}
 	mortTable, _ := h.service.GetMortalityTable(req.Policy.Gender)
@@ -256,8 +657,7 @@ func (h *ActuarialHandler) BondValuation(w http.ResponseWriter, r *http.Request)
 		Years  int     `json:"years"`
 		YTM    float64 `json:"yield_to_maturity"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Invalid JSON", http.StatusBadRequest)
+	if !parseJSON(w, r, &req) {
 		return
 	}
 	result := actuarial.ValueBond(req.Face, req.Coupon, req.Years, req.YTM)
@@ -265,6 +665,23 @@ func (h *ActuarialHandler) BondValuation(w http.ResponseWriter, r *http.Request)
 }
 
 // Helpers
+
+// parseJSON decodes the request body into dst, answering 413 when the body is over
+// the size limit and 400 when it isn't valid JSON. Returns false if it replied.
+func parseJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(dst)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		sendError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	sendError(w, "Invalid JSON", http.StatusBadRequest)
+	return false
+}
+
 func sendJSON(w http.ResponseWriter, data interface{}, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -272,7 +689,5 @@ func sendJSON(w http.ResponseWriter, data interface{}, status int) {
 }
 
 func sendError(w http.ResponseWriter, message string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(models.ErrorResponse{Error: message})
+	middleware.WriteError(w, message, status)
 }
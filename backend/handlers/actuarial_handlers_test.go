@@ -0,0 +1,927 @@
+package handlers
+
+import (
+	"actuworry/backend/middleware"
+	"actuworry/backend/models"
+	"actuworry/backend/services"
+	"actuworry/backend/version"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestHandler returns a handler whose service has a small synthetic "male"
+// table loaded, so tests don't depend on the data files
+func newTestHandler(t *testing.T) *ActuarialHandler {
+	t.Helper()
+	var table strings.Builder
+	table.WriteString("age\tmx\tqx\n")
+	for age := 0; age <= 100; age++ {
+		fmt.Fprintf(&table, "%d\t0\t%f\n", age, 0.001+float64(age)*0.0005)
+	}
+	path := filepath.Join(t.TempDir(), "male.csv")
+	if err := os.WriteFile(path, []byte(table.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := services.NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+	return NewActuarialHandler(service)
+}
+
+const validPolicy = `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"product_type":"term_life"}`
+
+func TestHandlers(t *testing.T) {
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		method     string
+		body       string
+		wantStatus int
+		wantKeys   []string
+	}{
+		{"premium", handler.CalculatePremium, http.MethodPost, validPolicy, http.StatusOK, []string{"net_premium", "gross_premium", "reserve_schedule"}},
+		{"premium wrong method", handler.CalculatePremium, http.MethodGet, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"premium malformed JSON", handler.CalculatePremium, http.MethodPost, `{"age":`, http.StatusBadRequest, []string{"error"}},
+		{"premium invalid policy", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":-5,"interest_rate":0.05}`, http.StatusBadRequest, []string{"error"}},
+		{"premium unknown table", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":1000,"table_name":"martian"}`, http.StatusBadRequest, []string{"error"}},
+
+		{"batch", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `,` + validPolicy + `]}`, http.StatusOK, []string{"results", "summary"}},
+		{"batch minimum premium", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"minimum_premium":100000}}`, http.StatusOK, []string{"results"}},
+		{"batch negative minimum premium", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"minimum_premium":-1}}`, http.StatusBadRequest, []string{"error"}},
+		{"batch crvm reserves", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"reserve_method":"crvm","expense_allowance_cap":500}}`, http.StatusOK, []string{"results"}},
+		{"batch negative allowance cap", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"reserve_method":"crvm","expense_allowance_cap":-1}}`, http.StatusBadRequest, []string{"error"}},
+		{"batch allowance cap on net premium", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"expense_allowance_cap":500}}`, http.StatusBadRequest, []string{"error"}},
+		{"batch premium bands", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"premium_bands":[{"min_coverage":50000,"discount":0.05}]}}`, http.StatusOK, []string{"results"}},
+		{"batch overlapping premium bands", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"premium_bands":[{"min_coverage":50000,"discount":0.05},{"min_coverage":50000,"discount":0.08}]}}`, http.StatusBadRequest, []string{"error"}},
+		{"batch wrong method", handler.CalculateBatch, http.MethodPut, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"batch malformed JSON", handler.CalculateBatch, http.MethodPost, `{"policies":[`, http.StatusBadRequest, []string{"error"}},
+		{"batch empty", handler.CalculateBatch, http.MethodPost, `{"policies":[]}`, http.StatusBadRequest, []string{"error"}},
+		{"batch invalid policy", handler.CalculateBatch, http.MethodPost, `{"policies":[{"age":200,"term":5,"sum_assured":1000}]}`, http.StatusBadRequest, []string{"error"}},
+
+		{"sensitivity", handler.SensitivityAnalysis, http.MethodPost, `{"base_policy":` + validPolicy + `,"interest_rates":[0.03,0.07]}`, http.StatusOK, []string{"base_result", "analysis"}},
+		{"sensitivity wrong method", handler.SensitivityAnalysis, http.MethodGet, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"sensitivity malformed JSON", handler.SensitivityAnalysis, http.MethodPost, `not json`, http.StatusBadRequest, []string{"error"}},
+		{"sensitivity invalid policy", handler.SensitivityAnalysis, http.MethodPost, `{"base_policy":{"age":40,"term":-1,"sum_assured":1000}}`, http.StatusBadRequest, []string{"error"}},
+
+		{"portfolio", handler.PortfolioAnalysis, http.MethodPost, `{"policies":[` + validPolicy + `]}`, http.StatusOK, []string{"total_policies", "total_gross_premium", "product_distribution"}},
+		{"portfolio wrong method", handler.PortfolioAnalysis, http.MethodDelete, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"portfolio malformed JSON", handler.PortfolioAnalysis, http.MethodPost, `[`, http.StatusBadRequest, []string{"error"}},
+		{"portfolio bad claim rate", handler.PortfolioAnalysis, http.MethodPost, `{"policies":[` + validPolicy + `],"expected_claim_rate":2}`, http.StatusBadRequest, []string{"error"}},
+		{"portfolio empty", handler.PortfolioAnalysis, http.MethodPost, `{"policies":[]}`, http.StatusBadRequest, []string{"error"}},
+
+		{"group", handler.CalculateGroup, http.MethodPost, `{"scheme_name":"Acme","interest_rate":0.05,"census":[{"age":30,"sum_assured":100000},{"age":50,"sum_assured":300000}]}`, http.StatusOK, []string{"total_lives", "average_age", "net_rate_per_thousand", "gross_rate_per_thousand"}},
+		{"group wrong method", handler.CalculateGroup, http.MethodGet, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"group empty census", handler.CalculateGroup, http.MethodPost, `{"interest_rate":0.05,"census":[]}`, http.StatusBadRequest, []string{"error"}},
+		{"group bad life", handler.CalculateGroup, http.MethodPost, `{"interest_rate":0.05,"census":[{"age":30,"sum_assured":100000},{"age":200,"sum_assured":1000}]}`, http.StatusBadRequest, []string{"error"}},
+
+		{"experience refund", handler.CalculateExperienceRefund, http.MethodPost, `{"census":[{"age":40,"sum_assured":100000}],"actual_claims":0,"profit_share":0.5}`, http.StatusOK, []string{"expected_claims", "actual_to_expected", "refund"}},
+		{"experience refund wrong method", handler.CalculateExperienceRefund, http.MethodGet, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"experience refund empty census", handler.CalculateExperienceRefund, http.MethodPost, `{"census":[],"profit_share":0.5}`, http.StatusBadRequest, []string{"error"}},
+		{"experience refund bad profit share", handler.CalculateExperienceRefund, http.MethodPost, `{"census":[{"age":40,"sum_assured":100000}],"profit_share":1.5}`, http.StatusBadRequest, []string{"error"}},
+
+		{"gpv", handler.GrossPremiumValuation, http.MethodPost, `{"policy":` + validPolicy + `,"mortality_shock":0.2}`, http.StatusOK, []string{"gross_premium_reserve", "deficiency", "adequate"}},
+		{"reserve mid-year", handler.FractionalReserve, http.MethodPost, `{"policy":` + validPolicy + `,"duration":5.25}`, http.StatusOK, []string{"reserve", "reserve_at_start", "reserve_at_end", "unearned_premium"}},
+		{"reserve past term", handler.FractionalReserve, http.MethodPost, `{"policy":` + validPolicy + `,"duration":500}`, http.StatusBadRequest, []string{"error"}},
+		{"reserve annuity", handler.FractionalReserve, http.MethodPost, `{"policy":{"age":65,"sum_assured":1000,"product_type":"immediate_annuity"},"duration":1.5}`, http.StatusBadRequest, []string{"error"}},
+		{"gpv wrong method", handler.GrossPremiumValuation, http.MethodGet, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"gpv annuity", handler.GrossPremiumValuation, http.MethodPost, `{"policy":{"age":65,"sum_assured":1000,"product_type":"immediate_annuity"}}`, http.StatusBadRequest, []string{"error"}},
+		{"gpv bad shock", handler.GrossPremiumValuation, http.MethodPost, `{"policy":` + validPolicy + `,"mortality_shock":-1}`, http.StatusBadRequest, []string{"error"}},
+
+		{"tables", handler.GetTables, http.MethodGet, "", http.StatusOK, []string{"tables", "count"}},
+		{"tables wrong method", handler.GetTables, http.MethodPost, "{}", http.StatusMethodNotAllowed, []string{"error"}},
+
+		{"products", handler.GetProducts, http.MethodGet, "", http.StatusOK, []string{"products", "count"}},
+		{"products wrong method", handler.GetProducts, http.MethodPost, "{}", http.StatusMethodNotAllowed, []string{"error"}},
+		{"premium annuity in arrears", handler.CalculatePremium, http.MethodPost, `{"age":65,"sum_assured":1000,"interest_rate":0.05,"product_type":"immediate_annuity","annuity_timing":"immediate"}`, http.StatusOK, []string{"annuity_timing"}},
+		{"premium fractional deferral", handler.CalculatePremium, http.MethodPost, `{"age":50,"sum_assured":1000,"interest_rate":0.05,"product_type":"deferred_annuity","deferral_period":10,"deferral_fraction":0.5}`, http.StatusOK, []string{"effective_deferral"}},
+		{"premium deferral fraction of a year", handler.CalculatePremium, http.MethodPost, `{"age":50,"sum_assured":1000,"interest_rate":0.05,"product_type":"deferred_annuity","deferral_fraction":1}`, http.StatusBadRequest, []string{"error"}},
+		{"joint reversionary annuity", handler.CalculateJointLife, http.MethodPost, `{"life_1":{"age":65,"table_name":"male"},"life_2":{"age":60,"table_name":"male"},"annual_payout":1000,"interest_rate":0.04,"reversion_percentage":50}`, http.StatusOK, []string{"reversion_percentage", "single_premium"}},
+		{"joint reversion above 100%", handler.CalculateJointLife, http.MethodPost, `{"life_1":{"age":65,"table_name":"male"},"life_2":{"age":60,"table_name":"male"},"annual_payout":1000,"interest_rate":0.04,"reversion_percentage":150}`, http.StatusBadRequest, []string{"error"}},
+		{"joint reversion on equal age", handler.CalculateJointLife, http.MethodPost, `{"life_1":{"age":65,"table_name":"male"},"life_2":{"age":60,"table_name":"male"},"annual_payout":1000,"interest_rate":0.04,"method":"equal_age","reversion_percentage":50}`, http.StatusBadRequest, []string{"error"}},
+		{"joint dependent lives", handler.CalculateJointLife, http.MethodPost, `{"life_1":{"age":65,"table_name":"male"},"life_2":{"age":60,"table_name":"male"},"annual_payout":1000,"interest_rate":0.04,"reversion_percentage":50,"dependence":0.3}`, http.StatusOK, []string{"dependence", "single_premium"}},
+		{"joint dependence of 1", handler.CalculateJointLife, http.MethodPost, `{"life_1":{"age":65,"table_name":"male"},"life_2":{"age":60,"table_name":"male"},"annual_payout":1000,"interest_rate":0.04,"dependence":1}`, http.StatusBadRequest, []string{"error"}},
+		{"premium limited pay term", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"premium_paying_term":15,"sum_assured":100000,"interest_rate":0.05}`, http.StatusOK, []string{"premium_paying_years"}},
+		{"premium paying term beyond term", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"premium_paying_term":25,"sum_assured":100000,"interest_rate":0.05}`, http.StatusBadRequest, []string{"error"}},
+		{"premium renewable term", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":10,"renewable":true,"sum_assured":100000,"interest_rate":0.05}`, http.StatusOK, []string{"underwriting"}},
+		{"premium renewable whole life", handler.CalculatePremium, http.MethodPost, `{"age":40,"renewable":true,"sum_assured":100000,"interest_rate":0.05,"product_type":"whole_life"}`, http.StatusBadRequest, []string{"error"}},
+		{"premium with single premium", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"include_single_premium":true}`, http.StatusOK, []string{"single_premium", "gross_premium"}},
+		{"premium retrospective reserves", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"reserve_approach":"retrospective"}`, http.StatusOK, []string{"reserve_schedule", "reserve_approach"}},
+		{"premium unknown reserve approach", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"reserve_approach":"sideways"}`, http.StatusBadRequest, []string{"error"}},
+		{"premium generic impairment", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"impairment":"diabetic"}`, http.StatusOK, []string{"underwriting"}},
+		{"batch impairment curve", handler.CalculateBatch, http.MethodPost, `{"policies":[{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"impairment":"diabetic"}],"basis":{"underwriting":{"impairments":{"diabetic":{"rating_curve":[{"age":30,"multiplier":3},{"age":70,"multiplier":1.5}]}}}}}`, http.StatusOK, []string{"results"}},
+		{"batch impairment without data", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"underwriting":{"impairments":{"diabetic":{}}}}}`, http.StatusBadRequest, []string{"error"}},
+		{"batch impairment curve out of order", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"underwriting":{"impairments":{"diabetic":{"rating_curve":[{"age":70,"multiplier":1.5},{"age":30,"multiplier":3}]}}}}}`, http.StatusBadRequest, []string{"error"}},
+		{"premium whole life survival threshold", handler.CalculatePremium, http.MethodPost, `{"age":30,"term":20,"sum_assured":100000,"interest_rate":0.05,"product_type":"whole_life","survival_threshold":0.0001}`, http.StatusOK, []string{"horizon_years"}},
+		{"premium survival threshold on term", handler.CalculatePremium, http.MethodPost, `{"age":30,"term":20,"sum_assured":100000,"interest_rate":0.05,"survival_threshold":0.0001}`, http.StatusBadRequest, []string{"error"}},
+		{"premium cash refund annuity", handler.CalculatePremium, http.MethodPost, `{"age":65,"sum_assured":10000,"interest_rate":0.05,"product_type":"immediate_annuity","cash_refund":true}`, http.StatusOK, []string{"death_benefit_value", "cash_refund_converged"}},
+		{"premium cash refund on deferred annuity", handler.CalculatePremium, http.MethodPost, `{"age":50,"sum_assured":10000,"interest_rate":0.05,"product_type":"deferred_annuity","deferral_period":10,"cash_refund":true}`, http.StatusBadRequest, []string{"error"}},
+		{"premium warns of term past the table", handler.CalculatePremium, http.MethodPost, `{"age":90,"term":40,"sum_assured":100000,"interest_rate":0.05}`, http.StatusOK, []string{"warnings"}},
+		{"premium fee waiver", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"first_year_fee_waiver":"respread"}`, http.StatusOK, []string{"first_year_premium", "renewal_premium", "fee_waiver"}},
+		{"premium unknown fee waiver", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"first_year_fee_waiver":"forgive"}`, http.StatusBadRequest, []string{"error"}},
+		{"premium profit by year", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"include_profit_by_year":true}`, http.StatusOK, []string{"profit_by_year"}},
+		{"premium profit by year in force", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"in_force_duration":5,"include_profit_by_year":true}`, http.StatusBadRequest, []string{"error"}},
+		{"premium profit by year on annuity", handler.CalculatePremium, http.MethodPost, `{"age":65,"sum_assured":1000,"interest_rate":0.05,"product_type":"immediate_annuity","include_profit_by_year":true}`, http.StatusBadRequest, []string{"error"}},
+		{"premium settlement delay", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"settlement_delay":3}`, http.StatusOK, []string{"settlement_delay"}},
+		{"premium settlement delay of a year", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"settlement_delay":12}`, http.StatusBadRequest, []string{"error"}},
+		{"premium settlement delay on annuity", handler.CalculatePremium, http.MethodPost, `{"age":65,"sum_assured":1000,"interest_rate":0.05,"product_type":"immediate_annuity","settlement_delay":3}`, http.StatusBadRequest, []string{"error"}},
+		{"batch settlement delay basis", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `,{"age":65,"sum_assured":1000,"interest_rate":0.05,"product_type":"immediate_annuity"}],"basis":{"settlement_delay":3}}`, http.StatusOK, []string{"results"}},
+		{"batch negative settlement delay basis", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"settlement_delay":-1}}`, http.StatusBadRequest, []string{"error"}},
+		{"revaluation", handler.RevalueInForce, http.MethodPost, `{"basis":{"interest_rate":0.03},"policies":[{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"in_force_duration":5}]}`, http.StatusOK, []string{"policies", "total_reserve_movement", "profit_from_change", "movement_by_product"}},
+		{"revaluation wrong method", handler.RevalueInForce, http.MethodGet, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"revaluation no policies", handler.RevalueInForce, http.MethodPost, `{"basis":{"interest_rate":0.03},"policies":[]}`, http.StatusBadRequest, []string{"error"}},
+		{"batch premiums in arrears", handler.CalculateBatch, http.MethodPost, `{"basis":{"premium_timing":"annual_in_arrears"},"policies":[` + validPolicy + `]}`, http.StatusOK, []string{"results", "summary"}},
+		{"batch unknown premium timing", handler.CalculateBatch, http.MethodPost, `{"basis":{"premium_timing":"monthly_in_advance"},"policies":[` + validPolicy + `]}`, http.StatusBadRequest, []string{"error"}},
+		{"batch arrears with modified reserve", handler.CalculateBatch, http.MethodPost, `{"basis":{"premium_timing":"annual_in_arrears","reserve_method":"crvm"},"policies":[` + validPolicy + `]}`, http.StatusBadRequest, []string{"error"}},
+		{"premium layered term", handler.CalculatePremium, http.MethodPost, `{"age":35,"term":20,"sum_assured":500000,"layers":[{"sum_assured":200000,"term":30}]}`, http.StatusOK, []string{"net_premium", "layer_premiums"}},
+		{"premium layers on whole life", handler.CalculatePremium, http.MethodPost, `{"age":35,"sum_assured":500000,"product_type":"whole_life","layers":[{"sum_assured":200000,"term":30}]}`, http.StatusBadRequest, []string{"error"}},
+		{"premium layer past table", handler.CalculatePremium, http.MethodPost, `{"age":60,"term":20,"sum_assured":500000,"layers":[{"sum_assured":200000,"term":45}]}`, http.StatusBadRequest, []string{"error"}},
+		{"paid-up whole life", handler.PaidUpAnalysis, http.MethodPost, `{"age":45,"term":20,"sum_assured":100000,"interest_rate":0.05,"product_type":"whole_life"}`, http.StatusOK, []string{"paid_up_duration", "paid_up_age", "paid_up_sum_assured"}},
+		{"paid-up term", handler.PaidUpAnalysis, http.MethodPost, validPolicy, http.StatusBadRequest, []string{"error"}},
+		{"paid-up wrong method", handler.PaidUpAnalysis, http.MethodGet, "", http.StatusMethodNotAllowed, []string{"error"}},
+		{"premium monthly implied rate", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"payment_frequency":"monthly"}`, http.StatusOK, []string{"modal_premium", "modal_implied_rate"}},
+		{"premium age from date of birth", handler.CalculatePremium, http.MethodPost, `{"date_of_birth":"1985-03-10","issue_date":"2025-09-10","age_rounding":"nearest_birthday","term":20,"sum_assured":100000,"interest_rate":0.05}`, http.StatusOK, []string{"age_at_issue", "age_rounding"}},
+		{"premium bad date of birth", handler.CalculatePremium, http.MethodPost, `{"date_of_birth":"10/03/1985","term":20,"sum_assured":100000}`, http.StatusBadRequest, []string{"error"}},
+		{"premium age rounding without date of birth", handler.CalculatePremium, http.MethodPost, `{"age_rounding":"next_birthday","term":20,"sum_assured":100000}`, http.StatusBadRequest, []string{"error"}},
+		{"scenarios unknown set", handler.ScenarioAnalysis, http.MethodPost, `{"policy":`+validPolicy+`,"scenario_set":"regulator"}`, http.StatusBadRequest, []string{"error"}},
+		{"batch premium tax", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"premium_tax_rate":0.05},"summary_stats":["total_premium_tax","total_tax_inclusive_premium"]}`, http.StatusOK, []string{"results", "summary"}},
+		{"batch premium tax above 1", handler.CalculateBatch, http.MethodPost, `{"policies":[` + validPolicy + `],"basis":{"premium_tax_rate":1.5}}`, http.StatusBadRequest, []string{"error"}},
+		{"premium dynamic lapse", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.04,"lapse_rate":0.05,"dynamic_lapse":{"reference_rate":0.06,"sensitivity":2}}`, http.StatusOK, []string{"lapse_path"}},
+		{"premium dynamic lapse on annuity", handler.CalculatePremium, http.MethodPost, `{"age":65,"sum_assured":10000,"interest_rate":0.04,"product_type":"immediate_annuity","dynamic_lapse":{"sensitivity":2}}`, http.StatusBadRequest, []string{"error"}},
+		{"level premium", handler.EquivalentLevelPremium, http.MethodPost, `{"age":40,"term":10,"sum_assured":100000,"premiums":[5000,2000,2000,2000,2000,2000,2000,2000,2000,2000],"credited_rate":0.04,"premium_load":0.05}`, http.StatusOK, []string{"level_premium", "level_account_values"}},
+		{"level premium in force", handler.EquivalentLevelPremium, http.MethodPost, `{"age":40,"term":10,"sum_assured":100000,"premiums":[100],"credited_rate":0.04,"target":"in_force"}`, http.StatusOK, []string{"level_premium", "irregular_lapse_year"}},
+		{"level premium past the table", handler.EquivalentLevelPremium, http.MethodPost, `{"age":95,"term":10,"sum_assured":100000,"premiums":[2000],"credited_rate":0.04}`, http.StatusBadRequest, []string{"error"}},
+		{"level premium without premiums", handler.EquivalentLevelPremium, http.MethodPost, `{"age":40,"term":10,"sum_assured":100000,"credited_rate":0.04}`, http.StatusBadRequest, []string{"error"}},
+		{"ifrs17 percentage", handler.IFRS17Valuation, http.MethodPost, `{"policy":` + validPolicy + `,"risk_adjustment":{"percentage":0.05}}`, http.StatusOK, []string{"best_estimate_liability", "risk_adjustment", "fulfilment_cash_flows"}},
+		{"ifrs17 confidence level", handler.IFRS17Valuation, http.MethodPost, `{"policy":` + validPolicy + `,"risk_adjustment":{"method":"confidence_level","confidence_level":0.75}}`, http.StatusOK, []string{"claims_standard_deviation", "risk_adjustment"}},
+		{"ifrs17 bad confidence level", handler.IFRS17Valuation, http.MethodPost, `{"policy":` + validPolicy + `,"risk_adjustment":{"method":"confidence_level","confidence_level":0.4}}`, http.StatusBadRequest, []string{"error"}},
+		{"ifrs17 annuity", handler.IFRS17Valuation, http.MethodPost, `{"policy":{"age":65,"sum_assured":1000,"product_type":"immediate_annuity"}}`, http.StatusBadRequest, []string{"error"}},
+		{"embedded value", handler.EmbeddedValue, http.MethodPost, `{"risk_discount_rate":0.1,"net_asset_value":50000,"policies":[{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"in_force_duration":5},{"age":65,"sum_assured":10000,"interest_rate":0.05,"product_type":"immediate_annuity"}]}`, http.StatusOK, []string{"policies", "value_of_in_force", "required_capital", "free_surplus", "embedded_value", "skipped_policies"}},
+		{"embedded value no rate", handler.EmbeddedValue, http.MethodPost, `{"policies":[{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05}]}`, http.StatusBadRequest, []string{"error"}},
+		{"embedded value bad capital", handler.EmbeddedValue, http.MethodPost, `{"risk_discount_rate":0.1,"required_capital":{"reserve_factor":-0.1},"policies":[{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05}]}`, http.StatusBadRequest, []string{"error"}},
+		{"family bundle", handler.CalculateBundle, http.MethodPost, `{"primary":{"age":40,"term":20,"sum_assured":200000,"interest_rate":0.05},"riders":[{"relationship":"spouse","age":38,"sum_assured":100000},{"relationship":"child","age":5,"sum_assured":20000,"term":15}]}`, http.StatusOK, []string{"components", "total_gross_premium", "total_modal_premium"}},
+		{"family bundle adult child", handler.CalculateBundle, http.MethodPost, `{"primary":{"age":40,"term":20,"sum_assured":200000,"interest_rate":0.05},"riders":[{"relationship":"child","age":30,"sum_assured":20000}]}`, http.StatusBadRequest, []string{"error"}},
+		{"profit release", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"risk_discount_rate":0.1,"required_capital":{"reserve_factor":0.05}}`, http.StatusOK, []string{"profit_test"}},
+		{"profit release needs a discount rate", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"required_capital":{"reserve_factor":0.05}}`, http.StatusBadRequest, []string{"error"}},
+		{"premium annuity timing on term", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":1000,"annuity_timing":"immediate"}`, http.StatusBadRequest, []string{"error"}},
+		{"premium unknown product", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":1000,"product_type":"endowment"}`, http.StatusBadRequest, []string{"error"}},
+
+		{"expenses", handler.GetExpenses, http.MethodGet, "", http.StatusOK, []string{"products", "fallback", "annuity_loading"}},
+		{"expenses wrong method", handler.GetExpenses, http.MethodPost, "{}", http.StatusMethodNotAllowed, []string{"error"}},
+
+		{"health", handler.HealthCheck, http.MethodGet, "", http.StatusOK, []string{"status", "tables_loaded"}},
+		{"health wrong method", handler.HealthCheck, http.MethodPost, "{}", http.StatusMethodNotAllowed, []string{"error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			tt.handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Expected a JSON response, got Content-Type %q", contentType)
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("Response is not a JSON object: %v", err)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := body[key]; !ok {
+					t.Errorf("Expected %q in the response, got %v", key, body)
+				}
+			}
+		})
+	}
+}
+
+func TestTablesListsLoadedTables(t *testing.T) {
+	handler := newTestHandler(t)
+	rec := httptest.NewRecorder()
+	handler.GetTables(rec, httptest.NewRequest(http.MethodGet, "/api/tables", nil))
+
+	var body struct {
+		Tables []string `json:"tables"`
+		Count  int      `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Count != 1 || len(body.Tables) != 1 || body.Tables[0] != "male" {
+		t.Errorf("Expected just the male table, got %+v", body)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	handler := newTestHandler(t)
+	defer func(commit, buildTime string) { version.Commit, version.BuildTime = commit, buildTime }(version.Commit, version.BuildTime)
+	version.Commit, version.BuildTime = "abc1234", "2026-10-16T09:30:00Z"
+
+	rec := httptest.NewRecorder()
+	handler.Version(rec, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	var info models.VersionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Commit != "abc1234" || info.BuildTime != "2026-10-16T09:30:00Z" || info.GoVersion == "" {
+		t.Errorf("Expected the build information, got %+v", info)
+	}
+	if len(info.Tables) != 1 || info.Tables[0].Name != "male" || info.Tables[0].MaxAge != 100 {
+		t.Errorf("Expected the loaded male table, got %+v", info.Tables)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.Version(rec, httptest.NewRequest(http.MethodPost, "/api/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for a POST, got %d", rec.Code)
+	}
+}
+
+func TestPremiumIsRoundedForDisplay(t *testing.T) {
+	handler := newTestHandler(t)
+	rec := httptest.NewRecorder()
+	handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(validPolicy)))
+
+	var result models.PremiumCalculation
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.GrossPremium <= result.NetPremium || result.NetPremium <= 0 {
+		t.Errorf("Expected a positive net premium below the gross, got %f and %f", result.NetPremium, result.GrossPremium)
+	}
+	for _, amount := range append([]float64{result.NetPremium, result.GrossPremium}, result.ReserveSchedule...) {
+		if cents := amount * 100; math.Abs(cents-math.Round(cents)) > 1e-6 {
+			t.Errorf("Expected amounts rounded to cents, got %v", amount)
+		}
+	}
+}
+
+func TestRoundingFollowsCurrency(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     float64
+	}{
+		{"", 1234.57},
+		{"BWP", 1234.57},
+		{"JPY", 1235},
+		{"BHD", 1234.568},
+	}
+	for _, tt := range tests {
+		calc := presentPremium(models.PremiumCalculation{Currency: tt.currency, GrossPremium: 1234.56789, ReserveSchedule: []float64{1234.56789}})
+		if calc.GrossPremium != tt.want || calc.ReserveSchedule[0] != tt.want {
+			t.Errorf("%q: expected %v, got %v and %v", tt.currency, tt.want, calc.GrossPremium, calc.ReserveSchedule[0])
+		}
+	}
+
+	// A batch summary is rounded to the currency its results share
+	batch := presentBatch(models.BatchCalculationResponse{
+		Results: []models.PremiumCalculation{{Currency: "JPY"}, {Currency: "JPY"}},
+		Summary: map[string]interface{}{
+			"total_policies":      2,
+			"average_net_premium": 1234.56789,
+			"product_subtotals":   map[string]models.ProductSubtotal{"term_life": {Policies: 2, TotalGrossPremium: 2469.1357}},
+		},
+	})
+	subtotals := batch.Summary["product_subtotals"].(map[string]models.ProductSubtotal)
+	if batch.Summary["total_policies"] != 2 || batch.Summary["average_net_premium"] != 1235.0 || subtotals["term_life"].TotalGrossPremium != 2469 {
+		t.Errorf("Expected the summary rounded to whole yen, got %v", batch.Summary)
+	}
+}
+
+func TestPresentingLeavesResultsAlone(t *testing.T) {
+	metrics := models.PortfolioMetrics{CurrencySubtotals: map[string]models.CurrencySubtotal{"BHD": {TotalGrossPremium: 1.23456}}}
+	if presented := presentPortfolio(metrics); presented.CurrencySubtotals["BHD"].TotalGrossPremium != 1.235 {
+		t.Errorf("Expected the BHD subtotal rounded to fils, got %v", presented.CurrencySubtotals["BHD"])
+	}
+	revaluation := models.RevaluationResult{MovementByProduct: map[string]float64{"term_life": 1.23456}}
+	presentRevaluation(revaluation)
+	embeddedValue := models.EmbeddedValueResult{ValueOfInForceByProduct: map[string]float64{"term_life": 1.23456}}
+	presentEmbeddedValue(embeddedValue)
+	if metrics.CurrencySubtotals["BHD"].TotalGrossPremium != 1.23456 || revaluation.MovementByProduct["term_life"] != 1.23456 ||
+		embeddedValue.ValueOfInForceByProduct["term_life"] != 1.23456 {
+		t.Error("Expected the service's results to keep full precision")
+	}
+}
+
+func TestEffectivePolicyIsEchoed(t *testing.T) {
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		name string
+		body string
+		want *models.Policy
+	}{
+		{"not requested", `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05}`, nil},
+		{"defaults filled in", `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"table_name":" Male ","echo_effective_policy":true}`,
+			&models.Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05, Gender: "male", ProductType: "term_life", PaymentFrequency: "annual", CompoundingConvention: "annual", AgeSent: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(tt.body)))
+
+			var result models.PremiumCalculation
+			if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				if result.EffectivePolicy != nil {
+					t.Errorf("Expected no effective policy, got %+v", result.EffectivePolicy)
+				}
+				return
+			}
+			if result.EffectivePolicy == nil || !reflect.DeepEqual(*result.EffectivePolicy, *tt.want) {
+				t.Errorf("Expected effective policy %+v, got %+v", tt.want, result.EffectivePolicy)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		locale, currency string
+		want             string
+	}{
+		{"en-US", "", "1,234,567.89"},
+		{"de-DE", "", "1.234.567,89"},
+		{"de-DE", "EUR", "€ 1.234.567,89"},
+		{"", "BWP", "BWP 1,234,567.89"},
+		{"en-BW", "BWP", "P 1,234,567.89"},
+	}
+	for _, tt := range tests {
+		if got := formatAmount(1234567.891, tt.locale, tt.currency); got != tt.want {
+			t.Errorf("formatAmount(%q, %q) = %q, want %q", tt.locale, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestPremiumIsFormattedForLocale(t *testing.T) {
+	handler := newTestHandler(t)
+	body := `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"locale":"de-DE","currency":"eur"}`
+	rec := httptest.NewRecorder()
+	handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(body)))
+
+	var result models.PremiumCalculation
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Currency != "EUR" || result.GrossPremium == 0 {
+		t.Fatalf("Expected a priced EUR quote, got %+v", result)
+	}
+	if want := formatAmount(result.GrossPremium, "de-DE", "EUR"); result.Formatted["gross_premium"] != want {
+		t.Errorf("Expected gross premium formatted as %q, got %q", want, result.Formatted["gross_premium"])
+	}
+
+	rec = httptest.NewRecorder()
+	invalid := `{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"currency":"XYZZY"}`
+	handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(invalid)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown currency to be rejected, got status %d", rec.Code)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	handler := newTestHandler(t)
+	panics := func(w http.ResponseWriter, r *http.Request) { panic("boom") }
+
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		method    string
+		body      string
+		requestID string
+		wantCode  string
+	}{
+		{"validation", handler.CalculatePremium, http.MethodPost, `{"age":40,"term":20,"sum_assured":-5}`, "", "bad_request"},
+		{"decode failure", handler.CalculatePremium, http.MethodPost, `{"age":`, "", "bad_request"},
+		{"method not allowed", handler.CalculatePremium, http.MethodGet, "", "abc-123", "method_not_allowed"},
+		{"internal", panics, http.MethodGet, "", "", "internal_server_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/calculate", strings.NewReader(tt.body))
+			if tt.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			middleware.Chain(tt.handler, middleware.RequestID, middleware.Recover)(rec, req)
+
+			var body models.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error == "" || body.Code != tt.wantCode || body.Timestamp.IsZero() {
+				t.Errorf("Expected a full envelope with code %s, got %+v", tt.wantCode, body)
+			}
+			if body.RequestID == "" || body.RequestID != rec.Header().Get(middleware.RequestIDHeader) {
+				t.Errorf("Expected the request ID %q in the body, got %q", rec.Header().Get(middleware.RequestIDHeader), body.RequestID)
+			}
+			if tt.requestID != "" && body.RequestID != tt.requestID {
+				t.Errorf("Expected the caller's request ID %q to be kept, got %q", tt.requestID, body.RequestID)
+			}
+		})
+	}
+}
+
+func TestOversizedBodyIsRejected(t *testing.T) {
+	handler := NewActuarialHandler(services.NewActuarialService())
+	limited := middleware.Chain(handler.CalculateBatch, middleware.LimitBody(1024))
+
+	oversized := `{"policies":[` + strings.Repeat(`{"age":30,"term":10,"sum_assured":1000},`, 100) + `{}]}`
+
+	tests := []struct {
+		name          string
+		contentLength int64
+	}{
+		{"declared length", int64(len(oversized))},
+		{"unknown length", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(oversized))
+			req.ContentLength = tt.contentLength
+			rec := httptest.NewRecorder()
+
+			limited(rec, req)
+
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("Expected status 413, got %d", rec.Code)
+			}
+			var body models.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error == "" {
+				t.Errorf("Expected a JSON error body, got %q (%v)", rec.Body.String(), err)
+			}
+		})
+	}
+}
+
+func TestPortfolioExpectedClaims(t *testing.T) {
+	handler := newTestHandler(t)
+	smoker := `{"age":50,"term":10,"sum_assured":200000,"interest_rate":0.05,"smoker_status":"smoker"}`
+
+	tests := []struct {
+		name string
+		body string
+		want float64
+	}{
+		// qx is 0.021 at 40 and 0.026 at 50, doubled for the smoker
+		{"underwritten mortality", `{"policies":[` + validPolicy + `,` + smoker + `]}`, 100000*0.021 + 200000*0.026*2},
+		{"flat rate override", `{"policies":[` + validPolicy + `,` + smoker + `],"expected_claim_rate":0.02}`, 300000 * 0.02},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.PortfolioAnalysis(rec, httptest.NewRequest(http.MethodPost, "/api/analyze/portfolio", strings.NewReader(tt.body)))
+
+		var metrics models.PortfolioMetrics
+		if err := json.NewDecoder(rec.Body).Decode(&metrics); err != nil {
+			t.Fatal(err)
+		}
+		if got := metrics.ProfitabilityMetrics["expected_claims"]; math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("%s: expected claims of %f, got %f", tt.name, tt.want, got)
+		}
+		if want := tt.want / metrics.TotalGrossPremium; math.Abs(metrics.ProfitabilityMetrics["loss_ratio"]-want) > 0.001 {
+			t.Errorf("%s: expected a loss ratio of %f, got %f", tt.name, want, metrics.ProfitabilityMetrics["loss_ratio"])
+		}
+	}
+}
+
+func TestQuotePDF(t *testing.T) {
+	handler := newTestHandler(t)
+	body := `{"policy_id":"P/1","age":40,"term":20,"sum_assured":100000,"interest_rate":0.05}`
+	rec := httptest.NewRecorder()
+	handler.QuotePDF(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/pdf", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("Expected a PDF, got %s", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="quote-P1.pdf"` {
+		t.Errorf("Expected a download named after the policy, got %s", got)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+		t.Errorf("Expected the body to be a PDF")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.QuotePDF(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/pdf", strings.NewReader(`{"age":200,"term":20,"sum_assured":1000}`)))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected an invalid policy to get a JSON 400, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestBatchValidateOnly(t *testing.T) {
+	handler := newTestHandler(t)
+	body := `{"validate_only":true,"policies":[` + validPolicy + `,{"policy_id":"old","age":200,"term":5,"sum_assured":1000},{"age":40,"term":20,"sum_assured":-1000}]}`
+	rec := httptest.NewRecorder()
+	handler.CalculateBatch(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch?page=1", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var response models.BatchCalculationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 0 || response.Pagination != nil {
+		t.Errorf("Expected no premiums and no pagination, got %+v", response)
+	}
+	if len(response.Validation) != 3 {
+		t.Fatalf("Expected a report for each of 3 policies, got %+v", response.Validation)
+	}
+	if first := response.Validation[0]; !first.Valid || first.PolicyID != "1" || first.Reason != "" {
+		t.Errorf("Expected the first policy to be valid, got %+v", first)
+	}
+	if old := response.Validation[1]; old.Valid || old.PolicyID != "old" || old.Reason == "" {
+		t.Errorf("Expected the out-of-range age to be reported, got %+v", old)
+	}
+	if beyond := response.Validation[2]; beyond.Valid || beyond.Reason == "" {
+		t.Errorf("Expected a negative sum assured to be reported, got %+v", beyond)
+	}
+	if response.Summary["invalid_policies"] != float64(2) || response.Summary["valid_policies"] != float64(1) {
+		t.Errorf("Expected 1 valid and 2 invalid policies in the summary, got %+v", response.Summary)
+	}
+}
+
+func TestBatchProductSubtotals(t *testing.T) {
+	handler := newTestHandler(t)
+	annuity := `{"age":65,"sum_assured":1000,"interest_rate":0.05,"product_type":"immediate_annuity"}`
+	body := `{"policies":[` + validPolicy + `,` + validPolicy + `,` + annuity + `]}`
+	rec := httptest.NewRecorder()
+	handler.CalculateBatch(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(body)))
+
+	var response struct {
+		Results []models.PremiumCalculation `json:"results"`
+		Summary struct {
+			ProductSubtotals map[string]models.ProductSubtotal `json:"product_subtotals"`
+		} `json:"summary"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	termLife := response.Summary.ProductSubtotals["term_life"]
+	if termLife.Policies != 2 || termLife.TotalCoverage != 200000 {
+		t.Errorf("Expected two term life policies covering 200000, got %+v", termLife)
+	}
+	if math.Abs(termLife.AverageGrossPremium-termLife.TotalGrossPremium/2) > 0.005 { // Both rounded to cents
+		t.Errorf("Expected the average to be half the total, got %+v", termLife)
+	}
+	if annuities := response.Summary.ProductSubtotals["immediate_annuity"]; annuities.Policies != 1 || annuities.TotalGrossPremium <= 0 {
+		t.Errorf("Expected one priced annuity, got %+v", annuities)
+	}
+}
+
+func TestPaginateBatch(t *testing.T) {
+	batch := models.BatchCalculationResponse{
+		Results: make([]models.PremiumCalculation, 7),
+		Summary: map[string]interface{}{"total_policies": 7},
+	}
+	for i := range batch.Results {
+		batch.Results[i].PolicyID = strconv.Itoa(i + 1)
+	}
+
+	tests := []struct {
+		page, pageSize int
+		wantIDs        []string
+		wantPages      int
+	}{
+		{1, 3, []string{"1", "2", "3"}, 3},
+		{3, 3, []string{"7"}, 3},
+		{4, 3, []string{}, 3},
+		{1, 10, []string{"1", "2", "3", "4", "5", "6", "7"}, 1},
+	}
+	for _, tt := range tests {
+		paged := paginateBatch(batch, tt.page, tt.pageSize)
+
+		if len(paged.Results) != len(tt.wantIDs) {
+			t.Fatalf("Page %d of %d: expected %d results, got %d", tt.page, tt.pageSize, len(tt.wantIDs), len(paged.Results))
+		}
+		for i, id := range tt.wantIDs {
+			if paged.Results[i].PolicyID != id {
+				t.Errorf("Page %d of %d: expected policy %s at %d, got %s", tt.page, tt.pageSize, id, i, paged.Results[i].PolicyID)
+			}
+		}
+		if paged.Pagination.Total != 7 || paged.Pagination.TotalPages != tt.wantPages {
+			t.Errorf("Page %d of %d: unexpected metadata %+v", tt.page, tt.pageSize, *paged.Pagination)
+		}
+		if paged.Summary["total_policies"] != 7 {
+			t.Errorf("Expected the summary to cover the full batch on every page")
+		}
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query          string
+		page, pageSize int
+		paginate       bool
+		wantErr        bool
+	}{
+		{"", 0, 0, false, false},
+		{"?page=2", 2, DefaultPageSize, true, false},
+		{"?page_size=5", 1, 5, true, false},
+		{"?page=0", 0, 0, false, true},
+		{"?page_size=abc", 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/calculate/batch"+tt.query, nil)
+		page, pageSize, paginate, err := parsePagination(req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: unexpected error %v", tt.query, err)
+			continue
+		}
+		if page != tt.page || pageSize != tt.pageSize || paginate != tt.paginate {
+			t.Errorf("%q: expected (%d, %d, %v), got (%d, %d, %v)", tt.query, tt.page, tt.pageSize, tt.paginate, page, pageSize, paginate)
+		}
+	}
+}
+
+// memoryQuotes is a QuoteStore that keeps quotes in a map, or fails every save
+type memoryQuotes struct {
+	quotes map[string]models.Quote
+	broken bool
+}
+
+func (store *memoryQuotes) SaveQuote(quote models.Quote) error {
+	if store.broken {
+		return errors.New("disk full")
+	}
+	store.quotes[quote.ID] = quote
+	return nil
+}
+
+func (store *memoryQuotes) Quote(id string) (models.Quote, error) {
+	quote, ok := store.quotes[id]
+	if !ok {
+		return models.Quote{}, services.ErrQuoteNotFound
+	}
+	return quote, nil
+}
+
+func TestQuotes(t *testing.T) {
+	handler := newTestHandler(t)
+	getQuote := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/quotes/"+id, nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		handler.GetQuote(rec, req)
+		return rec
+	}
+
+	// Stateless by default: nothing is stored and there is nothing to look up
+	rec := httptest.NewRecorder()
+	handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(validPolicy)))
+	if strings.Contains(rec.Body.String(), "quote_id") {
+		t.Errorf("Expected no quote ID without a store, got %s", rec.Body.String())
+	}
+	if rec := getQuote("abc"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with storage off, got %d", rec.Code)
+	}
+
+	store := &memoryQuotes{quotes: map[string]models.Quote{}}
+	handler.service.SetQuoteStore(store)
+	rec = httptest.NewRecorder()
+	handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(validPolicy)))
+	var quoted models.PremiumCalculation
+	if err := json.NewDecoder(rec.Body).Decode(&quoted); err != nil {
+		t.Fatal(err)
+	}
+	if quoted.QuoteID == "" || len(store.quotes) != 1 {
+		t.Fatalf("Expected the quote to be stored and its ID returned, got %q and %d quotes", quoted.QuoteID, len(store.quotes))
+	}
+
+	rec = getQuote(quoted.QuoteID)
+	var quote models.Quote
+	if err := json.NewDecoder(rec.Body).Decode(&quote); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || quote.ID != quoted.QuoteID || quote.Policy.Age != 40 || quote.Result.GrossPremium != quoted.GrossPremium || quote.CreatedAt.IsZero() {
+		t.Errorf("Expected the stored quote back, got %d %+v", rec.Code, quote)
+	}
+	if rec := getQuote("missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown quote, got %d", rec.Code)
+	}
+
+	// A quote that can't be kept isn't handed out
+	store.broken = true
+	rec = httptest.NewRecorder()
+	handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(validPolicy)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the quote can't be saved, got %d", rec.Code)
+	}
+}
+
+func TestRatePerMille(t *testing.T) {
+	handler := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	body := strings.Replace(validPolicy, "{", `{"include_rate_per_mille":true,`, 1)
+	handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(body)))
+	var result models.PremiumCalculation
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	// The premium is on 100000 sum assured, so the rate is premium / 100
+	if math.Abs(result.GrossRatePerMille-result.GrossPremium/100) > 0.01 || math.Abs(result.NetRatePerMille-result.NetPremium/100) > 0.01 {
+		t.Errorf("Expected rates of %f and %f per mille, got %f and %f", result.GrossPremium/100, result.NetPremium/100, result.GrossRatePerMille, result.NetRatePerMille)
+	}
+
+	// The batch summary averages each policy's rate, however big the policy
+	rec = httptest.NewRecorder()
+	double := strings.Replace(validPolicy, "100000", "200000", 1)
+	handler.CalculateBatch(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(`{"policies":[`+validPolicy+`,`+double+`]}`)))
+	var batch models.BatchCalculationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(batch.Results))
+	}
+	got, _ := batch.Summary["average_rate_per_mille"].(float64)
+	if expected := (batch.Results[0].GrossPremium/100 + batch.Results[1].GrossPremium/200) / 2; math.Abs(got-expected) > 0.01 {
+		t.Errorf("Expected an average rate of %f per mille, got %f", expected, got)
+	}
+}
+
+func TestAssumptions(t *testing.T) {
+	handler := newTestHandler(t)
+	decode := func(body string) models.PremiumCalculation {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.CalculatePremium(rec, httptest.NewRequest(http.MethodPost, "/api/calculate", strings.NewReader(body)))
+		var result models.PremiumCalculation
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || result.Assumptions == nil {
+			t.Fatalf("Expected a result with assumptions, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return result
+	}
+
+	result := decode(`{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"currency":"bwp"}`)
+	assumptions := result.Assumptions
+	if assumptions.Units.Amounts != "BWP" || assumptions.InterestRate != 0.05 || assumptions.Table.Name != "male" ||
+		assumptions.ProductType != "term_life" || assumptions.ReserveMethod != "net_premium" || assumptions.ClaimTiming != "end_of_year" {
+		t.Errorf("Unexpected term life assumptions %+v", assumptions)
+	}
+	if assumptions.Expenses == nil || assumptions.Expenses.InitialExpenseRate != result.ExpenseDetails["initial_expense_rate"] {
+		t.Errorf("Expected the expenses applied, got %+v", assumptions.Expenses)
+	}
+
+	// A basis replaces the policy's own assumptions, and the block says so
+	rec := httptest.NewRecorder()
+	body := `{"basis":{"interest_rate":0.03,"expenses":{"initial_expense_rate":0.4}},"policies":[` + validPolicy + `]}`
+	handler.CalculateBatch(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(body)))
+	var batch models.BatchCalculationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&batch); err != nil || len(batch.Results) != 1 {
+		t.Fatalf("Expected one result, got %d: %v", rec.Code, err)
+	}
+	if basis := batch.Results[0].Assumptions; basis == nil || basis.InterestRate != 0.03 || basis.Expenses.InitialExpenseRate != 0.4 {
+		t.Errorf("Expected the basis rate and expenses, got %+v", basis)
+	}
+
+	result = decode(`{"age":65,"sum_assured":100000,"interest_rate":0.05,"product_type":"immediate_annuity"}`)
+	if result.Assumptions.Units.Amounts != "unspecified" || result.Assumptions.Expenses != nil || result.Assumptions.AnnuityLoading == 0 {
+		t.Errorf("Expected annuity assumptions with a loading and no life expenses, got %+v", result.Assumptions)
+	}
+}
+
+func TestRevalueInForce(t *testing.T) {
+	handler := newTestHandler(t)
+	body := `{"prior_basis":{"interest_rate":0.05},"basis":{"interest_rate":0.03},"policies":[
+		{"policy_id":"A","age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"in_force_duration":5},
+		{"policy_id":"B","age":30,"term":60,"sum_assured":50000,"interest_rate":0.05,"product_type":"whole_life","in_force_duration":10},
+		{"policy_id":"C","age":65,"sum_assured":100000,"interest_rate":0.05,"product_type":"immediate_annuity"}]}`
+	rec := httptest.NewRecorder()
+	handler.RevalueInForce(rec, httptest.NewRequest(http.MethodPost, "/api/analyze/revaluation", strings.NewReader(body)))
+	var result models.RevaluationResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d (%v)", rec.Code, err)
+	}
+	if len(result.Policies) != 2 || len(result.SkippedPolicies) != 1 || result.SkippedPolicies[0].PolicyID != "C" {
+		t.Fatalf("Expected two revalued policies and the annuity skipped, got %+v", result)
+	}
+
+	// A lower interest rate strengthens the reserves, which costs profit
+	total := 0.0
+	for _, policy := range result.Policies {
+		if policy.ReserveMovement <= 0 || policy.ReserveSchedule[0] != policy.RevisedReserve {
+			t.Errorf("%s: expected a higher reserve at 3%%, got %+v", policy.PolicyID, policy)
+		}
+		total += policy.ReserveMovement
+	}
+	if math.Abs(result.TotalReserveMovement-total) > 0.02 || math.Abs(result.ProfitFromChange+result.TotalReserveMovement) > 0.01 {
+		t.Errorf("Expected a movement of %f and the opposite profit, got %f and %f", total, result.TotalReserveMovement, result.ProfitFromChange)
+	}
+	if len(result.MovementByProduct) != 2 || result.MovementByProduct["whole_life"] <= 0 {
+		t.Errorf("Expected the movement split between term and whole life, got %v", result.MovementByProduct)
+	}
+}
+
+func TestSurvivalCurve(t *testing.T) {
+	handler := newTestHandler(t)
+	getCurve := func(name, query string) (*httptest.ResponseRecorder, models.SurvivalCurve) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tables/"+name+"/survival?"+query, nil)
+		req.SetPathValue("name", name)
+		rec := httptest.NewRecorder()
+		handler.GetSurvivalCurve(rec, req)
+		var curve models.SurvivalCurve
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&curve); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, curve
+	}
+
+	rec, standard := getCurve("male", "from_age=40")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// Ages 40 to 100, and surviving past 100
+	if len(standard.Points) != 62 || standard.Points[0].Age != 40 || standard.Points[0].SurvivalProbability != 1 {
+		t.Fatalf("Expected 62 points from age 40 at 1, got %d starting %+v", len(standard.Points), standard.Points[0])
+	}
+	for i := 1; i < len(standard.Points); i++ {
+		if standard.Points[i].SurvivalProbability >= standard.Points[i-1].SurvivalProbability {
+			t.Fatalf("Expected survival to fall with age, got %+v then %+v", standard.Points[i-1], standard.Points[i])
+		}
+	}
+
+	// Smokers die sooner, so fewer of them reach each age
+	_, smoker := getCurve("male", "from_age=40&smoker_status=smoker")
+	_, rated := getCurve("male", "from_age=40&smoker_status=smoker&rating_factor=1")
+	if smoker.Points[20].SurvivalProbability >= standard.Points[20].SurvivalProbability {
+		t.Errorf("Expected smokers to survive less, got %f vs %f", smoker.Points[20].SurvivalProbability, standard.Points[20].SurvivalProbability)
+	}
+	// A rating factor replaces the smoker multiplier, as it does in pricing
+	if rated.Points[20].SurvivalProbability != standard.Points[20].SurvivalProbability {
+		t.Errorf("Expected a rating factor of 1 to give the standard curve, got %f", rated.Points[20].SurvivalProbability)
+	}
+
+	if _, curve := getCurve("male", ""); curve.FromAge != 0 {
+		t.Errorf("Expected the curve to start at the table's first age, got %d", curve.FromAge)
+	}
+	for _, bad := range []struct{ name, query string }{
+		{"male", "from_age=abc"},
+		{"male", "from_age=101"},
+		{"male", "rating_factor=-1"},
+		{"nope", "from_age=40"},
+	} {
+		if rec, _ := getCurve(bad.name, bad.query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s?%s: expected 400, got %d", bad.name, bad.query, rec.Code)
+		}
+	}
+}
+
+func TestStressPresets(t *testing.T) {
+	handler := newTestHandler(t)
+	body := `{"base_policy":{"age":40,"term":20,"sum_assured":100000,"interest_rate":0.05,"lapse_rate":0.05},
+		"stress_presets":["solvency_ii_mortality","solvency_ii_longevity","solvency_ii_mass_lapse","interest_down_100bps"]}`
+	rec := httptest.NewRecorder()
+	handler.SensitivityAnalysis(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/sensitivity", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response models.SensitivityAnalysisResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.StressPresets) != 4 || response.StressPresets["solvency_ii_mortality"].MortalityShock != 0.15 {
+		t.Errorf("Expected the four preset definitions, got %+v", response.StressPresets)
+	}
+
+	base := response.BaseResult.NetPremium
+	stressed := map[string]float64{}
+	for _, result := range response.Analysis["stress_preset"] {
+		stressed[result.Preset] = result.Result.NetPremium
+	}
+	if stressed["solvency_ii_mortality"] <= base || stressed["solvency_ii_longevity"] >= base {
+		t.Errorf("Expected heavier mortality to cost more and lighter less, got %v around %f", stressed, base)
+	}
+	// Lower rates discount the claims less; a mass lapse takes lives out before the dearer years
+	if stressed["interest_down_100bps"] <= base || stressed["solvency_ii_mass_lapse"] >= base {
+		t.Errorf("Expected lower rates to cost more and a mass lapse less, got %v around %f", stressed, base)
+	}
+
+	rec = httptest.NewRecorder()
+	unknown := `{"base_policy":{"age":40,"term":20,"sum_assured":100000},"stress_presets":["meteor_strike"]}`
+	handler.SensitivityAnalysis(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/sensitivity", strings.NewReader(unknown)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown preset, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"actuworry/backend/models"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// DefaultPageSize is used when a client asks for a page without a page_size
+const DefaultPageSize = 20
+
+// parsePagination reads the optional ?page and ?page_size query parameters.
+// paginate is false when neither is present, so existing clients get the full
+// result set as before. Pages are numbered from 1.
+func parsePagination(r *http.Request) (page, pageSize int, paginate bool, err error) {
+	query := r.URL.Query()
+	pageText, pageSizeText := query.Get("page"), query.Get("page_size")
+	if pageText == "" && pageSizeText == "" {
+		return 0, 0, false, nil
+	}
+
+	page, pageSize = 1, DefaultPageSize
+	if pageText != "" {
+		if page, err = strconv.Atoi(pageText); err != nil || page < 1 {
+			return 0, 0, false, fmt.Errorf("page must be a whole number of at least 1")
+		}
+	}
+	if pageSizeText != "" {
+		if pageSize, err = strconv.Atoi(pageSizeText); err != nil || pageSize < 1 {
+			return 0, 0, false, fmt.Errorf("page_size must be a whole number of at least 1")
+		}
+	}
+	return page, pageSize, true, nil
+}
+
+// paginateBatch cuts a batch response down to one page of results. The summary
+// still describes the whole batch, so every page carries the same totals.
+// A page past the end comes back with no results rather than an error.
+func paginateBatch(batch models.BatchCalculationResponse, page, pageSize int) models.BatchCalculationResponse {
+	total := len(batch.Results)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+
+	batch.Results = batch.Results[start:end]
+	batch.Pagination = &models.Pagination{
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}
+	return batch
+}
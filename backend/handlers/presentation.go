@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"actuworry/backend/models"
+	"math"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// defaultDecimals is how many decimal places money is rounded to in a result
+// without a currency: cents, as for BWP.
+const defaultDecimals = 2
+
+// currencyDecimals is how many decimal places a currency's amounts are
+// rounded to in responses - its minor unit, 0 for JPY and 3 for BHD. The
+// service keeps full precision so totals and chained calculations don't pick
+// up rounding error; rounding only happens here.
+func currencyDecimals(code string) int {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return defaultDecimals
+	}
+	decimals, _ := currency.Standard.Rounding(unit)
+	return decimals
+}
+
+// roundCurrency rounds a money amount for display
+func roundCurrency(amount float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	rounded := math.Round(amount*scale) / scale
+	if rounded == 0 {
+		return 0 // Avoid showing -0 for tiny negative reserves
+	}
+	return rounded
+}
+
+// roundSchedule rounds every entry of a schedule into a new slice
+func roundSchedule(schedule []float64, decimals int) []float64 {
+	if schedule == nil {
+		return nil
+	}
+	rounded := make([]float64, len(schedule))
+	for i, amount := range schedule {
+		rounded[i] = roundCurrency(amount, decimals)
+	}
+	return rounded
+}
+
+// formatAmount writes a money amount the way the locale does - "1.234,50" in
+// de-DE - with the currency symbol in front when one is given. The locale and
+// currency have already been validated by the service.
+func formatAmount(amount float64, locale, currencyCode string) string {
+	tag := language.English
+	if locale != "" {
+		tag = language.Make(locale)
+	}
+	printer := message.NewPrinter(tag)
+	if unit, err := currency.ParseISO(currencyCode); err == nil {
+		return printer.Sprint(currency.Symbol(unit.Amount(amount)))
+	}
+	return printer.Sprint(number.Decimal(amount, number.Scale(defaultDecimals)))
+}
+
+// formatHeadlineAmounts formats the premiums and payouts a quote is read for
+func formatHeadlineAmounts(calc models.PremiumCalculation) map[string]string {
+	amounts := map[string]float64{
+		"net_premium":   calc.NetPremium,
+		"gross_premium": calc.GrossPremium,
+	}
+	if calc.ModalPremium != 0 {
+		amounts["modal_premium"] = calc.ModalPremium
+	}
+	if calc.AnnualPayout != 0 {
+		amounts["annual_payout"] = calc.AnnualPayout
+	}
+	if calc.TotalPremiumCost != 0 {
+		amounts["total_premium_cost"] = calc.TotalPremiumCost
+	}
+
+	formatted := make(map[string]string, len(amounts))
+	for name, amount := range amounts {
+		formatted[name] = formatAmount(amount, calc.Locale, calc.Currency)
+	}
+	return formatted
+}
+
+// presentPremium returns a copy of a calculation with its money fields rounded
+func presentPremium(calc models.PremiumCalculation) models.PremiumCalculation {
+	decimals := currencyDecimals(calc.Currency)
+	calc.NetPremium = roundCurrency(calc.NetPremium, decimals)
+	calc.GrossPremium = roundCurrency(calc.GrossPremium, decimals)
+	calc.ReserveSchedule = roundSchedule(calc.ReserveSchedule, decimals)
+	calc.AnnualPayout = roundCurrency(calc.AnnualPayout, decimals)
+	calc.TotalPremiumCost = roundCurrency(calc.TotalPremiumCost, decimals)
+	calc.ModalPremium = roundCurrency(calc.ModalPremium, decimals)
+	calc.NetAmountAtRisk = roundSchedule(calc.NetAmountAtRisk, decimals)
+	calc.SinglePremium = roundCurrency(calc.SinglePremium, decimals)
+	calc.GrossRatePerMille = roundCurrency(calc.GrossRatePerMille, decimals)
+	calc.NetRatePerMille = roundCurrency(calc.NetRatePerMille, decimals)
+	calc.NewBusinessStrain = roundCurrency(calc.NewBusinessStrain, decimals)
+	calc.DeathBenefitValue = roundCurrency(calc.DeathBenefitValue, decimals)
+	calc.ActuarialPremium = roundCurrency(calc.ActuarialPremium, decimals)
+	calc.VolumeDiscount = roundCurrency(calc.VolumeDiscount, decimals)
+	calc.PremiumTax = roundCurrency(calc.PremiumTax, decimals)
+	calc.TaxInclusivePremium = roundCurrency(calc.TaxInclusivePremium, decimals)
+	calc.FirstYearPremium = roundCurrency(calc.FirstYearPremium, decimals)
+	calc.RenewalPremium = roundCurrency(calc.RenewalPremium, decimals)
+	if calc.LayerPremiums != nil {
+		layers := make([]models.LayerPremium, len(calc.LayerPremiums))
+		for i, layer := range calc.LayerPremiums {
+			layer.NetPremium = roundCurrency(layer.NetPremium, decimals)
+			layers[i] = layer
+		}
+		calc.LayerPremiums = layers
+	}
+	if calc.PremiumBreakdown != nil {
+		breakdown := *calc.PremiumBreakdown
+		breakdown.NetPremium = roundCurrency(breakdown.NetPremium, decimals)
+		breakdown.ExpenseLoading = roundCurrency(breakdown.ExpenseLoading, decimals)
+		breakdown.ProfitLoading = roundCurrency(breakdown.ProfitLoading, decimals)
+		calc.PremiumBreakdown = &breakdown
+	}
+	if calc.ProfitTest != nil {
+		profitTest := *calc.ProfitTest
+		profitTest.ProfitVector = roundSchedule(profitTest.ProfitVector, decimals)
+		profitTest.ProfitSignature = roundSchedule(profitTest.ProfitSignature, decimals)
+		profitTest.ProfitValue = roundCurrency(profitTest.ProfitValue, decimals)
+		profitTest.PremiumValue = roundCurrency(profitTest.PremiumValue, decimals)
+		if profitTest.ProfitRelease != nil {
+			release := *profitTest.ProfitRelease
+			release.CapitalStrain = roundCurrency(release.CapitalStrain, decimals)
+			release.RequiredCapital = roundSchedule(release.RequiredCapital, decimals)
+			release.CostOfCapital = roundSchedule(release.CostOfCapital, decimals)
+			release.DistributableEarnings = roundSchedule(release.DistributableEarnings, decimals)
+			release.DistributableValue = roundCurrency(release.DistributableValue, decimals)
+			release.CostOfCapitalValue = roundCurrency(release.CostOfCapitalValue, decimals)
+			profitTest.ProfitRelease = &release
+		}
+		calc.ProfitTest = &profitTest
+	}
+	calc.Duration = presentDuration(calc.Duration, decimals)
+	if calc.PaidUp != nil {
+		paidUp := *calc.PaidUp
+		paidUp.CostOfCover = roundSchedule(paidUp.CostOfCover, decimals)
+		paidUp.PaidUpSumAssured = roundSchedule(paidUp.PaidUpSumAssured, decimals)
+		calc.PaidUp = &paidUp
+	}
+	if calc.ModifiedReserve != nil {
+		modified := *calc.ModifiedReserve
+		modified.FirstYearPremium = roundCurrency(modified.FirstYearPremium, decimals)
+		modified.RenewalPremium = roundCurrency(modified.RenewalPremium, decimals)
+		modified.ExpenseAllowance = roundCurrency(modified.ExpenseAllowance, decimals)
+		modified.UncappedAllowance = roundCurrency(modified.UncappedAllowance, decimals)
+		if modified.AllowanceCap != nil {
+			allowanceCap := roundCurrency(*modified.AllowanceCap, decimals)
+			modified.AllowanceCap = &allowanceCap
+		}
+		calc.ModifiedReserve = &modified
+	}
+	if calc.ProfitByYear != nil {
+		profit := *calc.ProfitByYear
+		profit.ExpectedProfit = roundSchedule(profit.ExpectedProfit, decimals)
+		profit.DiscountedTotal = roundCurrency(profit.DiscountedTotal, decimals)
+		calc.ProfitByYear = &profit
+	}
+	if calc.Locale != "" || calc.Currency != "" {
+		calc.Formatted = formatHeadlineAmounts(calc)
+	}
+	return calc
+}
+
+func presentBatch(batch models.BatchCalculationResponse) models.BatchCalculationResponse {
+	results := make([]models.PremiumCalculation, len(batch.Results))
+	for i, result := range batch.Results {
+		results[i] = presentPremium(result)
+	}
+	batch.Results = results
+	batch.Summary = presentBatchSummary(batch.Summary, batchDecimals(batch.Results))
+	return batch
+}
+
+// batchDecimals is the minor unit of the currency every result in a batch
+// shares, or the default when they have none or mix them
+func batchDecimals(results []models.PremiumCalculation) int {
+	if len(results) == 0 {
+		return defaultDecimals
+	}
+	for _, result := range results[1:] {
+		if result.Currency != results[0].Currency {
+			return defaultDecimals
+		}
+	}
+	return currencyDecimals(results[0].Currency)
+}
+
+// presentBatchSummary returns a copy of a batch summary with its amounts
+// rounded. Counts are left as they are.
+func presentBatchSummary(summary map[string]interface{}, decimals int) map[string]interface{} {
+	if summary == nil {
+		return nil
+	}
+	presented := make(map[string]interface{}, len(summary))
+	for name, value := range summary {
+		switch value := value.(type) {
+		case float64:
+			presented[name] = roundCurrency(value, decimals)
+		case map[string]models.ProductSubtotal:
+			subtotals := make(map[string]models.ProductSubtotal, len(value))
+			for productType, subtotal := range value {
+				subtotal.TotalNetPremium = roundCurrency(subtotal.TotalNetPremium, decimals)
+				subtotal.TotalGrossPremium = roundCurrency(subtotal.TotalGrossPremium, decimals)
+				subtotal.AverageNetPremium = roundCurrency(subtotal.AverageNetPremium, decimals)
+				subtotal.AverageGrossPremium = roundCurrency(subtotal.AverageGrossPremium, decimals)
+				subtotal.TotalCoverage = roundCurrency(subtotal.TotalCoverage, decimals)
+				subtotal.TotalPremiumTax = roundCurrency(subtotal.TotalPremiumTax, decimals)
+				subtotals[productType] = subtotal
+			}
+			presented[name] = subtotals
+		default:
+			presented[name] = value
+		}
+	}
+	return presented
+}
+
+// presentDuration rounds the money in a duration; the durations are years
+func presentDuration(duration *models.CashFlowDuration, decimals int) *models.CashFlowDuration {
+	if duration == nil {
+		return nil
+	}
+	presented := *duration
+	presented.NetPresentValue = roundCurrency(presented.NetPresentValue, decimals)
+	return &presented
+}
+
+func presentSensitivity(sensitivity models.SensitivityAnalysisResponse) models.SensitivityAnalysisResponse {
+	decimals := currencyDecimals(sensitivity.BaseResult.Currency)
+	sensitivity.BaseResult = presentPremium(sensitivity.BaseResult)
+	analysis := make(map[string][]models.SensitivityResult, len(sensitivity.Analysis))
+	for parameter, results := range sensitivity.Analysis {
+		presented := make([]models.SensitivityResult, len(results))
+		for i, result := range results {
+			result.Result = presentPremium(result.Result)
+			result.Duration = presentDuration(result.Duration, decimals)
+			presented[i] = result
+		}
+		analysis[parameter] = presented
+	}
+	sensitivity.Analysis = analysis
+	return sensitivity
+}
+
+func presentPortfolio(metrics models.PortfolioMetrics) models.PortfolioMetrics {
+	decimals := currencyDecimals(metrics.ReportingCurrency)
+	metrics.TotalNetPremium = roundCurrency(metrics.TotalNetPremium, decimals)
+	metrics.TotalGrossPremium = roundCurrency(metrics.TotalGrossPremium, decimals)
+	metrics.AverageCoverage = roundCurrency(metrics.AverageCoverage, decimals)
+	metrics.Concentration.LargestNetAmountAtRisk = roundCurrency(metrics.Concentration.LargestNetAmountAtRisk, decimals)
+	metrics.NetAmountAtRisk = roundCurrency(metrics.NetAmountAtRisk, decimals)
+	if metrics.CurrencySubtotals != nil {
+		// Each subtotal is in its own currency; copied so the service's result is left alone
+		subtotals := make(map[string]models.CurrencySubtotal, len(metrics.CurrencySubtotals))
+		for code, subtotal := range metrics.CurrencySubtotals {
+			subtotalDecimals := currencyDecimals(code)
+			subtotal.TotalCoverage = roundCurrency(subtotal.TotalCoverage, subtotalDecimals)
+			subtotal.TotalNetPremium = roundCurrency(subtotal.TotalNetPremium, subtotalDecimals)
+			subtotal.TotalGrossPremium = roundCurrency(subtotal.TotalGrossPremium, subtotalDecimals)
+			subtotals[code] = subtotal
+		}
+		metrics.CurrencySubtotals = subtotals
+	}
+	return metrics
+}
+
+func presentGroup(group models.GroupQuoteResponse) models.GroupQuoteResponse {
+	group.TotalCoverage = roundCurrency(group.TotalCoverage, defaultDecimals)
+	group.TotalNetPremium = roundCurrency(group.TotalNetPremium, defaultDecimals)
+	group.TotalGrossPremium = roundCurrency(group.TotalGrossPremium, defaultDecimals)
+	return group
+}
+
+func presentExperienceRefund(refund models.ExperienceRefundResult) models.ExperienceRefundResult {
+	refund.ExpectedClaims = roundCurrency(refund.ExpectedClaims, defaultDecimals)
+	refund.ActualClaims = roundCurrency(refund.ActualClaims, defaultDecimals)
+	refund.Refund = roundCurrency(refund.Refund, defaultDecimals)
+	return refund
+}
+
+func presentGrossPremiumValuation(valuation models.GrossPremiumValuationResult) models.GrossPremiumValuationResult {
+	valuation.GrossPremium = roundCurrency(valuation.GrossPremium, defaultDecimals)
+	valuation.NetPremiumReserve = roundSchedule(valuation.NetPremiumReserve, defaultDecimals)
+	valuation.GrossPremiumReserve = roundSchedule(valuation.GrossPremiumReserve, defaultDecimals)
+	valuation.Deficiency = roundSchedule(valuation.Deficiency, defaultDecimals)
+	return valuation
+}
+
+func presentIFRS17(valuation models.IFRS17Result) models.IFRS17Result {
+	valuation.GrossPremium = roundCurrency(valuation.GrossPremium, defaultDecimals)
+	valuation.PremiumValue = roundCurrency(valuation.PremiumValue, defaultDecimals)
+	valuation.ClaimsValue = roundCurrency(valuation.ClaimsValue, defaultDecimals)
+	valuation.ExpenseValue = roundCurrency(valuation.ExpenseValue, defaultDecimals)
+	valuation.BestEstimateLiability = roundCurrency(valuation.BestEstimateLiability, defaultDecimals)
+	valuation.ClaimsStandardDeviation = roundCurrency(valuation.ClaimsStandardDeviation, defaultDecimals)
+	valuation.RiskAdjustment = roundCurrency(valuation.RiskAdjustment, defaultDecimals)
+	valuation.FulfilmentCashFlows = roundCurrency(valuation.FulfilmentCashFlows, defaultDecimals)
+	return valuation
+}
+
+func presentRevaluation(revaluation models.RevaluationResult) models.RevaluationResult {
+	decimals := currencyDecimals(revaluation.ReportingCurrency)
+	policies := make([]models.PolicyRevaluation, len(revaluation.Policies))
+	for i, policy := range revaluation.Policies {
+		policy.PriorReserve = roundCurrency(policy.PriorReserve, decimals)
+		policy.RevisedReserve = roundCurrency(policy.RevisedReserve, decimals)
+		policy.ReserveMovement = roundCurrency(policy.ReserveMovement, decimals)
+		policy.ReserveSchedule = roundSchedule(policy.ReserveSchedule, decimals)
+		policies[i] = policy
+	}
+	revaluation.Policies = policies
+	revaluation.TotalPriorReserve = roundCurrency(revaluation.TotalPriorReserve, decimals)
+	revaluation.TotalRevisedReserve = roundCurrency(revaluation.TotalRevisedReserve, decimals)
+	revaluation.TotalReserveMovement = roundCurrency(revaluation.TotalReserveMovement, decimals)
+	revaluation.ProfitFromChange = roundCurrency(revaluation.ProfitFromChange, decimals)
+	if revaluation.MovementByProduct != nil {
+		movements := make(map[string]float64, len(revaluation.MovementByProduct))
+		for product, movement := range revaluation.MovementByProduct {
+			movements[product] = roundCurrency(movement, decimals)
+		}
+		revaluation.MovementByProduct = movements
+	}
+	return revaluation
+}
+
+func presentEmbeddedValue(embeddedValue models.EmbeddedValueResult) models.EmbeddedValueResult {
+	decimals := currencyDecimals(embeddedValue.ReportingCurrency)
+	policies := make([]models.PolicyEmbeddedValue, len(embeddedValue.Policies))
+	for i, policy := range embeddedValue.Policies {
+		policy.Reserve = roundCurrency(policy.Reserve, decimals)
+		policy.RequiredCapital = roundCurrency(policy.RequiredCapital, decimals)
+		policy.ValueOfInForce = roundCurrency(policy.ValueOfInForce, decimals)
+		policies[i] = policy
+	}
+	embeddedValue.Policies = policies
+	embeddedValue.TotalReserve = roundCurrency(embeddedValue.TotalReserve, decimals)
+	embeddedValue.NetAssetValue = roundCurrency(embeddedValue.NetAssetValue, decimals)
+	embeddedValue.RequiredCapital = roundCurrency(embeddedValue.RequiredCapital, decimals)
+	embeddedValue.FreeSurplus = roundCurrency(embeddedValue.FreeSurplus, decimals)
+	embeddedValue.ValueOfInForce = roundCurrency(embeddedValue.ValueOfInForce, decimals)
+	embeddedValue.EmbeddedValue = roundCurrency(embeddedValue.EmbeddedValue, decimals)
+	if embeddedValue.ValueOfInForceByProduct != nil {
+		values := make(map[string]float64, len(embeddedValue.ValueOfInForceByProduct))
+		for product, value := range embeddedValue.ValueOfInForceByProduct {
+			values[product] = roundCurrency(value, decimals)
+		}
+		embeddedValue.ValueOfInForceByProduct = values
+	}
+	return embeddedValue
+}
+
+func presentPaidUp(paidUp models.PaidUpResult) models.PaidUpResult {
+	paidUp.NetPremium = roundCurrency(paidUp.NetPremium, defaultDecimals)
+	paidUp.ReserveSchedule = roundSchedule(paidUp.ReserveSchedule, defaultDecimals)
+	paidUp.CostOfCover = roundSchedule(paidUp.CostOfCover, defaultDecimals)
+	paidUp.PaidUpSumAssured = roundSchedule(paidUp.PaidUpSumAssured, defaultDecimals)
+	return paidUp
+}
+
+func presentScenarioAnalysis(analysis models.ScenarioAnalysisResult) models.ScenarioAnalysisResult {
+	analysis.NetPremium = presentScenarioDistribution(analysis.NetPremium, defaultDecimals)
+	analysis.GrossPremium = presentScenarioDistribution(analysis.GrossPremium, defaultDecimals)
+	if analysis.ReservePercentiles != nil {
+		reserves := make(map[string][]float64, len(analysis.ReservePercentiles))
+		for label, schedule := range analysis.ReservePercentiles {
+			reserves[label] = roundSchedule(schedule, defaultDecimals)
+		}
+		analysis.ReservePercentiles = reserves
+	}
+	outcomes := make([]models.ScenarioOutcome, len(analysis.Outcomes))
+	for i, outcome := range analysis.Outcomes {
+		outcome.NetPremium = roundCurrency(outcome.NetPremium, defaultDecimals)
+		outcome.GrossPremium = roundCurrency(outcome.GrossPremium, defaultDecimals)
+		outcome.ReserveSchedule = roundSchedule(outcome.ReserveSchedule, defaultDecimals)
+		outcomes[i] = outcome
+	}
+	analysis.Outcomes = outcomes
+	return analysis
+}
+
+func presentScenarioDistribution(distribution models.ScenarioDistribution, decimals int) models.ScenarioDistribution {
+	distribution.Mean = roundCurrency(distribution.Mean, decimals)
+	distribution.Min = roundCurrency(distribution.Min, decimals)
+	distribution.Max = roundCurrency(distribution.Max, decimals)
+	percentiles := make(map[string]float64, len(distribution.Percentiles))
+	for label, value := range distribution.Percentiles {
+		percentiles[label] = roundCurrency(value, decimals)
+	}
+	distribution.Percentiles = percentiles
+	return distribution
+}
+
+func presentFractionalReserve(reserve models.FractionalReserve) models.FractionalReserve {
+	reserve.Reserve = roundCurrency(reserve.Reserve, defaultDecimals)
+	reserve.ReserveAtStart = roundCurrency(reserve.ReserveAtStart, defaultDecimals)
+	reserve.ReserveAtEnd = roundCurrency(reserve.ReserveAtEnd, defaultDecimals)
+	reserve.UnearnedPremium = roundCurrency(reserve.UnearnedPremium, defaultDecimals)
+	reserve.NetPremium = roundCurrency(reserve.NetPremium, defaultDecimals)
+	return reserve
+}
+
+func presentBundle(bundle models.BundleResult) models.BundleResult {
+	components := make([]models.BundleComponent, len(bundle.Components))
+	for i, component := range bundle.Components {
+		component.NetPremium = roundCurrency(component.NetPremium, defaultDecimals)
+		component.GrossPremium = roundCurrency(component.GrossPremium, defaultDecimals)
+		component.ModalPremium = roundCurrency(component.ModalPremium, defaultDecimals)
+		components[i] = component
+	}
+	bundle.Components = components
+	bundle.TotalNetPremium = roundCurrency(bundle.TotalNetPremium, defaultDecimals)
+	bundle.TotalGrossPremium = roundCurrency(bundle.TotalGrossPremium, defaultDecimals)
+	bundle.TotalModalPremium = roundCurrency(bundle.TotalModalPremium, defaultDecimals)
+	return bundle
+}
+
+func presentJointLife(joint models.JointLifeResult) models.JointLifeResult {
+	joint.SinglePremium = roundCurrency(joint.SinglePremium, defaultDecimals)
+	return joint
+}
+
+func presentLevelPremium(level models.LevelPremiumResult) models.LevelPremiumResult {
+	level.LevelPremium = roundCurrency(level.LevelPremium, defaultDecimals)
+	level.IrregularAccountValues = roundSchedule(level.IrregularAccountValues, defaultDecimals)
+	level.LevelAccountValues = roundSchedule(level.LevelAccountValues, defaultDecimals)
+	return level
+}
+
+func presentBreakEven(breakEven models.BreakEvenResult) models.BreakEvenResult {
+	breakEven.GrossPremium = roundCurrency(breakEven.GrossPremium, defaultDecimals)
+	breakEven.ReserveSchedule = roundSchedule(breakEven.ReserveSchedule, defaultDecimals)
+	return breakEven
+}
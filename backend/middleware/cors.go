@@ -11,7 +11,9 @@ func CORS(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		// Browsers may send their own request ID and read back the one used
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+RequestIDHeader)
+		w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
 		
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -36,11 +38,12 @@ func Logger(next http.HandlerFunc) http.HandlerFunc {
 		next(wrapped, r)
 		
 		log.Printf(
-			"%s %s %d %v",
+			"%s %s %d %v %s",
 			r.Method,
 			r.URL.Path,
 			wrapped.statusCode,
 			time.Since(start),
+			r.Header.Get(RequestIDHeader),
 		)
 	}
 }
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"actuworry/backend/models"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log line and any
+// error it produced. Callers can send their own; otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength stops a caller filling the logs through the header
+const maxRequestIDLength = 128
+
+// RequestID makes sure every request has an ID and echoes it on the response
+func RequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get(RequestIDHeader))
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		r.Header.Set(RequestIDHeader, id)
+		w.Header().Set(RequestIDHeader, id)
+		next(w, r)
+	}
+}
+
+func newRequestID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// Recover turns a panic in a handler into a 500 error response
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Printf("panic handling %s %s (request %s): %v", r.Method, r.URL.Path, r.Header.Get(RequestIDHeader), recovered)
+				WriteError(w, "Internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next(w, r)
+	}
+}
+
+// WriteError sends the error envelope every endpoint uses: the message, a code
+// derived from the status (e.g. "method_not_allowed"), when it happened and
+// the request ID
+func WriteError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error:     message,
+		Code:      strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+		Timestamp: time.Now().UTC(),
+		RequestID: w.Header().Get(RequestIDHeader),
+	})
+}
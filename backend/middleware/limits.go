@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// DefaultMaxBodyBytes is the largest request body accepted when MAX_BODY_BYTES isn't set
+const DefaultMaxBodyBytes int64 = 10 << 20 // 10MB
+
+// MaxBodyBytesFromEnv reads the request size limit from MAX_BODY_BYTES
+func MaxBodyBytesFromEnv() int64 {
+	if value := os.Getenv("MAX_BODY_BYTES"); value != "" {
+		if limit, err := strconv.ParseInt(value, 10, 64); err == nil && limit > 0 {
+			return limit
+		}
+	}
+	return DefaultMaxBodyBytes
+}
+
+// LimitBody caps request bodies at maxBytes so a huge upload can't exhaust memory.
+// Requests that declare a larger Content-Length are rejected straight away; for the
+// rest, reads past the limit fail and the handler answers 413.
+func LimitBody(maxBytes int64) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				WriteError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next(w, r)
+		}
+	}
+}
@@ -1,7 +1,13 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Policy represents a life insurance policy
 type Policy struct {
+	PolicyID       string  `json:"policy_id,omitempty"` // Echoed back in results; defaults to the position in a batch
 	Age            int     `json:"age" validate:"min=0,max=120"`
 	Term           int     `json:"term" validate:"min=0"`
 	CoverageAmount float64 `json:"sum_assured" validate:"min=0"`
@@ -10,12 +16,104 @@ type Policy struct {
 	ProductType    string  `json:"product_type"`
 	SmokerStatus   string  `json:"smoker_status,omitempty"`
 	HealthRating   string  `json:"health_rating,omitempty"`
+	Impairment     string  `json:"impairment,omitempty"` // e.g. "diabetic"
 	RatingFactor   float64 `json:"rating_factor,omitempty"`
 	DeferralPeriod int     `json:"deferral_period,omitempty"`
+	LapseRate      float64 `json:"lapse_rate,omitempty"`
+
+	UnderwritingClass       string    `json:"underwriting_class,omitempty"`
+	SimplifiedIssueLoadings []float64 `json:"simplified_issue_loadings,omitempty"`
+	Renewable               bool      `json:"renewable,omitempty"`               // Term life: yearly renewable without re-underwriting
+	AntiSelectionLoadings   []float64 `json:"anti_selection_loadings,omitempty"` // Renewable term: qx loading by policy year, the last carrying on
+	DisabilityBenefit       float64   `json:"disability_benefit,omitempty"`
+	InForceDuration         int       `json:"in_force_duration,omitempty"`
+	MortalityExtrapolation  string    `json:"mortality_extrapolation,omitempty"` // Whole life: "immediate" or "linear"
+	UltimateAge             int       `json:"ultimate_age,omitempty"`            // Age where qx reaches 1 for "linear"
+	SurvivalThreshold       float64   `json:"survival_threshold,omitempty"`      // Whole life: stop valuing once the chance of being in force is below this, e.g. 0.0001
+	PaymentFrequency        string    `json:"payment_frequency,omitempty"`       // annual, semi_annual, quarterly, monthly
+	IncludeNetAmountAtRisk  bool      `json:"include_net_amount_at_risk,omitempty"`
+	IncludeSinglePremium    bool      `json:"include_single_premium,omitempty"`   // Also return the one-off premium funding the same benefits
+	FirstYearFeeWaiver      string    `json:"first_year_fee_waiver,omitempty"`    // "absorb" or "respread" to waive the policy fee in year one
+	MassLapse               float64   `json:"mass_lapse,omitempty"`               // Share lapsing at the end of year one on top of lapse_rate
+	SettlementDelay         float64   `json:"settlement_delay,omitempty"`         // Months between the end of the year of death and paying the claim, under 12
+	IncludeDuration         bool      `json:"include_duration,omitempty"`         // Also return the durations of the expected cash flows
+	IncludeProfitByYear     bool      `json:"include_profit_by_year,omitempty"`   // Also return the expected profit in each policy year and its present value
+	IncludePaidUp           bool      `json:"include_paid_up,omitempty"`          // Whole life: also return when the reserve alone pays for the cover
+	IncludeRatePerMille     bool      `json:"include_rate_per_mille,omitempty"`   // Also return the premiums per 1000 sum assured
+	MaturityBenefit         float64   `json:"maturity_benefit,omitempty"`         // Term life: paid on survival to the end of the term
+	CompoundingConvention   string    `json:"compounding_convention,omitempty"`   // "annual" (default) or "continuous"
+	RiskDiscountRate        float64   `json:"risk_discount_rate,omitempty"`       // Set to profit test the policy at this hurdle rate
+	PremiumCeaseAge         int       `json:"premium_cease_age,omitempty"`        // Whole life: premiums stop at this attained age
+	PremiumPayingTerm       int       `json:"premium_paying_term,omitempty"`      // Term life: years premiums are paid for, if fewer than term (e.g. 15 on a 20-year term)
+	ReserveApproach         string    `json:"reserve_approach,omitempty"`         // "prospective" (default) or "retrospective"
+	EchoEffectivePolicy     bool      `json:"echo_effective_policy,omitempty"`    // Return the policy as priced, defaults filled in
+	Locale                  string    `json:"locale,omitempty"`                   // e.g. "de-DE"; adds formatted amounts to the result
+	Currency                string    `json:"currency,omitempty"`                 // ISO 4217 code, e.g. "BWP"
+	ReturnOfPurchasePrice   string    `json:"return_of_purchase_price,omitempty"` // Deferred annuity death benefit: "premium" or "accumulated"
+	CashRefund              bool      `json:"cash_refund,omitempty"`              // Immediate annuity: on death, refund the price less payments made
+	YieldCurve              string    `json:"yield_curve,omitempty"`              // Name of a loaded curve; replaces interest_rate
+	AnnuityTiming           string    `json:"annuity_timing,omitempty"`           // Annuities: "due" (default, in advance) or "immediate" (in arrears)
+	DeferralFraction        float64   `json:"deferral_fraction,omitempty"`        // Deferred annuity: part of a year deferred on top of deferral_period, e.g. 0.5
+
+	Layers []CoverageLayer `json:"layers,omitempty"` // Term life: further tiers of cover on top of sum_assured for term
+
+	DynamicLapse *DynamicLapse `json:"dynamic_lapse,omitempty"` // Life: lapses that rise when market rates beat the credited rate
+
+	RequiredCapital *CapitalBasis `json:"required_capital,omitempty"` // Life: capital held on top of the reserve; with risk_discount_rate, adds the profit release to the profit test
+
+	// The age at issue can be worked out from dates instead; an age sent as well is used as it is
+	DateOfBirth string `json:"date_of_birth,omitempty"` // YYYY-MM-DD
+	IssueDate   string `json:"issue_date,omitempty"`    // YYYY-MM-DD, defaults to today
+	AgeRounding string `json:"age_rounding,omitempty"`  // "last_birthday" (default), "nearest_birthday" or "next_birthday"
+
+	AgeSent bool `json:"-"` // Set when decoding a request that has an age, so an age of 0 counts as sent
+}
+
+// UnmarshalJSON decodes a policy and notes whether it had an age
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	type plain Policy
+	var sent struct {
+		Age *int `json:"age"`
+	}
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		return err
+	}
+	p.AgeSent = sent.Age != nil
+	return nil
+}
+
+// HasAge reports whether the policy was given an age, which then takes
+// precedence over its dates
+func (p *Policy) HasAge() bool {
+	return p.AgeSent || p.Age != 0
+}
+
+// CoverageLayer is a tier of term cover on top of a policy's own sum assured
+// and term, paid on death in the first Term years from issue
+type CoverageLayer struct {
+	CoverageAmount float64 `json:"sum_assured"`
+	Term           int     `json:"term"`
+}
+
+// DynamicLapse adds lapses when market rates beat the rate a policy credits:
+// each year's lapse rate is lapse_rate + sensitivity x the spread of the
+// reference rate over the credited rate beyond the threshold, up to max_lapse.
+// With a yield curve, the reference rate is the curve's one-year forward rate.
+type DynamicLapse struct {
+	ReferenceRate float64  `json:"reference_rate,omitempty"` // Market rate when the policy has no yield curve
+	CreditedRate  *float64 `json:"credited_rate,omitempty"`  // Defaults to the policy's interest rate
+	Sensitivity   float64  `json:"sensitivity"`              // Extra lapse per unit of spread, e.g. 2
+	Threshold     float64  `json:"threshold,omitempty"`      // Spread ignored before lapses rise, e.g. 0.01
+	MaxLapse      float64  `json:"max_lapse,omitempty"`      // Ceiling on the lapse rate, default 0.5
 }
 
 // PremiumCalculation contains the results of premium calculations
 type PremiumCalculation struct {
+	PolicyID         string                 `json:"policy_id,omitempty"`
+	QuoteID          string                 `json:"quote_id,omitempty"` // Set when quotes are stored, for GET /api/quotes/{id}
 	NetPremium       float64                `json:"net_premium"`
 	GrossPremium     float64                `json:"gross_premium"`
 	ReserveSchedule  []float64              `json:"reserve_schedule"`
@@ -25,6 +123,151 @@ type PremiumCalculation struct {
 	TotalPremiumCost float64                `json:"total_premium_cost,omitempty"`
 	UnderwritingInfo map[string]interface{} `json:"underwriting,omitempty"`
 	RiskAssessment   map[string]float64     `json:"risk_assessment,omitempty"`
+	LapseRate        float64                `json:"lapse_rate,omitempty"`
+	InForceDuration  int                    `json:"in_force_duration,omitempty"`
+	UltimateAge      int                    `json:"ultimate_age,omitempty"`
+	PaymentFrequency string                 `json:"payment_frequency,omitempty"`
+	ModalFactor      float64                `json:"modal_factor,omitempty"`
+	ModalPremium     float64                `json:"modal_premium,omitempty"`
+	ModalImpliedRate float64                `json:"modal_implied_rate,omitempty"` // Annual interest rate the modal loading charges; above what money earns, paying annually is worthwhile
+	NetAmountAtRisk  []float64              `json:"net_amount_at_risk,omitempty"`
+	SinglePremium    float64                `json:"single_premium,omitempty"` // Net single premium, with include_single_premium
+	Basis            string                 `json:"basis,omitempty"`
+
+	GrossRatePerMille float64 `json:"gross_rate_per_mille,omitempty"` // Gross premium per 1000 sum assured, with include_rate_per_mille
+	NetRatePerMille   float64 `json:"net_rate_per_mille,omitempty"`
+
+	NewBusinessStrain     float64           `json:"new_business_strain,omitempty"` // First-year expenses + end-year-1 reserve - premium
+	PremiumBreakdown      *PremiumBreakdown `json:"premium_breakdown,omitempty"`   // Life products: the gross premium split into net, expense and profit
+	CompoundingConvention string            `json:"compounding_convention,omitempty"`
+	ProfitTest            *ProfitTestResult `json:"profit_test,omitempty"`
+	PremiumPayingYears    int               `json:"premium_paying_years,omitempty"`
+	EffectivePolicy       *Policy           `json:"effective_policy,omitempty"`    // Set when the request asked for echo_effective_policy
+	DeathBenefitValue     float64           `json:"death_benefit_value,omitempty"` // Annuities: cost of the return of purchase price or cash refund
+
+	ActuarialPremium      float64 `json:"actuarial_premium,omitempty"`       // Gross premium before the minimum premium, when one is set
+	MinimumPremiumApplied bool    `json:"minimum_premium_applied,omitempty"` // GrossPremium is the minimum, not the calculated premium
+	MinimumPremiumLoading float64 `json:"minimum_premium_loading,omitempty"` // Extra loading the minimum implies (0.25 = 25%)
+
+	PremiumBand    *PremiumBand `json:"premium_band,omitempty"`    // Volume discount band the sum assured fell in
+	VolumeDiscount float64      `json:"volume_discount,omitempty"` // Amount taken off the gross premium for the band
+	AnnuityTiming  string       `json:"annuity_timing,omitempty"`  // Annuities: "due" or "immediate"
+
+	PremiumTaxRate      float64 `json:"premium_tax_rate,omitempty"`      // Premium tax levied, when one is set (0.05 = 5%)
+	PremiumTax          float64 `json:"premium_tax,omitempty"`           // Tax on the gross premium
+	TaxInclusivePremium float64 `json:"tax_inclusive_premium,omitempty"` // Gross premium plus tax: what the policyholder pays
+
+	EffectiveDeferral float64 `json:"effective_deferral,omitempty"` // Deferred annuity: years until payments start, fraction included
+	ReserveApproach   string  `json:"reserve_approach,omitempty"`   // Life products: "prospective" or "retrospective"
+	PremiumTiming     string  `json:"premium_timing,omitempty"`     // Life products: "annual_in_advance" or "annual_in_arrears"
+
+	HorizonYears     int  `json:"horizon_years,omitempty"`     // Whole life with a survival threshold: years valued
+	HorizonTruncated bool `json:"horizon_truncated,omitempty"` // The survival threshold cut the valuation short of the table's end
+
+	CashRefundIterations int  `json:"cash_refund_iterations,omitempty"` // Steps taken to solve a cash-refund annuity's price
+	CashRefundConverged  bool `json:"cash_refund_converged,omitempty"`  // False if the price hadn't settled when the solver stopped
+
+	Warnings []string `json:"warnings,omitempty"` // Caveats on the numbers: assumptions made and soft edge cases hit
+
+	Duration *CashFlowDuration `json:"duration,omitempty"` // With include_duration
+
+	PaidUp *PaidUp `json:"paid_up,omitempty"` // With include_paid_up
+
+	FeeWaiver        string  `json:"fee_waiver,omitempty"`         // Rule applied to a first-year fee waiver
+	FirstYearPremium float64 `json:"first_year_premium,omitempty"` // With a fee waiver: the first premium, without the fee
+	RenewalPremium   float64 `json:"renewal_premium,omitempty"`    // With a fee waiver: each premium after the first
+
+	ModifiedReserve *ModifiedReserve `json:"modified_reserve,omitempty"` // With a full_preliminary_term or crvm reserve method
+
+	ProfitByYear *ProfitByYear `json:"profit_by_year,omitempty"` // With include_profit_by_year
+
+	SettlementDelay float64 `json:"settlement_delay,omitempty"` // Months death claims were discounted for on top of the year of death
+
+	ResolvedBasis *Basis `json:"resolved_basis,omitempty"` // The basis valued on, if any
+	BasisSource   string `json:"basis_source,omitempty"`   // "request" or "default" (registered for the table and product)
+
+	Assumptions *Assumptions `json:"assumptions,omitempty"` // Everything the numbers above were calculated on
+
+	LayerPremiums []LayerPremium `json:"layer_premiums,omitempty"` // Layered term: each layer's share of the net premium, the base cover first
+	LapsePath     []float64      `json:"lapse_path,omitempty"`     // Dynamic lapses: the lapse rate in each policy year from issue
+
+	AgeAtIssue  int    `json:"age_at_issue,omitempty"` // The age priced on, when worked out from the date of birth
+	AgeRounding string `json:"age_rounding,omitempty"` // The rule it was rounded by
+
+	// Headline amounts formatted for display, when the policy set a locale or currency.
+	// The float fields above stay the values to calculate with.
+	Locale    string            `json:"locale,omitempty"`
+	Currency  string            `json:"currency,omitempty"`
+	Formatted map[string]string `json:"formatted,omitempty"`
+}
+
+// LayerPremium is one layer's share of a layered policy's net premium
+type LayerPremium struct {
+	CoverageAmount float64 `json:"sum_assured"`
+	Term           int     `json:"term"`
+	NetPremium     float64 `json:"net_premium"`
+}
+
+// ProfitTestResult is a policy's projected profit, discounted at the risk discount rate
+type ProfitTestResult struct {
+	RiskDiscountRate float64   `json:"risk_discount_rate"`
+	ProfitVector     []float64 `json:"profit_vector"`    // Per policy in force at the start of each year
+	ProfitSignature  []float64 `json:"profit_signature"` // Per policy sold
+	ProfitValue      float64   `json:"profit_value"`     // Signature discounted at the risk discount rate
+	PremiumValue     float64   `json:"premium_value"`    // Premiums discounted at the risk discount rate
+	ProfitMargin     float64   `json:"profit_margin"`    // ProfitValue / PremiumValue
+
+	BreakEvenMortality       float64 `json:"break_even_mortality,omitempty"`        // Multiple of the priced qx at which the profit value is zero
+	BreakEvenMortalityStatus string  `json:"break_even_mortality_status,omitempty"` // "found", "never_profitable" or "not_reached"
+
+	ProfitRelease *ProfitRelease `json:"profit_release,omitempty"` // When the policy has required_capital
+}
+
+// ProfitRelease is the profit a policy releases to shareholders after the
+// capital held against it
+type ProfitRelease struct {
+	CapitalStrain         float64   `json:"capital_strain"`         // Capital set up at the start
+	RequiredCapital       []float64 `json:"required_capital"`       // Per policy in force at the start of each year
+	CostOfCapital         []float64 `json:"cost_of_capital"`        // Per policy sold, each year
+	DistributableEarnings []float64 `json:"distributable_earnings"` // Per policy sold, at the end of each year
+	DistributableValue    float64   `json:"distributable_value"`    // Earnings less the strain, discounted at the risk discount rate
+	CostOfCapitalValue    float64   `json:"cost_of_capital_value"`  // Cost of capital discounted at the risk discount rate
+}
+
+// ProfitByYear is one policy's expected profit in each policy year
+type ProfitByYear struct {
+	ExpectedProfit  []float64 `json:"expected_profit"`         // Per policy sold, after deaths and lapses
+	DiscountRate    float64   `json:"discount_rate,omitempty"` // The risk discount rate; omitted when discounted at the pricing interest rate
+	DiscountedTotal float64   `json:"discounted_total"`
+}
+
+// Assumptions is the basis a result was actually calculated on, after the
+// policy, any basis and the server's defaults were combined, so a response can
+// be read and audited on its own
+type Assumptions struct {
+	Units Units `json:"units"`
+
+	InterestRate          float64   `json:"interest_rate"`         // Flat valuation rate; not used when on a yield curve
+	YieldCurve            string    `json:"yield_curve,omitempty"` // Curve valued on instead of the flat rate
+	SpotRates             []float64 `json:"spot_rates,omitempty"`  // The curve's rate for 1, 2, ... years, after any basis shift
+	CompoundingConvention string    `json:"compounding_convention"`
+
+	Table       TableMetadata `json:"table"`
+	ProductType string        `json:"product_type"`
+
+	ReserveMethod    string            `json:"reserve_method,omitempty"` // Life products
+	PremiumTiming    string            `json:"premium_timing,omitempty"` // Life products
+	ClaimTiming      string            `json:"claim_timing,omitempty"`   // Life products
+	PaymentFrequency string            `json:"payment_frequency"`
+	Expenses         *ExpenseStructure `json:"expenses,omitempty"`        // Life products
+	AnnuityLoading   float64           `json:"annuity_loading,omitempty"` // Annuities, on the single premium
+}
+
+// Units says how to read the numbers in a result
+type Units struct {
+	Amounts string `json:"amounts"` // The policy's currency code, or "unspecified"
+	Rates   string `json:"rates"`   // "decimal": 0.05 is 5%
+	Time    string `json:"time"`    // "years", for terms, durations and schedule entries
 }
 
 // ExpenseStructure defines expense assumptions for premium calculations
@@ -35,15 +278,128 @@ type ExpenseStructure struct {
 	ProfitMargin       float64 `json:"profit_margin"`
 }
 
+// DefaultExpenses are the expenses each product is priced with when the request
+// doesn't supply its own through a basis
+type DefaultExpenses struct {
+	Products       map[string]ExpenseStructure `json:"products"`
+	Fallback       ExpenseStructure            `json:"fallback"`        // For products not listed
+	AnnuityLoading float64                     `json:"annuity_loading"` // Annuities are loaded on the single premium instead
+}
+
+// ProductInfo describes a product type and the policy fields it reads
+type ProductInfo struct {
+	ProductType string   `json:"product_type"`
+	Description string   `json:"description"`
+	Required    []string `json:"required"`
+	Optional    []string `json:"optional"`
+	Ignored     []string `json:"ignored"`
+}
+
+// UnderwritingConfig maps smoker statuses and health ratings to mortality multipliers
+type UnderwritingConfig struct {
+	SmokerStatus map[string]float64 `json:"smoker_status,omitempty"` // e.g. {"smoker": 2.0, "non_smoker": 0.8}
+	HealthRating map[string]float64 `json:"health_rating,omitempty"` // e.g. {"preferred": 0.75, "substandard": 1.5}
+
+	Impairments map[string]ImpairmentBasis `json:"impairments,omitempty"` // Registered by name, e.g. {"diabetic": {...}}
+}
+
+// ImpairmentBasis is the mortality basis for one impairment: a table of qx by
+// age replacing the base table, a rating curve of multipliers by attained age,
+// or both
+type ImpairmentBasis struct {
+	Table       []float64     `json:"table,omitempty"`
+	RatingCurve []RatingPoint `json:"rating_curve,omitempty"` // Interpolated linearly, flat beyond the first and last ages
+}
+
+// RatingPoint is an impairment's mortality multiplier at one attained age
+type RatingPoint struct {
+	Age        int     `json:"age"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Basis is a set of valuation assumptions applied to every policy it is sent
+// with. Anything left out falls back to the policy's own values or the defaults.
+type Basis struct {
+	Name                  string              `json:"name,omitempty"`                   // e.g. "pricing", "statutory"
+	InterestRate          *float64            `json:"interest_rate,omitempty"`          // Replaces each policy's interest rate
+	TableName             string              `json:"table_name,omitempty"`             // Replaces each policy's table
+	Expenses              *ExpenseStructure   `json:"expenses,omitempty"`               // Replaces the default expenses
+	Underwriting          *UnderwritingConfig `json:"underwriting,omitempty"`           // Overrides individual smoker/health multipliers
+	AnnuityLoading        *float64            `json:"annuity_loading,omitempty"`        // Replaces the 10% annuity loading
+	ReserveMethod         string              `json:"reserve_method,omitempty"`         // "net_premium", "full_preliminary_term" or "crvm"
+	PremiumTiming         string              `json:"premium_timing,omitempty"`         // "annual_in_advance" (default) or "annual_in_arrears"
+	ClaimTiming           string              `json:"claim_timing,omitempty"`           // "end_of_year"
+	CompoundingConvention string              `json:"compounding_convention,omitempty"` // "annual" or "continuous"
+	RiskDiscountRate      *float64            `json:"risk_discount_rate,omitempty"`     // Hurdle rate for profit testing
+	MinimumPremium        *float64            `json:"minimum_premium,omitempty"`        // Replaces the server's minimum gross premium
+	YieldCurve            string              `json:"yield_curve,omitempty"`            // Name of a loaded curve; replaces the interest rate
+	PremiumBands          []PremiumBand       `json:"premium_bands,omitempty"`          // Replaces the server's volume discount bands
+	PremiumTaxRate        *float64            `json:"premium_tax_rate,omitempty"`       // Replaces the server's premium tax rate
+	ZeroPremium           string              `json:"zero_premium,omitempty"`           // A zero net premium is an error ("reject", the default) or quoted with a warning ("warn")
+
+	MortalityShock *float64 `json:"mortality_shock,omitempty"` // Every qx x (1 + shock): 0.15 = 15% heavier
+	InterestShift  *float64 `json:"interest_shift,omitempty"`  // Added to the interest rate, or to every rate of a yield curve
+
+	ExpenseAllowanceCap *float64 `json:"expense_allowance_cap,omitempty"` // Modified reserves: largest expense allowance; CRVM defaults to the 20-pay whole life allowance
+
+	SettlementDelay *float64 `json:"settlement_delay,omitempty"` // Replaces each policy's claim settlement delay, in months
+}
+
+// DefaultBasis is the basis for policies on one table and product type that
+// are sent without a basis of their own
+type DefaultBasis struct {
+	Table       string `json:"table"`
+	ProductType string `json:"product_type"`
+	Basis       Basis  `json:"basis"`
+}
+
+// PremiumBand gives policies with at least MinCoverage sum assured a discount
+// off the gross premium (0.05 = 5%)
+type PremiumBand struct {
+	MinCoverage float64 `json:"min_coverage"`
+	Discount    float64 `json:"discount"`
+}
+
 // BatchCalculationRequest contains multiple policies for batch processing
 type BatchCalculationRequest struct {
-	Policies []Policy `json:"policies" validate:"required,min=1,max=100"`
+	Policies     []Policy `json:"policies" validate:"required,min=1,max=100"`
+	SummaryStats []string `json:"summary_stats,omitempty"` // e.g. "median_gross", "std_gross", "total_coverage"
+	Basis        *Basis   `json:"basis,omitempty"`         // Valuation basis for every policy in the batch
+	ValidateOnly bool     `json:"validate_only,omitempty"` // Check every policy and report which are invalid, without pricing
 }
 
 // BatchCalculationResponse contains results for batch calculations
 type BatchCalculationResponse struct {
-	Results []PremiumCalculation   `json:"results"`
-	Summary map[string]interface{} `json:"summary"`
+	Results    []PremiumCalculation   `json:"results"`
+	Summary    map[string]interface{} `json:"summary"`
+	Pagination *Pagination            `json:"pagination,omitempty"`
+	Validation []PolicyValidation     `json:"validation,omitempty"` // Per-policy report, set for validate_only requests
+}
+
+// PolicyValidation records whether one policy in a validate_only batch would price
+type PolicyValidation struct {
+	PolicyID string `json:"policy_id"`
+	Valid    bool   `json:"valid"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ProductSubtotal is one product line's share of a batch
+type ProductSubtotal struct {
+	Policies            int     `json:"policies"`
+	TotalNetPremium     float64 `json:"total_net_premium"`
+	TotalGrossPremium   float64 `json:"total_gross_premium"`
+	AverageNetPremium   float64 `json:"average_net_premium"`
+	AverageGrossPremium float64 `json:"average_gross_premium"`
+	TotalCoverage       float64 `json:"total_coverage"`
+	TotalPremiumTax     float64 `json:"total_premium_tax,omitempty"`
+}
+
+// Pagination describes which slice of a larger result set a response holds
+type Pagination struct {
+	Total      int `json:"total"`
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	TotalPages int `json:"total_pages"`
 }
 
 // SensitivityAnalysisRequest defines parameters for sensitivity analysis
@@ -52,6 +408,17 @@ type SensitivityAnalysisRequest struct {
 	InterestRates   []float64 `json:"interest_rates"`
 	Ages            []int     `json:"ages,omitempty"`
 	CoverageAmounts []float64 `json:"coverage_amounts,omitempty"`
+	StressPresets   []string  `json:"stress_presets,omitempty"` // Names of standard stresses, e.g. "solvency_ii_mortality"
+}
+
+// StressPreset is a named, standard stress scenario. Each shock is applied
+// to the base policy's own assumptions; unset shocks leave them alone.
+type StressPreset struct {
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	MortalityShock float64 `json:"mortality_shock,omitempty"` // Every qx x (1 + shock)
+	InterestShift  float64 `json:"interest_shift,omitempty"`  // Added to the interest rate or yield curve
+	MassLapse      float64 `json:"mass_lapse,omitempty"`      // Share lapsing at the end of year one
 }
 
 // SensitivityResult contains a single sensitivity analysis result
@@ -59,17 +426,60 @@ type SensitivityResult struct {
 	Parameter string             `json:"parameter"`
 	Value     float64            `json:"value"`
 	Result    PremiumCalculation `json:"result"`
+	Preset    string             `json:"preset,omitempty"` // Stress preset results: the preset's name
+
+	Duration *CashFlowDuration `json:"duration,omitempty"` // Interest rate results: how far the value moves with rates
+}
+
+// PremiumBreakdown splits a gross premium into the net premium, the expense
+// loading built in from the expense structure and the profit left over
+type PremiumBreakdown struct {
+	NetPremium     float64 `json:"net_premium"`
+	ExpenseLoading float64 `json:"expense_loading"` // Setup cost spread over the premiums, commission and maintenance
+	ProfitLoading  float64 `json:"profit_loading"`  // The rest, after any volume discount or minimum premium
+}
+
+// ModifiedReserve describes the first-year and renewal net premiums a modified
+// reserve was held on, and the expense allowance between them
+type ModifiedReserve struct {
+	Method                string   `json:"method"`
+	FirstYearPremium      float64  `json:"first_year_premium"`
+	RenewalPremium        float64  `json:"renewal_premium"`
+	ExpenseAllowance      float64  `json:"expense_allowance"`  // Renewal less first-year premium, as used
+	UncappedAllowance     float64  `json:"uncapped_allowance"` // The full preliminary term allowance
+	AllowanceCap          *float64 `json:"allowance_cap,omitempty"`
+	LimitedByGrossPremium bool     `json:"limited_by_gross_premium,omitempty"` // Cut so the renewal premium stays within the gross premium
+}
+
+// CashFlowDuration is the Macaulay duration in years of a policy's expected
+// cash flows at the rate it was priced at
+type CashFlowDuration struct {
+	Benefits        float64 `json:"benefits"`
+	Premiums        float64 `json:"premiums"`
+	Net             float64 `json:"net"`               // Benefits less premiums, the liability; 0 when their values cancel out
+	NetPresentValue float64 `json:"net_present_value"` // Value of the benefits less the premiums
 }
 
 // SensitivityAnalysisResponse contains full sensitivity analysis results
 type SensitivityAnalysisResponse struct {
 	BaseResult PremiumCalculation        `json:"base_result"`
 	Analysis   map[string][]SensitivityResult `json:"analysis"`
+
+	StressPresets map[string]StressPreset `json:"stress_presets,omitempty"` // Definitions of the presets applied, so the results document their basis
 }
 
 // PortfolioAnalysisRequest contains policies for portfolio analysis
 type PortfolioAnalysisRequest struct {
 	Policies []Policy `json:"policies" validate:"required,min=1"`
+
+	ExpectedClaimRate *float64 `json:"expected_claim_rate,omitempty"` // Flat claims as a share of coverage, replacing the mortality-based figure
+	TopN              int      `json:"top_n,omitempty"`               // Largest policies counted in the concentration share (default 10)
+
+	// Set ReportingCurrency to total policies in different currencies. Each
+	// policy's amounts are converted at its currency's rate, in units of the
+	// reporting currency per unit; policies without a currency are in it.
+	ReportingCurrency string             `json:"reporting_currency,omitempty"`
+	ExchangeRates     map[string]float64 `json:"exchange_rates,omitempty"`
 }
 
 // PortfolioMetrics contains aggregated portfolio statistics
@@ -83,11 +493,504 @@ type PortfolioMetrics struct {
 	GenderDistribution   map[string]int     `json:"gender_distribution"`
 	RiskDistribution     map[string]int     `json:"risk_distribution"`
 	ProfitabilityMetrics map[string]float64 `json:"profitability_metrics"`
+	Concentration        ConcentrationMetrics `json:"concentration"`
+	NetAmountAtRisk      float64              `json:"net_amount_at_risk"` // Sum assured less today's reserve, totalled over the policies paying on death
+	AtRiskMortality      float64              `json:"at_risk_mortality"`  // Underwritten qx weighted by net amount at risk: Σ(NAR × qx) / Σ NAR
+	SkippedPolicies      []SkippedPolicy      `json:"skipped_policies,omitempty"`
+
+	ReportingCurrency string                      `json:"reporting_currency,omitempty"` // Currency every total above is in
+	CurrencySubtotals map[string]CurrencySubtotal `json:"currency_subtotals,omitempty"` // By policy currency, when converting
+}
+
+// CurrencySubtotal totals the policies in one currency, in that currency
+type CurrencySubtotal struct {
+	Policies          int     `json:"policies"`
+	ExchangeRate      float64 `json:"exchange_rate"` // Reporting currency per unit
+	TotalCoverage     float64 `json:"total_coverage"`
+	TotalNetPremium   float64 `json:"total_net_premium"`
+	TotalGrossPremium float64 `json:"total_gross_premium"`
+}
+
+// ConcentrationMetrics show how far a portfolio's cover rests on a few large
+// policies. High concentration is a sign the portfolio needs reinsurance.
+type ConcentrationMetrics struct {
+	TopN                       int     `json:"top_n"`
+	TopNShare                  float64 `json:"top_n_share"`      // Share of the total sum assured on the TopN largest policies
+	HerfindahlIndex            float64 `json:"herfindahl_index"` // Sum of each policy's squared share of the sum assured: 1/n when even, 1 for a single policy
+	LargestNetAmountAtRisk     float64 `json:"largest_net_amount_at_risk"`
+	LargestNetAmountAtRiskFrom string  `json:"largest_net_amount_at_risk_policy,omitempty"` // The policy it is on
+}
+
+// SkippedPolicy records a policy left out of a portfolio analysis and why
+type SkippedPolicy struct {
+	PolicyID string `json:"policy_id"`
+	Reason   string `json:"reason"`
+}
+
+// BreakEvenResult reports when a policy's cumulative premiums cross key milestones.
+// Durations are policy years; nil means the milestone is never reached within the term.
+type BreakEvenResult struct {
+	ProductType                string    `json:"product_type"`
+	GrossPremium               float64   `json:"gross_premium"`
+	SumAssured                 float64   `json:"sum_assured"`
+	PremiumExceedsCoverageYear *int      `json:"premium_exceeds_coverage_year"`
+	ReserveExceedsPremiumsYear *int      `json:"reserve_exceeds_premiums_year"`
+	ReserveSchedule            []float64 `json:"reserve_schedule"`
+}
+
+// PaidUp is when a policy's reserve alone would pay for the rest of its cover
+type PaidUp struct {
+	Duration         *int      `json:"duration"`            // Years from issue; null if the policy never becomes paid-up
+	CostOfCover      []float64 `json:"cost_of_cover"`       // Single premium for the remaining cover at each duration
+	PaidUpSumAssured []float64 `json:"paid_up_sum_assured"` // Reduced paid-up cover the reserve buys at each duration
+}
+
+// PaidUpResult is when a whole life policy becomes fully paid-up and
+// self-sustaining, with the reduced paid-up cover at every duration before
+type PaidUpResult struct {
+	ProductType        string    `json:"product_type"`
+	NetPremium         float64   `json:"net_premium"`
+	PremiumPayingYears int       `json:"premium_paying_years"`
+	SelfSustaining     bool      `json:"self_sustaining"`
+	PaidUpDuration     *int      `json:"paid_up_duration"` // Years from issue; null if never
+	PaidUpAge          *int      `json:"paid_up_age"`
+	ReserveSchedule    []float64 `json:"reserve_schedule"`
+	CostOfCover        []float64 `json:"cost_of_cover"`
+	PaidUpSumAssured   []float64 `json:"paid_up_sum_assured"`
+}
+
+// ScenarioAnalysisRequest reprices a policy on every scenario of a loaded set
+type ScenarioAnalysisRequest struct {
+	Policy      Policy    `json:"policy"`
+	ScenarioSet string    `json:"scenario_set"`
+	Percentiles []float64 `json:"percentiles,omitempty"` // Shares, e.g. 0.995; defaults to 0.05, 0.25, 0.5, 0.75 and 0.95
+}
+
+// ScenarioOutcome is a policy priced on one scenario
+type ScenarioOutcome struct {
+	Scenario        string    `json:"scenario"`
+	NetPremium      float64   `json:"net_premium"`
+	GrossPremium    float64   `json:"gross_premium"`
+	ReserveSchedule []float64 `json:"reserve_schedule,omitempty"`
+	LapsePath       []float64 `json:"lapse_path,omitempty"` // With dynamic lapses: the lapse rate in each year on this scenario
+}
+
+// ScenarioDistribution is how one amount is spread across the scenarios
+type ScenarioDistribution struct {
+	Mean        float64            `json:"mean"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles"` // By share, 0.995 as "p99.5"
+}
+
+// ScenarioAnalysisResult is a policy priced on every scenario of a set, with
+// the spread of its premiums and reserves
+type ScenarioAnalysisResult struct {
+	ScenarioSet        string               `json:"scenario_set"`
+	ProductType        string               `json:"product_type"`
+	NetPremium         ScenarioDistribution `json:"net_premium"`
+	GrossPremium       ScenarioDistribution `json:"gross_premium"`
+	ReservePercentiles map[string][]float64 `json:"reserve_percentiles,omitempty"` // Each percentile of the reserve at every duration
+	Outcomes           []ScenarioOutcome    `json:"outcomes"`
+}
+
+// GrossPremiumValuationRequest tests a policy's net premium reserve against a
+// gross premium valuation on a best-estimate table
+type GrossPremiumValuationRequest struct {
+	Policy            Policy  `json:"policy"`
+	BestEstimateTable string  `json:"best_estimate_table,omitempty"` // Defaults to the policy's own table
+	MortalityShock    float64 `json:"mortality_shock,omitempty"`     // Added to every best-estimate rate: 0.1 = 10% heavier
+}
+
+// FractionalReserveRequest asks for a policy's reserve between anniversaries
+type FractionalReserveRequest struct {
+	Policy   Policy  `json:"policy"`
+	Duration float64 `json:"duration"`         // Years since issue, e.g. 5.25
+	Method   string  `json:"method,omitempty"` // "premium_adjusted" (default) or "linear"
+}
+
+// FractionalReserve is the reserve part of the way through a policy year,
+// with the anniversary reserves it was interpolated between
+type FractionalReserve struct {
+	Duration        float64 `json:"duration"`
+	Method          string  `json:"method"`
+	Reserve         float64 `json:"reserve"`
+	ReserveAtStart  float64 `json:"reserve_at_start"`
+	ReserveAtEnd    float64 `json:"reserve_at_end"`
+	UnearnedPremium float64 `json:"unearned_premium,omitempty"` // Net premium paid for the rest of the year
+	NetPremium      float64 `json:"net_premium"`
+}
+
+// GrossPremiumValuationResult compares the gross premium reserve with the net
+// premium reserve at each duration. Years where the gross premium reserve is
+// higher are deficient.
+type GrossPremiumValuationResult struct {
+	ProductType         string    `json:"product_type"`
+	BestEstimateTable   string    `json:"best_estimate_table"`
+	MortalityShock      float64   `json:"mortality_shock,omitempty"`
+	GrossPremium        float64   `json:"gross_premium"`
+	NetPremiumReserve   []float64 `json:"net_premium_reserve"`
+	GrossPremiumReserve []float64 `json:"gross_premium_reserve"`
+	Deficiency          []float64 `json:"deficiency"`      // max(0, gross premium reserve - net premium reserve)
+	DeficientYears      []int     `json:"deficient_years"` // Durations where the net premium reserve falls short
+	Adequate            bool      `json:"adequate"`
+}
+
+// IFRS17Request asks for a policy's IFRS 17 fulfilment cash flows, valued at
+// its in-force duration on a best-estimate table as for gross premium valuation
+type IFRS17Request struct {
+	Policy            Policy               `json:"policy"`
+	BestEstimateTable string               `json:"best_estimate_table,omitempty"` // Defaults to the policy's own table
+	MortalityShock    float64              `json:"mortality_shock,omitempty"`     // Added to every best-estimate rate: 0.1 = 10% heavier
+	RiskAdjustment    RiskAdjustmentConfig `json:"risk_adjustment"`
+}
+
+// RiskAdjustmentConfig sets the IFRS 17 risk adjustment for non-financial risk
+type RiskAdjustmentConfig struct {
+	Method          string  `json:"method,omitempty"`           // "percentage" (default) or "confidence_level"
+	Percentage      float64 `json:"percentage,omitempty"`       // Loading on the claims value: 0.05 = 5%
+	ConfidenceLevel float64 `json:"confidence_level,omitempty"` // e.g. 0.75 to hold claims at their 75th percentile
+}
+
+// IFRS17Result is a policy's fulfilment cash flows: the present value of its
+// best-estimate premiums, claims and expenses, and the risk adjustment
+type IFRS17Result struct {
+	ProductType       string  `json:"product_type"`
+	BestEstimateTable string  `json:"best_estimate_table"`
+	MortalityShock    float64 `json:"mortality_shock,omitempty"`
+	ValuationDuration int     `json:"valuation_duration"` // Years since issue the cash flows are valued at
+	GrossPremium      float64 `json:"gross_premium"`
+
+	PremiumValue            float64 `json:"premium_value"`
+	ClaimsValue             float64 `json:"claims_value"`
+	ExpenseValue            float64 `json:"expense_value"`
+	BestEstimateLiability   float64 `json:"best_estimate_liability"`   // Claims and expenses less premiums
+	ClaimsStandardDeviation float64 `json:"claims_standard_deviation"` // Of the present value of claims
+
+	RiskAdjustmentMethod string  `json:"risk_adjustment_method"`
+	RiskAdjustment       float64 `json:"risk_adjustment"`
+	FulfilmentCashFlows  float64 `json:"fulfilment_cash_flows"` // Best-estimate liability plus risk adjustment
+}
+
+// RevaluationRequest values a book of in-force policies on new assumptions and
+// compares the reserves with those on the basis they were last valued on.
+// Each policy gives its age at issue, in_force_duration and original terms.
+type RevaluationRequest struct {
+	Policies   []Policy `json:"policies" validate:"required,min=1"`
+	Basis      Basis    `json:"basis"`                 // The new assumptions
+	PriorBasis *Basis   `json:"prior_basis,omitempty"` // Defaults to each policy's default basis, or its own assumptions
+
+	// As for portfolio analysis, to total policies in different currencies
+	ReportingCurrency string             `json:"reporting_currency,omitempty"`
+	ExchangeRates     map[string]float64 `json:"exchange_rates,omitempty"`
+}
+
+// PolicyRevaluation is one policy's reserve today on the prior and new bases,
+// in its own currency
+type PolicyRevaluation struct {
+	PolicyID        string    `json:"policy_id"`
+	ProductType     string    `json:"product_type"`
+	InForceDuration int       `json:"in_force_duration"`
+	PriorReserve    float64   `json:"prior_reserve"`
+	RevisedReserve  float64   `json:"revised_reserve"`
+	ReserveMovement float64   `json:"reserve_movement"` // Revised less prior: positive strengthens the reserve
+	ReserveSchedule []float64 `json:"reserve_schedule"` // On the new basis, from today
+}
+
+// RevaluationResult is the effect of an assumption change on the in-force
+// book. The totals are in the reporting currency, when one is set.
+type RevaluationResult struct {
+	Policies             []PolicyRevaluation `json:"policies"`
+	TotalPriorReserve    float64             `json:"total_prior_reserve"`
+	TotalRevisedReserve  float64             `json:"total_revised_reserve"`
+	TotalReserveMovement float64             `json:"total_reserve_movement"`
+	ProfitFromChange     float64             `json:"profit_from_change"`  // Reserve released, or a loss when negative
+	MovementByProduct    map[string]float64  `json:"movement_by_product"` // TotalReserveMovement split by product
+	ReportingCurrency    string              `json:"reporting_currency,omitempty"`
+	SkippedPolicies      []SkippedPolicy     `json:"skipped_policies,omitempty"`
+}
+
+// EmbeddedValueRequest values a book of policies for the shareholders: the
+// profits still to come on each, discounted at the risk discount rate, plus
+// the net assets held today
+type EmbeddedValueRequest struct {
+	Policies         []Policy      `json:"policies" validate:"required,min=1"`
+	RiskDiscountRate float64       `json:"risk_discount_rate"`         // Discounts every policy's profit signature
+	NetAssetValue    float64       `json:"net_asset_value"`            // Shareholders' net assets today, in the reporting currency
+	RequiredCapital  *CapitalBasis `json:"required_capital,omitempty"` // Defaults to 4% of reserves plus 0.3% of sums at risk
+
+	// As for portfolio analysis, to total policies in different currencies
+	ReportingCurrency string             `json:"reporting_currency,omitempty"`
+	ExchangeRates     map[string]float64 `json:"exchange_rates,omitempty"`
+}
+
+// CapitalBasis sets the capital required against each policy. A factor left
+// out keeps its default.
+type CapitalBasis struct {
+	ReserveFactor   *float64 `json:"reserve_factor,omitempty"`     // Share of the reserve
+	SumAtRiskFactor *float64 `json:"sum_at_risk_factor,omitempty"` // Share of the sum assured less the reserve
+}
+
+// PolicyEmbeddedValue is one policy's share of the embedded value, in its own currency
+type PolicyEmbeddedValue struct {
+	PolicyID        string  `json:"policy_id"`
+	ProductType     string  `json:"product_type"`
+	InForceDuration int     `json:"in_force_duration"`
+	Reserve         float64 `json:"reserve"`          // Held today
+	RequiredCapital float64 `json:"required_capital"` // Held today on top of the reserve
+	ValueOfInForce  float64 `json:"value_of_in_force"`
+}
+
+// EmbeddedValueResult is a book's embedded value and its components. The
+// totals are in the reporting currency, when one is set.
+type EmbeddedValueResult struct {
+	Policies                []PolicyEmbeddedValue `json:"policies"`
+	RiskDiscountRate        float64               `json:"risk_discount_rate"`
+	TotalReserve            float64               `json:"total_reserve"`
+	NetAssetValue           float64               `json:"net_asset_value"`
+	RequiredCapital         float64               `json:"required_capital"`
+	FreeSurplus             float64               `json:"free_surplus"`                 // Net assets less required capital; negative when short of capital
+	ValueOfInForce          float64               `json:"value_of_in_force"`            // Future profits discounted at the risk discount rate
+	EmbeddedValue           float64               `json:"embedded_value"`               // Net assets plus value of in-force
+	ValueOfInForceByProduct map[string]float64    `json:"value_of_in_force_by_product"` // ValueOfInForce split by product
+	ReportingCurrency       string                `json:"reporting_currency,omitempty"`
+	SkippedPolicies         []SkippedPolicy       `json:"skipped_policies,omitempty"`
+}
+
+// CensusLife is one member of a group scheme's census
+type CensusLife struct {
+	Age            int     `json:"age"`
+	Gender         string  `json:"table_name"`
+	CoverageAmount float64 `json:"sum_assured"`
+
+	SmokerStatus string `json:"smoker_status,omitempty"`
+	HealthRating string `json:"health_rating,omitempty"`
+}
+
+// GroupQuoteRequest prices a whole scheme from its census using shared scheme-level terms
+type GroupQuoteRequest struct {
+	SchemeName   string       `json:"scheme_name,omitempty"`
+	Term         int          `json:"term"`
+	InterestRate float64      `json:"interest_rate"`
+	ProductType  string       `json:"product_type,omitempty"`
+	Census       []CensusLife `json:"census" validate:"required,min=1"`
+}
+
+// GroupQuoteResponse is the single blended result for a group scheme
+type GroupQuoteResponse struct {
+	SchemeName           string  `json:"scheme_name,omitempty"`
+	TotalLives           int     `json:"total_lives"`
+	TotalCoverage        float64 `json:"total_coverage"`
+	TotalNetPremium      float64 `json:"total_net_premium"`
+	TotalGrossPremium    float64 `json:"total_gross_premium"`
+	AverageAge           float64 `json:"average_age"`
+	NetRatePerThousand   float64 `json:"net_rate_per_thousand"`
+	GrossRatePerThousand float64 `json:"gross_rate_per_thousand"`
+}
+
+// BundleRider is term cover on a dependant's life, sold with a bundle's main policy
+type BundleRider struct {
+	Relationship   string  `json:"relationship"` // "spouse" or "child"
+	Age            int     `json:"age"`
+	Gender         string  `json:"table_name,omitempty"` // Defaults to the main life's table
+	CoverageAmount float64 `json:"sum_assured"`
+	Term           int     `json:"term,omitempty"` // Defaults to the main policy's term, and can't run past it
+
+	SmokerStatus string `json:"smoker_status,omitempty"`
+	HealthRating string `json:"health_rating,omitempty"`
+}
+
+// BundleRequest is a family bundle: a main term or whole life policy and
+// riders covering the spouse and children. The riders are paid for on the
+// main policy's interest rate, payment frequency and currency.
+type BundleRequest struct {
+	Primary Policy        `json:"primary"`
+	Riders  []BundleRider `json:"riders"`
+}
+
+// BundleComponent is one life's cover in a bundle and what it costs
+type BundleComponent struct {
+	Component      string  `json:"component"` // "primary", "spouse" or "child"
+	Age            int     `json:"age"`
+	TableName      string  `json:"table_name"`
+	CoverageAmount float64 `json:"sum_assured"`
+	Term           int     `json:"term,omitempty"`
+	NetPremium     float64 `json:"net_premium"`
+	GrossPremium   float64 `json:"gross_premium"`
+	ModalPremium   float64 `json:"modal_premium"`
+}
+
+// BundleResult is a bundle's single premium and the components it adds up from
+type BundleResult struct {
+	Components        []BundleComponent `json:"components"` // The primary first, then the riders in the order sent
+	PaymentFrequency  string            `json:"payment_frequency"`
+	TotalCoverage     float64           `json:"total_coverage"`
+	TotalNetPremium   float64           `json:"total_net_premium"`
+	TotalGrossPremium float64           `json:"total_gross_premium"`
+	TotalModalPremium float64           `json:"total_modal_premium"` // Each installment of the whole bundle
+}
+
+// ExperienceRefundRequest is a group scheme's census and the death claims it
+// actually paid over one policy year
+type ExperienceRefundRequest struct {
+	SchemeName   string       `json:"scheme_name,omitempty"`
+	Census       []CensusLife `json:"census" validate:"required,min=1"`
+	ActualClaims float64      `json:"actual_claims"`
+	ProfitShare  float64      `json:"profit_share"` // Share of the shortfall refunded (0.5 = 50%)
+}
+
+// ExperienceRefundResult compares a scheme's claims with those expected from
+// the mortality basis and gives the refund due
+type ExperienceRefundResult struct {
+	SchemeName       string  `json:"scheme_name,omitempty"`
+	TotalLives       int     `json:"total_lives"`
+	ExpectedClaims   float64 `json:"expected_claims"` // Sum of sum assured x underwritten qx
+	ActualClaims     float64 `json:"actual_claims"`
+	ActualToExpected float64 `json:"actual_to_expected"` // A/E ratio
+	ProfitShare      float64 `json:"profit_share"`
+	Refund           float64 `json:"refund"`
+}
+
+// JointLife is one of the two lives on a joint-life policy
+type JointLife struct {
+	Age    int    `json:"age"`
+	Gender string `json:"table_name"`
+}
+
+// JointLifeRequest prices an annuity paid while both lives are alive and,
+// with a reversion, a reduced amount to the survivor after the first death
+type JointLifeRequest struct {
+	Life1               JointLife `json:"life_1"`
+	Life2               JointLife `json:"life_2"`
+	AnnualPayout        float64   `json:"annual_payout"`
+	InterestRate        float64   `json:"interest_rate"`
+	Method              string    `json:"method,omitempty"`               // "exact" (default) or "equal_age"
+	ReversionPercentage float64   `json:"reversion_percentage,omitempty"` // Percent of the payout the survivor keeps, e.g. 50 (default 0: payments stop)
+	Dependence          float64   `json:"dependence,omitempty"`           // Kendall's tau between the lifetimes under a Clayton copula, 0 (default: independent) to below 1
+}
+
+// JointLifeResult is the value of a joint-life annuity
+type JointLifeResult struct {
+	Method        string  `json:"method"`
+	AnnuityFactor float64 `json:"annuity_factor"`
+	SinglePremium float64 `json:"single_premium"`
+	AnnualPayout  float64 `json:"annual_payout"`
+	EquivalentAge *int    `json:"equivalent_age,omitempty"` // equal_age method only
+
+	ReversionPercentage float64 `json:"reversion_percentage"` // Percent of the payout paid to the survivor
+	Dependence          float64 `json:"dependence"`           // Kendall's tau between the lifetimes
+}
+
+// LevelPremiumRequest is a universal life policy paid by irregular premiums,
+// to be compared with the level annual premium funding the same benefits
+type LevelPremiumRequest struct {
+	Age          int       `json:"age"`
+	Term         int       `json:"term"`
+	SumAssured   float64   `json:"sum_assured"`
+	Gender       string    `json:"table_name,omitempty"`
+	Premiums     []float64 `json:"premiums"`               // Paid at the start of each policy year; years past the end pay nothing
+	CreditedRate float64   `json:"credited_rate"`          // Interest credited to the account each year
+	PremiumLoad  float64   `json:"premium_load,omitempty"` // Part of each premium kept by the insurer (0.05 = 5%)
+	AdminCharge  float64   `json:"admin_charge,omitempty"` // Taken from the account at the start of each year
+	Target       string    `json:"target,omitempty"`       // "terminal_value" (default) or "in_force"
+
+	CorridorFactors []CorridorFactor `json:"corridor_factors,omitempty"` // Death benefit minimum as a multiple of the account; default US corridor, [] for none
+}
+
+// CorridorFactor is the smallest death benefit allowed at an attained age, as
+// a multiple of the account value
+type CorridorFactor struct {
+	Age    int     `json:"age"`
+	Factor float64 `json:"factor"`
+}
+
+// LevelPremiumResult is the level annual premium equivalent to an irregular
+// premium stream, with the account value each leaves at the end of every year
+type LevelPremiumResult struct {
+	Target       string  `json:"target"`
+	LevelPremium float64 `json:"level_premium"`
+
+	IrregularAccountValues []float64 `json:"irregular_account_values"`
+	LevelAccountValues     []float64 `json:"level_account_values"`
+	IrregularLapseYear     *int      `json:"irregular_lapse_year,omitempty"` // Policy year the irregular premiums ran out in, counting from 1
+}
+
+// TableMetadata describes a loaded mortality table
+type TableMetadata struct {
+	Name     string    `json:"name"`
+	Source   string    `json:"source"`
+	MinAge   int       `json:"min_age"`
+	MaxAge   int       `json:"max_age"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Abridged bool      `json:"abridged,omitempty"` // Missing ages were interpolated
+
+	RateScale string `json:"rate_scale,omitempty"` // How the file wrote qx: "probability" or "per_1000" (divided by 1000 on loading)
+
+	DerivedFrom  []string `json:"derived_from,omitempty"`  // Source tables of a derived table
+	SelectPeriod int      `json:"select_period,omitempty"` // Years of select rates after issue, for select tables
+}
+
+// TableOperation is one term of a derived table: Weight x Scalar x the source's rates
+type TableOperation struct {
+	Table  string   `json:"table"`
+	Weight *float64 `json:"weight,omitempty"` // Defaults to 1
+	Scalar *float64 `json:"scalar,omitempty"` // Defaults to 1
+}
+
+// DeriveTableRequest builds a new table from existing ones, e.g.
+// [{table: male, weight: 0.5}, {table: female, weight: 0.5}] for a unisex table
+type DeriveTableRequest struct {
+	Name       string           `json:"name"`
+	Operations []TableOperation `json:"operations"`
+}
+
+// SurvivalCurveRequest asks for a table's survival curve from an age, with an
+// optional underwriting overlay applied to the table first
+type SurvivalCurveRequest struct {
+	Table        string  `json:"table"`
+	FromAge      *int    `json:"from_age,omitempty"` // Defaults to the table's first age
+	SmokerStatus string  `json:"smoker_status,omitempty"`
+	HealthRating string  `json:"health_rating,omitempty"`
+	RatingFactor float64 `json:"rating_factor,omitempty"` // Replaces the smoker and health multipliers, as on a policy
+}
+
+// SurvivalCurve is tpx by attained age, ready to plot
+type SurvivalCurve struct {
+	Table        string          `json:"table"`
+	FromAge      int             `json:"from_age"`
+	SmokerStatus string          `json:"smoker_status,omitempty"`
+	HealthRating string          `json:"health_rating,omitempty"`
+	RatingFactor float64         `json:"rating_factor,omitempty"`
+	Points       []SurvivalPoint `json:"points"`
+}
+
+// SurvivalPoint is the chance of being alive at Age, having been alive at the curve's FromAge
+type SurvivalPoint struct {
+	Age                 int     `json:"age"`
+	SurvivalProbability float64 `json:"survival_probability"`
+}
+
+// VersionInfo identifies the code and calculation basis that produced a result
+type VersionInfo struct {
+	Commit    string          `json:"commit"`
+	BuildTime string          `json:"build_time"`
+	GoVersion string          `json:"go_version"`
+	Tables    []TableMetadata `json:"tables"`
+}
+
+// Quote is a stored premium calculation: the policy as requested and the result returned
+type Quote struct {
+	ID        string             `json:"id"`
+	CreatedAt time.Time          `json:"created_at"`
+	Policy    Policy             `json:"policy"`
+	Result    PremiumCalculation `json:"result"`
 }
 
 // ErrorResponse standardizes error responses
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    string `json:"code,omitempty"`
-	Details string `json:"details,omitempty"`
+	Error     string    `json:"error"`
+	Code      string    `json:"code,omitempty"`
+	Details   string    `json:"details,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id,omitempty"` // Matches the X-Request-ID response header
 }
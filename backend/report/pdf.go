@@ -0,0 +1,86 @@
+// Package report renders calculation results as documents for people rather
+// than programs, such as the branded PDF quote.
+package report
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// A4 in PDF points (1/72 inch)
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// The standard font every PDF reader has, so none needs embedding
+const fontFamily = "Helvetica"
+
+// rgb is a colour with each part between 0 and 255
+type rgb struct{ r, g, b int }
+
+// pdfImage is a JPEG logo, which PDF can show without re-encoding
+type pdfImage struct {
+	data          []byte
+	width, height int
+}
+
+// pdfDocument draws on an fpdf document in points measured from the bottom
+// left of the page, the way the quote is laid out, rather than fpdf's top left
+type pdfDocument struct {
+	pdf       *fpdf.Fpdf
+	translate func(string) string // UTF-8 into the standard fonts' encoding
+	image     *pdfImage
+}
+
+// newDocument starts an A4 document. Pages are broken by the layout, not by
+// fpdf, and "{nb}" in the text becomes the number of pages.
+func newDocument(image *pdfImage, created time.Time) *pdfDocument {
+	pdf := fpdf.New("P", "pt", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AliasNbPages("")
+	pdf.SetCreationDate(created)
+	if image != nil {
+		pdf.RegisterImageOptionsReader("logo", fpdf.ImageOptions{ImageType: "JPG"}, bytes.NewReader(image.data))
+	}
+	return &pdfDocument{pdf: pdf, translate: pdf.UnicodeTranslatorFromDescriptor(""), image: image}
+}
+
+// text draws s with its baseline starting at (x, y)
+func (d *pdfDocument) text(x, y float64, bold bool, size float64, colour rgb, s string) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	d.pdf.SetFont(fontFamily, style, size)
+	d.pdf.SetTextColor(colour.r, colour.g, colour.b)
+	d.pdf.Text(x, pageHeight-y, d.translate(s))
+}
+
+// fillRect fills the rectangle with its bottom left at (x, y)
+func (d *pdfDocument) fillRect(x, y, width, height float64, colour rgb) {
+	d.pdf.SetFillColor(colour.r, colour.g, colour.b)
+	d.pdf.Rect(x, pageHeight-y-height, width, height, "F")
+}
+
+func (d *pdfDocument) line(x1, y1, x2, y2 float64, colour rgb) {
+	d.pdf.SetDrawColor(colour.r, colour.g, colour.b)
+	d.pdf.SetLineWidth(0.5)
+	d.pdf.Line(x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+// drawImage places the document's image with its bottom left at (x, y)
+func (d *pdfDocument) drawImage(x, y, width, height float64) {
+	d.pdf.ImageOptions("logo", x, pageHeight-y-height, width, height, false, fpdf.ImageOptions{ImageType: "JPG"}, 0, "")
+}
+
+// bytes writes the document out, closing its last page
+func (d *pdfDocument) bytes() ([]byte, error) {
+	var out bytes.Buffer
+	if err := d.pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
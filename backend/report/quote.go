@@ -0,0 +1,344 @@
+package report
+
+import (
+	"actuworry/backend/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Branding is how a distribution partner's quotes look
+type Branding struct {
+	CompanyName  string `json:"company_name"`
+	Tagline      string `json:"tagline,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty"` // "#RRGGBB", for the header band and headings
+	LogoPath     string `json:"logo_path,omitempty"`     // A JPEG, relative to the branding file
+	Footer       string `json:"footer,omitempty"`        // e.g. the insurer's registration and disclaimer
+
+	logo *pdfImage
+}
+
+// DefaultBranding is used when no branding is configured
+func DefaultBranding() Branding {
+	return Branding{CompanyName: "Actuworry", Tagline: "Life insurance quote", PrimaryColor: "#1F4E79"}
+}
+
+// LoadBranding reads branding from a JSON file and loads its logo. Anything
+// the file leaves out keeps its default.
+func LoadBranding(path string) (Branding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Branding{}, err
+	}
+	branding := DefaultBranding()
+	if err := json.Unmarshal(data, &branding); err != nil {
+		return Branding{}, fmt.Errorf("invalid branding in %s: %w", path, err)
+	}
+	if _, err := parseColor(branding.PrimaryColor); err != nil {
+		return Branding{}, err
+	}
+	if branding.LogoPath != "" {
+		logoPath := branding.LogoPath
+		if !filepath.IsAbs(logoPath) {
+			logoPath = filepath.Join(filepath.Dir(path), logoPath)
+		}
+		if branding.logo, err = loadJPEG(logoPath); err != nil {
+			return Branding{}, fmt.Errorf("logo %s: %w", logoPath, err)
+		}
+	}
+	return branding, nil
+}
+
+// loadJPEG reads a logo. Only JPEGs are supported, since PDF can embed them
+// without re-encoding.
+func loadJPEG(path string) (*pdfImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("logo must be a JPEG: %w", err)
+	}
+	return &pdfImage{data: data, width: config.Width, height: config.Height}, nil
+}
+
+func parseColor(hex string) (rgb, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(hex, "#")) != 6 {
+		return rgb{}, fmt.Errorf("primary color must be #RRGGBB, got %q", hex)
+	}
+	return rgb{int(value >> 16 & 0xff), int(value >> 8 & 0xff), int(value & 0xff)}, nil
+}
+
+var (
+	black = rgb{0, 0, 0}
+	grey  = rgb{102, 102, 102}
+	rule  = rgb{204, 204, 204}
+	white = rgb{255, 255, 255}
+)
+
+// Page layout, in points
+const (
+	margin       = 50.0
+	headerHeight = 80.0
+	lineHeight   = 15.0
+	footerSpace  = 60.0 // Kept clear at the bottom of each page for the footer
+)
+
+// quoteLayout writes a quote top to bottom, starting new pages as it fills them
+type quoteLayout struct {
+	document *pdfDocument
+	y        float64 // Baseline of the next line
+	brand    rgb
+	branding Branding
+}
+
+// Quote renders a calculated quote as a PDF: the policy, the premium summary,
+// the underwriting details, the risk assessment and the reserve schedule.
+// Amounts are shown in the policy's locale and currency, if it has them.
+func Quote(policy models.Policy, result models.PremiumCalculation, branding Branding, issued time.Time) ([]byte, error) {
+	brand, err := parseColor(branding.PrimaryColor)
+	if err != nil {
+		brand = rgb{31, 78, 121}
+	}
+	layout := &quoteLayout{document: newDocument(branding.logo, issued), brand: brand, branding: branding}
+	layout.document.pdf.SetFooterFunc(layout.footer)
+	layout.newPage()
+
+	layout.heading("Quotation")
+	layout.row("Issued", issued.UTC().Format("2 January 2006"))
+	if result.QuoteID != "" {
+		layout.row("Quote reference", result.QuoteID)
+	}
+	if policy.PolicyID != "" {
+		layout.row("Policy", policy.PolicyID)
+	}
+
+	money := moneyFormatter(policy.Locale, policy.Currency)
+	layout.heading("Policy")
+	layout.row("Product", describe(result.ProductType))
+	layout.row("Age at issue", strconv.Itoa(policy.Age))
+	if policy.Term > 0 {
+		layout.row("Term", fmt.Sprintf("%d years", policy.Term))
+	}
+	layout.row("Sum assured", money(policy.CoverageAmount))
+	table := policy.Gender
+	if table == "" {
+		table = "male"
+	}
+	layout.row("Mortality table", table)
+
+	layout.heading("Premium Summary")
+	layout.row("Gross premium (annual)", money(result.GrossPremium))
+	if result.ModalPremium != 0 && result.PaymentFrequency != "" && result.PaymentFrequency != "annual" {
+		layout.row(fmt.Sprintf("Premium (%s)", describe(result.PaymentFrequency)), money(result.ModalPremium))
+	}
+	if result.FirstYearPremium != 0 {
+		layout.row("First-year premium", money(result.FirstYearPremium))
+		layout.row("Renewal premium", money(result.RenewalPremium))
+	}
+	layout.row("Net premium (annual)", money(result.NetPremium))
+	if result.TotalPremiumCost != 0 {
+		layout.row("Total premium cost", money(result.TotalPremiumCost))
+	}
+	if result.AnnualPayout != 0 {
+		layout.row("Annual payout", money(result.AnnualPayout))
+	}
+
+	if len(result.UnderwritingInfo) > 0 {
+		layout.heading("Underwriting")
+		for _, key := range sortedKeys(result.UnderwritingInfo) {
+			layout.row(describe(key), formatValue(result.UnderwritingInfo[key]))
+		}
+	}
+	if len(result.RiskAssessment) > 0 {
+		layout.heading("Risk Assessment")
+		for _, key := range sortedKeys(result.RiskAssessment) {
+			layout.row(describe(key), formatValue(result.RiskAssessment[key]))
+		}
+	}
+	if len(result.Warnings) > 0 {
+		layout.heading("Notes")
+		for _, warning := range result.Warnings {
+			layout.note(warning)
+		}
+	}
+	if len(result.ReserveSchedule) > 0 {
+		layout.reserveTable(result, money)
+	}
+
+	return layout.document.bytes()
+}
+
+// newPage starts a page under the branded header band
+func (l *quoteLayout) newPage() {
+	l.document.pdf.AddPage()
+	top := pageHeight - headerHeight
+	l.document.fillRect(0, top, pageWidth, headerHeight, l.brand)
+	l.document.text(margin, top+45, true, 20, white, l.branding.CompanyName)
+	if l.branding.Tagline != "" {
+		l.document.text(margin, top+25, false, 10, white, l.branding.Tagline)
+	}
+	if logo := l.document.image; logo != nil && logo.height > 0 {
+		height := headerHeight - 20
+		width := height * float64(logo.width) / float64(logo.height)
+		l.document.drawImage(pageWidth-margin-width, top+10, width, height)
+	}
+	l.y = top - 35
+}
+
+// need starts a new page unless there is room for height more points
+func (l *quoteLayout) need(height float64) {
+	if l.y-height < footerSpace {
+		l.newPage()
+	}
+}
+
+func (l *quoteLayout) heading(title string) {
+	l.need(3 * lineHeight)
+	l.y -= 8
+	l.document.text(margin, l.y, true, 13, l.brand, title)
+	l.document.line(margin, l.y-4, pageWidth-margin, l.y-4, rule)
+	l.y -= lineHeight + 4
+}
+
+func (l *quoteLayout) row(label, value string) {
+	l.need(lineHeight)
+	l.document.text(margin, l.y, false, 10, grey, label)
+	l.document.text(margin+200, l.y, false, 10, black, value)
+	l.y -= lineHeight
+}
+
+// note writes a bullet point, wrapped to the width of the page
+func (l *quoteLayout) note(s string) {
+	const maxLineLength = 100 // Characters of 9pt Helvetica that fit between the margins
+	prefix := "- "
+	for _, wrapped := range wrap(s, maxLineLength) {
+		l.need(lineHeight)
+		l.document.text(margin, l.y, false, 9, grey, prefix+wrapped)
+		prefix = "  "
+		l.y -= lineHeight
+	}
+}
+
+// wrap breaks s into lines of at most width characters at spaces
+func wrap(s string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(s) {
+		if current != "" && len(current)+1+len(word) > width {
+			lines = append(lines, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+// reserveTable lists the reserve at each duration, with the net amount at
+// risk beside it when the result has one
+func (l *quoteLayout) reserveTable(result models.PremiumCalculation, money func(float64) string) {
+	columns := []string{"Year", "Reserve"}
+	if len(result.NetAmountAtRisk) == len(result.ReserveSchedule) {
+		columns = append(columns, "Net amount at risk")
+	}
+	header := func() {
+		for i, column := range columns {
+			l.document.text(margin+float64(i)*150, l.y, true, 10, black, column)
+		}
+		l.document.line(margin, l.y-4, pageWidth-margin, l.y-4, rule)
+		l.y -= lineHeight + 2
+	}
+
+	l.heading("Reserve Schedule")
+	header()
+	for year, reserve := range result.ReserveSchedule {
+		if l.y-lineHeight < footerSpace {
+			l.newPage()
+			header()
+		}
+		l.document.text(margin, l.y, false, 10, black, strconv.Itoa(result.InForceDuration+year))
+		l.document.text(margin+150, l.y, false, 10, black, money(reserve))
+		if len(columns) == 3 {
+			l.document.text(margin+300, l.y, false, 10, black, money(result.NetAmountAtRisk[year]))
+		}
+		l.y -= lineHeight
+	}
+}
+
+// footer puts the branding footer and page number on a page as it is
+// finished; the number of pages is filled in when the document is written
+func (l *quoteLayout) footer() {
+	l.document.line(margin, 45, pageWidth-margin, 45, rule)
+	if l.branding.Footer != "" {
+		l.document.text(margin, 32, false, 8, grey, l.branding.Footer)
+	}
+	l.document.text(pageWidth-margin-60, 32, false, 8, grey, fmt.Sprintf("Page %d of {nb}", l.document.pdf.PageNo()))
+}
+
+// moneyFormatter formats amounts for the locale, with the currency code in
+// front when there is one. The code is used rather than a symbol so the
+// standard fonts can always show it.
+func moneyFormatter(locale, currencyCode string) func(float64) string {
+	tag := language.English
+	if locale != "" {
+		tag = language.Make(locale)
+	}
+	printer := message.NewPrinter(tag)
+	return func(amount float64) string {
+		formatted := printer.Sprint(number.Decimal(amount, number.Scale(2)))
+		if currencyCode != "" {
+			return strings.ToUpper(currencyCode) + " " + formatted
+		}
+		return formatted
+	}
+}
+
+// describe turns an identifier like "term_life" into "Term life"
+func describe(identifier string) string {
+	words := strings.ReplaceAll(identifier, "_", " ")
+	if words == "" {
+		return words
+	}
+	return strings.ToUpper(words[:1]) + words[1:]
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', 6, 64)
+	case []float64:
+		parts := make([]string, len(v))
+		for i, f := range v {
+			parts[i] = strconv.FormatFloat(f, 'g', 6, 64)
+		}
+		return strings.Join(parts, ", ")
+	case string:
+		return describe(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,138 @@
+package report
+
+import (
+	"actuworry/backend/models"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// checkPDF makes sure a document is well formed: it starts and ends right and
+// every cross-reference entry points at the object it names. It returns the
+// document with its compressed streams inflated, to look for what was drawn.
+func checkPDF(t *testing.T, document []byte) []byte {
+	t.Helper()
+	if !bytes.HasPrefix(document, []byte("%PDF-1.")) || !bytes.HasSuffix(document, []byte("%%EOF\n")) {
+		t.Fatalf("Expected a PDF header and trailer")
+	}
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(document)
+	if startxref == nil {
+		t.Fatalf("Expected a startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(document[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d doesn't point at the cross-reference table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(document[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(document[offset:], []byte(want)) {
+			t.Errorf("Object %d: offset %d doesn't point at it", i+1, offset)
+		}
+	}
+
+	content := bytes.Clone(document)
+	for _, stream := range regexp.MustCompile(`(?s)/FlateDecode /Length \d+>>\nstream\n(.*?)\nendstream`).FindAllSubmatch(document, -1) {
+		reader, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			t.Fatalf("Expected a compressed stream: %v", err)
+		}
+		inflated, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Expected a compressed stream: %v", err)
+		}
+		content = append(content, inflated...)
+	}
+	return content
+}
+
+func TestQuote(t *testing.T) {
+	policy := models.Policy{PolicyID: "P-1", Age: 40, Term: 60, CoverageAmount: 250000, ProductType: "whole_life", Currency: "BWP"}
+	result := models.PremiumCalculation{
+		QuoteID:          "abc123",
+		ProductType:      "whole_life",
+		NetPremium:       1234.5,
+		GrossPremium:     1500.25,
+		ReserveSchedule:  make([]float64, 61),
+		UnderwritingInfo: map[string]interface{}{"smoker_status": "smoker", "smoker_multiplier": 2.0},
+		RiskAssessment:   map[string]float64{"annual_death_probability": 0.0042},
+		Warnings:         []string{"a note (with brackets)"},
+	}
+	for year := range result.ReserveSchedule {
+		result.ReserveSchedule[year] = float64(year) * 1000
+	}
+
+	document, err := Quote(policy, result, DefaultBranding(), time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := checkPDF(t, document)
+	for _, want := range []string{"(Actuworry)", "(BWP 1,500.25)", "(Smoker multiplier)", "(Annual death probability)", `(- a note \(with brackets\))`, "(16 October 2026)", "(abc123)", "(BWP 60,000.00)"} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("Expected the quote to contain %s", want)
+		}
+	}
+	// 61 reserves don't fit on one page, and each page carries the header
+	pages := bytes.Count(document, []byte("/Type /Page\n"))
+	if pages < 2 || bytes.Count(content, []byte("(Actuworry)")) != pages {
+		t.Errorf("Expected the reserve schedule to run onto more pages, each with the header; got %d pages", pages)
+	}
+	if !bytes.Contains(content, []byte("(Year)")) || !bytes.Contains(content, fmt.Appendf(nil, "(Page %d of %d)", pages, pages)) {
+		t.Errorf("Expected the page numbers to count every page")
+	}
+}
+
+func TestLoadBranding(t *testing.T) {
+	dir := t.TempDir()
+	var logo bytes.Buffer
+	if err := jpeg.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logo.jpg"), logo.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "branding.json")
+	config := `{"company_name":"Kalahari Life","primary_color":"#AA3300","logo_path":"logo.jpg","footer":"Licensed insurer"}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	branding, err := LoadBranding(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branding.Tagline != DefaultBranding().Tagline || branding.logo == nil || branding.logo.width != 40 {
+		t.Errorf("Expected the default tagline and a 40-pixel-wide logo, got %+v", branding)
+	}
+	document, err := Quote(models.Policy{Age: 40, Term: 1, CoverageAmount: 1000}, models.PremiumCalculation{ReserveSchedule: []float64{0, 0}}, branding, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := checkPDF(t, document)
+	for _, want := range []string{"(Kalahari Life)", "(Licensed insurer)", "/Filter /DCTDecode", "/Subtype /Image", "0.667 0.200 0.000 rg"} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("Expected the branded quote to contain %s", want)
+		}
+	}
+
+	for name, config := range map[string]string{
+		"bad colour": `{"primary_color":"orange"}`,
+		"not a jpeg": `{"logo_path":"branding.json"}`,
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadBranding(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -9,51 +9,132 @@ import (
 // SetupRoutes configures all application routes
 func SetupRoutes(handler *handlers.ActuarialHandler) *http.ServeMux {
 	mux := http.NewServeMux()
+	bodyLimit := middleware.LimitBody(middleware.MaxBodyBytesFromEnv())
+	api := []func(http.HandlerFunc) http.HandlerFunc{
+		middleware.RequestID, middleware.Logger, middleware.CORS, middleware.Recover, bodyLimit,
+	}
 
 	// Standard API routes
 	mux.HandleFunc("/api/calculate",
-		middleware.Chain(handler.CalculatePremium, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.CalculatePremium, api...))
+
+	mux.HandleFunc("/api/calculate/pdf",
+		middleware.Chain(handler.QuotePDF, api...))
 
 	mux.HandleFunc("/api/calculate/batch",
-		middleware.Chain(handler.CalculateBatch, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.CalculateBatch, api...))
+
+	mux.HandleFunc("/api/calculate/group",
+		middleware.Chain(handler.CalculateGroup, api...))
+
+	mux.HandleFunc("/api/calculate/group/refund",
+		middleware.Chain(handler.CalculateExperienceRefund, api...))
+
+	mux.HandleFunc("/api/calculate/bundle",
+		middleware.Chain(handler.CalculateBundle, api...))
+
+	mux.HandleFunc("/api/calculate/joint",
+		middleware.Chain(handler.CalculateJointLife, api...))
 
 	mux.HandleFunc("/api/calculate/sensitivity",
-		middleware.Chain(handler.SensitivityAnalysis, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.SensitivityAnalysis, api...))
 
 	mux.HandleFunc("/api/analyze/portfolio",
-		middleware.Chain(handler.PortfolioAnalysis, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.PortfolioAnalysis, api...))
+
+	mux.HandleFunc("/api/analyze/break-even",
+		middleware.Chain(handler.BreakEvenAnalysis, api...))
+
+	mux.HandleFunc("/api/analyze/gpv",
+		middleware.Chain(handler.GrossPremiumValuation, api...))
+
+	mux.HandleFunc("/api/analyze/ifrs17",
+		middleware.Chain(handler.IFRS17Valuation, api...))
+
+	mux.HandleFunc("/api/analyze/embedded-value",
+		middleware.Chain(handler.EmbeddedValue, api...))
+
+	mux.HandleFunc("/api/analyze/revaluation",
+		middleware.Chain(handler.RevalueInForce, api...))
+
+	mux.HandleFunc("/api/analyze/paid-up",
+		middleware.Chain(handler.PaidUpAnalysis, api...))
+
+	mux.HandleFunc("/api/analyze/scenarios",
+		middleware.Chain(handler.ScenarioAnalysis, api...))
+
+	mux.HandleFunc("/api/analyze/level-premium",
+		middleware.Chain(handler.EquivalentLevelPremium, api...))
+
+	mux.HandleFunc("/api/analyze/reserve",
+		middleware.Chain(handler.FractionalReserve, api...))
+
+	mux.HandleFunc("/api/quotes/{id}",
+		middleware.Chain(handler.GetQuote, api...))
 
 	mux.HandleFunc("/api/tables",
-		middleware.Chain(handler.GetTables, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.GetTables, api...))
+
+	mux.HandleFunc("/api/curves",
+		middleware.Chain(handler.GetYieldCurves, api...))
+
+	mux.HandleFunc("/api/products",
+		middleware.Chain(handler.GetProducts, api...))
+
+	mux.HandleFunc("/api/expenses",
+		middleware.Chain(handler.GetExpenses, api...))
+
+	mux.HandleFunc("/api/tables/derive",
+		middleware.Chain(handler.DeriveTable, api...))
+
+	mux.HandleFunc("/api/tables/{name}/survival",
+		middleware.Chain(handler.GetSurvivalCurve, api...))
 
 	mux.HandleFunc("/api/health",
-		middleware.Chain(handler.HealthCheck, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.HealthCheck, api...))
+
+	mux.HandleFunc("/api/version",
+		middleware.Chain(handler.Version, api...))
 
 	// v-star advanced features
 	mux.HandleFunc("/api/vstar/montecarlo",
-		middleware.Chain(handler.MonteCarloSimulation, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.MonteCarloSimulation, api...))
 
 	mux.HandleFunc("/api/vstar/risk",
-		middleware.Chain(handler.RiskAnalysis, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.RiskAnalysis, api...))
 
 	mux.HandleFunc("/api/vstar/duration",
-		middleware.Chain(handler.DurationCalculator, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.DurationCalculator, api...))
 
 	mux.HandleFunc("/api/vstar/rate-convert",
-		middleware.Chain(handler.RateConverterHandler, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.RateConverterHandler, api...))
 
 	mux.HandleFunc("/api/vstar/endowment",
-		middleware.Chain(handler.EndowmentCalculator, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.EndowmentCalculator, api...))
 
 	mux.HandleFunc("/api/vstar/reserve-retro",
-		middleware.Chain(handler.RetrospectiveReserve, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.RetrospectiveReserve, api...))
 
 	mux.HandleFunc("/api/vstar/bond",
-		middleware.Chain(handler.BondValuation, middleware.Logger, middleware.CORS))
+		middleware.Chain(handler.BondValuation, api...))
 
-	// Static file server for frontend
+	// Anything else under /api/ is an unknown endpoint. Registering it keeps
+	// those paths out of the file server, so they get the API's JSON errors and
+	// CORS headers like every other API route.
+	mux.HandleFunc("/api/",
+		middleware.Chain(notFound, api...))
+
+	// Static file server for frontend, behind CORS so a preflight to any other
+	// path is answered too. A method-only "OPTIONS /" catch-all would conflict
+	// with the path-only patterns above, so each route answers its own.
 	fs := http.FileServer(http.Dir("frontend/"))
-	mux.Handle("/", fs)
+	mux.HandleFunc("/",
+		middleware.Chain(fs.ServeHTTP, middleware.RequestID, middleware.Logger, middleware.CORS, middleware.Recover))
 
 	return mux
 }
+
+// notFound answers requests for API paths that don't exist
+func notFound(w http.ResponseWriter, r *http.Request) {
+	middleware.WriteError(w, "Not found", http.StatusNotFound)
+}
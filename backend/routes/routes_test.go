@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"actuworry/backend/handlers"
+	"actuworry/backend/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreflightOnEveryPath(t *testing.T) {
+	mux := SetupRoutes(handlers.NewActuarialHandler(services.NewActuarialService()))
+
+	for _, path := range []string{"/api/calculate", "/api/tables/male/survival", "/api/unknown", "/", "/index.html", "/no/such/page"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "http://example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected the preflight to succeed, got %d", path, rec.Code)
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("%s: expected CORS headers, got %v", path, rec.Header())
+		}
+		if !strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "X-Request-ID") || rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+			t.Errorf("%s: expected X-Request-ID to be allowed in and exposed, got %v", path, rec.Header())
+		}
+	}
+}
+
+func TestUnknownAPIPath(t *testing.T) {
+	mux := SetupRoutes(handlers.NewActuarialHandler(services.NewActuarialService()))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+	if rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Expected a JSON error with CORS headers, got %v", rec.Header())
+	}
+}
+
+func TestVersionRoute(t *testing.T) {
+	mux := SetupRoutes(handlers.NewActuarialHandler(services.NewActuarialService()))
+
+	// Registered on its own, not answered by the /api/ catch-all's 404
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"commit"`) {
+		t.Errorf("Expected the version, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,180 @@
+// Package server starts the actuarial service the same way for every entry point
+package server
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/services"
+	"actuworry/backend/storage"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+// Setup loads the tables, curves and scenario sets under backend/data and
+// applies the settings in the environment. A setting that can't be applied
+// stops the server rather than let it quote on the wrong basis. The returned
+// function closes anything opened along the way.
+func Setup() (*services.ActuarialService, func()) {
+	actuarialService := services.NewActuarialService()
+	closers := []func() error{}
+
+	// Load mortality tables
+	tables := []string{"male", "female"}
+	for _, tableName := range tables {
+		filePath := fmt.Sprintf("backend/data/%s.csv", tableName)
+		if err := actuarialService.LoadMortalityTable(tableName, filePath); err != nil {
+			log.Fatalf("Failed to load mortality table %s: %v", tableName, err)
+		}
+		log.Printf("Successfully loaded mortality table: %s", tableName)
+	}
+
+	// Death/disability/withdrawal tables, one file per table, selected by name
+	// like the mortality tables
+	multiDecrement, err := actuarialService.LoadMultiDecrementTables("backend/data/multi_decrement")
+	if err != nil {
+		log.Fatalf("Failed to load multi-decrement tables: %v", err)
+	}
+	if len(multiDecrement) > 0 {
+		log.Printf("Loaded multi-decrement tables: %v", multiDecrement)
+	}
+
+	// Select-and-ultimate tables, one file per table, selected by name too
+	selectTables, err := actuarialService.LoadSelectTables("backend/data/select")
+	if err != nil {
+		log.Fatalf("Failed to load select tables: %v", err)
+	}
+	if len(selectTables) > 0 {
+		log.Printf("Loaded select tables: %v", selectTables)
+	}
+
+	// Named yield curves, one file per curve
+	curves, err := actuarialService.LoadYieldCurves("backend/data/curves")
+	if err != nil {
+		log.Fatalf("Failed to load yield curves: %v", err)
+	}
+	if len(curves) > 0 {
+		log.Printf("Loaded yield curves: %v", curves)
+	}
+
+	// Prescribed interest rate scenarios, one file per set
+	scenarioSets, err := actuarialService.LoadScenarioSets("backend/data/scenarios")
+	if err != nil {
+		log.Fatalf("Failed to load scenario sets: %v", err)
+	}
+	if len(scenarioSets) > 0 {
+		log.Printf("Loaded scenario sets: %v", scenarioSets)
+	}
+
+	// Smoker/health multipliers calibrated to our own experience, if configured
+	if configPath := os.Getenv("UNDERWRITING_CONFIG"); configPath != "" {
+		if err := actuarialService.LoadUnderwritingConfig(configPath); err != nil {
+			log.Fatalf("Failed to load underwriting config: %v", err)
+		}
+		log.Printf("Loaded underwriting multipliers from %s", configPath)
+	}
+
+	// Pricing bases by table and product, for policies sent without a basis
+	if configPath := os.Getenv("DEFAULT_BASES"); configPath != "" {
+		count, err := actuarialService.LoadDefaultBases(configPath)
+		if err != nil {
+			log.Fatalf("Failed to load default bases: %v", err)
+		}
+		log.Printf("Loaded %d default bases from %s", count, configPath)
+	}
+
+	// Logo, colours and footer for PDF quotes, if configured
+	if configPath := os.Getenv("REPORT_BRANDING"); configPath != "" {
+		if err := actuarialService.LoadReportBranding(configPath); err != nil {
+			log.Fatalf("Failed to load report branding: %v", err)
+		}
+		log.Printf("Loaded report branding from %s", configPath)
+	}
+
+	// Small policies are charged at least the minimum premium, if configured
+	if value := os.Getenv("MINIMUM_PREMIUM"); value != "" {
+		minimum, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			err = actuarialService.SetMinimumPremium(minimum)
+		}
+		if err != nil {
+			log.Fatalf("Invalid MINIMUM_PREMIUM %q: %v", value, err)
+		}
+		log.Printf("Minimum premium set to %.2f", minimum)
+	}
+	if value := os.Getenv("PREMIUM_TAX_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			err = actuarialService.SetPremiumTaxRate(rate)
+		}
+		if err != nil {
+			log.Fatalf("Invalid PREMIUM_TAX_RATE %q: %v", value, err)
+		}
+		log.Printf("Premium tax rate set to %g", rate)
+	}
+	if value := os.Getenv("PREMIUM_BANDS"); value != "" {
+		bands, err := services.ParsePremiumBands(value)
+		if err == nil {
+			err = actuarialService.SetPremiumBands(bands)
+		}
+		if err != nil {
+			log.Fatalf("Invalid PREMIUM_BANDS %q: %v", value, err)
+		}
+		log.Printf("Premium bands set to %s", value)
+	}
+
+	// Iterative solves use the default tolerance and step limit unless overridden
+	solver := actuarial.DefaultSolverConfig()
+	if value := os.Getenv("SOLVER_TOLERANCE"); value != "" {
+		tolerance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Fatalf("Invalid SOLVER_TOLERANCE %q: %v", value, err)
+		}
+		solver.Tolerance = tolerance
+	}
+	if value := os.Getenv("SOLVER_MAX_ITERATIONS"); value != "" {
+		iterations, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatalf("Invalid SOLVER_MAX_ITERATIONS %q: %v", value, err)
+		}
+		solver.MaxIterations = iterations
+	}
+	if err := actuarialService.SetSolverConfig(solver); err != nil {
+		log.Fatalf("Invalid solver configuration: %v", err)
+	}
+
+	// Every quote is kept for audit if a quote database is configured;
+	// otherwise the server stays stateless
+	if path := os.Getenv("QUOTE_DB"); path != "" {
+		quoteStore, err := storage.OpenSQLiteQuoteStore(path)
+		if err != nil {
+			log.Fatalf("Failed to open quote database %s: %v", path, err)
+		}
+		closers = append(closers, quoteStore.Close)
+		actuarialService.SetQuoteStore(quoteStore)
+		log.Printf("Storing quotes in %s", path)
+	}
+
+	// Re-read the tables from disk on SIGHUP, without a restart
+	reloadSignal := make(chan os.Signal, 1)
+	signal.Notify(reloadSignal, syscall.SIGHUP)
+	go func() {
+		for range reloadSignal {
+			if err := actuarialService.ReloadTables(); err != nil {
+				log.Printf("Table reload failed: %v", err)
+				continue
+			}
+			log.Printf("Reloaded mortality tables")
+		}
+	}()
+
+	return actuarialService, func() {
+		for _, close := range closers {
+			if err := close(); err != nil {
+				log.Printf("Shutdown: %v", err)
+			}
+		}
+	}
+}
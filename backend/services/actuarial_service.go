@@ -3,118 +3,583 @@ package services
 import (
 	"actuworry/backend/actuarial"
 	"actuworry/backend/models"
+	"actuworry/backend/report"
+	"cmp"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
 )
 
 // ActuarialService wraps the actuarial calculator and loaded mortality tables
 // It acts as a simple API for the rest of the app
 type ActuarialService struct {
-	mortalityTables map[string]actuarial.MortalityTable
+	mu     sync.RWMutex // Guards tables; held only long enough to read or swap the pointer
+	tables *tableSet
+
+	loadMu sync.Mutex // Serialises loads and reloads so neither loses the other's tables
+
+	underwriting   actuarial.UnderwritingConfig // Smoker/health multipliers, guarded by mu
+	minimumPremium float64                      // Smallest gross premium charged for life products, guarded by mu
+	premiumBands   []actuarial.PremiumBand      // Volume discounts by sum assured, guarded by mu
+	premiumTaxRate float64                      // Premium tax levied on gross premiums, guarded by mu
+	solver         actuarial.SolverConfig       // Tolerance and iteration limit for iterative solves, guarded by mu
+	quotes         QuoteStore                   // Where calculated quotes are kept, if anywhere; guarded by mu
+	defaultBases   map[basisKey]models.Basis    // Bases for policies sent without one, by table and product; guarded by mu
+	branding       *report.Branding             // How PDF quotes look, if configured; guarded by mu
+	adjusted       *adjustedTableCache          // Underwritten tables for the current tables and config; guarded by mu, dropped when either changes
 }
 
 // NewActuarialService creates a new actuarial service instance
 func NewActuarialService() *ActuarialService {
 	return &ActuarialService{
-		mortalityTables: make(map[string]actuarial.MortalityTable),
+		tables:       newTableSet(),
+		underwriting: actuarial.DefaultUnderwritingConfig(),
+		solver:       actuarial.DefaultSolverConfig(),
 	}
 }
 
-// LoadMortalityTable loads a mortality table by a friendly name (e.g., "male")
-func (s *ActuarialService) LoadMortalityTable(name, filePath string) error {
-	table, err := actuarial.LoadMortalityTable(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to load mortality table %s: %w", name, err)
+// GetTableMetadata returns metadata for every loaded table, sorted by name
+func (s *ActuarialService) GetTableMetadata() []models.TableMetadata {
+	tables := s.currentTables()
+	metadata := make([]models.TableMetadata, 0, len(tables.metadata))
+	for _, info := range tables.metadata {
+		metadata = append(metadata, info)
 	}
-	s.mortalityTables[name] = table
-	return nil
+	sort.Slice(metadata, func(i, j int) bool { return metadata[i].Name < metadata[j].Name })
+	return metadata
 }
 
 // GetAvailableTables returns the names of all loaded tables
 func (s *ActuarialService) GetAvailableTables() []string {
-	tables := make([]string, 0, len(s.mortalityTables))
-	for name := range s.mortalityTables {
+	current := s.currentTables()
+	tables := make([]string, 0, len(current.mortality)+len(current.multiDecrement))
+	for name := range current.mortality {
+		tables = append(tables, name)
+	}
+	for name := range current.multiDecrement {
 		tables = append(tables, name)
 	}
 	return tables
 }
 
-// GetMortalityTable gets a table by gender/name, defaults to "male" if empty
-func (s *ActuarialService) GetMortalityTable(gender string) (actuarial.MortalityTable, error) {
-	tableName := strings.ToLower(strings.TrimSpace(gender))
-	if tableName == "" {
-		tableName = "male"
+// GetDefaultExpenses returns the per-product expense defaults
+func (s *ActuarialService) GetDefaultExpenses() models.DefaultExpenses {
+	products := make(map[string]models.ExpenseStructure, len(actuarial.ProductExpenses))
+	for productType, expenses := range actuarial.ProductExpenses {
+		products[productType] = convertExpenses(expenses)
+	}
+	return models.DefaultExpenses{
+		Products:       products,
+		Fallback:       convertExpenses(actuarial.CreateDefaultExpenses()),
+		AnnuityLoading: actuarial.DefaultAnnuityLoading,
+	}
+}
+
+// GetProducts returns every supported product type and the fields it reads
+func (s *ActuarialService) GetProducts() []models.ProductInfo {
+	products := make([]models.ProductInfo, 0, len(actuarial.Products))
+	for _, product := range actuarial.Products {
+		products = append(products, models.ProductInfo{
+			ProductType: product.Type,
+			Description: product.Description,
+			Required:    product.Required,
+			Optional:    product.Optional,
+			Ignored:     product.Ignored,
+		})
 	}
+	return products
+}
 
-	table, exists := s.mortalityTables[tableName]
-	if !exists {
-		return nil, fmt.Errorf("mortality table '%s' not found", tableName)
+func convertExpenses(expenses actuarial.ExpenseStructure) models.ExpenseStructure {
+	return models.ExpenseStructure{
+		InitialExpenseRate: expenses.InitialExpenseRate,
+		RenewalExpenseRate: expenses.RenewalExpenseRate,
+		MaintenanceExpense: expenses.MaintenanceExpense,
+		ProfitMargin:       expenses.ProfitMargin,
 	}
-	return table, nil
+}
+
+// GetMortalityTable gets a table by gender/name, defaults to "male" if empty
+func (s *ActuarialService) GetMortalityTable(gender string) (actuarial.MortalityTable, error) {
+	return s.currentTables().mortalityTable(gender)
+}
+
+// getTableRange returns the ages a table supports, from its metadata when loaded
+// from file or from its length otherwise
+func (s *ActuarialService) getTableRange(gender string, table actuarial.MortalityTable) models.TableMetadata {
+	return s.currentTables().tableRange(gender, table)
 }
 
 // CalculatePremium calculates premiums for a single policy
 func (s *ActuarialService) CalculatePremium(policy *models.Policy) (models.PremiumCalculation, error) {
-	// 1) Validate request
-	if err := s.validatePolicy(policy); err != nil {
+	return s.CalculatePremiumWithBasis(policy, nil)
+}
+
+// CalculatePremiumWithBasis calculates premiums for a single policy on a
+// valuation basis. A nil basis uses the default basis registered for the
+// policy's table and product, or the policy's own assumptions if there is none.
+func (s *ActuarialService) CalculatePremiumWithBasis(policy *models.Policy, basis *models.Basis) (models.PremiumCalculation, error) {
+	run, err := s.preparePricing(policy, basis)
+	if err != nil {
 		return models.PremiumCalculation{}, err
 	}
+	return s.priceRun(run)
+}
+
+// priceRun prices a policy preparePricing has resolved and checked
+func (s *ActuarialService) priceRun(run pricingRun) (models.PremiumCalculation, error) {
+	policy, basis := run.policy, run.basis
+
+	if run.multiTable != nil {
+		result := s.calculateMultiDecrementPremium(policy, *run.multiTable, run.yieldCurve, s.underwritingFor(basis))
+		zeroPremium := actuarial.ZeroPremiumReject
+		if basis != nil && basis.ZeroPremium != "" {
+			zeroPremium = basis.ZeroPremium
+		}
+		if err := actuarial.CheckPremium(result.NetPremium, result.GrossPremium, zeroPremium); err != nil {
+			return models.PremiumCalculation{}, err
+		}
+		if taxRate := s.premiumTaxRateFor(basis); taxRate > 0 {
+			result.PremiumTaxRate = taxRate
+			result.PremiumTax, result.TaxInclusivePremium = actuarial.ApplyPremiumTax(result.GrossPremium, taxRate)
+		}
+		result.Warnings = append(run.warnings, result.Warnings...)
+		echoBasis(&result, basis, run.basisSource)
+		describeAssumptions(&result, run, actuarial.DefaultBasis(&actuarial.Policy{ProductType: "term_life"}, nil))
+		return result, nil
+	}
+
+	// 4) Do the calculation
+	actuarialPolicy := run.actuarialPolicy
+	actuarialBasis := actuarial.DefaultBasis(&actuarialPolicy, run.mortalityTable)
+	applyBasis(&actuarialBasis, basis)
+	actuarialBasis.Underwriting = s.underwritingFor(basis)
+	if cache := s.adjustedTablesFor(run.tables); cache != nil && run.standardMortality() {
+		actuarialBasis.Underwriting = cache.underwriting
+		actuarialBasis.AdjustedMortality = cache.table(&actuarialPolicy, resolveTableName(policy.Gender), run.selectTable, run.mortalityTable)
+	}
+	actuarialBasis.MinimumPremium = s.minimumPremiumFor(basis)
+	actuarialBasis.PremiumBands = s.premiumBandsFor(basis)
+	actuarialBasis.PremiumTaxRate = s.premiumTaxRateFor(basis)
+	actuarialBasis.Solver = s.solverConfig()
+	calc := actuarial.CalculateFullPremiumWithBasis(&actuarialPolicy, actuarialBasis)
+	if calc.SolverError != nil {
+		return models.PremiumCalculation{}, calc.SolverError
+	}
+	if calc.PremiumError != nil {
+		return models.PremiumCalculation{}, calc.PremiumError
+	}
+	if math.IsInf(calc.GrossPremium, 0) {
+		return models.PremiumCalculation{}, fmt.Errorf("the return of purchase price costs more than any premium; shorten the deferral period")
+	}
+
+	// 5) Convert result to API model
+	result := s.convertToPremiumCalculation(calc)
+	result.Warnings = append(run.warnings, result.Warnings...)
+	echoBasis(&result, basis, run.basisSource)
+	describeResult(&result, policy)
+	describeAssumptions(&result, run, actuarialBasis)
+	return result, nil
+}
+
+// pricingRun is a policy resolved against its basis and checked against the
+// tables it will be priced on
+type pricingRun struct {
+	policy          *models.Policy
+	basis           *models.Basis
+	basisSource     string
+	warnings        []string
+	yieldCurve      actuarial.YieldCurve
+	multiTable      *actuarial.MultiDecrementTable // Set when the policy's table is multi-decrement
+	mortalityTable  actuarial.MortalityTable
+	table           models.TableMetadata // The table priced on, as it was loaded
+	selectTable     bool                 // Whether mortalityTable is a select table's table for the policy's age at issue
+	tables          *tableSet            // The snapshot the run was checked against
+	actuarialPolicy actuarial.Policy
+}
+
+// standardMortality reports whether the run is priced on the loaded table and
+// the server's underwriting, so its underwritten table can be shared
+func (r pricingRun) standardMortality() bool {
+	return r.basis == nil || (r.basis.MortalityShock == nil && r.basis.Underwriting == nil)
+}
+
+// preparePricing resolves a policy's basis and runs every check pricing needs
+// without computing anything, so validation and pricing can't disagree
+func (s *ActuarialService) preparePricing(policy *models.Policy, basis *models.Basis) (pricingRun, error) {
+	basisSource := BasisFromRequest
+	if basis == nil {
+		basis, basisSource = s.defaultBasisFor(policy), BasisFromDefault
+	}
+	if err := validateBasis(basis); err != nil {
+		return pricingRun{}, err
+	}
+	effective := normalizePolicy(applyBasisToPolicy(policy, basis))
+	run := pricingRun{policy: &effective, basis: basis, basisSource: basisSource, warnings: basisWarnings(policy, &effective)}
+	policy = run.policy
 
-	// 2) Load mortality data
-	mortalityTable, err := s.GetMortalityTable(policy.Gender)
+	// Work from one snapshot of the tables so a reload mid-calculation can't mix old and new
+	tables := s.currentTables()
+	yieldCurve, err := tables.yieldCurve(policy.YieldCurve)
 	if err != nil {
-		return models.PremiumCalculation{}, err
+		return pricingRun{}, err
+	}
+	if basis != nil && basis.InterestShift != nil {
+		yieldCurve = yieldCurve.Shift(*basis.InterestShift)
+	}
+	run.yieldCurve = yieldCurve
+	if multiTable, ok := tables.multiDecrement[resolveTableName(policy.Gender)]; ok {
+		if basis != nil && basis.MortalityShock != nil {
+			return pricingRun{}, fmt.Errorf("mortality shock does not apply to multi-decrement tables")
+		}
+		if basis != nil && actuarial.IsModifiedReserve(basis.ReserveMethod) {
+			return pricingRun{}, fmt.Errorf("reserve method '%s' does not apply to multi-decrement tables", basis.ReserveMethod)
+		}
+		if basis != nil && basis.PremiumTiming == actuarial.PremiumsAnnuallyInArrears {
+			return pricingRun{}, fmt.Errorf("premiums in arrears are not supported with multi-decrement tables")
+		}
+		if err := s.validateMultiDecrementPolicy(policy, multiTable); err != nil {
+			return pricingRun{}, err
+		}
+		run.multiTable = &multiTable
+		run.table = tables.tableRange(policy.Gender, multiTable.Death[:multiTable.Len()])
+		return run, nil
+	}
+
+	// 1) Load mortality data
+	mortalityTable, err := tables.policyMortalityTable(policy)
+	if err != nil {
+		return pricingRun{}, err
+	}
+	if basis != nil && basis.MortalityShock != nil {
+		mortalityTable = actuarial.CombineTables([]actuarial.MortalityTable{mortalityTable}, []float64{1 + *basis.MortalityShock})
+	}
+
+	// 2) Validate request against the table's supported ages
+	if err := s.validatePolicy(policy, tables.tableRange(policy.Gender, mortalityTable)); err != nil {
+		return pricingRun{}, err
+	}
+
+	if basis != nil && basis.PremiumTiming == actuarial.PremiumsAnnuallyInArrears {
+		if actuarial.IsModifiedReserve(basis.ReserveMethod) {
+			return pricingRun{}, fmt.Errorf("reserve method '%s' assumes premiums in advance", basis.ReserveMethod)
+		}
+		if policy.FirstYearFeeWaiver != "" {
+			return pricingRun{}, fmt.Errorf("a first-year fee waiver assumes premiums in advance")
+		}
+	}
+	if basis != nil && actuarial.IsModifiedReserve(basis.ReserveMethod) && policy.ReserveApproach == actuarial.ReserveRetrospective {
+		return pricingRun{}, fmt.Errorf("reserve method '%s' is prospective and can't be used with a retrospective reserve", basis.ReserveMethod)
 	}
 
 	// 3) Convert to internal actuarial model
 	actuarialPolicy := s.convertToActuarialPolicy(policy)
+	actuarialPolicy.YieldCurve = yieldCurve
+	if actuarialPolicy.ProductType == "whole_life" && actuarialPolicy.MortalityExtrapolation == "" {
+		if err := actuarial.CheckWholeLifeAge(&actuarialPolicy, mortalityTable); err != nil {
+			return pricingRun{}, err
+		}
+	}
+	_, run.selectTable = tables.selectTables[resolveTableName(policy.Gender)]
+	run.mortalityTable = mortalityTable
+	run.table = tables.tableRange(policy.Gender, mortalityTable)
+	run.tables = tables
+	run.actuarialPolicy = actuarialPolicy
+	return run, nil
+}
 
-	// 4) Do the calculation
-	calc := actuarial.CalculateFullPremium(&actuarialPolicy, mortalityTable)
+// echoBasis puts the basis a result was valued on, if any, and where it came
+// from onto the result
+func echoBasis(result *models.PremiumCalculation, basis *models.Basis, source string) {
+	if basis == nil {
+		return
+	}
+	resolved := *basis
+	result.ResolvedBasis = &resolved
+	result.BasisSource = source
+}
 
-	// 5) Convert result to API model
-	return s.convertToPremiumCalculation(calc), nil
+// validateMultiDecrementPolicy checks a policy can be priced against a
+// death/disability/withdrawal table
+func (s *ActuarialService) validateMultiDecrementPolicy(policy *models.Policy, table actuarial.MultiDecrementTable) error {
+	tableRange := s.getTableRange(policy.Gender, table.Death[:table.Len()])
+	if err := s.validatePolicy(policy, tableRange); err != nil {
+		return err
+	}
+	if policy.ProductType != "" && policy.ProductType != "term_life" {
+		return fmt.Errorf("multi-decrement table '%s' only supports term_life", tableRange.Name)
+	}
+	if policy.MaturityBenefit > 0 {
+		return fmt.Errorf("maturity benefit is not supported with multi-decrement tables")
+	}
+	if policy.InForceDuration > 0 {
+		return fmt.Errorf("in-force policies are not supported with multi-decrement tables")
+	}
+	if policy.ReserveApproach == actuarial.ReserveRetrospective {
+		return fmt.Errorf("retrospective reserves are not supported with multi-decrement tables")
+	}
+	if policy.DisabilityBenefit < 0 {
+		return fmt.Errorf("disability benefit must not be negative")
+	}
+	if policy.IncludeProfitByYear {
+		return fmt.Errorf("profit by year is not supported with multi-decrement tables")
+	}
+	if policy.SettlementDelay != 0 {
+		return fmt.Errorf("settlement delay is not supported with multi-decrement tables")
+	}
+	if len(policy.Layers) > 0 {
+		return fmt.Errorf("layers are not supported with multi-decrement tables")
+	}
+	if policy.DynamicLapse != nil {
+		return fmt.Errorf("dynamic lapse is not supported with multi-decrement tables")
+	}
+	if policy.RequiredCapital != nil {
+		return fmt.Errorf("required capital is not supported with multi-decrement tables")
+	}
+	return nil
 }
 
-// CalculateBatch processes multiple policies and returns a summary
-func (s *ActuarialService) CalculateBatch(policies []models.Policy) (models.BatchCalculationResponse, error) {
+// calculateMultiDecrementPremium prices a policy against a death/disability/withdrawal
+// table. The policy must already have passed validateMultiDecrementPolicy.
+func (s *ActuarialService) calculateMultiDecrementPremium(policy *models.Policy, table actuarial.MultiDecrementTable, yieldCurve actuarial.YieldCurve, underwriting actuarial.UnderwritingConfig) models.PremiumCalculation {
+	actuarialPolicy := s.convertToActuarialPolicy(policy)
+	actuarialPolicy.YieldCurve = yieldCurve
+	calc := actuarial.CalculateFullMultiDecrementPremiumWithConfig(&actuarialPolicy, table, underwriting)
+	result := s.convertToPremiumCalculation(calc)
+	describeResult(&result, policy)
+	return result
+}
+
+// CalculateBatch processes multiple policies and returns a summary.
+// The summary holds the stats named in the request, or DefaultBatchSummaryStats.
+func (s *ActuarialService) CalculateBatch(request models.BatchCalculationRequest) (models.BatchCalculationResponse, error) {
+	policies := request.Policies
 	if len(policies) == 0 {
 		return models.BatchCalculationResponse{}, fmt.Errorf("no policies provided")
 	}
 	if len(policies) > 100 {
 		return models.BatchCalculationResponse{}, fmt.Errorf("too many policies (max 100)")
 	}
+	if err := checkSummaryStats(request.SummaryStats); err != nil {
+		return models.BatchCalculationResponse{}, err
+	}
+	if err := validateBasis(request.Basis); err != nil {
+		return models.BatchCalculationResponse{}, err
+	}
+
+	if request.ValidateOnly {
+		return s.validateBatch(request), nil
+	}
 
 	results := make([]models.PremiumCalculation, 0, len(policies))
-	totalNet := 0.0
-	totalGross := 0.0
-	perProductCount := make(map[string]int)
+	values := batchValues{productCounts: make(map[string]int), productTotals: make(map[string]models.ProductSubtotal)}
 
 	for i, p := range policies {
-		res, err := s.CalculatePremium(&p)
+		res, err := s.CalculatePremiumWithBasis(&p, request.Basis)
 		if err != nil {
-			return models.BatchCalculationResponse{}, fmt.Errorf("failed to calculate policy %d: %w", i+1, err)
+			return models.BatchCalculationResponse{}, fmt.Errorf("failed to calculate policy %s: %w", policyLabel(&p, i), err)
 		}
 		results = append(results, res)
+		values.add(res, p.CoverageAmount)
+	}
+
+	summary := buildBatchSummary(values, request.SummaryStats)
+	return models.BatchCalculationResponse{Results: results, Summary: summary}, nil
+}
+
+// validateBatch runs the pricing checks on every policy in a batch and reports
+// which would fail, without pricing any of them
+func (s *ActuarialService) validateBatch(request models.BatchCalculationRequest) models.BatchCalculationResponse {
+	report := make([]models.PolicyValidation, 0, len(request.Policies))
+	invalid := 0
+	for i, p := range request.Policies {
+		check := models.PolicyValidation{PolicyID: policyLabel(&p, i), Valid: true}
+		if _, err := s.preparePricing(&p, request.Basis); err != nil {
+			check.Valid = false
+			check.Reason = err.Error()
+			invalid++
+		}
+		report = append(report, check)
+	}
+	summary := map[string]interface{}{
+		"total_policies":   len(request.Policies),
+		"valid_policies":   len(request.Policies) - invalid,
+		"invalid_policies": invalid,
+	}
+	return models.BatchCalculationResponse{Results: []models.PremiumCalculation{}, Summary: summary, Validation: report}
+}
+
+// maxCensusLives is the largest census one group quote prices, each life
+// being priced in full
+const maxCensusLives = 1000
+
+// CalculateGroup prices every life in a scheme census and blends them into one scheme rate
+func (s *ActuarialService) CalculateGroup(req models.GroupQuoteRequest) (models.GroupQuoteResponse, error) {
+	if len(req.Census) == 0 {
+		return models.GroupQuoteResponse{}, fmt.Errorf("census is empty")
+	}
+	if len(req.Census) > maxCensusLives {
+		return models.GroupQuoteResponse{}, fmt.Errorf("too many lives (max %d)", maxCensusLives)
+	}
+
+	// Group life is usually renewed every year
+	term := req.Term
+	if term == 0 {
+		term = 1
+	}
+
+	totalAge := 0
+	totalCoverage := 0.0
+	totalNet := 0.0
+	totalGross := 0.0
+
+	for i, life := range req.Census {
+		policy := models.Policy{
+			Age:            life.Age,
+			Term:           term,
+			CoverageAmount: life.CoverageAmount,
+			InterestRate:   req.InterestRate,
+			Gender:         life.Gender,
+			ProductType:    req.ProductType,
+			SmokerStatus:   life.SmokerStatus,
+			HealthRating:   life.HealthRating,
+		}
+		res, err := s.CalculatePremium(&policy)
+		if err != nil {
+			return models.GroupQuoteResponse{}, fmt.Errorf("failed to calculate life %d: %w", i+1, err)
+		}
+		totalAge += life.Age
+		totalCoverage += life.CoverageAmount
 		totalNet += res.NetPremium
 		totalGross += res.GrossPremium
-		perProductCount[res.ProductType]++
 	}
 
-	summary := map[string]interface{}{
-		"total_policies":        len(results),
-		"total_net_premium":     totalNet,
-		"total_gross_premium":   totalGross,
-		"average_net_premium":   totalNet / float64(len(results)),
-		"average_gross_premium": totalGross / float64(len(results)),
-		"product_type_counts":   perProductCount,
+	return models.GroupQuoteResponse{
+		SchemeName:           req.SchemeName,
+		TotalLives:           len(req.Census),
+		TotalCoverage:        totalCoverage,
+		TotalNetPremium:      totalNet,
+		TotalGrossPremium:    totalGross,
+		AverageAge:           float64(totalAge) / float64(len(req.Census)),
+		NetRatePerThousand:   totalNet / totalCoverage * 1000,
+		GrossRatePerThousand: totalGross / totalCoverage * 1000,
+	}, nil
+}
+
+// CalculateExperienceRefund compares a group scheme's actual death claims for
+// a policy year with those expected from its census, and works out the
+// experience refund due on its profit share
+func (s *ActuarialService) CalculateExperienceRefund(req models.ExperienceRefundRequest) (models.ExperienceRefundResult, error) {
+	if len(req.Census) == 0 {
+		return models.ExperienceRefundResult{}, fmt.Errorf("census is empty")
+	}
+	if req.ActualClaims < 0 {
+		return models.ExperienceRefundResult{}, fmt.Errorf("actual claims cannot be negative")
+	}
+	if req.ProfitShare < 0 || req.ProfitShare > 1 {
+		return models.ExperienceRefundResult{}, fmt.Errorf("profit share must be between 0 and 1")
 	}
 
-	return models.BatchCalculationResponse{Results: results, Summary: summary}, nil
+	tables := s.currentTables()
+	underwriting := s.underwritingFor(nil)
+	expectedClaims := 0.0
+	for i, life := range req.Census {
+		policy := models.Policy{Age: life.Age, Gender: life.Gender, SmokerStatus: life.SmokerStatus, HealthRating: life.HealthRating}
+		table, err := tables.policyMortalityTable(&policy)
+		if err != nil {
+			return models.ExperienceRefundResult{}, fmt.Errorf("life %d: %w", i+1, err)
+		}
+		if err := checkAgeInRange(life.Age, tables.tableRange(life.Gender, table)); err != nil {
+			return models.ExperienceRefundResult{}, fmt.Errorf("life %d: %w", i+1, err)
+		}
+		if life.CoverageAmount < 0 {
+			return models.ExperienceRefundResult{}, fmt.Errorf("life %d: sum assured cannot be negative", i+1)
+		}
+
+		actuarialPolicy := s.convertToActuarialPolicy(&policy)
+		smokerMultiplier, healthMultiplier := underwriting.Multipliers(&actuarialPolicy)
+		qx := math.Min(table[life.Age]*smokerMultiplier*healthMultiplier, 1.0)
+		expectedClaims += life.CoverageAmount * qx
+	}
+	if expectedClaims <= 0 {
+		return models.ExperienceRefundResult{}, fmt.Errorf("expected claims are zero, so there is no A/E ratio")
+	}
+
+	return models.ExperienceRefundResult{
+		SchemeName:       req.SchemeName,
+		TotalLives:       len(req.Census),
+		ExpectedClaims:   expectedClaims,
+		ActualClaims:     req.ActualClaims,
+		ActualToExpected: req.ActualClaims / expectedClaims,
+		ProfitShare:      req.ProfitShare,
+		Refund:           actuarial.ExperienceRefund(expectedClaims, req.ActualClaims, req.ProfitShare),
+	}, nil
+}
+
+// CalculateJointLife values an annuity paid while both lives are alive, either
+// exactly from both tables or with the equal-age shortcut. With a reversion
+// the survivor goes on receiving that percentage of it, valued exactly. The
+// lives are independent unless a dependence between them is given.
+func (s *ActuarialService) CalculateJointLife(req models.JointLifeRequest) (models.JointLifeResult, error) {
+	if req.AnnualPayout <= 0 {
+		return models.JointLifeResult{}, fmt.Errorf("annual payout must be positive")
+	}
+	if req.InterestRate < 0 || req.InterestRate > 1 {
+		return models.JointLifeResult{}, fmt.Errorf("interest rate must be between 0 and 1")
+	}
+	if req.ReversionPercentage < 0 || req.ReversionPercentage > 100 {
+		return models.JointLifeResult{}, fmt.Errorf("reversion percentage must be between 0 and 100")
+	}
+	if err := actuarial.ValidateJointDependence(req.Dependence); err != nil {
+		return models.JointLifeResult{}, err
+	}
+
+	tables := make([]actuarial.MortalityTable, 2)
+	for i, life := range []models.JointLife{req.Life1, req.Life2} {
+		table, err := s.GetMortalityTable(life.Gender)
+		if err != nil {
+			return models.JointLifeResult{}, fmt.Errorf("life %d: %w", i+1, err)
+		}
+		if err := checkAgeInRange(life.Age, s.getTableRange(life.Gender, table)); err != nil {
+			return models.JointLifeResult{}, fmt.Errorf("life %d: %w", i+1, err)
+		}
+		tables[i] = table
+	}
+
+	result := models.JointLifeResult{Method: req.Method, AnnualPayout: req.AnnualPayout, ReversionPercentage: req.ReversionPercentage, Dependence: req.Dependence}
+	switch req.Method {
+	case "", "exact":
+		result.Method = "exact"
+		result.AnnuityFactor = actuarial.ReversionaryAnnuityDue(req.Life1.Age, req.Life2.Age, tables[0], tables[1], req.InterestRate, req.ReversionPercentage/100, req.Dependence)
+	case "equal_age":
+		if req.ReversionPercentage > 0 {
+			return models.JointLifeResult{}, fmt.Errorf("equal_age method can't value a reversion; use exact")
+		}
+		// Price a single life on the first life's table at the equivalent age
+		equivalentAge := actuarial.EquivalentJointAge(req.Life1.Age, req.Life2.Age, tables[0], tables[1], tables[0], req.InterestRate, req.Dependence)
+		result.EquivalentAge = &equivalentAge
+		result.AnnuityFactor = actuarial.SingleLifeAnnuityDue(equivalentAge, tables[0], req.InterestRate)
+	default:
+		return models.JointLifeResult{}, fmt.Errorf("unknown joint-life method '%s'", req.Method)
+	}
+	result.SinglePremium = result.AnnuityFactor * req.AnnualPayout
+
+	return result, nil
 }
 
 // SensitivityAnalysis runs the base policy and then tweaks inputs to see impact
 func (s *ActuarialService) SensitivityAnalysis(req models.SensitivityAnalysisRequest) (models.SensitivityAnalysisResponse, error) {
+	presets, err := lookupStressPresets(req.StressPresets)
+	if err != nil {
+		return models.SensitivityAnalysisResponse{}, err
+	}
 	base, err := s.CalculatePremium(&req.BasePolicy)
 	if err != nil {
 		return models.SensitivityAnalysisResponse{}, fmt.Errorf("failed to calculate base policy: %w", err)
@@ -122,17 +587,22 @@ func (s *ActuarialService) SensitivityAnalysis(req models.SensitivityAnalysisReq
 
 	analysis := map[string][]models.SensitivityResult{}
 
-	// Interest rate sensitivity
+	// Interest rate sensitivity, with the duration that shows the rate risk
 	if len(req.InterestRates) > 0 {
 		var out []models.SensitivityResult
 		for _, rate := range req.InterestRates {
 			tmp := req.BasePolicy
 			tmp.InterestRate = rate
+			tmp.IncludeDuration = true
 			res, err := s.CalculatePremium(&tmp)
 			if err != nil {
 				continue
 			}
-			out = append(out, models.SensitivityResult{Parameter: "interest_rate", Value: rate, Result: res})
+			duration := res.Duration
+			if !req.BasePolicy.IncludeDuration {
+				res.Duration = nil
+			}
+			out = append(out, models.SensitivityResult{Parameter: "interest_rate", Value: rate, Result: res, Duration: duration})
 		}
 		analysis["interest_rate"] = out
 	}
@@ -167,110 +637,676 @@ func (s *ActuarialService) SensitivityAnalysis(req models.SensitivityAnalysisReq
 		analysis["coverage_amount"] = out
 	}
 
-	return models.SensitivityAnalysisResponse{BaseResult: base, Analysis: analysis}, nil
+	// Standard stresses, returned with their definitions
+	response := models.SensitivityAnalysisResponse{BaseResult: base, Analysis: analysis}
+	if len(req.StressPresets) > 0 {
+		var out []models.SensitivityResult
+		for _, name := range req.StressPresets {
+			stressed, basis := stressPolicy(req.BasePolicy, presets[name], s.defaultBasisFor(&req.BasePolicy))
+			res, err := s.CalculatePremiumWithBasis(&stressed, basis)
+			if err != nil {
+				continue
+			}
+			out = append(out, models.SensitivityResult{Parameter: "stress_preset", Preset: name, Result: res})
+		}
+		analysis["stress_preset"] = out
+		response.StressPresets = presets
+	}
+	return response, nil
 }
 
 // PortfolioAnalysis analyzes a portfolio of policies
-func (s *ActuarialService) PortfolioAnalysis(policies []models.Policy) (models.PortfolioMetrics, error) {
+func (s *ActuarialService) PortfolioAnalysis(req models.PortfolioAnalysisRequest) (models.PortfolioMetrics, error) {
+	policies := req.Policies
 	if len(policies) == 0 {
 		return models.PortfolioMetrics{}, fmt.Errorf("no policies provided")
 	}
+	if rate := req.ExpectedClaimRate; rate != nil && (*rate < 0 || *rate > 1) {
+		return models.PortfolioMetrics{}, fmt.Errorf("expected claim rate must be between 0 and 1")
+	}
+	if req.TopN < 0 {
+		return models.PortfolioMetrics{}, fmt.Errorf("top_n must not be negative")
+	}
 
-	totalAge := 0
-	totalCoverage := 0.0
-	totalNetPremium := 0.0
-	totalGrossPremium := 0.0
-	productDist := make(map[string]int)
-	genderDist := make(map[string]int)
-	riskDist := make(map[string]int)
-
-	validPolicies := 0
-	for _, policy := range policies {
-		result, err := s.CalculatePremium(&policy)
-		if err != nil {
-			continue
-		}
-
-		validPolicies++
-		totalAge += policy.Age
-		totalCoverage += policy.CoverageAmount
-		totalNetPremium += result.NetPremium
-		totalGrossPremium += result.GrossPremium
-		productDist[result.ProductType]++
-		genderDist[policy.Gender]++
-
-		// Risk categorization
-		if policy.SmokerStatus == "smoker" || policy.HealthRating == "substandard" {
-			riskDist["high_risk"]++
-		} else if policy.HealthRating == "preferred" || policy.SmokerStatus == "non_smoker" {
-			riskDist["low_risk"]++
-		} else {
-			riskDist["standard_risk"]++
-		}
+	conversion, err := newCurrencyConversion(req.Policies, req.ReportingCurrency, req.ExchangeRates)
+	if err != nil {
+		return models.PortfolioMetrics{}, err
 	}
 
+	totals := s.totalPortfolio(policies, conversion)
+	validPolicies := totals.policies
+	totalCoverage := totals.coverage
+	totalNetPremium := totals.netPremium
+	totalGrossPremium := totals.grossPremium
+
 	if validPolicies == 0 {
 		return models.PortfolioMetrics{}, fmt.Errorf("no valid policies found")
 	}
 
-	// Calculate profitability metrics
-	totalExpectedPayout := totalCoverage * 0.02
-	expectedProfit := totalGrossPremium - totalNetPremium
+	// Calculate profitability metrics. Expected claims are a year's deaths on
+	// each policy's underwritten mortality, unless a flat rate is given to test a scenario.
+	totalExpectedPayout := totals.expectedClaims
+	if req.ExpectedClaimRate != nil {
+		totalExpectedPayout = totalCoverage * *req.ExpectedClaimRate
+	}
+	// The gross premiums split into net premium, the expenses they are loaded
+	// for and profit. The combined ratio is claims and expenses only; the
+	// profit loading is what it leaves as margin.
+	expectedProfit := totals.profitLoading
 	profitMargin := expectedProfit / totalGrossPremium
 	lossRatio := totalExpectedPayout / totalGrossPremium
+	expenseRatio := totals.expenseLoading / totalGrossPremium
 
 	profitabilityMetrics := map[string]float64{
+		"expected_claims":   totalExpectedPayout,
+		"expense_loading":   totals.expenseLoading,
 		"expected_profit":   expectedProfit,
 		"profit_margin":     profitMargin,
 		"loss_ratio":        lossRatio,
-		"expense_ratio":     (totalGrossPremium - totalNetPremium) / totalGrossPremium,
-		"combined_ratio":    lossRatio + ((totalGrossPremium - totalNetPremium) / totalGrossPremium),
+		"expense_ratio":     expenseRatio,
+		"combined_ratio":    lossRatio + expenseRatio,
 		"return_on_premium": expectedProfit / totalNetPremium,
 	}
 
-	return models.PortfolioMetrics{
+	metrics := models.PortfolioMetrics{
 		TotalPolicies:        validPolicies,
 		TotalNetPremium:      totalNetPremium,
 		TotalGrossPremium:    totalGrossPremium,
-		AverageAge:           float64(totalAge) / float64(validPolicies),
+		AverageAge:           float64(totals.totalAge) / float64(validPolicies),
 		AverageCoverage:      totalCoverage / float64(validPolicies),
-		ProductDistribution:  productDist,
-		GenderDistribution:   genderDist,
-		RiskDistribution:     riskDist,
+		ProductDistribution:  totals.products,
+		GenderDistribution:   totals.genders,
+		RiskDistribution:     totals.risks,
 		ProfitabilityMetrics: profitabilityMetrics,
+		Concentration:        totals.concentration(req.TopN),
+		NetAmountAtRisk:      totals.netAmountAtRisk,
+		AtRiskMortality:      totals.atRiskWeightedMortality(),
+		SkippedPolicies:      totals.skipped,
+	}
+	if conversion != nil {
+		metrics.ReportingCurrency = conversion.reporting
+		metrics.CurrencySubtotals = totals.currencies
+	}
+	return metrics, nil
+}
+
+// BreakEvenAnalysis works out when cumulative gross premiums first exceed the sum
+// assured and when the reserve first exceeds the premiums paid so far
+func (s *ActuarialService) BreakEvenAnalysis(policy *models.Policy) (models.BreakEvenResult, error) {
+	result, err := s.CalculatePremium(policy)
+	if err != nil {
+		return models.BreakEvenResult{}, err
+	}
+	if len(result.ReserveSchedule) == 0 {
+		return models.BreakEvenResult{}, fmt.Errorf("break-even analysis is not available for %s", result.ProductType)
+	}
+
+	// Limited-pay policies stop paying before the term ends, and layers of
+	// cover run off before it
+	normalized := normalizePolicy(policy)
+	pricedPolicy := s.convertToActuarialPolicy(&normalized)
+	// With a fee waiver the gross premium is the renewal one
+	firstYearPremium := cmp.Or(result.FirstYearPremium, result.GrossPremium)
+	premiumExceedsCover, reserveExceedsPremiums := actuarial.BreakEvenDurations(
+		&pricedPolicy, firstYearPremium, result.GrossPremium, result.ReserveSchedule)
+
+	return models.BreakEvenResult{
+		ProductType:                result.ProductType,
+		GrossPremium:               result.GrossPremium,
+		SumAssured:                 actuarial.SumAssuredInYear(&pricedPolicy, 0),
+		PremiumExceedsCoverageYear: premiumExceedsCover,
+		ReserveExceedsPremiumsYear: reserveExceedsPremiums,
+		ReserveSchedule:            result.ReserveSchedule,
 	}, nil
 }
 
 // Helper functions
 
-func (s *ActuarialService) validatePolicy(policy *models.Policy) error {
-	if policy.Age < 0 || policy.Age > 120 {
-		return fmt.Errorf("age must be between 0 and 120")
+// policyLabel identifies a policy in messages: its PolicyID, or its 1-based
+// position in the request when no ID was sent
+func policyLabel(policy *models.Policy, index int) string {
+	if policy.PolicyID != "" {
+		return policy.PolicyID
+	}
+	return strconv.Itoa(index + 1)
+}
+
+func resolveTableName(gender string) string {
+	tableName := strings.ToLower(strings.TrimSpace(gender))
+	if tableName == "" {
+		tableName = "male"
+	}
+	return tableName
+}
+
+// normalizePolicy returns a copy of the policy with every default filled in, so
+// the rest of the pricing sees the same values the caller is shown as effective
+func normalizePolicy(policy *models.Policy) models.Policy {
+	normalized := *policy
+	normalized.Gender = resolveTableName(policy.Gender)
+	if normalized.ProductType == "" {
+		normalized.ProductType = "term_life"
+	}
+	if normalized.PaymentFrequency == "" {
+		normalized.PaymentFrequency = "annual"
+	}
+	if strings.HasSuffix(normalized.ProductType, "_annuity") && normalized.AnnuityTiming == "" {
+		normalized.AnnuityTiming = actuarial.AnnuityDue
+	}
+	if normalized.CompoundingConvention == "" {
+		normalized.CompoundingConvention = actuarial.AnnualCompounding
+	}
+	normalized.Currency = strings.ToUpper(strings.TrimSpace(policy.Currency))
+	if policy.HasAge() {
+		// An age sent takes precedence, and the dates play no part
+		normalized.DateOfBirth, normalized.IssueDate, normalized.AgeRounding = "", "", ""
+	} else if policy.DateOfBirth != "" {
+		// Bad dates are left for validatePolicy to report
+		if age, issueDate, err := ageFromDates(policy); err == nil {
+			normalized.Age = age
+			normalized.IssueDate = issueDate.Format(time.DateOnly)
+			normalized.AgeRounding = cmp.Or(policy.AgeRounding, actuarial.AgeLastBirthday)
+		}
+	}
+	return normalized
+}
+
+// ageFromDates works out a policy's age at issue from its date of birth, and
+// the issue date it was worked out at
+func ageFromDates(policy *models.Policy) (int, time.Time, error) {
+	dateOfBirth, err := time.Parse(time.DateOnly, policy.DateOfBirth)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("date of birth must be a date like 1985-03-10")
+	}
+	issueDate, err := time.Parse(time.DateOnly, time.Now().UTC().Format(time.DateOnly))
+	if policy.IssueDate != "" {
+		issueDate, err = time.Parse(time.DateOnly, policy.IssueDate)
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("issue date must be a date like 2025-03-10")
+	}
+	age, err := actuarial.AgeAtIssue(dateOfBirth, issueDate, policy.AgeRounding)
+	return age, issueDate, err
+}
+
+// describeResult carries what the caller sent about the policy itself - its ID,
+// display locale and, when asked for, the policy as priced - onto the result
+func describeResult(result *models.PremiumCalculation, policy *models.Policy) {
+	result.PolicyID = policy.PolicyID
+	result.Locale = policy.Locale
+	result.Currency = policy.Currency
+	if policy.DateOfBirth != "" {
+		result.AgeAtIssue = policy.Age
+		result.AgeRounding = policy.AgeRounding
+	}
+	if policy.EchoEffectivePolicy {
+		effective := *policy
+		effective.EchoEffectivePolicy = false
+		result.EffectivePolicy = &effective
+	}
+	if policy.IncludeRatePerMille {
+		result.GrossRatePerMille = ratePerMille(result.GrossPremium, policy.CoverageAmount)
+		result.NetRatePerMille = ratePerMille(result.NetPremium, policy.CoverageAmount)
+	}
+}
+
+// ratePerMille is a premium per 1000 of sum assured, the way brokers quote
+// it. There is no rate without cover to divide by.
+func ratePerMille(premium, coverage float64) float64 {
+	if coverage <= 0 {
+		return 0
+	}
+	return premium / coverage * 1000
+}
+
+func checkAgeInRange(age int, tableRange models.TableMetadata) error {
+	if age < tableRange.MinAge || age > tableRange.MaxAge {
+		return fmt.Errorf("age must be between %d and %d for table '%s'", tableRange.MinAge, tableRange.MaxAge, tableRange.Name)
+	}
+	return nil
+}
+
+// maxUltimateAge is the oldest age mortality may be extrapolated to
+const maxUltimateAge = 150
+
+func (s *ActuarialService) validatePolicy(policy *models.Policy, tableRange models.TableMetadata) error {
+	if policy.DateOfBirth != "" {
+		if _, _, err := ageFromDates(policy); err != nil {
+			return err
+		}
+	} else if policy.IssueDate != "" || policy.AgeRounding != "" {
+		return fmt.Errorf("issue date and age rounding need a date of birth")
+	}
+	if err := checkAgeInRange(policy.Age, tableRange); err != nil {
+		return err
 	}
 	if policy.Term < 0 {
 		return fmt.Errorf("term must be positive")
 	}
+	if _, ok := actuarial.LookupProduct(policy.ProductType); !ok {
+		return fmt.Errorf("unknown product type '%s'", policy.ProductType)
+	}
+	if policy.DeferralFraction < 0 || policy.DeferralFraction >= 1 {
+		return fmt.Errorf("deferral fraction must be at least 0 and below 1")
+	}
+	if policy.DeferralFraction > 0 && policy.ProductType != "deferred_annuity" {
+		return fmt.Errorf("deferral fraction only applies to deferred_annuity")
+	}
+	if policy.ProductType == "deferred_annuity" && policy.DeferralPeriod <= 0 && policy.DeferralFraction == 0 {
+		return fmt.Errorf("deferred_annuity needs a deferral period")
+	}
 	if policy.CoverageAmount <= 0 {
 		return fmt.Errorf("coverage amount must be positive")
 	}
 	if policy.InterestRate < 0 || policy.InterestRate > 1 {
 		return fmt.Errorf("interest rate must be between 0 and 1")
 	}
+	if policy.LapseRate < 0 || policy.LapseRate >= 1 {
+		return fmt.Errorf("lapse rate must be at least 0 and below 1")
+	}
+	if policy.MassLapse < 0 || policy.MassLapse >= 1 {
+		return fmt.Errorf("mass lapse must be at least 0 and below 1")
+	}
+	if err := validateDynamicLapse(policy); err != nil {
+		return err
+	}
+	if err := validateRequiredCapital(policy); err != nil {
+		return err
+	}
+	if policy.InForceDuration < 0 {
+		return fmt.Errorf("in-force duration must not be negative")
+	}
+	if policy.InForceDuration > 0 && policy.ProductType != "whole_life" && policy.InForceDuration > policy.Term {
+		return fmt.Errorf("in-force duration %d is beyond the %d year term", policy.InForceDuration, policy.Term)
+	}
+	switch policy.MortalityExtrapolation {
+	case "", "immediate":
+		if policy.UltimateAge != 0 {
+			return fmt.Errorf("ultimate age only applies to linear mortality extrapolation")
+		}
+	case "linear":
+		if policy.UltimateAge <= tableRange.MaxAge || policy.UltimateAge > maxUltimateAge {
+			return fmt.Errorf("ultimate age must be between %d and %d", tableRange.MaxAge+1, maxUltimateAge)
+		}
+	default:
+		return fmt.Errorf("unknown mortality extrapolation '%s'", policy.MortalityExtrapolation)
+	}
+	if _, ok := actuarial.ModalFactors[policy.PaymentFrequency]; policy.PaymentFrequency != "" && !ok {
+		return fmt.Errorf("unknown payment frequency '%s'", policy.PaymentFrequency)
+	}
+	if policy.MaturityBenefit < 0 || policy.MaturityBenefit > policy.CoverageAmount {
+		return fmt.Errorf("maturity benefit must be between 0 and the sum assured")
+	}
+	if policy.MaturityBenefit > 0 && policy.ProductType != "" && policy.ProductType != "term_life" {
+		return fmt.Errorf("maturity benefit only applies to term_life")
+	}
+	if policy.RiskDiscountRate < 0 || policy.RiskDiscountRate > 1 {
+		return fmt.Errorf("risk discount rate must be between 0 and 1")
+	}
+	if policy.SettlementDelay != 0 {
+		if policy.ProductType != "" && policy.ProductType != "term_life" && policy.ProductType != "whole_life" {
+			return fmt.Errorf("settlement delay only applies to term_life and whole_life")
+		}
+		if policy.SettlementDelay < 0 || policy.SettlementDelay >= 12 {
+			return fmt.Errorf("settlement delay must be at least 0 and under 12 months")
+		}
+	}
+	if policy.IncludeProfitByYear {
+		if policy.ProductType != "" && policy.ProductType != "term_life" && policy.ProductType != "whole_life" {
+			return fmt.Errorf("profit by year only applies to term_life and whole_life")
+		}
+		if policy.InForceDuration > 0 {
+			return fmt.Errorf("profit by year is only available for new business (in-force duration 0)")
+		}
+	}
+	if policy.IncludePaidUp && policy.ProductType != "whole_life" {
+		return fmt.Errorf("paid-up analysis only applies to whole_life")
+	}
+	if policy.PremiumCeaseAge != 0 {
+		if policy.ProductType != "whole_life" {
+			return fmt.Errorf("premium cease age only applies to whole_life")
+		}
+		if policy.PremiumCeaseAge <= policy.Age {
+			return fmt.Errorf("premium cease age must be after the age at issue")
+		}
+	}
+	if policy.SurvivalThreshold != 0 {
+		if policy.ProductType != "whole_life" {
+			return fmt.Errorf("survival threshold only applies to whole_life")
+		}
+		if policy.SurvivalThreshold < 0 || policy.SurvivalThreshold >= 1 {
+			return fmt.Errorf("survival threshold must be at least 0 and below 1")
+		}
+	}
+	switch policy.ReserveApproach {
+	case "", actuarial.ReserveProspective, actuarial.ReserveRetrospective:
+	default:
+		return fmt.Errorf("unknown reserve approach '%s'", policy.ReserveApproach)
+	}
+	switch policy.FirstYearFeeWaiver {
+	case "":
+	case actuarial.FeeWaiverAbsorbed, actuarial.FeeWaiverRespread:
+		if policy.ProductType != "" && policy.ProductType != "term_life" && policy.ProductType != "whole_life" {
+			return fmt.Errorf("first-year fee waiver only applies to term_life and whole_life")
+		}
+	default:
+		return fmt.Errorf("unknown first-year fee waiver '%s'", policy.FirstYearFeeWaiver)
+	}
+	if policy.PremiumPayingTerm != 0 {
+		if policy.ProductType != "term_life" {
+			return fmt.Errorf("premium paying term only applies to term_life")
+		}
+		if policy.PremiumPayingTerm < 0 || policy.PremiumPayingTerm > policy.Term {
+			return fmt.Errorf("premium paying term must be between 1 and the %d year term", policy.Term)
+		}
+	}
+	if err := checkCompoundingConvention(policy.CompoundingConvention); err != nil {
+		return err
+	}
+	if policy.CashRefund && policy.ProductType != "immediate_annuity" {
+		return fmt.Errorf("cash refund only applies to immediate_annuity")
+	}
+	switch policy.ReturnOfPurchasePrice {
+	case "":
+	case actuarial.RefundPremium, actuarial.RefundAccumulated:
+		if policy.ProductType != "deferred_annuity" {
+			return fmt.Errorf("return of purchase price only applies to deferred_annuity")
+		}
+		if policy.DeferralPeriod <= 0 {
+			return fmt.Errorf("return of purchase price needs a deferral period")
+		}
+		if policy.DeferralFraction > 0 {
+			return fmt.Errorf("return of purchase price needs a whole number of years' deferral")
+		}
+	default:
+		return fmt.Errorf("return of purchase price must be '%s' or '%s'", actuarial.RefundPremium, actuarial.RefundAccumulated)
+	}
+	switch policy.AnnuityTiming {
+	case "":
+	case actuarial.AnnuityDue, actuarial.AnnuityImmediate:
+		if !strings.HasSuffix(policy.ProductType, "_annuity") {
+			return fmt.Errorf("annuity timing only applies to annuities")
+		}
+	default:
+		return fmt.Errorf("annuity timing must be '%s' or '%s'", actuarial.AnnuityDue, actuarial.AnnuityImmediate)
+	}
+	if policy.Locale != "" {
+		if _, err := language.Parse(policy.Locale); err != nil {
+			return fmt.Errorf("unknown locale '%s'", policy.Locale)
+		}
+	}
+	if policy.Currency != "" {
+		if _, err := currency.ParseISO(policy.Currency); err != nil {
+			return fmt.Errorf("unknown currency '%s'", policy.Currency)
+		}
+	}
+	switch policy.UnderwritingClass {
+	case "", "full", "simplified_issue":
+	default:
+		return fmt.Errorf("unknown underwriting class '%s'", policy.UnderwritingClass)
+	}
+	for _, loading := range policy.SimplifiedIssueLoadings {
+		if loading <= 0 {
+			return fmt.Errorf("simplified issue loadings must be positive")
+		}
+	}
+	if policy.Renewable && policy.ProductType != "term_life" {
+		return fmt.Errorf("renewable only applies to term_life")
+	}
+	for _, loading := range policy.AntiSelectionLoadings {
+		if loading <= 0 {
+			return fmt.Errorf("anti-selection loadings must be positive")
+		}
+	}
+	if len(policy.Layers) > 0 {
+		if policy.ProductType != "term_life" {
+			return fmt.Errorf("layers only apply to term_life")
+		}
+		if policy.MaturityBenefit > 0 {
+			return fmt.Errorf("maturity benefit is not supported with layers")
+		}
+		for i, layer := range policy.Layers {
+			if layer.CoverageAmount <= 0 {
+				return fmt.Errorf("layer %d: coverage amount must be positive", i+1)
+			}
+			if layer.Term <= 0 {
+				return fmt.Errorf("layer %d: term must be positive", i+1)
+			}
+			if lastAge := policy.Age + layer.Term - 1; lastAge > tableRange.MaxAge {
+				return fmt.Errorf("layer %d: cover to age %d runs past the end of table '%s' at %d", i+1, lastAge+1, tableRange.Name, tableRange.MaxAge)
+			}
+		}
+	}
 	return nil
 }
 
+func convertPremiumBreakdown(breakdown *actuarial.PremiumBreakdown) *models.PremiumBreakdown {
+	if breakdown == nil {
+		return nil
+	}
+	return &models.PremiumBreakdown{
+		NetPremium:     breakdown.NetPremium,
+		ExpenseLoading: breakdown.ExpenseLoading,
+		ProfitLoading:  breakdown.ProfitLoading,
+	}
+}
+
+func convertModifiedReserve(modified *actuarial.ModifiedReserve) *models.ModifiedReserve {
+	if modified == nil {
+		return nil
+	}
+	return &models.ModifiedReserve{
+		Method:                modified.Method,
+		FirstYearPremium:      modified.FirstYearPremium,
+		RenewalPremium:        modified.RenewalPremium,
+		ExpenseAllowance:      modified.ExpenseAllowance,
+		UncappedAllowance:     modified.UncappedAllowance,
+		AllowanceCap:          modified.AllowanceCap,
+		LimitedByGrossPremium: modified.LimitedByGrossPremium,
+	}
+}
+
+func convertProfitByYear(profit *actuarial.ProfitByYear) *models.ProfitByYear {
+	if profit == nil {
+		return nil
+	}
+	return &models.ProfitByYear{
+		ExpectedProfit:  profit.ExpectedProfit,
+		DiscountRate:    profit.DiscountRate,
+		DiscountedTotal: profit.DiscountedTotal,
+	}
+}
+
+func convertCashFlowDuration(duration *actuarial.CashFlowDuration) *models.CashFlowDuration {
+	if duration == nil {
+		return nil
+	}
+	return &models.CashFlowDuration{
+		Benefits:        duration.Benefits,
+		Premiums:        duration.Premiums,
+		Net:             duration.Net,
+		NetPresentValue: duration.NetPresentValue,
+	}
+}
+
+func convertPaidUp(paidUp *actuarial.PaidUp) *models.PaidUp {
+	if paidUp == nil {
+		return nil
+	}
+	return &models.PaidUp{
+		Duration:         paidUp.Duration,
+		CostOfCover:      paidUp.CostOfCover,
+		PaidUpSumAssured: paidUp.PaidUpSumAssured,
+	}
+}
+
+// convertLayers turns a policy's extra layers into the actuarial layers, the
+// policy's own sum assured and term first, and returns the longest term
+func convertLayers(policy *models.Policy) ([]actuarial.CoverageLayer, int) {
+	if len(policy.Layers) == 0 {
+		return nil, policy.Term
+	}
+	layers := make([]actuarial.CoverageLayer, 0, len(policy.Layers)+1)
+	layers = append(layers, actuarial.CoverageLayer{CoverageAmount: policy.CoverageAmount, Term: policy.Term})
+	term := policy.Term
+	for _, layer := range policy.Layers {
+		layers = append(layers, actuarial.CoverageLayer{CoverageAmount: layer.CoverageAmount, Term: layer.Term})
+		term = max(term, layer.Term)
+	}
+	return layers, term
+}
+
 func (s *ActuarialService) convertToActuarialPolicy(policy *models.Policy) actuarial.Policy {
+	layers, term := convertLayers(policy)
 	return actuarial.Policy{
 		Age:            policy.Age,
-		Term:           policy.Term,
+		Term:           term,
 		CoverageAmount: policy.CoverageAmount,
 		InterestRate:   policy.InterestRate,
 		Gender:         policy.Gender,
 		ProductType:    policy.ProductType,
 		SmokerStatus:   policy.SmokerStatus,
 		HealthRating:   policy.HealthRating,
+		Impairment:     policy.Impairment,
 		RatingFactor:   policy.RatingFactor,
 		DeferralPeriod: policy.DeferralPeriod,
+		LapseRate:      policy.LapseRate,
+
+		UnderwritingClass:       policy.UnderwritingClass,
+		SimplifiedIssueLoadings: policy.SimplifiedIssueLoadings,
+		Renewable:               policy.Renewable,
+		AntiSelectionLoadings:   policy.AntiSelectionLoadings,
+		DisabilityBenefit:       policy.DisabilityBenefit,
+		InForceDuration:         policy.InForceDuration,
+		MortalityExtrapolation:  policy.MortalityExtrapolation,
+		UltimateAge:             policy.UltimateAge,
+		SurvivalThreshold:       policy.SurvivalThreshold,
+		PaymentFrequency:        policy.PaymentFrequency,
+		IncludeNetAmountAtRisk:  policy.IncludeNetAmountAtRisk,
+		IncludeSinglePremium:    policy.IncludeSinglePremium,
+		IncludeDuration:         policy.IncludeDuration,
+		IncludeProfitByYear:     policy.IncludeProfitByYear,
+		IncludePaidUp:           policy.IncludePaidUp,
+		FirstYearFeeWaiver:      policy.FirstYearFeeWaiver,
+		MassLapse:               policy.MassLapse,
+		SettlementDelay:         policy.SettlementDelay,
+		MaturityBenefit:         policy.MaturityBenefit,
+		CompoundingConvention:   policy.CompoundingConvention,
+		RiskDiscountRate:        policy.RiskDiscountRate,
+		PremiumCeaseAge:         policy.PremiumCeaseAge,
+		PremiumPayingTerm:       policy.PremiumPayingTerm,
+		ReserveApproach:         policy.ReserveApproach,
+		ReturnOfPurchasePrice:   policy.ReturnOfPurchasePrice,
+		CashRefund:              policy.CashRefund,
+		AnnuityTiming:           policy.AnnuityTiming,
+		DeferralFraction:        policy.DeferralFraction,
+		Layers:                  layers,
+		DynamicLapse:            convertDynamicLapse(policy),
+		RequiredCapital:         convertRequiredCapital(policy.RequiredCapital),
+	}
+}
+
+// validateDynamicLapse checks a policy's dynamic lapse formula can be applied
+func validateDynamicLapse(policy *models.Policy) error {
+	lapse := policy.DynamicLapse
+	if lapse == nil {
+		return nil
+	}
+	if policy.ProductType != "term_life" && policy.ProductType != "whole_life" {
+		return fmt.Errorf("dynamic lapse only applies to life products")
+	}
+	if lapse.Sensitivity < 0 {
+		return fmt.Errorf("dynamic lapse sensitivity must not be negative")
+	}
+	if lapse.Threshold < 0 {
+		return fmt.Errorf("dynamic lapse threshold must not be negative")
+	}
+	if lapse.ReferenceRate <= -1 || (lapse.CreditedRate != nil && *lapse.CreditedRate <= -1) {
+		return fmt.Errorf("dynamic lapse rates must be above -1")
+	}
+	maxLapse := cmp.Or(lapse.MaxLapse, actuarial.DefaultMaxDynamicLapse)
+	if maxLapse < 0 || maxLapse >= 1 {
+		return fmt.Errorf("dynamic lapse max lapse must be at least 0 and below 1")
+	}
+	if policy.LapseRate > maxLapse {
+		return fmt.Errorf("lapse rate %g is above the dynamic lapse ceiling %g", policy.LapseRate, maxLapse)
+	}
+	return nil
+}
+
+// convertDynamicLapse fills in the credited rate a dynamic lapse formula
+// leaves out with the policy's own interest rate
+func convertDynamicLapse(policy *models.Policy) *actuarial.DynamicLapse {
+	if policy.DynamicLapse == nil {
+		return nil
+	}
+	lapse := policy.DynamicLapse
+	return &actuarial.DynamicLapse{
+		ReferenceRate: lapse.ReferenceRate,
+		CreditedRate:  *cmp.Or(lapse.CreditedRate, &policy.InterestRate),
+		Sensitivity:   lapse.Sensitivity,
+		Threshold:     lapse.Threshold,
+		MaxLapse:      lapse.MaxLapse,
+	}
+}
+
+// validateRequiredCapital checks a policy's required capital basis can be
+// used in its profit test
+func validateRequiredCapital(policy *models.Policy) error {
+	if policy.RequiredCapital == nil {
+		return nil
+	}
+	if policy.ProductType != "" && policy.ProductType != "term_life" && policy.ProductType != "whole_life" {
+		return fmt.Errorf("required capital only applies to life products")
+	}
+	if policy.RiskDiscountRate <= 0 {
+		return fmt.Errorf("required capital needs a risk discount rate to profit test at")
+	}
+	return convertRequiredCapital(policy.RequiredCapital).Validate()
+}
+
+// convertRequiredCapital fills in the factors a capital basis leaves out from
+// the default basis
+func convertRequiredCapital(basis *models.CapitalBasis) *actuarial.CapitalBasis {
+	if basis == nil {
+		return nil
+	}
+	capital := actuarial.DefaultCapitalBasis
+	capital.ReserveFactor = *cmp.Or(basis.ReserveFactor, &capital.ReserveFactor)
+	capital.SumAtRiskFactor = *cmp.Or(basis.SumAtRiskFactor, &capital.SumAtRiskFactor)
+	return &capital
+}
+
+func convertProfitTest(profitTest *actuarial.ProfitTestResult) *models.ProfitTestResult {
+	if profitTest == nil {
+		return nil
+	}
+	return &models.ProfitTestResult{
+		RiskDiscountRate: profitTest.RiskDiscountRate,
+		ProfitVector:     profitTest.ProfitVector,
+		ProfitSignature:  profitTest.ProfitSignature,
+		ProfitValue:      profitTest.ProfitValue,
+		PremiumValue:     profitTest.PremiumValue,
+		ProfitMargin:     profitTest.ProfitMargin,
+
+		BreakEvenMortality:       profitTest.BreakEvenMortality,
+		BreakEvenMortalityStatus: profitTest.BreakEvenMortalityStatus,
+
+		ProfitRelease: convertProfitRelease(profitTest.ProfitRelease),
+	}
+}
+
+func convertProfitRelease(release *actuarial.ProfitRelease) *models.ProfitRelease {
+	if release == nil {
+		return nil
+	}
+	return &models.ProfitRelease{
+		CapitalStrain:         release.CapitalStrain,
+		RequiredCapital:       release.RequiredCapital,
+		CostOfCapital:         release.CostOfCapital,
+		DistributableEarnings: release.DistributableEarnings,
+		DistributableValue:    release.DistributableValue,
+		CostOfCapitalValue:    release.CostOfCapitalValue,
 	}
 }
 
@@ -285,5 +1321,64 @@ func (s *ActuarialService) convertToPremiumCalculation(calc actuarial.PremiumCal
 		TotalPremiumCost: calc.TotalPremiumCost,
 		UnderwritingInfo: calc.UnderwritingInfo,
 		RiskAssessment:   calc.RiskAssessment,
+		LapseRate:        calc.LapseRate,
+		InForceDuration:  calc.InForceDuration,
+		Basis:            calc.Basis,
+
+		NewBusinessStrain:     calc.NewBusinessStrain,
+		PremiumBreakdown:      convertPremiumBreakdown(calc.PremiumBreakdown),
+		CompoundingConvention: calc.CompoundingConvention,
+		ProfitTest:            convertProfitTest(calc.ProfitTest),
+		PremiumPayingYears:    calc.PremiumPayingYears,
+		DeathBenefitValue:     calc.DeathBenefitValue,
+		ActuarialPremium:      calc.ActuarialPremium,
+		MinimumPremiumApplied: calc.MinimumPremiumApplied,
+		MinimumPremiumLoading: calc.MinimumPremiumLoading,
+		PremiumBand:           convertPremiumBand(calc.PremiumBand),
+		VolumeDiscount:        calc.VolumeDiscount,
+		PremiumTaxRate:        calc.PremiumTaxRate,
+		PremiumTax:            calc.PremiumTax,
+		TaxInclusivePremium:   calc.TaxInclusivePremium,
+		AnnuityTiming:         calc.AnnuityTiming,
+		EffectiveDeferral:     calc.EffectiveDeferral,
+		ReserveApproach:       calc.ReserveApproach,
+		PremiumTiming:         calc.PremiumTiming,
+		HorizonYears:          calc.HorizonYears,
+		HorizonTruncated:      calc.HorizonTruncated,
+		CashRefundIterations:  calc.CashRefundIterations,
+		CashRefundConverged:   calc.CashRefundConverged,
+		Warnings:              calc.Warnings,
+		Duration:              convertCashFlowDuration(calc.Duration),
+		PaidUp:                convertPaidUp(calc.PaidUp),
+		FeeWaiver:             calc.FeeWaiver,
+		FirstYearPremium:      calc.FirstYearPremium,
+		RenewalPremium:        calc.RenewalPremium,
+		ModifiedReserve:       convertModifiedReserve(calc.ModifiedReserve),
+		ProfitByYear:          convertProfitByYear(calc.ProfitByYear),
+		SettlementDelay:       calc.SettlementDelay,
+		UltimateAge:           calc.UltimateAge,
+		PaymentFrequency:      calc.PaymentFrequency,
+		ModalFactor:           calc.ModalFactor,
+		ModalPremium:          calc.ModalPremium,
+		ModalImpliedRate:      calc.ModalImpliedRate,
+		NetAmountAtRisk:       calc.NetAmountAtRisk,
+		SinglePremium:         calc.SinglePremium,
+		LayerPremiums:         convertLayerPremiums(calc.LayerPremiums),
+		LapsePath:             calc.LapsePath,
+	}
+}
+
+func convertLayerPremiums(premiums []actuarial.LayerPremium) []models.LayerPremium {
+	if len(premiums) == 0 {
+		return nil
+	}
+	converted := make([]models.LayerPremium, len(premiums))
+	for i, premium := range premiums {
+		converted[i] = models.LayerPremium{
+			CoverageAmount: premium.CoverageAmount,
+			Term:           premium.Term,
+			NetPremium:     premium.NetPremium,
+		}
 	}
+	return converted
 }
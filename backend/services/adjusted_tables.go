@@ -0,0 +1,73 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"sync"
+)
+
+// maxAdjustedTables bounds the cache; past it the cache starts again empty
+const maxAdjustedTables = 4096
+
+// adjustedTableKey is everything that decides an underwritten table, given
+// the table set and underwriting config the cache belongs to
+type adjustedTableKey struct {
+	table     string // Resolved name of the base table
+	issueAge  int    // Select tables give each age at issue its own base table; -1 otherwise
+	signature string // actuarial.UnderwritingSignature
+}
+
+// adjustedTableCache keeps underwritten tables so policies underwritten alike,
+// as in a batch of similar risks, share one table instead of each copying and
+// adjusting the base table. A cache belongs to one table set and one
+// underwriting config, and is dropped when either changes.
+type adjustedTableCache struct {
+	tables       *tableSet
+	underwriting actuarial.UnderwritingConfig
+
+	mu      sync.Mutex
+	entries map[adjustedTableKey]actuarial.MortalityTable
+}
+
+// adjustedTablesFor returns the cache for a table set, or nil if the set has
+// already been replaced by a reload
+func (s *ActuarialService) adjustedTablesFor(tables *tableSet) *adjustedTableCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tables != tables {
+		return nil
+	}
+	if s.adjusted == nil || s.adjusted.tables != tables {
+		s.adjusted = &adjustedTableCache{
+			tables:       tables,
+			underwriting: s.underwriting,
+			entries:      make(map[adjustedTableKey]actuarial.MortalityTable),
+		}
+	}
+	return s.adjusted
+}
+
+// table returns the base table with the policy's underwriting applied, from
+// the cache when the same adjustment has been made before. The table is
+// shared, so callers must not modify it.
+func (c *adjustedTableCache) table(policy *actuarial.Policy, tableName string, selectTable bool, base actuarial.MortalityTable) actuarial.MortalityTable {
+	key := adjustedTableKey{table: tableName, issueAge: -1, signature: actuarial.UnderwritingSignature(policy, c.underwriting)}
+	if selectTable {
+		key.issueAge = policy.Age
+	}
+
+	c.mu.Lock()
+	adjusted, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return adjusted
+	}
+
+	adjusted = actuarial.ApplyUnderwritingFactorsWithConfig(policy, base, c.underwriting)
+	c.mu.Lock()
+	if len(c.entries) >= maxAdjustedTables {
+		clear(c.entries)
+	}
+	c.entries[key] = adjusted
+	c.mu.Unlock()
+	return adjusted
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestAgeFromDateOfBirth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.001")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := models.Policy{DateOfBirth: "1985-03-10", IssueDate: "2025-09-10", AgeRounding: actuarial.AgeNearestBirthday,
+		Term: 20, CoverageAmount: 100000, InterestRate: 0.05, EchoEffectivePolicy: true}
+	result, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AgeAtIssue != 41 || result.AgeRounding != actuarial.AgeNearestBirthday || result.EffectivePolicy.Age != 41 {
+		t.Errorf("Expected age 41 nearest birthday, got %d %q (priced at %d)", result.AgeAtIssue, result.AgeRounding, result.EffectivePolicy.Age)
+	}
+	direct, err := service.CalculatePremium(&models.Policy{Age: 41, Term: 20, CoverageAmount: 100000, InterestRate: 0.05})
+	if err != nil || direct.NetPremium != result.NetPremium {
+		t.Errorf("Expected the same premium as age 41 sent directly, got %f vs %f (%v)", result.NetPremium, direct.NetPremium, err)
+	}
+
+	// An age sent takes precedence over the dates
+	policy.Age = 30
+	result, err = service.CalculatePremium(&policy)
+	if err != nil || result.AgeAtIssue != 0 || result.EffectivePolicy.Age != 30 {
+		t.Errorf("Expected the age sent to be used, got %+v (%v)", result.EffectivePolicy, err)
+	}
+
+	// Age 0 sent in a request is an age too, and still beats the dates
+	var sent models.Policy
+	body := `{"age":0,"date_of_birth":"1985-03-10","issue_date":"2025-09-10","term":20,"sum_assured":100000,"interest_rate":0.05,"echo_effective_policy":true}`
+	if err := json.Unmarshal([]byte(body), &sent); err != nil {
+		t.Fatal(err)
+	}
+	result, err = service.CalculatePremium(&sent)
+	if err != nil || result.AgeAtIssue != 0 || result.EffectivePolicy.Age != 0 {
+		t.Errorf("Expected age 0 sent to be used, got %+v (%v)", result.EffectivePolicy, err)
+	}
+
+	// Without an age sent, a newborn's dates give age 0
+	sent.AgeSent = false
+	sent.DateOfBirth = "2025-06-01"
+	result, err = service.CalculatePremium(&sent)
+	if err != nil || result.AgeAtIssue != 0 || result.EffectivePolicy.Age != 0 || result.EffectivePolicy.DateOfBirth != "2025-06-01" {
+		t.Errorf("Expected a newborn's dates to give age 0, got %+v (%v)", result.EffectivePolicy, err)
+	}
+}
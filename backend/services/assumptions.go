@@ -0,0 +1,49 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// describeAssumptions puts the assumptions a result was calculated on onto it.
+// The expenses are read back from the result, so they are the ones applied.
+func describeAssumptions(result *models.PremiumCalculation, run pricingRun, basis actuarial.Basis) {
+	policy := run.policy
+	assumptions := &models.Assumptions{
+		Units: models.Units{
+			Amounts: cmp.Or(policy.Currency, "unspecified"),
+			Rates:   "decimal",
+			Time:    "years",
+		},
+		InterestRate:          policy.InterestRate,
+		YieldCurve:            policy.YieldCurve,
+		CompoundingConvention: policy.CompoundingConvention,
+		Table:                 run.table,
+		ProductType:           policy.ProductType,
+		PaymentFrequency:      policy.PaymentFrequency,
+	}
+	if policy.YieldCurve != "" {
+		assumptions.InterestRate = 0
+		assumptions.SpotRates = slices.Clone(run.yieldCurve)
+	}
+
+	if strings.HasSuffix(policy.ProductType, "_annuity") {
+		assumptions.AnnuityLoading = basis.AnnuityLoading
+	} else {
+		assumptions.ReserveMethod = basis.ReserveMethod
+		assumptions.PremiumTiming = basis.PremiumTiming
+		assumptions.ClaimTiming = basis.ClaimTiming
+		if expenses := result.ExpenseDetails; expenses != nil {
+			assumptions.Expenses = &models.ExpenseStructure{
+				InitialExpenseRate: expenses["initial_expense_rate"],
+				RenewalExpenseRate: expenses["renewal_expense_rate"],
+				MaintenanceExpense: expenses["maintenance_expense"],
+				ProfitMargin:       expenses["profit_margin"],
+			}
+		}
+	}
+	result.Assumptions = assumptions
+}
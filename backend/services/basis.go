@@ -0,0 +1,298 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// validateBasis checks the parts of a basis that can be wrong on their own.
+// The interest rate and table are checked with the policy they are applied to.
+func validateBasis(basis *models.Basis) error {
+	if basis == nil {
+		return nil
+	}
+	if basis.ReserveMethod != "" && basis.ReserveMethod != actuarial.NetPremiumReserve && !actuarial.IsModifiedReserve(basis.ReserveMethod) {
+		return fmt.Errorf("unsupported reserve method '%s'", basis.ReserveMethod)
+	}
+	if basis.ExpenseAllowanceCap != nil {
+		if !actuarial.IsModifiedReserve(basis.ReserveMethod) {
+			return fmt.Errorf("expense allowance cap only applies to the full_preliminary_term and crvm reserve methods")
+		}
+		if *basis.ExpenseAllowanceCap < 0 {
+			return fmt.Errorf("expense allowance cap must not be negative")
+		}
+	}
+	if basis.PremiumTiming != "" && basis.PremiumTiming != actuarial.PremiumsAnnuallyInAdvance && basis.PremiumTiming != actuarial.PremiumsAnnuallyInArrears {
+		return fmt.Errorf("unsupported premium timing '%s'", basis.PremiumTiming)
+	}
+	if basis.ClaimTiming != "" && basis.ClaimTiming != actuarial.ClaimsEndOfYear {
+		return fmt.Errorf("unsupported claim timing '%s'", basis.ClaimTiming)
+	}
+	if err := checkCompoundingConvention(basis.CompoundingConvention); err != nil {
+		return err
+	}
+	if basis.ZeroPremium != "" && basis.ZeroPremium != actuarial.ZeroPremiumReject && basis.ZeroPremium != actuarial.ZeroPremiumWarn {
+		return fmt.Errorf("unknown zero premium handling '%s'", basis.ZeroPremium)
+	}
+	if basis.Underwriting != nil {
+		if err := validateUnderwritingConfig(*basis.Underwriting); err != nil {
+			return err
+		}
+	}
+	if basis.MinimumPremium != nil && *basis.MinimumPremium < 0 {
+		return fmt.Errorf("minimum premium must not be negative")
+	}
+	if basis.PremiumTaxRate != nil {
+		if err := checkPremiumTaxRate(*basis.PremiumTaxRate); err != nil {
+			return err
+		}
+	}
+	if err := actuarial.ValidatePremiumBands(toActuarialBands(basis.PremiumBands)); err != nil {
+		return err
+	}
+	if basis.MortalityShock != nil && *basis.MortalityShock <= -1 {
+		return fmt.Errorf("mortality shock must be above -1")
+	}
+	if basis.SettlementDelay != nil && (*basis.SettlementDelay < 0 || *basis.SettlementDelay >= 12) {
+		return fmt.Errorf("settlement delay must be at least 0 and under 12 months")
+	}
+	if basis.AnnuityLoading != nil && *basis.AnnuityLoading < 0 {
+		return fmt.Errorf("annuity loading must not be negative")
+	}
+	if expenses := basis.Expenses; expenses != nil {
+		if expenses.InitialExpenseRate < 0 || expenses.RenewalExpenseRate < 0 ||
+			expenses.MaintenanceExpense < 0 || expenses.ProfitMargin < 0 {
+			return fmt.Errorf("expense assumptions must not be negative")
+		}
+		if expenses.RenewalExpenseRate >= 1 {
+			return fmt.Errorf("renewal expense rate must be below 1")
+		}
+	}
+	return nil
+}
+
+func checkCompoundingConvention(convention string) error {
+	switch convention {
+	case "", actuarial.AnnualCompounding, actuarial.ContinuousCompounding:
+		return nil
+	}
+	return fmt.Errorf("unknown compounding convention '%s'", convention)
+}
+
+// applyBasisToPolicy returns the policy with the basis interest rate and table
+// swapped in, so the usual validation and table lookup see the values actually used
+func applyBasisToPolicy(policy *models.Policy, basis *models.Basis) *models.Policy {
+	if basis == nil {
+		return policy
+	}
+	valued := *policy
+	if basis.InterestRate != nil {
+		valued.InterestRate = *basis.InterestRate
+	}
+	if basis.CompoundingConvention != "" {
+		valued.CompoundingConvention = basis.CompoundingConvention
+	}
+	if basis.RiskDiscountRate != nil {
+		valued.RiskDiscountRate = *basis.RiskDiscountRate
+	}
+	if basis.SettlementDelay != nil && (valued.ProductType == "" || valued.ProductType == "term_life" || valued.ProductType == "whole_life") {
+		valued.SettlementDelay = *basis.SettlementDelay // Annuities have no death claims to delay
+	}
+	if basis.TableName != "" {
+		valued.Gender = basis.TableName
+	}
+	if basis.YieldCurve != "" {
+		valued.YieldCurve = basis.YieldCurve
+	}
+	if basis.InterestShift != nil && valued.YieldCurve == "" {
+		valued.InterestRate += *basis.InterestShift // A curve is shifted once it is loaded
+	}
+	return &valued
+}
+
+// basisWarnings notes the policy's own assumptions a basis replaced, since the
+// caller may not expect them to be ignored
+func basisWarnings(policy, valued *models.Policy) []string {
+	var warnings []string
+	if valued.InterestRate != policy.InterestRate {
+		warnings = append(warnings, fmt.Sprintf("valued at the basis interest rate %g, not the policy's %g", valued.InterestRate, policy.InterestRate))
+	}
+	if valued.YieldCurve != policy.YieldCurve && policy.YieldCurve != "" {
+		warnings = append(warnings, fmt.Sprintf("valued on the basis yield curve '%s', not the policy's '%s'", valued.YieldCurve, policy.YieldCurve))
+	}
+	if policy.Gender != "" && resolveTableName(valued.Gender) != resolveTableName(policy.Gender) {
+		warnings = append(warnings, fmt.Sprintf("valued on the basis table '%s', not the policy's '%s'", valued.Gender, policy.Gender))
+	}
+	return warnings
+}
+
+// applyBasis overrides the default actuarial basis with whatever the request set
+func applyBasis(actuarialBasis *actuarial.Basis, basis *models.Basis) {
+	if basis == nil {
+		return
+	}
+	actuarialBasis.Name = basis.Name
+	if basis.Expenses != nil {
+		actuarialBasis.Expenses = actuarial.ExpenseStructure{
+			InitialExpenseRate: basis.Expenses.InitialExpenseRate,
+			RenewalExpenseRate: basis.Expenses.RenewalExpenseRate,
+			MaintenanceExpense: basis.Expenses.MaintenanceExpense,
+			ProfitMargin:       basis.Expenses.ProfitMargin,
+		}
+	}
+	if basis.AnnuityLoading != nil {
+		actuarialBasis.AnnuityLoading = *basis.AnnuityLoading
+	}
+	if basis.ReserveMethod != "" {
+		actuarialBasis.ReserveMethod = basis.ReserveMethod
+	}
+	actuarialBasis.ExpenseAllowanceCap = basis.ExpenseAllowanceCap
+	if basis.PremiumTiming != "" {
+		actuarialBasis.PremiumTiming = basis.PremiumTiming
+	}
+	if basis.ClaimTiming != "" {
+		actuarialBasis.ClaimTiming = basis.ClaimTiming
+	}
+	if basis.ZeroPremium != "" {
+		actuarialBasis.ZeroPremium = basis.ZeroPremium
+	}
+}
+
+// SetMinimumPremium sets the smallest gross premium charged for a life policy.
+// Calculated premiums below it are raised to it. 0 turns the floor off.
+func (s *ActuarialService) SetMinimumPremium(amount float64) error {
+	if amount < 0 {
+		return fmt.Errorf("minimum premium must not be negative")
+	}
+	s.mu.Lock()
+	s.minimumPremium = amount
+	s.mu.Unlock()
+	return nil
+}
+
+// minimumPremiumFor is the server's minimum premium unless the basis sets its own
+func (s *ActuarialService) minimumPremiumFor(basis *models.Basis) float64 {
+	if basis != nil && basis.MinimumPremium != nil {
+		return *basis.MinimumPremium
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.minimumPremium
+}
+
+// SetPremiumTaxRate sets the premium tax levied on every gross premium, e.g.
+// 0.05 for a 5% insurance premium tax. Results report the tax and the
+// tax-inclusive premium alongside the gross premium. 0 turns the tax off.
+func (s *ActuarialService) SetPremiumTaxRate(rate float64) error {
+	if err := checkPremiumTaxRate(rate); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.premiumTaxRate = rate
+	s.mu.Unlock()
+	return nil
+}
+
+// premiumTaxRateFor is the server's premium tax rate unless the basis sets its own
+func (s *ActuarialService) premiumTaxRateFor(basis *models.Basis) float64 {
+	if basis != nil && basis.PremiumTaxRate != nil {
+		return *basis.PremiumTaxRate
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.premiumTaxRate
+}
+
+func checkPremiumTaxRate(rate float64) error {
+	if !(rate >= 0 && rate <= 1) {
+		return fmt.Errorf("premium tax rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// SetPremiumBands sets the volume discounts given by sum assured. Bands must
+// start at increasing sums assured with discounts that never go down. An
+// empty list turns banding off.
+func (s *ActuarialService) SetPremiumBands(bands []models.PremiumBand) error {
+	actuarialBands := toActuarialBands(bands)
+	if err := actuarial.ValidatePremiumBands(actuarialBands); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.premiumBands = actuarialBands
+	s.mu.Unlock()
+	return nil
+}
+
+// premiumBandsFor is the server's bands unless the basis sets its own
+func (s *ActuarialService) premiumBandsFor(basis *models.Basis) []actuarial.PremiumBand {
+	if basis != nil && basis.PremiumBands != nil {
+		return toActuarialBands(basis.PremiumBands)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.premiumBands
+}
+
+// SetSolverConfig sets how closely iterative calculations solve and how many
+// steps they may take. A solve that runs out of steps fails with the last
+// residual rather than returning its unsettled answer.
+func (s *ActuarialService) SetSolverConfig(config actuarial.SolverConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.solver = config
+	s.mu.Unlock()
+	return nil
+}
+
+// solverConfig is the server's solver tolerance and iteration limit
+func (s *ActuarialService) solverConfig() actuarial.SolverConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.solver
+}
+
+// ParsePremiumBands reads bands written as "threshold:discount" pairs
+// separated by commas, e.g. "500000:0.05,1000000:0.08"
+func ParsePremiumBands(text string) ([]models.PremiumBand, error) {
+	var bands []models.PremiumBand
+	for _, pair := range strings.Split(text, ",") {
+		threshold, discount, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			return nil, fmt.Errorf("premium band %q is not threshold:discount", pair)
+		}
+		minCoverage, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			return nil, fmt.Errorf("premium band %q has an invalid threshold", pair)
+		}
+		rate, err := strconv.ParseFloat(discount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("premium band %q has an invalid discount", pair)
+		}
+		bands = append(bands, models.PremiumBand{MinCoverage: minCoverage, Discount: rate})
+	}
+	return bands, nil
+}
+
+func toActuarialBands(bands []models.PremiumBand) []actuarial.PremiumBand {
+	if bands == nil {
+		return nil
+	}
+	converted := make([]actuarial.PremiumBand, len(bands))
+	for i, band := range bands {
+		converted[i] = actuarial.PremiumBand{MinCoverage: band.MinCoverage, Discount: band.Discount}
+	}
+	return converted
+}
+
+func convertPremiumBand(band *actuarial.PremiumBand) *models.PremiumBand {
+	if band == nil {
+		return nil
+	}
+	return &models.PremiumBand{MinCoverage: band.MinCoverage, Discount: band.Discount}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Where the basis a result was valued on came from
+const (
+	BasisFromRequest = "request" // Sent with the calculation
+	BasisFromDefault = "default" // Registered for the policy's table and product
+)
+
+// basisKey identifies the policies a default basis applies to
+type basisKey struct {
+	table       string
+	productType string
+}
+
+// newBasisKey resolves a table and product the way policies are resolved, so
+// "" finds the same basis as "male" and "term_life"
+func newBasisKey(table, productType string) basisKey {
+	if productType == "" {
+		productType = "term_life"
+	}
+	return basisKey{table: resolveTableName(table), productType: productType}
+}
+
+// SetDefaultBasis registers the basis for policies on one table and product
+// that are calculated without a basis of their own, replacing any already set
+func (s *ActuarialService) SetDefaultBasis(entry models.DefaultBasis) error {
+	if err := validateDefaultBasis(entry); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.defaultBases == nil {
+		s.defaultBases = make(map[basisKey]models.Basis)
+	}
+	s.defaultBases[newBasisKey(entry.Table, entry.ProductType)] = entry.Basis
+	return nil
+}
+
+// LoadDefaultBases reads default bases from a JSON file, e.g.
+//
+//	[{"table": "female", "product_type": "whole_life",
+//	  "basis": {"name": "female_whole_life", "interest_rate": 0.045, "expenses": {...}}}]
+//
+// The file is checked as a whole first, so a bad entry leaves the registry unchanged.
+func (s *ActuarialService) LoadDefaultBases(filePath string) (int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("could not read default bases: %w", err)
+	}
+	var entries []models.DefaultBasis
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("default bases %s are not valid JSON: %w", filePath, err)
+	}
+	for _, entry := range entries {
+		if err := validateDefaultBasis(entry); err != nil {
+			return 0, err
+		}
+	}
+	for _, entry := range entries {
+		if err := s.SetDefaultBasis(entry); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
+
+func validateDefaultBasis(entry models.DefaultBasis) error {
+	if _, ok := actuarial.LookupProduct(entry.ProductType); !ok {
+		return fmt.Errorf("unknown product type '%s'", entry.ProductType)
+	}
+	if err := validateBasis(&entry.Basis); err != nil {
+		return fmt.Errorf("default basis for %s %s: %w", entry.Table, entry.ProductType, err)
+	}
+	return nil
+}
+
+// defaultBasisFor returns the basis registered for a policy's table and
+// product, or nil if there isn't one. A default only fills in what the policy
+// leaves out, so the assumptions the policy sets itself are dropped from it.
+func (s *ActuarialService) defaultBasisFor(policy *models.Policy) *models.Basis {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	basis, ok := s.defaultBases[newBasisKey(policy.Gender, policy.ProductType)]
+	if !ok {
+		return nil
+	}
+	if policy.InterestRate != 0 || policy.YieldCurve != "" {
+		basis.InterestRate, basis.YieldCurve = nil, ""
+	}
+	if policy.CompoundingConvention != "" {
+		basis.CompoundingConvention = ""
+	}
+	if policy.RiskDiscountRate != 0 {
+		basis.RiskDiscountRate = nil
+	}
+	if policy.SettlementDelay != 0 {
+		basis.SettlementDelay = nil
+	}
+	if policy.Gender != "" {
+		basis.TableName = ""
+	}
+	return &basis
+}
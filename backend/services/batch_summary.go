@@ -0,0 +1,180 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// DefaultBatchSummaryStats is the summary every batch gets unless the request
+// asks for a specific set of stats
+var DefaultBatchSummaryStats = []string{
+	"total_policies",
+	"total_net_premium",
+	"total_gross_premium",
+	"average_net_premium",
+	"average_gross_premium",
+	"product_type_counts",
+	"product_subtotals",
+	"average_rate_per_mille",
+}
+
+// batchValues keeps the per-policy figures from a batch so order statistics
+// and spreads can be worked out after the loop
+type batchValues struct {
+	netPremiums    []float64
+	grossPremiums  []float64
+	premiumTax     []float64
+	taxInclusive   []float64 // Gross premium plus any premium tax
+	coverage       []float64
+	expectedClaims []float64
+	productCounts  map[string]int
+	productTotals  map[string]models.ProductSubtotal // Running totals; averages are filled in at the end
+}
+
+// add records one priced policy
+func (v *batchValues) add(result models.PremiumCalculation, coverage float64) {
+	v.netPremiums = append(v.netPremiums, result.NetPremium)
+	v.grossPremiums = append(v.grossPremiums, result.GrossPremium)
+	v.premiumTax = append(v.premiumTax, result.PremiumTax)
+	v.taxInclusive = append(v.taxInclusive, result.GrossPremium+result.PremiumTax)
+	v.coverage = append(v.coverage, coverage)
+	v.expectedClaims = append(v.expectedClaims, coverage*result.RiskAssessment["annual_death_probability"])
+	v.productCounts[result.ProductType]++
+
+	totals := v.productTotals[result.ProductType]
+	totals.Policies++
+	totals.TotalNetPremium += result.NetPremium
+	totals.TotalGrossPremium += result.GrossPremium
+	totals.TotalPremiumTax += result.PremiumTax
+	totals.TotalCoverage += coverage
+	v.productTotals[result.ProductType] = totals
+}
+
+// productSubtotals is the product-line view of a batch
+func productSubtotals(v batchValues) map[string]models.ProductSubtotal {
+	subtotals := make(map[string]models.ProductSubtotal, len(v.productTotals))
+	for productType, totals := range v.productTotals {
+		totals.AverageNetPremium = totals.TotalNetPremium / float64(totals.Policies)
+		totals.AverageGrossPremium = totals.TotalGrossPremium / float64(totals.Policies)
+		subtotals[productType] = totals
+	}
+	return subtotals
+}
+
+// batchSummaryStats maps each stat a caller can ask for to how it's calculated
+var batchSummaryStats = map[string]func(v batchValues) interface{}{
+	"total_policies":        func(v batchValues) interface{} { return len(v.grossPremiums) },
+	"total_net_premium":     func(v batchValues) interface{} { return sum(v.netPremiums) },
+	"total_gross_premium":   func(v batchValues) interface{} { return sum(v.grossPremiums) },
+	"average_net_premium":   func(v batchValues) interface{} { return mean(v.netPremiums) },
+	"average_gross_premium": func(v batchValues) interface{} { return mean(v.grossPremiums) },
+	"product_type_counts":   func(v batchValues) interface{} { return v.productCounts },
+	"product_subtotals":     func(v batchValues) interface{} { return productSubtotals(v) },
+	"median_net":            func(v batchValues) interface{} { return median(v.netPremiums) },
+	"median_gross":          func(v batchValues) interface{} { return median(v.grossPremiums) },
+	"std_net":               func(v batchValues) interface{} { return stdDev(v.netPremiums) },
+	"std_gross":             func(v batchValues) interface{} { return stdDev(v.grossPremiums) },
+	"min_gross":             func(v batchValues) interface{} { return minimum(v.grossPremiums) },
+	"max_gross":             func(v batchValues) interface{} { return maximum(v.grossPremiums) },
+	"total_coverage":        func(v batchValues) interface{} { return sum(v.coverage) },
+	"total_expected_claims": func(v batchValues) interface{} { return sum(v.expectedClaims) },
+	"total_premium_tax":     func(v batchValues) interface{} { return sum(v.premiumTax) },
+
+	"total_tax_inclusive_premium":   func(v batchValues) interface{} { return sum(v.taxInclusive) },
+	"average_tax_inclusive_premium": func(v batchValues) interface{} { return mean(v.taxInclusive) },
+
+	"average_rate_per_mille":     func(v batchValues) interface{} { return averageRatePerMille(v.grossPremiums, v.coverage) },
+	"average_net_rate_per_mille": func(v batchValues) interface{} { return averageRatePerMille(v.netPremiums, v.coverage) },
+}
+
+// averageRatePerMille is the mean of each policy's premium per 1000 sum
+// assured, leaving out any policy without cover
+func averageRatePerMille(premiums, coverage []float64) float64 {
+	var rates []float64
+	for i, premium := range premiums {
+		if coverage[i] > 0 {
+			rates = append(rates, ratePerMille(premium, coverage[i]))
+		}
+	}
+	return mean(rates)
+}
+
+// checkSummaryStats makes sure every requested stat is one we know how to calculate
+func checkSummaryStats(requested []string) error {
+	for _, name := range requested {
+		if _, ok := batchSummaryStats[name]; !ok {
+			return fmt.Errorf("unknown summary stat '%s'", name)
+		}
+	}
+	return nil
+}
+
+// buildBatchSummary calculates only the requested stats (or the defaults)
+func buildBatchSummary(values batchValues, requested []string) map[string]interface{} {
+	if len(requested) == 0 {
+		requested = DefaultBatchSummaryStats
+	}
+	summary := make(map[string]interface{}, len(requested))
+	for _, name := range requested {
+		summary[name] = batchSummaryStats[name](values)
+	}
+	return summary
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return sum(values) / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+func minimum(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return slices.Min(values)
+}
+
+func maximum(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return slices.Max(values)
+}
+
+// stdDev is the population standard deviation
+func stdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	average := mean(values)
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - average) * (v - average)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"maps"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBatchSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.002")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+	policies := []models.Policy{
+		{Age: 30, Term: 10, CoverageAmount: 100000, InterestRate: 0.05},
+		{Age: 40, Term: 10, CoverageAmount: 200000, InterestRate: 0.05},
+		{Age: 50, Term: 10, CoverageAmount: 300000, InterestRate: 0.05},
+	}
+
+	response, err := service.CalculateBatch(models.BatchCalculationRequest{Policies: policies})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Summary) != len(DefaultBatchSummaryStats) {
+		t.Errorf("Expected the default stats, got %v", response.Summary)
+	}
+	gross := []float64{response.Results[0].GrossPremium, response.Results[1].GrossPremium, response.Results[2].GrossPremium}
+	if response.Summary["total_policies"] != 3 || math.Abs(response.Summary["total_gross_premium"].(float64)-(gross[0]+gross[1]+gross[2])) > 1e-9 {
+		t.Errorf("Expected 3 policies totalling their gross premiums, got %v", response.Summary)
+	}
+
+	// Only the stats asked for
+	response, err = service.CalculateBatch(models.BatchCalculationRequest{Policies: policies, SummaryStats: []string{"median_gross", "min_gross", "max_gross", "total_coverage"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"median_gross": gross[1], "min_gross": slices.Min(gross), "max_gross": slices.Max(gross), "total_coverage": 600000}
+	if len(response.Summary) != len(want) {
+		t.Errorf("Expected only the requested stats, got %v", response.Summary)
+	}
+	for name, value := range want {
+		if got, ok := response.Summary[name].(float64); !ok || math.Abs(got-value) > 1e-9 {
+			t.Errorf("%s: expected %f, got %v", name, value, response.Summary[name])
+		}
+	}
+
+	if _, err := service.CalculateBatch(models.BatchCalculationRequest{Policies: policies, SummaryStats: []string{"mode_gross"}}); err == nil || !strings.Contains(err.Error(), "mode_gross") {
+		t.Errorf("Expected an unknown stat to be rejected, got %v", err)
+	}
+
+	// A batch where every policy fails reports the first failure rather than
+	// summarising nothing
+	failing := []models.Policy{{Age: 200, Term: 10, CoverageAmount: 1000}, {Age: 40, Term: -1, CoverageAmount: 1000}}
+	if _, err := service.CalculateBatch(models.BatchCalculationRequest{Policies: failing, SummaryStats: []string{"min_gross", "max_gross"}}); err == nil {
+		t.Error("Expected a batch of failing policies to be an error")
+	}
+	validated, err := service.CalculateBatch(models.BatchCalculationRequest{Policies: failing, SummaryStats: []string{"min_gross", "max_gross"}, ValidateOnly: true})
+	if err != nil || validated.Summary["invalid_policies"] != 2 {
+		t.Errorf("Expected both policies reported invalid, got %v (%v)", validated.Summary, err)
+	}
+
+	// With nothing priced every stat is zero rather than a panic
+	empty := buildBatchSummary(batchValues{}, slices.Collect(maps.Keys(batchSummaryStats)))
+	for _, name := range []string{"min_gross", "max_gross", "median_gross", "average_gross_premium"} {
+		if empty[name] != 0.0 {
+			t.Errorf("%s: expected 0 for an empty batch, got %v", name, empty[name])
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestBreakEvenAnalysis(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.002")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		policy models.Policy
+		// Whether premiums overtake the cover, in the first year they add up to more than it
+		coverReached bool
+	}{
+		{"term", models.Policy{Age: 40, Term: 20, CoverageAmount: 1000, InterestRate: 0.05}, true},
+		// Five premiums never add up to the cover, though twenty would
+		{"limited pay", models.Policy{Age: 40, Term: 20, PremiumPayingTerm: 5, CoverageAmount: 1000, InterestRate: 0.05}, false},
+		// Years count from issue, though the reserves start at duration 5
+		{"in force", models.Policy{Age: 40, Term: 20, InForceDuration: 5, CoverageAmount: 1000, InterestRate: 0.05}, true},
+		{"never reached", models.Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05}, false},
+	}
+	for _, tt := range tests {
+		result, err := service.BreakEvenAnalysis(&tt.policy)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if want := tt.policy.Term - tt.policy.InForceDuration + 1; len(result.ReserveSchedule) != want {
+			t.Errorf("%s: expected %d reserves, got %d", tt.name, want, len(result.ReserveSchedule))
+		}
+		// A flat table keeps the reserve small
+		if result.ReserveExceedsPremiumsYear != nil {
+			t.Errorf("%s: expected the reserve never to exceed the premiums paid, got year %d", tt.name, *result.ReserveExceedsPremiumsYear)
+		}
+		if !tt.coverReached {
+			if result.PremiumExceedsCoverageYear != nil {
+				t.Errorf("%s: expected premiums never to exceed the cover, got year %d", tt.name, *result.PremiumExceedsCoverageYear)
+			}
+			continue
+		}
+		want := int(tt.policy.CoverageAmount/result.GrossPremium) + 1
+		if result.PremiumExceedsCoverageYear == nil || *result.PremiumExceedsCoverageYear != want {
+			t.Errorf("%s: expected premiums of %.2f to exceed the cover in year %d, got %v", tt.name, result.GrossPremium, want, result.PremiumExceedsCoverageYear)
+		}
+	}
+}
+
+func TestBreakEvenAnalysisCoverAndWaiver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.002")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+
+	// A 100000 layer for the first five years, then 1000 of cover for ten more:
+	// premiums pass the cover once the layer has run off
+	layered := models.Policy{Age: 40, Term: 15, CoverageAmount: 1000, InterestRate: 0.05,
+		Layers: []models.CoverageLayer{{CoverageAmount: 100000, Term: 5}}}
+	result, err := service.BreakEvenAnalysis(&layered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SumAssured != 101000 {
+		t.Errorf("Expected the cover at issue to include the layer, got %f", result.SumAssured)
+	}
+	if year := result.PremiumExceedsCoverageYear; year == nil || *year != 6 {
+		t.Errorf("Expected premiums of %.2f to pass the 1000 left in year 6, got %v", result.GrossPremium, year)
+	}
+
+	// A waived fee makes the first premium lower than the renewals
+	waived := models.Policy{Age: 40, Term: 20, CoverageAmount: 1000, InterestRate: 0.05, FirstYearFeeWaiver: "absorb"}
+	priced, err := service.CalculatePremium(&waived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priced.FirstYearPremium >= priced.RenewalPremium {
+		t.Fatalf("Expected a lower first premium, got %f and %f", priced.FirstYearPremium, priced.RenewalPremium)
+	}
+	want := 1
+	for total := priced.FirstYearPremium; total <= waived.CoverageAmount; total += priced.RenewalPremium {
+		want++
+	}
+	result, err = service.BreakEvenAnalysis(&waived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if year := result.PremiumExceedsCoverageYear; year == nil || *year != want {
+		t.Errorf("Expected premiums to pass the cover in year %d counting the lower first premium, got %v", want, year)
+	}
+}
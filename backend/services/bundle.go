@@ -0,0 +1,115 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"fmt"
+)
+
+// maxChildRiderAge is the oldest a child can be when a child rider is taken out
+const maxChildRiderAge = 17
+
+// CalculateBundle prices a family bundle: the main policy as it is sent, and
+// each rider as term life on the dependant's own life and table, on the main
+// policy's interest rate, payment frequency and currency. The bundle's
+// premium is the sum of them all.
+func (s *ActuarialService) CalculateBundle(req models.BundleRequest) (models.BundleResult, error) {
+	primary := req.Primary
+	if primary.ProductType != "" && primary.ProductType != "term_life" && primary.ProductType != "whole_life" {
+		return models.BundleResult{}, fmt.Errorf("a bundle's primary policy must be term_life or whole_life")
+	}
+	priced, err := s.CalculatePremium(&primary)
+	if err != nil {
+		return models.BundleResult{}, fmt.Errorf("primary: %w", err)
+	}
+
+	result := models.BundleResult{
+		Components:       make([]models.BundleComponent, 0, len(req.Riders)+1),
+		PaymentFrequency: priced.PaymentFrequency,
+	}
+	addBundleComponent(&result, "primary", &primary, priced)
+
+	spouses := 0
+	for i, rider := range req.Riders {
+		if rider.Relationship == "spouse" {
+			spouses++
+		}
+		policy, err := riderPolicy(&primary, rider, spouses)
+		if err != nil {
+			return models.BundleResult{}, fmt.Errorf("rider %d: %w", i+1, err)
+		}
+		priced, err := s.CalculatePremium(&policy)
+		if err != nil {
+			return models.BundleResult{}, fmt.Errorf("rider %d: %w", i+1, err)
+		}
+		addBundleComponent(&result, rider.Relationship, &policy, priced)
+	}
+	return result, nil
+}
+
+// riderPolicy checks a rider on its own terms and turns it into the term life
+// policy it is priced as. spouses counts the spouse riders so far.
+func riderPolicy(primary *models.Policy, rider models.BundleRider, spouses int) (models.Policy, error) {
+	switch rider.Relationship {
+	case "spouse":
+		if spouses > 1 {
+			return models.Policy{}, fmt.Errorf("a bundle covers only one spouse")
+		}
+	case "child":
+		if rider.Age > maxChildRiderAge {
+			return models.Policy{}, fmt.Errorf("a child rider is for children up to age %d", maxChildRiderAge)
+		}
+	default:
+		return models.Policy{}, fmt.Errorf("unknown relationship '%s'; use spouse or child", rider.Relationship)
+	}
+	if rider.CoverageAmount <= 0 || rider.CoverageAmount > primary.CoverageAmount {
+		return models.Policy{}, fmt.Errorf("sum assured must be positive and no more than the primary's")
+	}
+
+	term := rider.Term
+	if term == 0 {
+		term = primary.Term
+	}
+	if term <= 0 {
+		return models.Policy{}, fmt.Errorf("needs a term when the primary policy has none")
+	}
+	if (primary.ProductType == "" || primary.ProductType == "term_life") && term > primary.Term {
+		return models.Policy{}, fmt.Errorf("term %d runs past the primary policy's %d years", term, primary.Term)
+	}
+
+	gender := rider.Gender
+	if gender == "" {
+		gender = primary.Gender
+	}
+	return models.Policy{
+		Age:                   rider.Age,
+		Term:                  term,
+		CoverageAmount:        rider.CoverageAmount,
+		InterestRate:          primary.InterestRate,
+		Gender:                gender,
+		ProductType:           "term_life",
+		SmokerStatus:          rider.SmokerStatus,
+		HealthRating:          rider.HealthRating,
+		PaymentFrequency:      primary.PaymentFrequency,
+		CompoundingConvention: primary.CompoundingConvention,
+		YieldCurve:            primary.YieldCurve,
+		Currency:              primary.Currency,
+	}, nil
+}
+
+// addBundleComponent itemizes one priced component and adds it to the bundle's totals
+func addBundleComponent(bundle *models.BundleResult, component string, policy *models.Policy, priced models.PremiumCalculation) {
+	bundle.Components = append(bundle.Components, models.BundleComponent{
+		Component:      component,
+		Age:            policy.Age,
+		TableName:      resolveTableName(policy.Gender),
+		CoverageAmount: policy.CoverageAmount,
+		Term:           policy.Term,
+		NetPremium:     priced.NetPremium,
+		GrossPremium:   priced.GrossPremium,
+		ModalPremium:   priced.ModalPremium,
+	})
+	bundle.TotalCoverage += policy.CoverageAmount
+	bundle.TotalNetPremium += priced.NetPremium
+	bundle.TotalGrossPremium += priced.GrossPremium
+	bundle.TotalModalPremium += priced.ModalPremium
+}
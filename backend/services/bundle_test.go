@@ -0,0 +1,66 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCalculateBundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.002")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+
+	primary := models.Policy{Age: 40, Term: 20, CoverageAmount: 200000, InterestRate: 0.05, PaymentFrequency: "monthly"}
+	riders := []models.BundleRider{
+		{Relationship: "spouse", Age: 38, CoverageAmount: 100000},
+		{Relationship: "child", Age: 5, CoverageAmount: 20000, Term: 15, SmokerStatus: "non_smoker"},
+	}
+	bundle, err := service.CalculateBundle(models.BundleRequest{Primary: primary, Riders: riders})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Components) != 3 || bundle.Components[0].Component != "primary" || bundle.Components[2].Component != "child" {
+		t.Fatalf("Expected the primary then the two riders, got %+v", bundle.Components)
+	}
+
+	// Each rider costs what the same term cover on that life costs alone, on
+	// the primary's rate and frequency
+	child := models.Policy{Age: 5, Term: 15, CoverageAmount: 20000, InterestRate: 0.05, PaymentFrequency: "monthly", SmokerStatus: "non_smoker"}
+	alone, err := service.CalculatePremium(&child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(bundle.Components[2].GrossPremium-alone.GrossPremium) > 1e-9 || math.Abs(bundle.Components[2].ModalPremium-alone.ModalPremium) > 1e-9 {
+		t.Errorf("Expected the child rider to cost %f, got %+v", alone.GrossPremium, bundle.Components[2])
+	}
+	total, modal := 0.0, 0.0
+	for _, component := range bundle.Components {
+		total += component.GrossPremium
+		modal += component.ModalPremium
+	}
+	if math.Abs(bundle.TotalGrossPremium-total) > 1e-9 || math.Abs(bundle.TotalModalPremium-modal) > 1e-9 || bundle.TotalCoverage != 320000 {
+		t.Errorf("Expected the totals to add up the components, got %+v", bundle)
+	}
+
+	for _, rider := range []models.BundleRider{
+		{Relationship: "child", Age: 30, CoverageAmount: 20000},
+		{Relationship: "spouse", Age: 38, CoverageAmount: 300000},
+		{Relationship: "spouse", Age: 38, CoverageAmount: 100000, Term: 25},
+		{Relationship: "sibling", Age: 38, CoverageAmount: 100000},
+		{Relationship: "spouse", Age: 500, CoverageAmount: 100000},
+	} {
+		_, err := service.CalculateBundle(models.BundleRequest{Primary: primary, Riders: []models.BundleRider{riders[1], rider}})
+		if err == nil || !strings.HasPrefix(err.Error(), "rider 2: ") {
+			t.Errorf("Expected rider %+v to be rejected as rider 2, got %v", rider, err)
+		}
+	}
+	if _, err := service.CalculateBundle(models.BundleRequest{Primary: primary, Riders: []models.BundleRider{riders[0], riders[0]}}); err == nil {
+		t.Error("Expected a second spouse to be rejected")
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// loadYieldCurve reads a yield curve from file into the set
+func (t *tableSet) loadYieldCurve(name, filePath string) error {
+	curve, err := actuarial.LoadYieldCurve(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load yield curve %s: %w", name, err)
+	}
+	t.curves[name] = curve
+	t.curveSources[name] = filePath
+	return nil
+}
+
+func (t *tableSet) yieldCurve(name string) (actuarial.YieldCurve, error) {
+	if name == "" {
+		return nil, nil
+	}
+	curve, exists := t.curves[strings.ToLower(strings.TrimSpace(name))]
+	if !exists {
+		return nil, fmt.Errorf("yield curve '%s' not found", name)
+	}
+	return curve, nil
+}
+
+// LoadYieldCurve loads a named yield curve (e.g. "statutory_2024") that
+// policies and bases can then refer to instead of giving an interest rate
+func (s *ActuarialService) LoadYieldCurve(name, filePath string) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	next := s.currentTables().clone()
+	if err := next.loadYieldCurve(strings.ToLower(name), filePath); err != nil {
+		return err
+	}
+	s.swapTables(next)
+	return nil
+}
+
+// LoadYieldCurves loads every .csv file in a directory as a yield curve named
+// after the file, so statutory_2024.csv becomes the "statutory_2024" curve
+func (s *ActuarialService) LoadYieldCurves(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := s.LoadYieldCurve(name, path); err != nil {
+			return names, err
+		}
+		names = append(names, strings.ToLower(name))
+	}
+	return names, nil
+}
+
+// GetYieldCurve gets a loaded yield curve by name
+func (s *ActuarialService) GetYieldCurve(name string) (actuarial.YieldCurve, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("yield curve name is required")
+	}
+	return s.currentTables().yieldCurve(name)
+}
+
+// GetYieldCurves returns every loaded curve's spot rates by name
+func (s *ActuarialService) GetYieldCurves() map[string][]float64 {
+	current := s.currentTables()
+	curves := make(map[string][]float64, len(current.curves))
+	for name, curve := range current.curves {
+		curves[name] = curve
+	}
+	return curves
+}
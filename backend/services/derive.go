@@ -0,0 +1,82 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeriveTable builds a table from scaled and weighted existing tables and
+// registers it under a new name, so policies can then be priced on it.
+// Derived tables are rebuilt from their sources when the tables are reloaded.
+func (s *ActuarialService) DeriveTable(request models.DeriveTableRequest) (models.TableMetadata, error) {
+	name := strings.ToLower(strings.TrimSpace(request.Name))
+	if name == "" {
+		return models.TableMetadata{}, fmt.Errorf("derived table needs a name")
+	}
+	if len(request.Operations) == 0 {
+		return models.TableMetadata{}, fmt.Errorf("derived table needs at least one operation")
+	}
+
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	current := s.currentTables()
+	if _, exists := current.metadata[name]; exists {
+		return models.TableMetadata{}, fmt.Errorf("table '%s' already exists", name)
+	}
+
+	next := current.clone()
+	request.Name = name
+	if err := next.derive(request); err != nil {
+		return models.TableMetadata{}, err
+	}
+	s.swapTables(next)
+	return next.metadata[name], nil
+}
+
+// derive computes a derived table from the tables already in the set and adds it
+func (t *tableSet) derive(request models.DeriveTableRequest) error {
+	sources := make([]actuarial.MortalityTable, 0, len(request.Operations))
+	factors := make([]float64, 0, len(request.Operations))
+	sourceNames := make([]string, 0, len(request.Operations))
+	minAge := 0
+
+	for _, operation := range request.Operations {
+		sourceName := resolveTableName(operation.Table)
+		source, exists := t.mortality[sourceName]
+		if !exists {
+			return fmt.Errorf("source table '%s' not found", sourceName)
+		}
+		weight, scalar := 1.0, 1.0
+		if operation.Weight != nil {
+			weight = *operation.Weight
+		}
+		if operation.Scalar != nil {
+			scalar = *operation.Scalar
+		}
+		if weight < 0 || scalar < 0 {
+			return fmt.Errorf("weights and scalars must not be negative")
+		}
+
+		sources = append(sources, source)
+		factors = append(factors, weight*scalar)
+		sourceNames = append(sourceNames, sourceName)
+		minAge = max(minAge, t.metadata[sourceName].MinAge)
+	}
+
+	table := actuarial.CombineTables(sources, factors)
+	t.mortality[request.Name] = table
+	t.derivations[request.Name] = request
+	t.metadata[request.Name] = models.TableMetadata{
+		Name:        request.Name,
+		Source:      "derived",
+		MinAge:      minAge,
+		MaxAge:      len(table) - 1,
+		LoadedAt:    time.Now().UTC(),
+		DerivedFrom: sourceNames,
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCalculateGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.002")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+
+	census := []models.CensusLife{
+		{Age: 30, CoverageAmount: 100000},
+		{Age: 50, CoverageAmount: 300000, SmokerStatus: "smoker"},
+	}
+	quote, err := service.CalculateGroup(models.GroupQuoteRequest{SchemeName: "Acme", InterestRate: 0.05, Census: census})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each life is priced on its own for a year, and the scheme totals them
+	totalNet, totalGross := 0.0, 0.0
+	for _, life := range census {
+		result, err := service.CalculatePremium(&models.Policy{Age: life.Age, Term: 1, CoverageAmount: life.CoverageAmount, InterestRate: 0.05, SmokerStatus: life.SmokerStatus})
+		if err != nil {
+			t.Fatal(err)
+		}
+		totalNet += result.NetPremium
+		totalGross += result.GrossPremium
+	}
+	if quote.SchemeName != "Acme" || quote.TotalLives != 2 || quote.TotalCoverage != 400000 || quote.AverageAge != 40 {
+		t.Errorf("Expected 2 lives, 400000 cover and an average age of 40, got %+v", quote)
+	}
+	if math.Abs(quote.TotalNetPremium-totalNet) > 1e-9 || math.Abs(quote.TotalGrossPremium-totalGross) > 1e-9 {
+		t.Errorf("Expected premiums of %f net and %f gross, got %f and %f", totalNet, totalGross, quote.TotalNetPremium, quote.TotalGrossPremium)
+	}
+	if math.Abs(quote.NetRatePerThousand-totalNet/400) > 1e-9 || math.Abs(quote.GrossRatePerThousand-totalGross/400) > 1e-9 {
+		t.Errorf("Expected rates per 1000 of cover, got %f net and %f gross", quote.NetRatePerThousand, quote.GrossRatePerThousand)
+	}
+
+	if _, err := service.CalculateGroup(models.GroupQuoteRequest{InterestRate: 0.05}); err == nil {
+		t.Error("Expected an empty census to be rejected")
+	}
+	bad := append(census, models.CensusLife{Age: 200, CoverageAmount: 1000})
+	if _, err := service.CalculateGroup(models.GroupQuoteRequest{InterestRate: 0.05, Census: bad}); err == nil || !strings.Contains(err.Error(), "life 3") {
+		t.Errorf("Expected the bad life to be named by its position, got %v", err)
+	}
+	large := make([]models.CensusLife, maxCensusLives+1)
+	if _, err := service.CalculateGroup(models.GroupQuoteRequest{InterestRate: 0.05, Census: large}); err == nil || !strings.Contains(err.Error(), "too many lives") {
+		t.Errorf("Expected a census over %d lives to be rejected, got %v", maxCensusLives, err)
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"cmp"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/currency"
+)
+
+// portfolioTotals accumulates one share of a portfolio. Each worker fills its
+// own, and they are merged once the workers are done, so no map is ever
+// written by two goroutines and the workers never wait on a lock.
+type portfolioTotals struct {
+	policies       int
+	totalAge       int
+	coverage       float64
+	netPremium     float64
+	grossPremium   float64
+	expectedClaims float64
+	expenseLoading float64 // The expenses the gross premiums are loaded for
+	profitLoading  float64 // What the gross premiums hold over net premium and expenses
+	products       map[string]int
+	genders        map[string]int
+	risks          map[string]int
+	skipped        []models.SkippedPolicy
+
+	coverages         []float64 // Each policy's sum assured, for the concentration measures
+	largestAtRisk     float64   // Largest sum assured less reserve on any one policy
+	largestAtRiskFrom string    // The policy it is on
+	netAmountAtRisk   float64   // Sum assured less reserve, over every policy paying on death
+	deathsAtRisk      float64   // Each policy's net amount at risk times its underwritten qx
+
+	currencies map[string]models.CurrencySubtotal // In each policy's own currency, when converting
+}
+
+func newPortfolioTotals() *portfolioTotals {
+	return &portfolioTotals{
+		products:   make(map[string]int),
+		genders:    make(map[string]int),
+		risks:      make(map[string]int),
+		currencies: make(map[string]models.CurrencySubtotal),
+	}
+}
+
+// currencyConversion converts each policy's amounts to the reporting
+// currency. Rates are reporting currency per unit, keyed by upper-case code.
+type currencyConversion struct {
+	reporting string
+	rates     map[string]float64
+}
+
+// newCurrencyConversion checks there is a positive rate for every currency the
+// policies are in. It returns nil, meaning no conversion, when no reporting
+// currency is asked for.
+func newCurrencyConversion(policies []models.Policy, reportingCurrency string, exchangeRates map[string]float64) (*currencyConversion, error) {
+	if reportingCurrency == "" {
+		if len(exchangeRates) > 0 {
+			return nil, fmt.Errorf("exchange rates need a reporting currency")
+		}
+		return nil, nil
+	}
+	conversion := &currencyConversion{
+		reporting: strings.ToUpper(strings.TrimSpace(reportingCurrency)),
+		rates:     make(map[string]float64, len(exchangeRates)+1),
+	}
+	if _, err := currency.ParseISO(conversion.reporting); err != nil {
+		return nil, fmt.Errorf("unknown reporting currency '%s'", reportingCurrency)
+	}
+	for code, rate := range exchangeRates {
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return nil, fmt.Errorf("exchange rate for %s must be positive", code)
+		}
+		conversion.rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+	}
+	conversion.rates[conversion.reporting] = 1
+
+	var missing []string
+	for i := range policies {
+		code := conversion.currencyOf(&policies[i])
+		if _, ok := conversion.rates[code]; !ok && !slices.Contains(missing, code) {
+			missing = append(missing, code)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("no exchange rate to %s for %s", conversion.reporting, strings.Join(missing, ", "))
+	}
+	return conversion, nil
+}
+
+func (conversion *currencyConversion) currencyOf(policy *models.Policy) string {
+	return cmp.Or(strings.ToUpper(strings.TrimSpace(policy.Currency)), conversion.reporting)
+}
+
+// convert records the policy in its currency's subtotal and turns its
+// amounts into the reporting currency
+func (conversion *currencyConversion) convert(t *portfolioTotals, policy *models.Policy, result *models.PremiumCalculation) {
+	code := conversion.currencyOf(policy)
+	rate := conversion.rates[code]
+
+	subtotal := t.currencies[code]
+	subtotal.Policies++
+	subtotal.ExchangeRate = rate
+	subtotal.TotalCoverage += policy.CoverageAmount
+	subtotal.TotalNetPremium += result.NetPremium
+	subtotal.TotalGrossPremium += result.GrossPremium
+	t.currencies[code] = subtotal
+
+	policy.CoverageAmount *= rate
+	result.NetPremium *= rate
+	result.GrossPremium *= rate
+	if result.PremiumBreakdown != nil {
+		breakdown := *result.PremiumBreakdown
+		breakdown.NetPremium *= rate
+		breakdown.ExpenseLoading *= rate
+		breakdown.ProfitLoading *= rate
+		result.PremiumBreakdown = &breakdown
+	}
+	reserves := make([]float64, len(result.ReserveSchedule))
+	for year, reserve := range result.ReserveSchedule {
+		reserves[year] = reserve * rate
+	}
+	result.ReserveSchedule = reserves
+}
+
+// add records one priced policy
+func (t *portfolioTotals) add(policy *models.Policy, label string, result models.PremiumCalculation) {
+	t.policies++
+	t.coverages = append(t.coverages, policy.CoverageAmount)
+	// Annuities have no reserve schedule and nothing paid on death to be at risk
+	if len(result.ReserveSchedule) > 0 {
+		atRisk := policy.CoverageAmount - result.ReserveSchedule[0]
+		if atRisk > t.largestAtRisk || t.largestAtRiskFrom == "" {
+			t.largestAtRisk, t.largestAtRiskFrom = atRisk, label
+		}
+		t.netAmountAtRisk += max(0, atRisk)
+		t.deathsAtRisk += max(0, atRisk) * result.RiskAssessment["annual_death_probability"]
+	}
+	t.totalAge += policy.Age
+	t.coverage += policy.CoverageAmount
+	t.netPremium += result.NetPremium
+	t.grossPremium += result.GrossPremium
+	t.expectedClaims += policy.CoverageAmount * result.RiskAssessment["annual_death_probability"]
+	if breakdown := result.PremiumBreakdown; breakdown != nil {
+		t.expenseLoading += breakdown.ExpenseLoading
+		t.profitLoading += breakdown.ProfitLoading
+	} else {
+		// An annuity's loading isn't split, so all of it counts as expense
+		t.expenseLoading += result.GrossPremium - result.NetPremium
+	}
+	t.products[result.ProductType]++
+	t.genders[policy.Gender]++
+
+	// Risk categorization
+	if policy.SmokerStatus == "smoker" || policy.HealthRating == "substandard" {
+		t.risks["high_risk"]++
+	} else if policy.HealthRating == "preferred" || policy.SmokerStatus == "non_smoker" {
+		t.risks["low_risk"]++
+	} else {
+		t.risks["standard_risk"]++
+	}
+}
+
+// merge adds another worker's totals into these
+func (t *portfolioTotals) merge(other *portfolioTotals) {
+	t.policies += other.policies
+	t.totalAge += other.totalAge
+	t.coverage += other.coverage
+	t.netPremium += other.netPremium
+	t.grossPremium += other.grossPremium
+	t.expectedClaims += other.expectedClaims
+	t.expenseLoading += other.expenseLoading
+	t.profitLoading += other.profitLoading
+	for name, count := range other.products {
+		t.products[name] += count
+	}
+	for name, count := range other.genders {
+		t.genders[name] += count
+	}
+	for name, count := range other.risks {
+		t.risks[name] += count
+	}
+	t.skipped = append(t.skipped, other.skipped...)
+	t.coverages = append(t.coverages, other.coverages...)
+	t.netAmountAtRisk += other.netAmountAtRisk
+	t.deathsAtRisk += other.deathsAtRisk
+	if other.largestAtRiskFrom != "" && (other.largestAtRisk > t.largestAtRisk || t.largestAtRiskFrom == "") {
+		t.largestAtRisk, t.largestAtRiskFrom = other.largestAtRisk, other.largestAtRiskFrom
+	}
+	for code, subtotal := range other.currencies {
+		total := t.currencies[code]
+		total.Policies += subtotal.Policies
+		total.ExchangeRate = subtotal.ExchangeRate
+		total.TotalCoverage += subtotal.TotalCoverage
+		total.TotalNetPremium += subtotal.TotalNetPremium
+		total.TotalGrossPremium += subtotal.TotalGrossPremium
+		t.currencies[code] = total
+	}
+}
+
+// atRiskWeightedMortality is the portfolio's underwritten qx averaged over
+// its net amount at risk rather than its policies, the rate reinsurers quote
+// on: Σ(net amount at risk × qx) / Σ net amount at risk. It is 0 when nothing
+// is at risk.
+func (t *portfolioTotals) atRiskWeightedMortality() float64 {
+	if t.netAmountAtRisk <= 0 {
+		return 0
+	}
+	return t.deathsAtRisk / t.netAmountAtRisk
+}
+
+// defaultConcentrationTopN is how many of the largest policies the
+// concentration share counts when the request doesn't say
+const defaultConcentrationTopN = 10
+
+// concentration measures how much of the portfolio's cover sits on a few
+// policies: the share of the sum assured on the topN largest, and the
+// Herfindahl index, the sum of each policy's squared share of it. The index
+// is 1/n for n equal policies and 1 when a single policy holds everything.
+func (t *portfolioTotals) concentration(topN int) models.ConcentrationMetrics {
+	metrics := models.ConcentrationMetrics{
+		TopN:                       min(cmp.Or(topN, defaultConcentrationTopN), len(t.coverages)),
+		LargestNetAmountAtRisk:     t.largestAtRisk,
+		LargestNetAmountAtRiskFrom: t.largestAtRiskFrom,
+	}
+	if t.coverage <= 0 {
+		return metrics
+	}
+
+	largestFirst := slices.Clone(t.coverages)
+	slices.SortFunc(largestFirst, func(a, b float64) int { return cmp.Compare(b, a) })
+	topCoverage := 0.0
+	for _, coverage := range largestFirst[:metrics.TopN] {
+		topCoverage += coverage
+	}
+	metrics.TopNShare = topCoverage / t.coverage
+
+	for _, coverage := range t.coverages {
+		share := coverage / t.coverage
+		metrics.HerfindahlIndex += share * share
+	}
+	return metrics
+}
+
+// totalPortfolio prices the policies on several workers, each taking a
+// contiguous run of them. Merging the runs in order keeps skipped policies in
+// the order they were sent. With a conversion, every amount is totalled in
+// the reporting currency.
+func (s *ActuarialService) totalPortfolio(policies []models.Policy, conversion *currencyConversion) *portfolioTotals {
+	workers := min(runtime.GOMAXPROCS(0), len(policies))
+	chunkSize := (len(policies) + workers - 1) / workers
+
+	shares := make([]*portfolioTotals, workers)
+	var wg sync.WaitGroup
+	for worker := range shares {
+		share := newPortfolioTotals()
+		shares[worker] = share
+		start := worker * chunkSize
+		end := min(start+chunkSize, len(policies))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				policy := policies[i]
+				result, err := s.CalculatePremium(&policy)
+				if err != nil {
+					share.skipped = append(share.skipped, models.SkippedPolicy{PolicyID: policyLabel(&policy, i), Reason: err.Error()})
+					continue
+				}
+				if conversion != nil {
+					conversion.convert(share, &policy, &result)
+				}
+				share.add(&policy, policyLabel(&policy, i), result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	totals := newPortfolioTotals()
+	for _, share := range shares {
+		totals.merge(share)
+	}
+	return totals
+}
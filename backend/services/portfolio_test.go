@@ -0,0 +1,222 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newPortfolioTestService returns a service with a flat male table loaded
+func newPortfolioTestService(t *testing.T) *ActuarialService {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.002")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+// Run with -race: every call shares the service, and each spreads its
+// policies over several workers
+func TestPortfolioAnalysisConcurrently(t *testing.T) {
+	service := newPortfolioTestService(t)
+
+	smokers := []string{"", "smoker", "non_smoker"}
+	policies := make([]models.Policy, 200)
+	for i := range policies {
+		policies[i] = models.Policy{Age: 20 + i%50, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, SmokerStatus: smokers[i%3]}
+	}
+	policies[7].Age = 500 // Skipped, and must stay first
+	policies[150].Age = 600
+	request := models.PortfolioAnalysisRequest{Policies: policies}
+
+	want, err := service.PortfolioAnalysis(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.TotalPolicies != 198 || len(want.SkippedPolicies) != 2 || want.SkippedPolicies[0].PolicyID != "8" || want.SkippedPolicies[1].PolicyID != "151" {
+		t.Fatalf("Expected 198 policies and policies 8 and 151 skipped in order, got %d and %+v", want.TotalPolicies, want.SkippedPolicies)
+	}
+	if want.RiskDistribution["high_risk"]+want.RiskDistribution["low_risk"]+want.RiskDistribution["standard_risk"] != 198 {
+		t.Errorf("Expected every policy in the risk distribution, got %v", want.RiskDistribution)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]models.PortfolioMetrics, 16)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = service.PortfolioAnalysis(request)
+		}()
+	}
+	wg.Wait()
+
+	for i, got := range results {
+		if got.TotalPolicies != want.TotalPolicies || math.Abs(got.TotalGrossPremium-want.TotalGrossPremium) > 1e-6 ||
+			!reflect.DeepEqual(got.ProductDistribution, want.ProductDistribution) ||
+			!reflect.DeepEqual(got.RiskDistribution, want.RiskDistribution) ||
+			!reflect.DeepEqual(got.SkippedPolicies, want.SkippedPolicies) {
+			t.Errorf("Run %d: expected the same result as a lone run, got %+v", i, got)
+		}
+	}
+}
+
+func TestPortfolioConcentration(t *testing.T) {
+	service := newPortfolioTestService(t)
+
+	policies := []models.Policy{
+		{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05},
+		{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05},
+		{Age: 40, Term: 10, CoverageAmount: 200000, InterestRate: 0.05, PolicyID: "big"},
+	}
+	metrics, err := service.PortfolioAnalysis(models.PortfolioAnalysisRequest{Policies: policies, TopN: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	concentration := metrics.Concentration
+	if concentration.TopN != 1 || math.Abs(concentration.TopNShare-0.5) > 1e-12 {
+		t.Errorf("Expected the largest policy to hold half the cover, got %+v", concentration)
+	}
+	// 0.25^2 + 0.25^2 + 0.5^2
+	if math.Abs(concentration.HerfindahlIndex-0.375) > 1e-12 {
+		t.Errorf("Expected a Herfindahl index of 0.375, got %f", concentration.HerfindahlIndex)
+	}
+	// Nothing is reserved at issue, so the whole sum assured is at risk
+	if concentration.LargestNetAmountAtRiskFrom != "big" || math.Abs(concentration.LargestNetAmountAtRisk-200000) > 1e-6 {
+		t.Errorf("Expected 200000 at risk on policy big, got %f on %q", concentration.LargestNetAmountAtRisk, concentration.LargestNetAmountAtRiskFrom)
+	}
+
+	// The default counts the top 10, which here is every policy
+	metrics, err = service.PortfolioAnalysis(models.PortfolioAnalysisRequest{Policies: policies})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics.Concentration.TopN != 3 || math.Abs(metrics.Concentration.TopNShare-1) > 1e-12 {
+		t.Errorf("Expected all 3 policies to hold all the cover, got %+v", metrics.Concentration)
+	}
+}
+
+func TestPortfolioCurrencyConversion(t *testing.T) {
+	service := newPortfolioTestService(t)
+
+	policy := models.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+	single, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inEuros := policy
+	inEuros.Currency = "eur"
+	inDollars := policy
+	inDollars.Currency = "USD"
+	request := models.PortfolioAnalysisRequest{
+		Policies:          []models.Policy{policy, inEuros, inDollars},
+		ReportingCurrency: "usd",
+		ExchangeRates:     map[string]float64{"EUR": 2},
+	}
+
+	// One policy without a currency is in dollars, one in dollars and one in
+	// euros worth twice as much
+	metrics, err := service.PortfolioAnalysis(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics.ReportingCurrency != "USD" || math.Abs(metrics.TotalGrossPremium-4*single.GrossPremium) > 1e-6 {
+		t.Errorf("Expected USD %f in total, got %s %f", 4*single.GrossPremium, metrics.ReportingCurrency, metrics.TotalGrossPremium)
+	}
+	if math.Abs(metrics.AverageCoverage-400000.0/3) > 1e-6 {
+		t.Errorf("Expected average converted cover %f, got %f", 400000.0/3, metrics.AverageCoverage)
+	}
+	euros, dollars := metrics.CurrencySubtotals["EUR"], metrics.CurrencySubtotals["USD"]
+	if euros.Policies != 1 || euros.ExchangeRate != 2 || euros.TotalCoverage != 100000 || math.Abs(euros.TotalGrossPremium-single.GrossPremium) > 1e-9 {
+		t.Errorf("Expected the euro policy in euros, got %+v", euros)
+	}
+	if dollars.Policies != 2 || dollars.ExchangeRate != 1 || dollars.TotalCoverage != 200000 {
+		t.Errorf("Expected two dollar policies, got %+v", dollars)
+	}
+
+	// Every currency needs a rate
+	request.ExchangeRates = nil
+	if _, err := service.PortfolioAnalysis(request); err == nil || !strings.Contains(err.Error(), "EUR") {
+		t.Errorf("Expected an error naming the missing EUR rate, got %v", err)
+	}
+
+	// Without a reporting currency nothing is converted
+	unconverted, err := service.PortfolioAnalysis(models.PortfolioAnalysisRequest{Policies: request.Policies})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unconverted.CurrencySubtotals != nil || math.Abs(unconverted.TotalGrossPremium-3*single.GrossPremium) > 1e-6 {
+		t.Errorf("Expected plain totals without a reporting currency, got %f and %v", unconverted.TotalGrossPremium, unconverted.CurrencySubtotals)
+	}
+}
+
+func TestPortfolioAtRiskMortality(t *testing.T) {
+	service := newPortfolioTestService(t)
+
+	policies := []models.Policy{
+		{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, SmokerStatus: "smoker"},
+		{Age: 40, Term: 10, CoverageAmount: 300000, InterestRate: 0.05, SmokerStatus: "non_smoker"},
+		{Age: 65, CoverageAmount: 10000, InterestRate: 0.05, ProductType: "immediate_annuity"}, // Nothing paid on death
+	}
+	metrics, err := service.PortfolioAnalysis(models.PortfolioAnalysisRequest{Policies: policies})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(metrics.NetAmountAtRisk-400000) > 1e-6 {
+		t.Errorf("Expected the two term policies' 400000 at risk, got %f", metrics.NetAmountAtRisk)
+	}
+
+	// Weighted by the sum at risk, the larger non-smoker counts three times as much
+	smoker, _ := service.CalculatePremium(&policies[0])
+	nonSmoker, _ := service.CalculatePremium(&policies[1])
+	smokerRate := smoker.RiskAssessment["annual_death_probability"]
+	nonSmokerRate := nonSmoker.RiskAssessment["annual_death_probability"]
+	if smokerRate <= nonSmokerRate {
+		t.Fatalf("Expected smokers to be rated up, got %f and %f", smokerRate, nonSmokerRate)
+	}
+	if want := (smokerRate + 3*nonSmokerRate) / 4; math.Abs(metrics.AtRiskMortality-want) > 1e-12 {
+		t.Errorf("Expected an at-risk mortality of %f, got %f", want, metrics.AtRiskMortality)
+	}
+}
+
+func TestPortfolioCombinedRatio(t *testing.T) {
+	service := newPortfolioTestService(t)
+
+	policies := []models.Policy{
+		{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05},
+		{Age: 50, Term: 10, CoverageAmount: 200000, InterestRate: 0.05},
+	}
+	metrics, err := service.PortfolioAnalysis(models.PortfolioAnalysisRequest{Policies: policies})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Term life is loaded for 3% of the sum assured over the 10 premiums,
+	// 5% commission and 50 a year of maintenance
+	expenseLoading := 0.03*300000/10 + 0.05*metrics.TotalGrossPremium + 2*50
+	ratios := metrics.ProfitabilityMetrics
+	if math.Abs(ratios["expense_loading"]-expenseLoading) > 1e-6 {
+		t.Errorf("Expected an expense loading of %f, got %f", expenseLoading, ratios["expense_loading"])
+	}
+	if want := expenseLoading / metrics.TotalGrossPremium; math.Abs(ratios["expense_ratio"]-want) > 1e-12 {
+		t.Errorf("Expected an expense ratio of %f, got %f", want, ratios["expense_ratio"])
+	}
+	// Profit is what's left, so it's no longer counted as expense
+	netShare := metrics.TotalNetPremium / metrics.TotalGrossPremium
+	if total := netShare + ratios["expense_ratio"] + ratios["profit_margin"]; math.Abs(total-1) > 1e-12 {
+		t.Errorf("Expected net, expenses and profit to make up the gross premium, got %f", total)
+	}
+	if want := ratios["loss_ratio"] + ratios["expense_ratio"]; math.Abs(ratios["combined_ratio"]-want) > 1e-12 {
+		t.Errorf("Expected the combined ratio to be loss plus expense, %f, got %f", want, ratios["combined_ratio"])
+	}
+	if ratios["profit_margin"] <= 0 || ratios["expense_ratio"] >= 1-netShare {
+		t.Errorf("Expected a profit margin separate from expenses, got %v", ratios)
+	}
+}
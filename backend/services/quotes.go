@@ -0,0 +1,74 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrQuotesDisabled is returned when looking up a quote with no store configured
+	ErrQuotesDisabled = errors.New("quote storage is not enabled")
+	// ErrQuoteNotFound is returned by a QuoteStore that has no quote with the ID
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrQuoteNotSaved wraps a store's failure to record a calculated quote
+	ErrQuoteNotSaved = errors.New("could not save quote")
+)
+
+// QuoteStore keeps calculated quotes for audit and later retrieval
+type QuoteStore interface {
+	SaveQuote(quote models.Quote) error
+	Quote(id string) (models.Quote, error) // ErrQuoteNotFound if there is none
+}
+
+// SetQuoteStore records every premium calculation in store from now on. nil
+// turns storage off, leaving the service stateless.
+func (s *ActuarialService) SetQuoteStore(store QuoteStore) {
+	s.mu.Lock()
+	s.quotes = store
+	s.mu.Unlock()
+}
+
+func (s *ActuarialService) quoteStore() QuoteStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.quotes
+}
+
+// GetQuote returns a stored quote by ID
+func (s *ActuarialService) GetQuote(id string) (models.Quote, error) {
+	store := s.quoteStore()
+	if store == nil {
+		return models.Quote{}, ErrQuotesDisabled
+	}
+	return store.Quote(id)
+}
+
+// QuotePremium calculates a premium for a customer-facing quote: like
+// CalculatePremium, but when a store is configured the quote is recorded and
+// the result carries its ID. A quote that can't be stored isn't returned, so
+// every quote a caller sees can be found again. Internal calculations such as
+// sensitivity runs use CalculatePremium and aren't stored.
+func (s *ActuarialService) QuotePremium(policy *models.Policy) (models.PremiumCalculation, error) {
+	result, err := s.CalculatePremium(policy)
+	store := s.quoteStore()
+	if err != nil || store == nil {
+		return result, err
+	}
+	quote := models.Quote{ID: newQuoteID(), CreatedAt: time.Now().UTC(), Policy: *policy}
+	result.QuoteID = quote.ID
+	quote.Result = result
+	if err := store.SaveQuote(quote); err != nil {
+		return models.PremiumCalculation{}, fmt.Errorf("%w: %v", ErrQuoteNotSaved, err)
+	}
+	return result, nil
+}
+
+func newQuoteID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
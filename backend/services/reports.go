@@ -0,0 +1,48 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"actuworry/backend/report"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQuoteNotRendered wraps a failure to write a priced quote out as a PDF
+var ErrQuoteNotRendered = errors.New("could not render quote")
+
+// LoadReportBranding sets how PDF quotes are branded from a JSON file; see
+// report.Branding. Without one, quotes carry report.DefaultBranding.
+func (s *ActuarialService) LoadReportBranding(path string) error {
+	branding, err := report.LoadBranding(path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.branding = &branding
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ActuarialService) reportBranding() report.Branding {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.branding == nil {
+		return report.DefaultBranding()
+	}
+	return *s.branding
+}
+
+// QuotePDF prices a policy, keeping the quote like QuotePremium does, and
+// renders it as a branded PDF
+func (s *ActuarialService) QuotePDF(policy *models.Policy) ([]byte, models.PremiumCalculation, error) {
+	result, err := s.QuotePremium(policy)
+	if err != nil {
+		return nil, models.PremiumCalculation{}, err
+	}
+	document, err := report.Quote(*policy, result, s.reportBranding(), time.Now())
+	if err != nil {
+		return nil, result, fmt.Errorf("%w: %v", ErrQuoteNotRendered, err)
+	}
+	return document, result, nil
+}
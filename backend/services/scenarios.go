@@ -0,0 +1,185 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultPercentiles are reported when a scenario analysis doesn't ask for its own
+var defaultPercentiles = []float64{0.05, 0.25, 0.5, 0.75, 0.95}
+
+// loadScenarioSet reads a set of interest rate scenarios from file into the set
+func (t *tableSet) loadScenarioSet(name, filePath string) error {
+	scenarios, err := actuarial.LoadScenarioSet(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load scenario set %s: %w", name, err)
+	}
+	t.scenarios[name] = scenarios
+	t.scenarioSources[name] = filePath
+	return nil
+}
+
+func (t *tableSet) scenarioSet(name string) ([]actuarial.Scenario, error) {
+	scenarios, exists := t.scenarios[strings.ToLower(strings.TrimSpace(name))]
+	if !exists {
+		return nil, fmt.Errorf("scenario set '%s' not found", name)
+	}
+	return scenarios, nil
+}
+
+// LoadScenarioSet loads a named set of interest rate scenarios, e.g. a
+// regulator's prescribed paths, for scenario analysis
+func (s *ActuarialService) LoadScenarioSet(name, filePath string) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	next := s.currentTables().clone()
+	if err := next.loadScenarioSet(strings.ToLower(name), filePath); err != nil {
+		return err
+	}
+	s.swapTables(next)
+	return nil
+}
+
+// LoadScenarioSets loads every .csv file in a directory as a scenario set
+// named after the file, so regulator_2025.csv becomes "regulator_2025"
+func (s *ActuarialService) LoadScenarioSets(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if err := s.LoadScenarioSet(name, path); err != nil {
+			return names, err
+		}
+		names = append(names, strings.ToLower(name))
+	}
+	return names, nil
+}
+
+// ScenarioAnalysis reprices a policy on every scenario of a loaded set, each
+// scenario's rate path standing in for the policy's interest rate or yield
+// curve, and reports how the premiums and reserves are spread across them
+func (s *ActuarialService) ScenarioAnalysis(req models.ScenarioAnalysisRequest) (models.ScenarioAnalysisResult, error) {
+	percentiles := req.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = defaultPercentiles
+	}
+	for _, share := range percentiles {
+		if share < 0 || share > 1 {
+			return models.ScenarioAnalysisResult{}, fmt.Errorf("percentiles must be between 0 and 1, e.g. 0.995")
+		}
+	}
+	run, err := s.preparePricing(&req.Policy, nil)
+	if err != nil {
+		return models.ScenarioAnalysisResult{}, err
+	}
+	scenarios, err := s.currentTables().scenarioSet(req.ScenarioSet)
+	if err != nil {
+		return models.ScenarioAnalysisResult{}, err
+	}
+	years := scenarioYears(run)
+	for _, scenario := range scenarios {
+		if len(scenario.Path) < years {
+			return models.ScenarioAnalysisResult{}, fmt.Errorf("scenario '%s' has %d years of rates but the policy needs %d", scenario.Name, len(scenario.Path), years)
+		}
+	}
+
+	result := models.ScenarioAnalysisResult{
+		ScenarioSet: strings.ToLower(strings.TrimSpace(req.ScenarioSet)),
+		Outcomes:    make([]models.ScenarioOutcome, len(scenarios)),
+	}
+	netPremiums := make([]float64, len(scenarios))
+	grossPremiums := make([]float64, len(scenarios))
+	for i, scenario := range scenarios {
+		curve := scenario.Curve()
+		if run.basis != nil && run.basis.InterestShift != nil {
+			curve = curve.Shift(*run.basis.InterestShift)
+		}
+		scenarioRun := run
+		scenarioRun.yieldCurve = curve
+		scenarioRun.actuarialPolicy.YieldCurve = curve
+		priced, err := s.priceRun(scenarioRun)
+		if err != nil {
+			return models.ScenarioAnalysisResult{}, fmt.Errorf("scenario '%s': %w", scenario.Name, err)
+		}
+		result.ProductType = priced.ProductType
+		result.Outcomes[i] = models.ScenarioOutcome{
+			Scenario:        scenario.Name,
+			NetPremium:      priced.NetPremium,
+			GrossPremium:    priced.GrossPremium,
+			ReserveSchedule: priced.ReserveSchedule,
+			LapsePath:       priced.LapsePath,
+		}
+		netPremiums[i], grossPremiums[i] = priced.NetPremium, priced.GrossPremium
+	}
+
+	result.NetPremium = scenarioDistribution(netPremiums, percentiles)
+	result.GrossPremium = scenarioDistribution(grossPremiums, percentiles)
+	result.ReservePercentiles = reservePercentiles(result.Outcomes, percentiles)
+	return result, nil
+}
+
+// scenarioYears is how many years of rates pricing a policy discounts over
+func scenarioYears(run pricingRun) int {
+	policy := run.actuarialPolicy
+	if run.multiTable != nil || policy.ProductType == "term_life" {
+		return policy.Term
+	}
+	lastAge := run.table.MaxAge
+	if policy.MortalityExtrapolation == "linear" {
+		lastAge = max(lastAge, policy.UltimateAge)
+	}
+	return lastAge + 1 - policy.Age
+}
+
+func scenarioDistribution(values []float64, percentiles []float64) models.ScenarioDistribution {
+	distribution := models.ScenarioDistribution{
+		Min:         math.Inf(1),
+		Max:         math.Inf(-1),
+		Percentiles: make(map[string]float64, len(percentiles)),
+	}
+	for _, value := range values {
+		distribution.Mean += value / float64(len(values))
+		distribution.Min = min(distribution.Min, value)
+		distribution.Max = max(distribution.Max, value)
+	}
+	for _, share := range percentiles {
+		distribution.Percentiles[percentileLabel(share)] = actuarial.Percentile(values, share)
+	}
+	return distribution
+}
+
+// reservePercentiles is each percentile of the reserve at every duration,
+// across the scenarios; nil for products without a reserve
+func reservePercentiles(outcomes []models.ScenarioOutcome, percentiles []float64) map[string][]float64 {
+	durations := len(outcomes[0].ReserveSchedule)
+	if durations == 0 {
+		return nil
+	}
+	reserves := make(map[string][]float64, len(percentiles))
+	values := make([]float64, len(outcomes))
+	for _, share := range percentiles {
+		schedule := make([]float64, durations)
+		for year := range schedule {
+			for i, outcome := range outcomes {
+				values[i] = outcome.ReserveSchedule[year]
+			}
+			schedule[year] = actuarial.Percentile(values, share)
+		}
+		reserves[percentileLabel(share)] = schedule
+	}
+	return reserves
+}
+
+// percentileLabel names a percentile by its share, 0.995 as "p99.5"
+func percentileLabel(share float64) string {
+	return "p" + strconv.FormatFloat(math.Round(share*1e6)/1e4, 'f', -1, 64)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestScenarioAnalysis(t *testing.T) {
+	dir := t.TempDir()
+	writeTable(t, filepath.Join(dir, "male.csv"), "0.002")
+	scenarioDir := filepath.Join(dir, "scenarios")
+	if err := os.MkdirAll(scenarioDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	row := func(name string, rate string, years int) string {
+		line := name
+		for range years {
+			line += "\t" + rate
+		}
+		return line + "\n"
+	}
+	header := "scenario"
+	for year := 1; year <= 10; year++ {
+		header += "\t" + strconv.Itoa(year)
+	}
+	contents := header + "\n" + row("low", "0.03", 10) + row("mid", "0.05", 10) + row("high", "0.07", 10)
+	if err := os.WriteFile(filepath.Join(scenarioDir, "Regulator.csv"), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", filepath.Join(dir, "male.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if names, err := service.LoadScenarioSets(scenarioDir); err != nil || len(names) != 1 || names[0] != "regulator" {
+		t.Fatalf("Expected the regulator set to load, got %v (%v)", names, err)
+	}
+
+	policy := models.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+	analysis, err := service.ScenarioAnalysis(models.ScenarioAnalysisRequest{Policy: policy, ScenarioSet: "regulator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.Outcomes) != 3 {
+		t.Fatalf("Expected an outcome for each scenario, got %d", len(analysis.Outcomes))
+	}
+
+	// A flat path prices like the same flat rate
+	atFivePercent, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mid := analysis.Outcomes[1]; math.Abs(mid.GrossPremium-atFivePercent.GrossPremium) > 1e-9 {
+		t.Errorf("Expected a flat 5%% path to price like a 5%% rate, got %f vs %f", mid.GrossPremium, atFivePercent.GrossPremium)
+	}
+	premiums := analysis.GrossPremium
+	if premiums.Max != analysis.Outcomes[0].GrossPremium || premiums.Min != analysis.Outcomes[2].GrossPremium || premiums.Percentiles["p50"] != analysis.Outcomes[1].GrossPremium {
+		t.Errorf("Expected the lowest rates to cost most and the middle path to be the median, got %+v", premiums)
+	}
+	if reserves := analysis.ReservePercentiles["p95"]; len(reserves) != len(atFivePercent.ReserveSchedule) {
+		t.Errorf("Expected a 95th percentile reserve at every duration, got %v", reserves)
+	}
+
+	// Dynamic lapses follow each scenario's rates: only the high path beats the 5% credited
+	policy.DynamicLapse = &models.DynamicLapse{Sensitivity: 2}
+	dynamic, err := service.ScenarioAnalysis(models.ScenarioAnalysisRequest{Policy: policy, ScenarioSet: "regulator"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low, high := dynamic.Outcomes[0].LapsePath, dynamic.Outcomes[2].LapsePath; len(low) != 10 || low[0] != 0 || math.Abs(high[0]-0.04) > 1e-9 {
+		t.Errorf("Expected no extra lapses on the low path and 4%% on the high, got %v and %v", low, high)
+	}
+	policy.DynamicLapse = nil
+
+	policy.Term = 20
+	if _, err := service.ScenarioAnalysis(models.ScenarioAnalysisRequest{Policy: policy, ScenarioSet: "regulator"}); err == nil {
+		t.Error("Expected scenarios shorter than the term to be rejected")
+	}
+	policy.Term = 10
+	if _, err := service.ScenarioAnalysis(models.ScenarioAnalysisRequest{Policy: policy, ScenarioSet: "stress_2030"}); err == nil {
+		t.Error("Expected an unknown scenario set to be rejected")
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSolverConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.02")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.SetSolverConfig(actuarial.SolverConfig{Tolerance: -1, MaxIterations: 10}); err == nil {
+		t.Error("Expected a negative tolerance to be rejected")
+	}
+
+	policy := models.Policy{Age: 65, CoverageAmount: 10000, InterestRate: 0.05, ProductType: "immediate_annuity", CashRefund: true}
+	if result, err := service.CalculatePremium(&policy); err != nil || !result.CashRefundConverged {
+		t.Fatalf("Expected the default solver to settle, got %+v (%v)", result, err)
+	}
+
+	// Too few steps is an error, not a price
+	if err := service.SetSolverConfig(actuarial.SolverConfig{Tolerance: 1e-10, MaxIterations: 1}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := service.CalculatePremium(&policy)
+	var convergence *actuarial.ConvergenceError
+	if !errors.As(err, &convergence) || convergence.Iterations != 1 {
+		t.Errorf("Expected a convergence error after 1 iteration, got %v", err)
+	}
+}
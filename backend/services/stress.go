@@ -0,0 +1,75 @@
+package services
+
+import (
+	"actuworry/backend/models"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// StressPresets are the standard stresses a sensitivity analysis can apply
+// by name. The Solvency II shocks follow the standard formula's life
+// underwriting module; they are applied to a single policy's premium, not to
+// a balance sheet, so they show the direction and size of each risk rather
+// than a capital requirement.
+var StressPresets = map[string]models.StressPreset{
+	"solvency_ii_mortality": {
+		Name:           "solvency_ii_mortality",
+		Description:    "Solvency II mortality: qx up 15% at every age",
+		MortalityShock: 0.15,
+	},
+	"solvency_ii_longevity": {
+		Name:           "solvency_ii_longevity",
+		Description:    "Solvency II longevity: qx down 20% at every age",
+		MortalityShock: -0.20,
+	},
+	"solvency_ii_mass_lapse": {
+		Name:        "solvency_ii_mass_lapse",
+		Description: "Solvency II mass lapse: 40% of policies lapse at once, taken at the end of the first year",
+		MassLapse:   0.40,
+	},
+	"interest_down_100bps": {
+		Name:          "interest_down_100bps",
+		Description:   "Interest rates 1% lower at every term",
+		InterestShift: -0.01,
+	},
+	"interest_up_100bps": {
+		Name:          "interest_up_100bps",
+		Description:   "Interest rates 1% higher at every term",
+		InterestShift: 0.01,
+	},
+}
+
+// lookupStressPresets finds each named preset, failing on the first unknown name
+func lookupStressPresets(names []string) (map[string]models.StressPreset, error) {
+	presets := make(map[string]models.StressPreset, len(names))
+	for _, name := range names {
+		preset, ok := StressPresets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown stress preset '%s'; available: %v", name, slices.Sorted(maps.Keys(StressPresets)))
+		}
+		presets[name] = preset
+	}
+	return presets, nil
+}
+
+// stressPolicy applies a preset to a policy, returning the stressed policy and
+// the basis carrying the shocks that act on the tables and rates. The shocks
+// go on top of base, the basis the policy is otherwise valued on, if any.
+func stressPolicy(policy models.Policy, preset models.StressPreset, base *models.Basis) (models.Policy, *models.Basis) {
+	basis := &models.Basis{}
+	if base != nil {
+		*basis = *base
+	}
+	basis.Name = preset.Name
+	if preset.MortalityShock != 0 {
+		basis.MortalityShock = &preset.MortalityShock
+	}
+	if preset.InterestShift != 0 {
+		basis.InterestShift = &preset.InterestShift
+	}
+	if preset.MassLapse > 0 {
+		policy.MassLapse = 1 - (1-policy.MassLapse)*(1-preset.MassLapse)
+	}
+	return policy, basis
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tableSet holds every loaded table. A set is never changed once the service
+// is using it: loads and reloads build a new set and swap it in, so a
+// calculation that already has the old set finishes on it.
+type tableSet struct {
+	mortality       map[string]actuarial.MortalityTable
+	multiDecrement  map[string]actuarial.MultiDecrementTable
+	selectTables    map[string]actuarial.SelectTable
+	metadata        map[string]models.TableMetadata
+	derivations     map[string]models.DeriveTableRequest // How each derived table was built
+	curves          map[string]actuarial.YieldCurve
+	curveSources    map[string]string // File each yield curve was loaded from
+	scenarios       map[string][]actuarial.Scenario
+	scenarioSources map[string]string // File each scenario set was loaded from
+}
+
+func newTableSet() *tableSet {
+	return &tableSet{
+		mortality:       make(map[string]actuarial.MortalityTable),
+		multiDecrement:  make(map[string]actuarial.MultiDecrementTable),
+		selectTables:    make(map[string]actuarial.SelectTable),
+		metadata:        make(map[string]models.TableMetadata),
+		derivations:     make(map[string]models.DeriveTableRequest),
+		curves:          make(map[string]actuarial.YieldCurve),
+		curveSources:    make(map[string]string),
+		scenarios:       make(map[string][]actuarial.Scenario),
+		scenarioSources: make(map[string]string),
+	}
+}
+
+// clone copies the maps (not the tables, which are never modified)
+func (t *tableSet) clone() *tableSet {
+	next := newTableSet()
+	for name, table := range t.mortality {
+		next.mortality[name] = table
+	}
+	for name, table := range t.multiDecrement {
+		next.multiDecrement[name] = table
+	}
+	maps.Copy(next.selectTables, t.selectTables)
+	for name, info := range t.metadata {
+		next.metadata[name] = info
+	}
+	for name, request := range t.derivations {
+		next.derivations[name] = request
+	}
+	maps.Copy(next.curves, t.curves)
+	maps.Copy(next.curveSources, t.curveSources)
+	maps.Copy(next.scenarios, t.scenarios)
+	maps.Copy(next.scenarioSources, t.scenarioSources)
+	return next
+}
+
+func (t *tableSet) mortalityTable(gender string) (actuarial.MortalityTable, error) {
+	tableName := resolveTableName(gender)
+	table, exists := t.mortality[tableName]
+	if !exists {
+		return nil, fmt.Errorf("mortality table '%s' not found", tableName)
+	}
+	return table, nil
+}
+
+// policyMortalityTable is the table a policy is priced and reserved on. A
+// select table becomes the rates for the policy's own age at issue.
+func (t *tableSet) policyMortalityTable(policy *models.Policy) (actuarial.MortalityTable, error) {
+	if table, ok := t.selectTables[resolveTableName(policy.Gender)]; ok {
+		return table.PolicyTable(policy.Age), nil
+	}
+	return t.mortalityTable(policy.Gender)
+}
+
+func (t *tableSet) tableRange(gender string, table actuarial.MortalityTable) models.TableMetadata {
+	tableName := resolveTableName(gender)
+	if info, ok := t.metadata[tableName]; ok {
+		return info
+	}
+	return models.TableMetadata{Name: tableName, MinAge: 0, MaxAge: len(table) - 1}
+}
+
+// loadMortality reads a single-decrement table from file into the set
+func (t *tableSet) loadMortality(name, filePath string) error {
+	table, info, err := actuarial.LoadMortalityTableWithOptions(filePath, actuarial.TableLoadOptions{InterpolateAbridged: true})
+	if err != nil {
+		return fmt.Errorf("failed to load mortality table %s: %w", name, err)
+	}
+	t.mortality[name] = table
+	t.metadata[name] = models.TableMetadata{
+		Name:     name,
+		Source:   filePath,
+		MinAge:   info.MinAge,
+		MaxAge:   info.MaxAge,
+		LoadedAt: time.Now().UTC(),
+		Abridged: info.Abridged,
+
+		RateScale: info.RateScale,
+	}
+	if info.RateScale == actuarial.RatesPerThousand {
+		log.Printf("Mortality table %s (%s) has rates above 1; read them as deaths per 1000", name, filePath)
+	}
+	return nil
+}
+
+// loadMultiDecrement reads a death/disability/withdrawal table from file into the set
+func (t *tableSet) loadMultiDecrement(name, filePath string) error {
+	table, err := actuarial.LoadMultiDecrementTable(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load multi-decrement table %s: %w", name, err)
+	}
+	t.multiDecrement[name] = table
+	t.metadata[name] = models.TableMetadata{
+		Name:     name,
+		Source:   filePath,
+		MinAge:   0,
+		MaxAge:   table.Len() - 1,
+		LoadedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+// loadSelect reads a select-and-ultimate table from file into the set
+func (t *tableSet) loadSelect(name, filePath string) error {
+	table, err := actuarial.LoadSelectTable(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load select table %s: %w", name, err)
+	}
+	minAge := table.Len()
+	for age := range table.Select {
+		minAge = min(minAge, age)
+	}
+	t.selectTables[name] = table
+	t.metadata[name] = models.TableMetadata{
+		Name:         name,
+		Source:       filePath,
+		MinAge:       minAge,
+		MaxAge:       table.Len() - 1,
+		LoadedAt:     time.Now().UTC(),
+		SelectPeriod: table.SelectPeriod,
+	}
+	return nil
+}
+
+// rederive rebuilds derived tables from freshly loaded sources. A derived
+// table can itself be a source, so keep going until nothing more can be built.
+func (t *tableSet) rederive(derivations map[string]models.DeriveTableRequest) error {
+	remaining := maps.Clone(derivations)
+	for len(remaining) > 0 {
+		progress := false
+		for name, request := range remaining {
+			if !t.hasSources(request) {
+				continue
+			}
+			if err := t.derive(request); err != nil {
+				return err
+			}
+			delete(remaining, name)
+			progress = true
+		}
+		if !progress {
+			for name := range remaining {
+				return fmt.Errorf("could not rebuild derived table %s: a source table is missing", name)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *tableSet) hasSources(request models.DeriveTableRequest) bool {
+	for _, operation := range request.Operations {
+		if _, exists := t.mortality[resolveTableName(operation.Table)]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// currentTables returns the table set in use right now
+func (s *ActuarialService) currentTables() *tableSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tables
+}
+
+// swapTables makes next the table set used by new calculations
+func (s *ActuarialService) swapTables(next *tableSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables = next
+	s.adjusted = nil
+}
+
+// LoadMortalityTable loads a mortality table by a friendly name (e.g., "male")
+func (s *ActuarialService) LoadMortalityTable(name, filePath string) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	next := s.currentTables().clone()
+	if err := next.loadMortality(name, filePath); err != nil {
+		return err
+	}
+	s.swapTables(next)
+	return nil
+}
+
+// LoadMultiDecrementTable loads a death/disability/withdrawal table by a friendly
+// name. Policies that select this name are priced on the multi-decrement model.
+func (s *ActuarialService) LoadMultiDecrementTable(name, filePath string) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	next := s.currentTables().clone()
+	if err := next.loadMultiDecrement(name, filePath); err != nil {
+		return err
+	}
+	s.swapTables(next)
+	return nil
+}
+
+// LoadMultiDecrementTables loads every .csv in dir as a multi-decrement
+// table named after its file, e.g. group.csv as "group". A missing directory
+// loads none.
+func (s *ActuarialService) LoadMultiDecrementTables(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		name := strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		if err := s.LoadMultiDecrementTable(name, path); err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// LoadSelectTables loads every .csv in dir as a select-and-ultimate table
+// named after its file, e.g. male_select.csv as "male_select". A missing
+// directory loads none.
+func (s *ActuarialService) LoadSelectTables(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		name := strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		if err := s.LoadSelectTable(name, path); err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// LoadSelectTable loads a select-and-ultimate table by a friendly name.
+// Policies that select this name use the select rates for their age at issue
+// for the first years of the policy, in premiums and reserves alike.
+func (s *ActuarialService) LoadSelectTable(name, filePath string) error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	next := s.currentTables().clone()
+	if err := next.loadSelect(name, filePath); err != nil {
+		return err
+	}
+	s.swapTables(next)
+	return nil
+}
+
+// ReloadTables re-reads every loaded table and yield curve from its source file, for when a CSV
+// has been updated on disk. All tables are read into a new set before anything
+// changes: if any of them fails the current tables stay in use and the error is
+// returned, so the service is never left half updated.
+func (s *ActuarialService) ReloadTables() error {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	current := s.currentTables()
+	next := newTableSet()
+	for name, info := range current.metadata {
+		if _, isDerived := current.derivations[name]; isDerived {
+			continue // Rebuilt below, once its sources are loaded
+		}
+		var err error
+		if _, isMultiDecrement := current.multiDecrement[name]; isMultiDecrement {
+			err = next.loadMultiDecrement(name, info.Source)
+		} else if _, isSelect := current.selectTables[name]; isSelect {
+			err = next.loadSelect(name, info.Source)
+		} else {
+			err = next.loadMortality(name, info.Source)
+		}
+		if err != nil {
+			return fmt.Errorf("reload abandoned, keeping the current tables: %w", err)
+		}
+	}
+	if err := next.rederive(current.derivations); err != nil {
+		return fmt.Errorf("reload abandoned, keeping the current tables: %w", err)
+	}
+	for name, filePath := range current.curveSources {
+		if err := next.loadYieldCurve(name, filePath); err != nil {
+			return fmt.Errorf("reload abandoned, keeping the current tables: %w", err)
+		}
+	}
+	for name, filePath := range current.scenarioSources {
+		if err := next.loadScenarioSet(name, filePath); err != nil {
+			return fmt.Errorf("reload abandoned, keeping the current tables: %w", err)
+		}
+	}
+	s.swapTables(next)
+	return nil
+}
+
+// SurvivalCurve works out a table's survival curve from an age, after the
+// underwriting multipliers for the requested smoker status, health rating or
+// rating factor, so a chart can show how underwriting moves it
+func (s *ActuarialService) SurvivalCurve(request models.SurvivalCurveRequest) (models.SurvivalCurve, error) {
+	tables := s.currentTables()
+	table, err := tables.mortalityTable(request.Table)
+	if err != nil {
+		return models.SurvivalCurve{}, err
+	}
+	tableRange := tables.tableRange(request.Table, table)
+	fromAge := tableRange.MinAge
+	if request.FromAge != nil {
+		fromAge = *request.FromAge
+	}
+	if err := checkAgeInRange(fromAge, tableRange); err != nil {
+		return models.SurvivalCurve{}, err
+	}
+	if request.RatingFactor < 0 {
+		return models.SurvivalCurve{}, fmt.Errorf("rating factor must not be negative")
+	}
+
+	policy := actuarial.Policy{
+		Age:          fromAge,
+		SmokerStatus: request.SmokerStatus,
+		HealthRating: request.HealthRating,
+		RatingFactor: request.RatingFactor,
+	}
+	adjusted := actuarial.ApplyUnderwritingFactorsWithConfig(&policy, table, s.underwritingFor(nil))
+
+	curve := models.SurvivalCurve{
+		Table:        tableRange.Name,
+		FromAge:      fromAge,
+		SmokerStatus: request.SmokerStatus,
+		HealthRating: request.HealthRating,
+		RatingFactor: request.RatingFactor,
+	}
+	for _, point := range actuarial.SurvivalCurve(adjusted, fromAge) {
+		curve.Points = append(curve.Points, models.SurvivalPoint{Age: point.Age, SurvivalProbability: point.SurvivalProbability})
+	}
+	return curve, nil
+}
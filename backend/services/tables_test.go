@@ -0,0 +1,441 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeTable(t *testing.T, path string, qx string) {
+	t.Helper()
+	contents := "age\tmx\tqx\n"
+	for age := 0; age <= 100; age++ {
+		contents += strconv.Itoa(age) + "\t0\t" + qx + "\n"
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadTables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0.001")
+
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+	policy := models.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+	before, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Calculations keep running while the table is reloaded
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				p := policy
+				if _, err := service.CalculatePremium(&p); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	writeTable(t, path, "0.002")
+	if err := service.ReloadTables(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	wg.Wait()
+
+	after, _ := service.CalculatePremium(&policy)
+	if after.NetPremium <= before.NetPremium {
+		t.Errorf("Expected the reloaded table to be used, premium went from %f to %f", before.NetPremium, after.NetPremium)
+	}
+
+	// A broken file leaves the working tables in place
+	if err := os.WriteFile(path, []byte{0x1f, 0x8b, 0x00}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReloadTables(); err == nil {
+		t.Fatal("Expected reload of a corrupt table to fail")
+	}
+	kept, err := service.CalculatePremium(&policy)
+	if err != nil || kept.NetPremium != after.NetPremium {
+		t.Errorf("Expected the previous tables to stay in use, got %f (%v)", kept.NetPremium, err)
+	}
+}
+
+func TestDeriveTable(t *testing.T) {
+	dir := t.TempDir()
+	writeTable(t, filepath.Join(dir, "male.csv"), "0.002")
+	writeTable(t, filepath.Join(dir, "female.csv"), "0.001")
+
+	service := NewActuarialService()
+	for _, gender := range []string{"male", "female"} {
+		if err := service.LoadMortalityTable(gender, filepath.Join(dir, gender+".csv")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	half, loading := 0.5, 1.2
+	request := models.DeriveTableRequest{Name: "Unisex", Operations: []models.TableOperation{
+		{Table: "male", Weight: &half, Scalar: &loading},
+		{Table: "female", Weight: &half},
+	}}
+	metadata, err := service.DeriveTable(request)
+	if err != nil {
+		t.Fatalf("Could not derive table: %v", err)
+	}
+	if metadata.Name != "unisex" || metadata.MaxAge != 100 || len(metadata.DerivedFrom) != 2 {
+		t.Errorf("Unexpected metadata %+v", metadata)
+	}
+	table, err := service.GetMortalityTable("unisex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(table[40]-0.0017) > 1e-12 {
+		t.Errorf("Expected 0.5 x 1.2 x 0.002 + 0.5 x 0.001 = 0.0017, got %f", table[40])
+	}
+
+	if _, err := service.DeriveTable(request); err == nil {
+		t.Error("Expected a duplicate name to be rejected")
+	}
+	missing := models.DeriveTableRequest{Name: "other", Operations: []models.TableOperation{{Table: "pensioners"}}}
+	if _, err := service.DeriveTable(missing); err == nil {
+		t.Error("Expected an unknown source table to be rejected")
+	}
+
+	// Reloading rebuilds the derived table from the new sources
+	writeTable(t, filepath.Join(dir, "female.csv"), "0.003")
+	if err := service.ReloadTables(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	table, _ = service.GetMortalityTable("unisex")
+	if math.Abs(table[40]-0.0027) > 1e-12 {
+		t.Errorf("Expected the derived table to follow its sources after a reload, got %f", table[40])
+	}
+}
+
+func TestYieldCurves(t *testing.T) {
+	dir := t.TempDir()
+	writeTable(t, filepath.Join(dir, "male.csv"), "0.002")
+	curvePath := filepath.Join(dir, "curves", "Pricing_2024.csv")
+	if err := os.MkdirAll(filepath.Dir(curvePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(curvePath, []byte("term\trate\n1\t0.05\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", filepath.Join(dir, "male.csv")); err != nil {
+		t.Fatal(err)
+	}
+	names, err := service.LoadYieldCurves(filepath.Join(dir, "curves"))
+	if err != nil || len(names) != 1 || names[0] != "pricing_2024" {
+		t.Fatalf("Expected the pricing_2024 curve to load, got %v (%v)", names, err)
+	}
+
+	policy := models.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05}
+	byRate, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy.InterestRate, policy.YieldCurve = 0, "pricing_2024"
+	byCurve, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(byCurve.GrossPremium-byRate.GrossPremium) > 1e-9 {
+		t.Errorf("Expected a flat 5%% curve to price like a 5%% rate, got %f vs %f", byCurve.GrossPremium, byRate.GrossPremium)
+	}
+
+	// Updating the file and reloading changes every quote on the curve
+	if err := os.WriteFile(curvePath, []byte("term\trate\n1\t0.03\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReloadTables(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, _ := service.CalculatePremium(&policy); reloaded.GrossPremium <= byCurve.GrossPremium {
+		t.Errorf("Expected the lower reloaded curve to raise the premium, got %f vs %f", reloaded.GrossPremium, byCurve.GrossPremium)
+	}
+
+	policy.YieldCurve = "statutory_1990"
+	if _, err := service.CalculatePremium(&policy); err == nil {
+		t.Error("Expected an unknown curve to be rejected")
+	}
+}
+
+func TestSelectTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "select.csv")
+	contents := "age\tq[x]\tq[x]+1\tqx\n"
+	for age := 20; age <= 80; age++ {
+		contents += strconv.Itoa(age) + "\t0.001\t0.002\t0.004\n"
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewActuarialService()
+	if err := service.LoadSelectTable("select", path); err != nil {
+		t.Fatal(err)
+	}
+	if metadata := service.GetTableMetadata(); len(metadata) != 1 || metadata[0].SelectPeriod != 2 || metadata[0].MinAge != 20 {
+		t.Fatalf("Expected metadata for a 2 year select table from age 20, got %+v", metadata)
+	}
+
+	policy := models.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, Gender: "select"}
+	result, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The reserve a year in uses q[40]+1 for one year and ultimate rates after
+	// that, so it must match a reserve on the policy's own select table
+	table, err := actuarial.LoadSelectTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actuarialPolicy := actuarial.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, ProductType: "term_life"}
+	netPremium := actuarial.CalculateTermLifeNetPremium(&actuarialPolicy, table.PolicyTable(40))
+	want := actuarial.CalculateTermLifeReserveSchedule(&actuarialPolicy, table.PolicyTable(40), netPremium)
+	if math.Abs(result.NetPremium-netPremium) > 1e-9 || math.Abs(result.ReserveSchedule[1]-want[1]) > 1e-9 {
+		t.Errorf("Expected net premium %f and duration 1 reserve %f, got %f and %f", netPremium, want[1], result.NetPremium, result.ReserveSchedule[1])
+	}
+
+	if err := service.ReloadTables(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := service.CalculatePremium(&policy); err != nil || reloaded.NetPremium != result.NetPremium {
+		t.Errorf("Expected the select table to survive a reload, got %f (%v)", reloaded.NetPremium, err)
+	}
+}
+
+func TestDefaultBases(t *testing.T) {
+	dir := t.TempDir()
+	service := NewActuarialService()
+	for _, table := range []string{"male", "female"} {
+		path := filepath.Join(dir, table+".csv")
+		writeTable(t, path, "0.002")
+		if err := service.LoadMortalityTable(table, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := filepath.Join(dir, "bases.json")
+	bases := `[{"table": "female", "product_type": "whole_life",
+		"basis": {"name": "female_whole_life", "interest_rate": 0.03, "expenses": {"initial_expense_rate": 0.02}}}]`
+	if err := os.WriteFile(config, []byte(bases), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := service.LoadDefaultBases(config); err != nil || count != 1 {
+		t.Fatalf("Expected one basis loaded, got %d (%v)", count, err)
+	}
+
+	female := models.Policy{Age: 40, Term: 60, CoverageAmount: 100000, Gender: "female", ProductType: "whole_life"}
+	result, err := service.CalculatePremium(&female)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BasisSource != BasisFromDefault || result.Basis != "female_whole_life" ||
+		result.ResolvedBasis == nil || *result.ResolvedBasis.InterestRate != 0.03 {
+		t.Fatalf("Expected the female whole life basis, got %q from %q: %+v", result.Basis, result.BasisSource, result.ResolvedBasis)
+	}
+	if result.ExpenseDetails["initial_expense_rate"] != 0.02 {
+		t.Errorf("Expected the basis expenses, got %v", result.ExpenseDetails)
+	}
+
+	// A policy that sends its own interest rate keeps it, and the default
+	// fills in the rest
+	ownRate := female
+	ownRate.InterestRate = 0.05
+	result, err = service.CalculatePremium(&ownRate)
+	if err != nil || result.BasisSource != BasisFromDefault || result.ResolvedBasis.InterestRate != nil || strings.Contains(strings.Join(result.Warnings, ";"), "basis interest rate") {
+		t.Fatalf("Expected the policy's own rate with no warning, got %+v %v (%v)", result.ResolvedBasis, result.Warnings, err)
+	}
+	if result.ExpenseDetails["initial_expense_rate"] != 0.02 {
+		t.Errorf("Expected the basis expenses alongside the policy's rate, got %v", result.ExpenseDetails)
+	}
+	onRequest, err := service.CalculatePremiumWithBasis(&ownRate, &models.Basis{Expenses: &models.ExpenseStructure{InitialExpenseRate: 0.02}})
+	if err != nil || math.Abs(onRequest.NetPremium-result.NetPremium) > 1e-9 {
+		t.Errorf("Expected to be priced at the policy's 5%%, got %f vs %f (%v)", result.NetPremium, onRequest.NetPremium, err)
+	}
+
+	// Other tables and products keep their own assumptions
+	for _, policy := range []models.Policy{
+		{Age: 40, Term: 60, CoverageAmount: 100000, InterestRate: 0.05, Gender: "male", ProductType: "whole_life"},
+		{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, Gender: "female"},
+	} {
+		if result, err := service.CalculatePremium(&policy); err != nil || result.ResolvedBasis != nil {
+			t.Errorf("%s %s: expected no default basis, got %+v (%v)", policy.Gender, policy.ProductType, result.ResolvedBasis, err)
+		}
+	}
+
+	// A basis sent with the request wins
+	rate := 0.04
+	result, err = service.CalculatePremiumWithBasis(&female, &models.Basis{InterestRate: &rate})
+	if err != nil || result.BasisSource != BasisFromRequest || *result.ResolvedBasis.InterestRate != 0.04 {
+		t.Errorf("Expected the request's basis, got %+v from %q (%v)", result.ResolvedBasis, result.BasisSource, err)
+	}
+
+	if err := service.SetDefaultBasis(models.DefaultBasis{Table: "male", ProductType: "endowment"}); err == nil {
+		t.Error("Expected an unknown product to be rejected")
+	}
+}
+
+func TestAdjustedTableCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "male.csv")
+	writeTable(t, path, "0.001")
+
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+	cached := func() int {
+		service.mu.RLock()
+		defer service.mu.RUnlock()
+		if service.adjusted == nil {
+			return 0
+		}
+		return len(service.adjusted.entries)
+	}
+
+	policy := models.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, SmokerStatus: "non_smoker"}
+	first, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An older policy underwritten the same way shares the table
+	older := policy
+	older.Age = 50
+	if _, err := service.CalculatePremium(&older); err != nil {
+		t.Fatal(err)
+	}
+	if cached() != 1 {
+		t.Fatalf("Expected policies underwritten alike to share one table, got %d", cached())
+	}
+
+	smoker := policy
+	smoker.SmokerStatus = "smoker"
+	smokerResult, err := service.CalculatePremium(&smoker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached() != 2 || smokerResult.NetPremium <= first.NetPremium {
+		t.Fatalf("Expected a smoker to get their own, dearer table, got %d tables and %f vs %f", cached(), smokerResult.NetPremium, first.NetPremium)
+	}
+
+	// A basis with its own underwriting prices without the cache, and agrees with it
+	again, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncached, err := service.CalculatePremiumWithBasis(&policy, &models.Basis{Underwriting: &models.UnderwritingConfig{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.NetPremium != first.NetPremium || uncached.NetPremium != first.NetPremium {
+		t.Errorf("Expected the cached table to price like a fresh one, got %f, %f and %f", first.NetPremium, again.NetPremium, uncached.NetPremium)
+	}
+
+	// A reload starts the cache again, so the new rates are used
+	writeTable(t, path, "0.002")
+	if err := service.ReloadTables(); err != nil {
+		t.Fatal(err)
+	}
+	if cached() != 0 {
+		t.Fatalf("Expected a reload to drop the cached tables, got %d", cached())
+	}
+	reloaded, err := service.CalculatePremium(&policy)
+	if err != nil || reloaded.NetPremium <= first.NetPremium {
+		t.Errorf("Expected the reloaded table to be used, got %f (%v)", reloaded.NetPremium, err)
+	}
+
+	// As does new underwriting config
+	config := filepath.Join(dir, "underwriting.json")
+	if err := os.WriteFile(config, []byte(`{"smoker_status": {"non_smoker": 0.5}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.LoadUnderwritingConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	if cached() != 0 {
+		t.Fatalf("Expected new underwriting config to drop the cached tables, got %d", cached())
+	}
+	if rerated, err := service.CalculatePremium(&policy); err != nil || rerated.NetPremium >= reloaded.NetPremium {
+		t.Errorf("Expected the lower non-smoker multiplier to be used, got %f vs %f (%v)", rerated.NetPremium, reloaded.NetPremium, err)
+	}
+}
+
+func TestLoadSelectTables(t *testing.T) {
+	service := NewActuarialService()
+	names, err := service.LoadSelectTables("../data/select")
+	if err != nil || len(names) != 1 || names[0] != "male_select" {
+		t.Fatalf("Expected the sample select table to load, got %v (%v)", names, err)
+	}
+	if names, err := service.LoadSelectTables(filepath.Join(t.TempDir(), "missing")); err != nil || len(names) != 0 {
+		t.Errorf("Expected a missing directory to load nothing, got %v (%v)", names, err)
+	}
+
+	// Newly selected lives are charged less than the ultimate rates would
+	selected, err := service.CalculatePremium(&models.Policy{Age: 40, Term: 2, CoverageAmount: 100000, InterestRate: 0.05, Gender: "male_select"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.LoadMortalityTable("male", "../data/male.csv"); err != nil {
+		t.Fatal(err)
+	}
+	ultimate, err := service.CalculatePremium(&models.Policy{Age: 40, Term: 2, CoverageAmount: 100000, InterestRate: 0.05, Gender: "male"})
+	if err != nil || selected.NetPremium >= ultimate.NetPremium {
+		t.Errorf("Expected the select premium below the ultimate one, got %f vs %f (%v)", selected.NetPremium, ultimate.NetPremium, err)
+	}
+}
+
+func TestLoadMultiDecrementTables(t *testing.T) {
+	dir := t.TempDir()
+	contents := "age\tqx\tqi\tqw\n"
+	for age := 0; age <= 100; age++ {
+		contents += strconv.Itoa(age) + "\t0.002\t0.001\t0.05\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Group.csv"), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	service := NewActuarialService()
+	names, err := service.LoadMultiDecrementTables(dir)
+	if err != nil || len(names) != 1 || names[0] != "group" {
+		t.Fatalf("Expected the group table to load, got %v (%v)", names, err)
+	}
+	if names, err := service.LoadMultiDecrementTables(filepath.Join(dir, "missing")); err != nil || len(names) != 0 {
+		t.Errorf("Expected a missing directory to load nothing, got %v (%v)", names, err)
+	}
+
+	// Selected by name, like a mortality table
+	policy := models.Policy{Age: 40, Term: 10, CoverageAmount: 100000, InterestRate: 0.05, Gender: "group"}
+	result, err := service.CalculatePremium(&policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NetPremium <= 0 {
+		t.Errorf("Expected a premium on the multi-decrement table, got %f", result.NetPremium)
+	}
+
+	// The schedule would start at issue, so policies already in force are turned away
+	inForce := policy
+	inForce.InForceDuration = 3
+	if _, err := service.CalculatePremium(&inForce); err == nil || !strings.Contains(err.Error(), "in-force") {
+		t.Errorf("Expected an in-force policy on a multi-decrement table to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+)
+
+// LoadUnderwritingConfig reads smoker/health multipliers from a JSON file, e.g.
+//
+//	{"smoker_status": {"smoker": 1.8}, "health_rating": {"preferred": 0.7},
+//	 "impairments": {"diabetic": {"rating_curve": [{"age": 30, "multiplier": 3}, {"age": 70, "multiplier": 1.5}]}}}
+//
+// Entries in the file replace the matching defaults; anything not listed keeps
+// its default multiplier. Impairments registers a table or rating curve per
+// impairment; a policy whose impairment isn't registered is rated substandard.
+func (s *ActuarialService) LoadUnderwritingConfig(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("could not read underwriting config: %w", err)
+	}
+	var config models.UnderwritingConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("underwriting config %s is not valid JSON: %w", filePath, err)
+	}
+	if err := validateUnderwritingConfig(config); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.underwriting = mergeUnderwritingConfig(actuarial.DefaultUnderwritingConfig(), &config)
+	s.adjusted = nil
+	return nil
+}
+
+// underwritingFor returns the multipliers to use for a calculation: the service's
+// configuration with any overrides from the request's basis on top
+func (s *ActuarialService) underwritingFor(basis *models.Basis) actuarial.UnderwritingConfig {
+	s.mu.RLock()
+	config := s.underwriting
+	s.mu.RUnlock()
+
+	if basis == nil {
+		return config
+	}
+	return mergeUnderwritingConfig(config, basis.Underwriting)
+}
+
+// mergeUnderwritingConfig copies base and replaces the entries set in overrides
+func mergeUnderwritingConfig(base actuarial.UnderwritingConfig, overrides *models.UnderwritingConfig) actuarial.UnderwritingConfig {
+	merged := actuarial.UnderwritingConfig{
+		SmokerStatus: maps.Clone(base.SmokerStatus),
+		HealthRating: maps.Clone(base.HealthRating),
+		Impairments:  maps.Clone(base.Impairments),
+	}
+	if overrides == nil {
+		return merged
+	}
+	for name, impairment := range overrides.Impairments {
+		if merged.Impairments == nil {
+			merged.Impairments = make(map[string]actuarial.ImpairmentBasis)
+		}
+		merged.Impairments[name] = convertImpairmentBasis(impairment)
+	}
+	if merged.SmokerStatus == nil {
+		merged.SmokerStatus = make(map[string]float64)
+	}
+	if merged.HealthRating == nil {
+		merged.HealthRating = make(map[string]float64)
+	}
+	maps.Copy(merged.SmokerStatus, overrides.SmokerStatus)
+	maps.Copy(merged.HealthRating, overrides.HealthRating)
+	return merged
+}
+
+func validateUnderwritingConfig(config models.UnderwritingConfig) error {
+	for status, multiplier := range config.SmokerStatus {
+		if multiplier <= 0 {
+			return fmt.Errorf("smoker status '%s' multiplier must be positive", status)
+		}
+	}
+	for rating, multiplier := range config.HealthRating {
+		if multiplier <= 0 {
+			return fmt.Errorf("health rating '%s' multiplier must be positive", rating)
+		}
+	}
+	for name, impairment := range config.Impairments {
+		if err := validateImpairmentBasis(impairment); err != nil {
+			return fmt.Errorf("impairment '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validateImpairmentBasis(impairment models.ImpairmentBasis) error {
+	if len(impairment.Table) == 0 && len(impairment.RatingCurve) == 0 {
+		return fmt.Errorf("needs a table or a rating curve")
+	}
+	for age, qx := range impairment.Table {
+		if qx < 0 || qx > 1 {
+			return fmt.Errorf("table qx at age %d must be between 0 and 1", age)
+		}
+	}
+	for i, point := range impairment.RatingCurve {
+		if point.Multiplier <= 0 {
+			return fmt.Errorf("rating curve multiplier at age %d must be positive", point.Age)
+		}
+		if i > 0 && point.Age <= impairment.RatingCurve[i-1].Age {
+			return fmt.Errorf("rating curve ages must be in increasing order")
+		}
+	}
+	return nil
+}
+
+func convertImpairmentBasis(impairment models.ImpairmentBasis) actuarial.ImpairmentBasis {
+	curve := make([]actuarial.RatingPoint, len(impairment.RatingCurve))
+	for i, point := range impairment.RatingCurve {
+		curve[i] = actuarial.RatingPoint{Age: point.Age, Multiplier: point.Multiplier}
+	}
+	return actuarial.ImpairmentBasis{Table: slices.Clone(impairment.Table), RatingCurve: curve}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"fmt"
+)
+
+// EquivalentLevelPremium finds the level annual premium that funds the same
+// universal life benefits as an irregular stream of premiums, using the
+// account value projection and the server's solver settings
+func (s *ActuarialService) EquivalentLevelPremium(req models.LevelPremiumRequest) (models.LevelPremiumResult, error) {
+	table, err := s.GetMortalityTable(req.Gender)
+	if err != nil {
+		return models.LevelPremiumResult{}, err
+	}
+	tableRange := s.getTableRange(req.Gender, table)
+	if err := checkAgeInRange(req.Age, tableRange); err != nil {
+		return models.LevelPremiumResult{}, err
+	}
+	if err := validateLevelPremiumRequest(req, tableRange); err != nil {
+		return models.LevelPremiumResult{}, err
+	}
+
+	policy := actuarial.UniversalLifePolicy{
+		Age:          req.Age,
+		Term:         req.Term,
+		SumAssured:   req.SumAssured,
+		Premiums:     req.Premiums,
+		CreditedRate: req.CreditedRate,
+		PremiumLoad:  req.PremiumLoad,
+		AdminCharge:  req.AdminCharge,
+	}
+	if req.CorridorFactors != nil {
+		policy.CorridorFactors = make([]actuarial.CorridorFactor, len(req.CorridorFactors))
+		for i, factor := range req.CorridorFactors {
+			policy.CorridorFactors[i] = actuarial.CorridorFactor{Age: factor.Age, Factor: factor.Factor}
+		}
+	}
+	solved, err := actuarial.SolveEquivalentLevelPremium(policy, table, req.Target, s.solverConfig())
+	if err != nil {
+		return models.LevelPremiumResult{}, err
+	}
+
+	result := models.LevelPremiumResult{
+		Target:                 solved.Target,
+		LevelPremium:           solved.LevelPremium,
+		IrregularAccountValues: accountValues(solved.Irregular),
+		LevelAccountValues:     accountValues(solved.Level),
+	}
+	if solved.Irregular.Lapsed {
+		lapseYear := solved.Irregular.LapseYear + 1
+		result.IrregularLapseYear = &lapseYear
+	}
+	return result, nil
+}
+
+func validateLevelPremiumRequest(req models.LevelPremiumRequest, tableRange models.TableMetadata) error {
+	if req.Term <= 0 {
+		return fmt.Errorf("term must be positive")
+	}
+	// The projection needs a rate for every year of cover
+	if lastAge := req.Age + req.Term - 1; lastAge > tableRange.MaxAge {
+		return fmt.Errorf("cover to age %d runs past the end of table '%s' at %d", lastAge+1, tableRange.Name, tableRange.MaxAge)
+	}
+	if req.SumAssured <= 0 {
+		return fmt.Errorf("sum assured must be positive")
+	}
+	if len(req.Premiums) == 0 {
+		return fmt.Errorf("premiums are required")
+	}
+	for i, premium := range req.Premiums {
+		if premium < 0 {
+			return fmt.Errorf("premium %d must not be negative", i+1)
+		}
+	}
+	if req.CreditedRate <= -1 || req.CreditedRate > 1 {
+		return fmt.Errorf("credited rate must be above -1 and at most 1")
+	}
+	if req.PremiumLoad < 0 || req.PremiumLoad >= 1 {
+		return fmt.Errorf("premium load must be at least 0 and below 1")
+	}
+	if req.AdminCharge < 0 {
+		return fmt.Errorf("admin charge must not be negative")
+	}
+	for i, factor := range req.CorridorFactors {
+		if factor.Factor <= 0 {
+			return fmt.Errorf("corridor factor at age %d must be positive", factor.Age)
+		}
+		if i > 0 && factor.Age <= req.CorridorFactors[i-1].Age {
+			return fmt.Errorf("corridor factors must be in increasing order of age")
+		}
+	}
+	return nil
+}
+
+// accountValues is the account value at the end of each projected year
+func accountValues(projection actuarial.AccountValueProjection) []float64 {
+	values := make([]float64, len(projection.Years))
+	for i, year := range projection.Years {
+		values[i] = year.AccountValue
+	}
+	return values
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"cmp"
+	"fmt"
+	"math"
+)
+
+// deficiencyTolerance ignores shortfalls that round away to nothing in currency
+const deficiencyTolerance = 0.005
+
+// bestEstimateValuation is a policy priced as usual and set up to be valued
+// again on a best-estimate table
+type bestEstimateValuation struct {
+	priced       models.PremiumCalculation
+	policy       actuarial.Policy
+	tableName    string
+	bestEstimate actuarial.MortalityTable // Underwritten, shocked and extended as the policy asks
+}
+
+// prepareBestEstimate prices a policy, then sets up its best-estimate table -
+// its own table unless another is named - with its underwriting applied and
+// every rate shocked
+func (s *ActuarialService) prepareBestEstimate(policy models.Policy, tableName string, mortalityShock float64) (bestEstimateValuation, error) {
+	if mortalityShock <= -1 {
+		return bestEstimateValuation{}, fmt.Errorf("mortality shock must be above -1")
+	}
+	priced, err := s.CalculatePremium(&policy)
+	if err != nil {
+		return bestEstimateValuation{}, err
+	}
+	if len(priced.ReserveSchedule) == 0 {
+		return bestEstimateValuation{}, fmt.Errorf("best-estimate valuation is not available for %s", priced.ProductType)
+	}
+
+	effective := normalizePolicy(&policy)
+	bestEstimateName := effective.Gender
+	if tableName != "" {
+		bestEstimateName = resolveTableName(tableName)
+	}
+
+	tables := s.currentTables()
+	lookup := effective
+	lookup.Gender = bestEstimateName
+	baseTable, err := tables.policyMortalityTable(&lookup)
+	if err != nil {
+		return bestEstimateValuation{}, err
+	}
+	yieldCurve, err := tables.yieldCurve(effective.YieldCurve)
+	if err != nil {
+		return bestEstimateValuation{}, err
+	}
+
+	actuarialPolicy := s.convertToActuarialPolicy(&effective)
+	actuarialPolicy.YieldCurve = yieldCurve
+	bestEstimate := actuarial.ApplyUnderwritingFactorsWithConfig(&actuarialPolicy, baseTable, s.underwritingFor(nil))
+	for age, rate := range bestEstimate {
+		bestEstimate[age] = math.Min(rate*(1+mortalityShock), 1.0)
+	}
+	if actuarialPolicy.ProductType == "whole_life" && actuarialPolicy.MortalityExtrapolation != "" {
+		bestEstimate, _ = actuarial.ExtendMortalityTable(bestEstimate, actuarialPolicy.MortalityExtrapolation, actuarialPolicy.UltimateAge)
+	}
+	return bestEstimateValuation{priced: priced, policy: actuarialPolicy, tableName: bestEstimateName, bestEstimate: bestEstimate}, nil
+}
+
+// GrossPremiumValuation prices a policy as usual, then values it again on a
+// gross premium basis - the premium charged, the product's expenses and a
+// best-estimate table, optionally shocked - and flags the durations where the
+// net premium reserve is not enough
+func (s *ActuarialService) GrossPremiumValuation(req models.GrossPremiumValuationRequest) (models.GrossPremiumValuationResult, error) {
+	valuation, err := s.prepareBestEstimate(req.Policy, req.BestEstimateTable, req.MortalityShock)
+	if err != nil {
+		return models.GrossPremiumValuationResult{}, err
+	}
+	priced, actuarialPolicy := valuation.priced, &valuation.policy
+
+	grossReserves := actuarial.GrossPremiumValuation(actuarialPolicy, valuation.bestEstimate,
+		priced.GrossPremium, actuarial.ExpensesForProduct(actuarialPolicy.ProductType))
+	grossReserves = actuarial.InForceReserves(actuarialPolicy, grossReserves)
+	deficiency := actuarial.ReserveDeficiency(priced.ReserveSchedule, grossReserves)
+
+	deficientYears := []int{}
+	for year, shortfall := range deficiency {
+		if shortfall > deficiencyTolerance {
+			deficientYears = append(deficientYears, actuarialPolicy.InForceDuration+year)
+		}
+	}
+
+	return models.GrossPremiumValuationResult{
+		ProductType:         priced.ProductType,
+		BestEstimateTable:   valuation.tableName,
+		MortalityShock:      req.MortalityShock,
+		GrossPremium:        priced.GrossPremium,
+		NetPremiumReserve:   priced.ReserveSchedule,
+		GrossPremiumReserve: grossReserves,
+		Deficiency:          deficiency,
+		DeficientYears:      deficientYears,
+		Adequate:            len(deficientYears) == 0,
+	}, nil
+}
+
+// IFRS17Valuation values a policy's fulfilment cash flows under IFRS 17: the
+// best-estimate liability on the gross premium basis GrossPremiumValuation
+// uses, plus a risk adjustment for the uncertainty in the claims
+func (s *ActuarialService) IFRS17Valuation(req models.IFRS17Request) (models.IFRS17Result, error) {
+	config := actuarial.RiskAdjustmentConfig{
+		Method:          req.RiskAdjustment.Method,
+		Percentage:      req.RiskAdjustment.Percentage,
+		ConfidenceLevel: req.RiskAdjustment.ConfidenceLevel,
+	}
+	if err := config.Validate(); err != nil {
+		return models.IFRS17Result{}, err
+	}
+	valuation, err := s.prepareBestEstimate(req.Policy, req.BestEstimateTable, req.MortalityShock)
+	if err != nil {
+		return models.IFRS17Result{}, err
+	}
+	policy := &valuation.policy
+
+	cashFlows := actuarial.CalculateFulfilmentCashFlows(policy, valuation.bestEstimate,
+		valuation.priced.GrossPremium, actuarial.ExpensesForProduct(policy.ProductType))
+	riskAdjustment := actuarial.RiskAdjustment(cashFlows, config)
+	return models.IFRS17Result{
+		ProductType:             valuation.priced.ProductType,
+		BestEstimateTable:       valuation.tableName,
+		MortalityShock:          req.MortalityShock,
+		ValuationDuration:       policy.InForceDuration,
+		GrossPremium:            valuation.priced.GrossPremium,
+		PremiumValue:            cashFlows.PremiumValue,
+		ClaimsValue:             cashFlows.ClaimsValue,
+		ExpenseValue:            cashFlows.ExpenseValue,
+		BestEstimateLiability:   cashFlows.BestEstimateLiability,
+		ClaimsStandardDeviation: cashFlows.ClaimsStandardDeviation,
+		RiskAdjustmentMethod:    cmp.Or(config.Method, actuarial.RiskAdjustmentPercentage),
+		RiskAdjustment:          riskAdjustment,
+		FulfilmentCashFlows:     cashFlows.BestEstimateLiability + riskAdjustment,
+	}, nil
+}
+
+// RevalueInForce values each in-force policy's reserve today on the prior basis
+// and on the new one, and totals the movement the change of assumptions
+// causes. Policies that can't be valued on either basis, or have no reserve,
+// are skipped and listed.
+func (s *ActuarialService) RevalueInForce(req models.RevaluationRequest) (models.RevaluationResult, error) {
+	if len(req.Policies) == 0 {
+		return models.RevaluationResult{}, fmt.Errorf("no policies provided")
+	}
+	if err := validateBasis(&req.Basis); err != nil {
+		return models.RevaluationResult{}, err
+	}
+	if err := validateBasis(req.PriorBasis); err != nil {
+		return models.RevaluationResult{}, fmt.Errorf("prior basis: %w", err)
+	}
+	conversion, err := newCurrencyConversion(req.Policies, req.ReportingCurrency, req.ExchangeRates)
+	if err != nil {
+		return models.RevaluationResult{}, err
+	}
+
+	result := models.RevaluationResult{
+		Policies:          make([]models.PolicyRevaluation, 0, len(req.Policies)),
+		MovementByProduct: make(map[string]float64),
+	}
+	for i, policy := range req.Policies {
+		label := policyLabel(&policy, i)
+		revaluation, err := s.revaluePolicy(&policy, req.PriorBasis, &req.Basis)
+		if err != nil {
+			result.SkippedPolicies = append(result.SkippedPolicies, models.SkippedPolicy{PolicyID: label, Reason: err.Error()})
+			continue
+		}
+		revaluation.PolicyID = label
+		result.Policies = append(result.Policies, revaluation)
+
+		rate := 1.0
+		if conversion != nil {
+			rate = conversion.rates[conversion.currencyOf(&policy)]
+		}
+		result.TotalPriorReserve += revaluation.PriorReserve * rate
+		result.TotalRevisedReserve += revaluation.RevisedReserve * rate
+		result.MovementByProduct[revaluation.ProductType] += revaluation.ReserveMovement * rate
+	}
+	if len(result.Policies) == 0 {
+		return models.RevaluationResult{}, fmt.Errorf("no valid policies found")
+	}
+
+	result.TotalReserveMovement = result.TotalRevisedReserve - result.TotalPriorReserve
+	result.ProfitFromChange = -result.TotalReserveMovement
+	if conversion != nil {
+		result.ReportingCurrency = conversion.reporting
+	}
+	return result, nil
+}
+
+// revaluePolicy values one policy's reserve today on both bases
+func (s *ActuarialService) revaluePolicy(policy *models.Policy, prior, revised *models.Basis) (models.PolicyRevaluation, error) {
+	before, err := s.CalculatePremiumWithBasis(policy, prior)
+	if err != nil {
+		return models.PolicyRevaluation{}, fmt.Errorf("on the prior basis: %w", err)
+	}
+	after, err := s.CalculatePremiumWithBasis(policy, revised)
+	if err != nil {
+		return models.PolicyRevaluation{}, fmt.Errorf("on the new basis: %w", err)
+	}
+	if len(before.ReserveSchedule) == 0 || len(after.ReserveSchedule) == 0 {
+		return models.PolicyRevaluation{}, fmt.Errorf("%s has no reserve to revalue", after.ProductType)
+	}
+	return models.PolicyRevaluation{
+		ProductType:     after.ProductType,
+		InForceDuration: policy.InForceDuration,
+		PriorReserve:    before.ReserveSchedule[0],
+		RevisedReserve:  after.ReserveSchedule[0],
+		ReserveMovement: after.ReserveSchedule[0] - before.ReserveSchedule[0],
+		ReserveSchedule: after.ReserveSchedule,
+	}, nil
+}
+
+// EmbeddedValue values a book of policies for the shareholders. Each policy
+// is profit tested at the risk discount rate from its in-force duration, on
+// the reserves it is priced with; the profits to come are its value of
+// in-force, and a share of its reserve and sum at risk is the capital it
+// requires. Policies that can't be priced, or have no profit test, are
+// skipped and listed.
+func (s *ActuarialService) EmbeddedValue(req models.EmbeddedValueRequest) (models.EmbeddedValueResult, error) {
+	if len(req.Policies) == 0 {
+		return models.EmbeddedValueResult{}, fmt.Errorf("no policies provided")
+	}
+	if !(req.RiskDiscountRate > 0 && req.RiskDiscountRate <= 1) {
+		return models.EmbeddedValueResult{}, fmt.Errorf("risk discount rate must be above 0 and at most 1")
+	}
+	if math.IsNaN(req.NetAssetValue) || math.IsInf(req.NetAssetValue, 0) {
+		return models.EmbeddedValueResult{}, fmt.Errorf("net asset value must be a finite amount")
+	}
+	capital := actuarial.DefaultCapitalBasis
+	if req.RequiredCapital != nil {
+		capital = *convertRequiredCapital(req.RequiredCapital)
+	}
+	if err := capital.Validate(); err != nil {
+		return models.EmbeddedValueResult{}, err
+	}
+	conversion, err := newCurrencyConversion(req.Policies, req.ReportingCurrency, req.ExchangeRates)
+	if err != nil {
+		return models.EmbeddedValueResult{}, err
+	}
+
+	result := models.EmbeddedValueResult{
+		Policies:                make([]models.PolicyEmbeddedValue, 0, len(req.Policies)),
+		RiskDiscountRate:        req.RiskDiscountRate,
+		ValueOfInForceByProduct: make(map[string]float64),
+	}
+	requiredCapital, valueOfInForce := 0.0, 0.0
+	for i, policy := range req.Policies {
+		label := policyLabel(&policy, i)
+		value, err := s.policyEmbeddedValue(policy, req.RiskDiscountRate, capital)
+		if err != nil {
+			result.SkippedPolicies = append(result.SkippedPolicies, models.SkippedPolicy{PolicyID: label, Reason: err.Error()})
+			continue
+		}
+		value.PolicyID = label
+		result.Policies = append(result.Policies, value)
+
+		rate := 1.0
+		if conversion != nil {
+			rate = conversion.rates[conversion.currencyOf(&policy)]
+		}
+		result.TotalReserve += value.Reserve * rate
+		requiredCapital += value.RequiredCapital * rate
+		valueOfInForce += value.ValueOfInForce * rate
+		result.ValueOfInForceByProduct[value.ProductType] += value.ValueOfInForce * rate
+	}
+	if len(result.Policies) == 0 {
+		return models.EmbeddedValueResult{}, fmt.Errorf("no valid policies found")
+	}
+
+	embeddedValue := actuarial.CalculateEmbeddedValue(req.NetAssetValue, requiredCapital, valueOfInForce)
+	result.NetAssetValue = embeddedValue.NetAssetValue
+	result.RequiredCapital = embeddedValue.RequiredCapital
+	result.FreeSurplus = embeddedValue.FreeSurplus
+	result.ValueOfInForce = embeddedValue.ValueOfInForce
+	result.EmbeddedValue = embeddedValue.EmbeddedValue
+	if conversion != nil {
+		result.ReportingCurrency = conversion.reporting
+	}
+	return result, nil
+}
+
+// policyEmbeddedValue profit tests one policy from today at the risk discount rate
+func (s *ActuarialService) policyEmbeddedValue(policy models.Policy, riskDiscountRate float64, capital actuarial.CapitalBasis) (models.PolicyEmbeddedValue, error) {
+	policy.RiskDiscountRate = riskDiscountRate
+	priced, err := s.CalculatePremium(&policy)
+	if err != nil {
+		return models.PolicyEmbeddedValue{}, err
+	}
+	if priced.ProfitTest == nil || len(priced.ReserveSchedule) == 0 {
+		return models.PolicyEmbeddedValue{}, fmt.Errorf("%s has no profit test to value", priced.ProductType)
+	}
+	reserve := priced.ReserveSchedule[0]
+	return models.PolicyEmbeddedValue{
+		ProductType:     priced.ProductType,
+		InForceDuration: policy.InForceDuration,
+		Reserve:         reserve,
+		RequiredCapital: capital.RequiredCapital(reserve, policy.CoverageAmount),
+		ValueOfInForce:  priced.ProfitTest.ProfitValue,
+	}, nil
+}
+
+// PaidUpAnalysis prices a whole life policy and finds the earliest duration at
+// which its reserve alone, with no further premiums, pays for the cover to the
+// end of the table
+func (s *ActuarialService) PaidUpAnalysis(policy *models.Policy) (models.PaidUpResult, error) {
+	request := *policy
+	request.IncludePaidUp = true
+	priced, err := s.CalculatePremium(&request)
+	if err != nil {
+		return models.PaidUpResult{}, err
+	}
+	if priced.PaidUp == nil {
+		return models.PaidUpResult{}, fmt.Errorf("paid-up analysis is not available for %s", priced.ProductType)
+	}
+
+	result := models.PaidUpResult{
+		ProductType:        priced.ProductType,
+		NetPremium:         priced.NetPremium,
+		PremiumPayingYears: priced.PremiumPayingYears,
+		SelfSustaining:     priced.PaidUp.Duration != nil,
+		PaidUpDuration:     priced.PaidUp.Duration,
+		ReserveSchedule:    priced.ReserveSchedule,
+		CostOfCover:        priced.PaidUp.CostOfCover,
+		PaidUpSumAssured:   priced.PaidUp.PaidUpSumAssured,
+	}
+	if result.SelfSustaining {
+		age := policy.Age + *result.PaidUpDuration
+		result.PaidUpAge = &age
+	}
+	return result, nil
+}
+
+// FractionalReserve prices a policy as usual and interpolates its reserve
+// schedule to a duration between anniversaries, for surrenders mid-year
+func (s *ActuarialService) FractionalReserve(req models.FractionalReserveRequest) (models.FractionalReserve, error) {
+	priced, err := s.CalculatePremium(&req.Policy)
+	if err != nil {
+		return models.FractionalReserve{}, err
+	}
+	if len(priced.ReserveSchedule) == 0 {
+		return models.FractionalReserve{}, fmt.Errorf("reserves are not available for %s", priced.ProductType)
+	}
+
+	effective := normalizePolicy(&req.Policy)
+	actuarialPolicy := s.convertToActuarialPolicy(&effective)
+	reserve, err := actuarial.ReserveAtDuration(&actuarialPolicy, priced.ReserveSchedule, priced.NetPremium, req.Duration, req.Method)
+	if err != nil {
+		return models.FractionalReserve{}, err
+	}
+	return models.FractionalReserve{
+		Duration:        reserve.Duration,
+		Method:          reserve.Method,
+		Reserve:         reserve.Reserve,
+		ReserveAtStart:  reserve.ReserveAtStart,
+		ReserveAtEnd:    reserve.ReserveAtEnd,
+		UnearnedPremium: reserve.UnearnedPremium,
+		NetPremium:      priced.NetPremium,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"actuworry/backend/actuarial"
+	"actuworry/backend/models"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestZeroNetPremium(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "male.csv")
+	writeTable(t, path, "0")
+	service := NewActuarialService()
+	if err := service.LoadMortalityTable("male", path); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := models.Policy{Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05}
+	if _, err := service.CalculatePremium(&policy); !errors.Is(err, actuarial.ErrZeroNetPremium) {
+		t.Errorf("Expected a zero net premium to be an error, got %v", err)
+	}
+	if _, err := service.CalculatePremiumWithBasis(&policy, &models.Basis{ZeroPremium: "ignore"}); err == nil {
+		t.Error("Expected unknown zero premium handling to be rejected")
+	}
+	result, err := service.CalculatePremiumWithBasis(&policy, &models.Basis{ZeroPremium: actuarial.ZeroPremiumWarn})
+	if err != nil || result.NetPremium != 0 || result.GrossPremium <= 0 {
+		t.Errorf("Expected an expense-only premium with a warning, got %+v (%v)", result, err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build sqlite
+
+package storage
+
+// The pure-Go SQLite driver, registered as "sqlite". It is only linked into
+// builds with -tags sqlite, so the default build stays free of the dependency
+// and can run stateless.
+import _ "modernc.org/sqlite"
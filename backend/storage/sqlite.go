@@ -0,0 +1,89 @@
+// Package storage keeps calculated quotes in a SQL database for audit and
+// retrieval. It uses database/sql, so it works with whichever SQLite driver
+// the binary is built with; see driver_sqlite.go.
+package storage
+
+import (
+	"actuworry/backend/models"
+	"actuworry/backend/services"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SQLiteDriver is the database/sql driver name quotes are opened with
+const SQLiteDriver = "sqlite"
+
+const createQuotesTable = `CREATE TABLE IF NOT EXISTS quotes (
+	id         TEXT PRIMARY KEY,
+	created_at TEXT NOT NULL,
+	policy     TEXT NOT NULL,
+	result     TEXT NOT NULL
+)`
+
+// SQLiteQuoteStore is a services.QuoteStore backed by a SQLite file. The
+// policy and result are stored as JSON, so new fields on either need no
+// schema change.
+type SQLiteQuoteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteQuoteStore opens (creating if need be) the quote database at path
+func OpenSQLiteQuoteStore(path string) (*SQLiteQuoteStore, error) {
+	db, err := sql.Open(SQLiteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open quote database (is the server built with -tags sqlite?): %w", err)
+	}
+	if _, err := db.Exec(createQuotesTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create quotes table: %w", err)
+	}
+	return &SQLiteQuoteStore{db: db}, nil
+}
+
+// Close closes the database
+func (store *SQLiteQuoteStore) Close() error {
+	return store.db.Close()
+}
+
+// SaveQuote records a quote
+func (store *SQLiteQuoteStore) SaveQuote(quote models.Quote) error {
+	policy, err := json.Marshal(quote.Policy)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(quote.Result)
+	if err != nil {
+		return err
+	}
+	_, err = store.db.Exec(`INSERT INTO quotes (id, created_at, policy, result) VALUES (?, ?, ?, ?)`,
+		quote.ID, quote.CreatedAt.UTC().Format(time.RFC3339Nano), string(policy), string(result))
+	return err
+}
+
+// Quote returns the quote with the ID, or services.ErrQuoteNotFound
+func (store *SQLiteQuoteStore) Quote(id string) (models.Quote, error) {
+	var createdAt, policy, result string
+	err := store.db.QueryRow(`SELECT created_at, policy, result FROM quotes WHERE id = ?`, id).
+		Scan(&createdAt, &policy, &result)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Quote{}, services.ErrQuoteNotFound
+	}
+	if err != nil {
+		return models.Quote{}, err
+	}
+
+	quote := models.Quote{ID: id}
+	if quote.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return models.Quote{}, fmt.Errorf("quote %s has a bad timestamp: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(policy), &quote.Policy); err != nil {
+		return models.Quote{}, fmt.Errorf("quote %s has a bad policy: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(result), &quote.Result); err != nil {
+		return models.Quote{}, fmt.Errorf("quote %s has a bad result: %w", id, err)
+	}
+	return quote, nil
+}
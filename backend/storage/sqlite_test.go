@@ -0,0 +1,60 @@
+//go:build sqlite
+
+package storage
+
+import (
+	"actuworry/backend/models"
+	"actuworry/backend/services"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteQuoteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+	store, err := OpenSQLiteQuoteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quote := models.Quote{
+		ID:        "abc123",
+		CreatedAt: time.Date(2026, 10, 16, 9, 30, 0, 123456789, time.FixedZone("CAT", 2*60*60)),
+		Policy:    models.Policy{PolicyID: "P-1", Age: 40, Term: 20, CoverageAmount: 100000, InterestRate: 0.05},
+		Result:    models.PremiumCalculation{QuoteID: "abc123", NetPremium: 1234.5, GrossPremium: 1500.25, ReserveSchedule: []float64{0, 812.3, 0}},
+	}
+	if err := store.SaveQuote(quote); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveQuote(quote); err == nil {
+		t.Error("Expected saving a second quote with the same ID to fail")
+	}
+
+	// The quote survives the database being closed and opened again
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = OpenSQLiteQuoteStore(path); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	saved, err := store.Quote("abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.CreatedAt.Equal(quote.CreatedAt) {
+		t.Errorf("Expected the quote created at %v, got %v", quote.CreatedAt, saved.CreatedAt)
+	}
+	if saved.Policy.PolicyID != "P-1" || saved.Policy.CoverageAmount != 100000 || saved.Policy.InterestRate != 0.05 {
+		t.Errorf("Expected the policy back as saved, got %+v", saved.Policy)
+	}
+	if saved.Result.GrossPremium != 1500.25 || len(saved.Result.ReserveSchedule) != 3 || saved.Result.ReserveSchedule[1] != 812.3 {
+		t.Errorf("Expected the result back as saved, got %+v", saved.Result)
+	}
+
+	if _, err := store.Quote("missing"); !errors.Is(err, services.ErrQuoteNotFound) {
+		t.Errorf("Expected ErrQuoteNotFound for an unknown ID, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+// Package version holds build information injected at link time, e.g.
+//
+//	go build -ldflags "-X actuworry/backend/version.Commit=$(git rev-parse --short HEAD)"
+package version
+
+// Commit is the git commit the binary was built from
+var Commit = "dev"
+
+// BuildTime is when the binary was built (UTC, RFC 3339)
+var BuildTime = "unknown"
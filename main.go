@@ -3,7 +3,7 @@ package main
 import (
 	"actuworry/backend/handlers"
 	"actuworry/backend/routes"
-	"actuworry/backend/services"
+	"actuworry/backend/server"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,19 +11,9 @@ import (
 )
 
 func main() {
-	// Initialize service
-	actuarialService := services.NewActuarialService()
-	
-	// Load mortality tables
-	tables := []string{"male", "female"}
-	for _, tableName := range tables {
-		filePath := fmt.Sprintf("backend/data/%s.csv", tableName)
-		if err := actuarialService.LoadMortalityTable(tableName, filePath); err != nil {
-			log.Fatalf("Failed to load mortality table %s: %v", tableName, err)
-		}
-		log.Printf("Successfully loaded mortality table: %s", tableName)
-	}
-	
+	// Load the tables and apply the environment's settings
+	actuarialService, shutdown := server.Setup()
+
 	// Initialize handlers
 	actuarialHandler := handlers.NewActuarialHandler(actuarialService)
 	
@@ -44,6 +34,8 @@ func main() {
 	fmt.Println("\n Server is ready to accept requests")
 	
 	if err := http.ListenAndServe(serverAddr, mux); err != nil {
+		// log.Fatalf skips deferred calls, so close the quote store first
+		shutdown()
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }